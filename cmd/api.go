@@ -2,66 +2,188 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
+	"net"
 	"net/http"
 	"time"
-	"yoharsh14/krant-backend/internal/business/user"
+	"yoharsh14/krant-backend/internal/auth"
+	"yoharsh14/krant-backend/internal/buildinfo"
+	"yoharsh14/krant-backend/internal/clientip"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/health"
+	"yoharsh14/krant-backend/internal/idempotency"
+	"yoharsh14/krant-backend/internal/lifecycle"
+	"yoharsh14/krant-backend/internal/logging"
+	"yoharsh14/krant-backend/internal/metrics"
+	"yoharsh14/krant-backend/internal/migrations"
+	"yoharsh14/krant-backend/internal/openapi"
+	"yoharsh14/krant-backend/internal/ratelimit"
+	"yoharsh14/krant-backend/internal/routes"
+	"yoharsh14/krant-backend/internal/tracing"
 
-	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
-type Dependencies struct {
-	userHandler *user.Handler
-}
-
-func (app *application) mount(ctx context.Context) http.Handler{
+func (app *application) mount() http.Handler{
 	r := chi.NewRouter()
 	// A good base middleware stack
-	r.Use(middleware.RequestID) // for rate limitting
-	r.Use(middleware.RealIP)    // for rate limtting and analytics
-	r.Use(middleware.Logger)    // logging
+	r.Use(middleware.RequestID) // for logs and traces
+	// the client's address, for rate limiting and analytics
+	r.Use(clientip.Middleware(app.config.Server.TrustedProxies))
+	r.Use(metrics.Middleware)   // request counts and latencies by route
+	r.Use(tracing.Middleware)   // a span per request, continuing the caller's trace
+	r.Use(logging.Middleware)   // request scoped log attributes and one line per request
 	r.Use(middleware.Recoverer) // recover from crashes
 
+	limiter := ratelimit.NewLimiter(app.RateLimits)
+	limits := app.config.RateLimit
+	authLimit := limiter.Limit(policy("auth", limits.Auth))
+	r.Use(limiter.Limit(policy("default", limits.Default))) // per client, see ratelimit.ClientKey
+
 	// set a timeout value on the request context (ctx), that will signal
 	// through ctx.Done() that the request has timed out and further
 	// processing should be stopped
-	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(middleware.Timeout(app.config.Server.RequestTimeout))
+	// replays retried POST, PUT, PATCH and DELETE requests with an Idempotency-Key
+	r.Use(idempotency.NewGuard(app.Idempotency, app.config.Idempotency.TTL, app.config.Server.RequestTimeout).Middleware)
 
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("all goood"))
 	})
-	userReposotory := user.NewRepository(app.db.Database("krant"))
-	userService :=user.NewService(userReposotory)
-	userHandler :=user.NewHandler(userService)
-	r.Post("/user/create",userHandler.CreateUser)
+	// stored files keep the URLs they were saved with, outside the versions;
+	// under a full MEDIA_BASE_URL another server serves them
+	if prefix, ok := app.config.Media.MountPath(); ok && app.Files != nil {
+		files := http.StripPrefix(prefix, app.Files)
+		r.Get(prefix+"/*", files.ServeHTTP)
+		r.Head(prefix+"/*", files.ServeHTTP)
+	}
+
+	metrics.IngestionLag.Set(app.ingestionLag())
+	r.Get("/healthz", health.Liveness)
+	r.Get("/readyz", app.readiness().Readiness)
+	r.Get("/version", buildinfo.Handler)
+	r.Get("/metrics", metrics.Handler())
+
+	routes.API{
+		Media:         app.MediaHandler,
+		Sources:       app.SourceHandler,
+		Categories:    app.CategoryHandler,
+		Users:         app.UserHandler,
+		News:          app.NewsHandler,
+		Notifications: app.NotificationHandler,
+		Webhooks:      app.WebhookHandler,
+		Jobs:          app.JobHandler,
+		LogLevels:     app.LogLevels,
+		// limited before the token check, so guessing is limited too
+		Admin:  chi.Middlewares{authLimit, auth.RequireAdmin(app.config.Auth.AdminToken)},
+		Signup: chi.Middlewares{authLimit},
+		// limited after the token check, per credential rather than per IP
+		Ingest: chi.Middlewares{
+			auth.RequireIngest(app.config.Auth.IngestToken, app.config.Auth.AdminToken),
+			limiter.Limit(policy("ingest", limits.Ingest)),
+		},
+	}.Mount(r)
+
+	r.Get("/openapi.json", apiSpec(app.config.Media).Handler(r))
+	r.Get("/docs", openapi.DocsHandler("/openapi.json"))
 
 	return r
 }
 
-func (app *application) run(h http.Handler) error{
-	srv := &http.Server{
-		Addr: app.config.addr,
-		Handler: h,
-		WriteTimeout: time.Second * 40,
-		ReadTimeout:  time.Second * 20,
-		IdleTimeout:  time.Minute * 2,
+// databaseHook checks the database is reachable on start and disconnects
+// once everything depending on it has stopped
+func (app *application) databaseHook() lifecycle.Hook {
+	return lifecycle.Hook{
+		Name: "mongo",
+		Start: func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, app.config.Database.ConnectTimeout)
+			defer cancel()
+			if err := app.DB.Ping(ctx, nil); err != nil {
+				return err
+			}
+			slog.Info("Connected with database", "uri", config.RedactURI(app.config.Database.URI), "database", app.config.Database.Name)
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			return app.DB.Disconnect(ctx)
+		},
 	}
-	log.Printf("Server started at port %s", app.config.addr)
-	return srv.ListenAndServe()
 }
 
-type application struct {
-	config config
-	db     *mongo.Client
+// migrationsHook applies pending migrations once the database is reachable.
+// It has nothing to stop.
+func (app *application) migrationsHook() lifecycle.Hook {
+	return lifecycle.Hook{
+		Name: "migrations",
+		Start: func(ctx context.Context) error {
+			runner, err := migrations.NewRunner(app.DB.Database(app.config.Database.Name), migrations.All(), slog.Default())
+			if err != nil {
+				return err
+			}
+			var applied []int
+			err = tracing.Job(ctx, "migrations", func(ctx context.Context) (err error) {
+				applied, err = runner.Up(ctx)
+				return err
+			})
+			if errors.Is(err, migrations.ErrLocked) {
+				// another instance is migrating; it is safe to serve meanwhile
+				slog.Warn("skipping migrations", "error", err)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			slog.Info("database migrated", "applied", len(applied))
+			return nil
+		},
+	}
 }
 
-type config struct {
-	addr string
-	db   dbConfig
+// serverHook serves h until shutdown. Stopping keeps serving for the drain
+// delay while /readyz fails, then closes the listener and waits for
+// in-flight requests until the shutdown deadline, dropping the rest.
+func (app *application) serverHook(h http.Handler) lifecycle.Hook {
+	srv := &http.Server{
+		Addr: app.config.Server.Addr,
+		Handler: h,
+		WriteTimeout: app.config.Server.WriteTimeout,
+		ReadTimeout:  app.config.Server.ReadTimeout,
+		IdleTimeout:  app.config.Server.IdleTimeout,
+	}
+
+	return lifecycle.Hook{
+		Name: "http",
+		Start: func(ctx context.Context) error {
+			// Listen up front so a taken port fails the start
+			ln, err := net.Listen("tcp", srv.Addr)
+			if err != nil {
+				return err
+			}
+			slog.Info("server started", "addr", app.config.Server.Addr)
+			go func() {
+				if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+					app.lifecycle.Fail(err)
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			select {
+			case <-ctx.Done():
+			case <-time.After(app.config.Server.DrainDelay):
+			}
+			if err := srv.Shutdown(ctx); err != nil {
+				srv.Close()
+				return err
+			}
+			return nil
+		},
+	}
 }
 
-type dbConfig struct {
-	dsn string
+// policy builds the rate limit policy called name from its configured rate
+func policy(name string, rate config.Rate) ratelimit.Policy {
+	return ratelimit.Policy{Name: name, Limit: rate.Limit, Period: rate.Period}
 }
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"yoharsh14/krant-backend/internal/business/category"
+	"yoharsh14/krant-backend/internal/business/job"
+	"yoharsh14/krant-backend/internal/business/news"
+	"yoharsh14/krant-backend/internal/business/notification"
+	"yoharsh14/krant-backend/internal/business/source"
+	"yoharsh14/krant-backend/internal/business/user"
+	"yoharsh14/krant-backend/internal/business/webhook"
+	"yoharsh14/krant-backend/internal/changes"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/events"
+	"yoharsh14/krant-backend/internal/idempotency"
+	"yoharsh14/krant-backend/internal/lifecycle"
+	"yoharsh14/krant-backend/internal/logging"
+	"yoharsh14/krant-backend/internal/media"
+	"yoharsh14/krant-backend/internal/metrics"
+	"yoharsh14/krant-backend/internal/ratelimit"
+	"yoharsh14/krant-backend/internal/tracing"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Dependencies are the components the application is assembled from.
+// newApplication builds every one left nil from the configuration, once, so
+// tests can swap any of them, e.g. an in-memory repository or a fixed clock,
+// and serve the rest as in production.
+type Dependencies struct {
+	// adapters
+	DB          *mongo.Client
+	Storage     media.Storage
+	Files       http.Handler    // serves Storage under MEDIA_BASE_URL, when it can
+	RateLimits  ratelimit.Store // nil disables rate limiting
+	Idempotency idempotency.Store
+	LogLevels   *logging.Levels
+	Now         func() time.Time
+
+	// repositories
+	MediaRepository        media.Repository
+	SourceRepository       source.Repository
+	CategoryRepository     category.Repository
+	UserRepository         user.Repository
+	NewsRepository         news.Repository
+	NotificationRepository notification.Repository
+	WebhookRepository      webhook.Repository
+	JobRepository          job.Repository
+	Outbox                 events.Store
+	ChangePositions        changes.Positions
+
+	// services
+	MediaService        media.Service
+	SourceService       source.Service
+	CategoryService     category.Service
+	UserService         user.Service
+	NewsService         news.Service
+	NotificationService notification.Service
+	WebhookService      webhook.Service
+	JobService          job.Service
+	Bus                 *events.Bus       // a nil one is built with the subscribers of the services
+	JobRunner           *job.Runner       // a nil one is built with the job handlers of the services
+	Changes             *changes.Consumer // a nil one is built with the watches of the services
+
+	// handlers
+	MediaHandler        media.Handler
+	SourceHandler       source.Handler
+	CategoryHandler     category.Handler
+	UserHandler         user.Handler
+	NewsHandler         news.Handler
+	NotificationHandler notification.Handler
+	WebhookHandler      webhook.Handler
+	JobHandler          job.Handler
+
+	// Workers run between the database and the server, see register. When
+	// nil, they are the change consumer and, unless disabled, the event
+	// relay, the webhook dispatcher and the job runner.
+	Workers []lifecycle.Hook
+}
+
+type application struct {
+	config    config.Config
+	lifecycle *lifecycle.Manager
+	Dependencies
+}
+
+// newApplication assembles the application from cfg and deps. Nothing is
+// started: register adds the hooks that start and stop it to the lifecycle.
+func newApplication(cfg config.Config, lc *lifecycle.Manager, deps Dependencies) (*application, error) {
+	app := &application{config: cfg, lifecycle: lc, Dependencies: deps}
+	if err := app.adapters(); err != nil {
+		return nil, err
+	}
+	app.components()
+	app.workers()
+	return app, nil
+}
+
+// adapters builds the clients of everything outside the process
+func (app *application) adapters() error {
+	cfg := app.config
+	if app.Now == nil {
+		app.Now = time.Now
+	}
+	if app.LogLevels == nil {
+		levels, err := logging.NewLevels(logging.Settings{Default: cfg.Log.Level, Packages: cfg.Log.Packages})
+		if err != nil {
+			return err
+		}
+		app.LogLevels = levels
+	}
+
+	if app.DB == nil {
+		serverAPI := options.ServerAPI(options.ServerAPIVersion1)
+		opts := options.Client().
+			ApplyURI(cfg.Database.URI).
+			SetServerAPIOptions(serverAPI).
+			SetConnectTimeout(cfg.Database.ConnectTimeout).
+			SetMonitor(commandMonitors(metrics.CommandMonitor(), tracing.CommandMonitor()))
+
+		// connects lazily, the database hook checks it is reachable
+		client, err := mongo.Connect(opts)
+		if err != nil {
+			return err
+		}
+		app.DB = client
+	}
+
+	if app.Storage == nil {
+		storage, err := media.NewLocalStorage(cfg.Media.Dir, cfg.Media.BaseURL)
+		if err != nil {
+			return err
+		}
+		app.Storage = storage
+	}
+	if served, ok := app.Storage.(interface{ Handler() http.Handler }); ok && app.Files == nil {
+		app.Files = served.Handler()
+	}
+
+	if app.RateLimits == nil && cfg.RateLimit.Enabled {
+		app.RateLimits = ratelimit.NewMemoryStore()
+	}
+	if app.Idempotency == nil {
+		switch cfg.Idempotency.Store {
+		case config.IdempotencyStoreMemory:
+			app.Idempotency = idempotency.NewMemoryStore()
+		default:
+			app.Idempotency = idempotency.NewMongoStore(app.DB.Database(cfg.Database.Name))
+		}
+	}
+	return nil
+}
+
+// components builds the repositories, services and handlers, each on top of
+// the ones before
+func (app *application) components() {
+	cfg := app.config
+	db := app.DB.Database(cfg.Database.Name)
+
+	if app.MediaRepository == nil {
+		app.MediaRepository = media.NewRepository(db)
+	}
+	if app.SourceRepository == nil {
+		app.SourceRepository = source.NewRepository(db)
+	}
+	if app.CategoryRepository == nil {
+		app.CategoryRepository = category.NewRepository(db)
+	}
+	if app.UserRepository == nil {
+		app.UserRepository = user.NewRepository(db)
+	}
+	if app.NewsRepository == nil {
+		app.NewsRepository = news.NewRepository(db)
+	}
+	if app.NotificationRepository == nil {
+		app.NotificationRepository = notification.NewRepository(db)
+	}
+	if app.WebhookRepository == nil {
+		app.WebhookRepository = webhook.NewRepository(db)
+	}
+	if app.JobRepository == nil {
+		app.JobRepository = job.NewRepository(db)
+	}
+	if app.Outbox == nil {
+		app.Outbox = events.NewStore(db)
+	}
+	if app.ChangePositions == nil {
+		app.ChangePositions = changes.NewPositions(db)
+	}
+
+	if app.MediaService == nil {
+		app.MediaService = media.NewService(app.MediaRepository, app.Storage, cfg.Media, cfg.Features)
+	}
+	if app.SourceService == nil {
+		app.SourceService = source.NewService(app.SourceRepository, cfg.Features)
+	}
+	if app.WebhookService == nil {
+		app.WebhookService = webhook.NewService(app.WebhookRepository)
+	}
+	if app.JobService == nil {
+		app.JobService = job.NewService(app.JobRepository, cfg.Jobs)
+	}
+	if app.CategoryService == nil {
+		app.CategoryService = category.NewService(app.CategoryRepository, app.Outbox, cfg.Cache)
+	}
+	if app.UserService == nil {
+		app.UserService = user.NewService(app.UserRepository, app.MediaService, app.SourceService, app.Outbox)
+	}
+	if app.NewsService == nil {
+		app.NewsService = news.NewService(app.NewsRepository, app.MediaService, app.SourceService, app.UserService, app.Outbox, cfg.Cache)
+	}
+	if app.NotificationService == nil {
+		app.NotificationService = notification.NewService(app.NotificationRepository, app.Outbox)
+	}
+	if app.Bus == nil {
+		app.Bus = events.NewBus()
+		subscribeMetrics(app.Bus)
+		notification.Subscribe(app.Bus, app.NotificationService)
+		webhook.Subscribe(app.Bus, app.WebhookService)
+	}
+	if app.JobRunner == nil {
+		app.JobRunner = job.NewRunner(app.JobRepository, cfg.Jobs)
+	}
+	if app.Changes == nil {
+		app.Changes = changes.NewConsumer(db, app.ChangePositions, cfg.Changes)
+		news.Watch(app.Changes, app.NewsService)
+	}
+
+	if app.MediaHandler == nil {
+		app.MediaHandler = media.NewHandler(app.MediaService)
+	}
+	if app.SourceHandler == nil {
+		app.SourceHandler = source.NewHandler(app.SourceService)
+	}
+	if app.CategoryHandler == nil {
+		app.CategoryHandler = category.NewHandler(app.CategoryService)
+	}
+	if app.UserHandler == nil {
+		app.UserHandler = user.NewHandler(app.UserService)
+	}
+	if app.NewsHandler == nil {
+		app.NewsHandler = news.NewHandler(app.NewsService)
+	}
+	if app.NotificationHandler == nil {
+		app.NotificationHandler = notification.NewHandler(app.NotificationService)
+	}
+	if app.WebhookHandler == nil {
+		app.WebhookHandler = webhook.NewHandler(app.WebhookService)
+	}
+	if app.JobHandler == nil {
+		app.JobHandler = job.NewHandler(app.JobService)
+	}
+}
+
+// workers builds the background workers. The change consumer runs on every
+// instance, as each has its own caches; the others only where workers are
+// enabled.
+func (app *application) workers() {
+	cfg := app.config
+	if app.Workers != nil {
+		return
+	}
+	if cfg.Changes.Enabled {
+		app.Workers = append(app.Workers, lifecycle.Worker("changes", app.Changes.Run))
+	}
+	if !cfg.Workers.Enabled {
+		return
+	}
+	relay := events.NewRelay(app.Outbox, app.Bus, cfg.Events)
+	dispatcher := webhook.NewDispatcher(app.WebhookRepository, cfg.Webhooks)
+	app.Workers = append(app.Workers,
+		lifecycle.Worker("events", func(ctx context.Context) {
+			relay.Run(ctx, cfg.Workers.Concurrency, cfg.Workers.PollInterval)
+		}),
+		lifecycle.Worker("webhooks", func(ctx context.Context) {
+			dispatcher.Run(ctx, cfg.Workers.Concurrency, cfg.Workers.PollInterval)
+		}),
+		lifecycle.Worker("jobs", func(ctx context.Context) {
+			app.JobRunner.Run(ctx, cfg.Workers.Concurrency, cfg.Workers.PollInterval)
+		}),
+	)
+}
+
+// register adds the application's hooks to its lifecycle. Registration order
+// is start order; shutdown runs in reverse, so the server drains first and
+// the database goes last.
+func (app *application) register() {
+	app.lifecycle.Append(app.databaseHook())
+	if app.config.Database.MigrateOnStart {
+		app.lifecycle.Append(app.migrationsHook())
+	}
+	for _, worker := range app.Workers {
+		app.lifecycle.Append(worker)
+	}
+	app.lifecycle.Append(app.serverHook(app.mount()))
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"yoharsh14/krant-backend/internal/business/category"
+	"yoharsh14/krant-backend/internal/business/news"
+	"yoharsh14/krant-backend/internal/business/source"
+	"yoharsh14/krant-backend/internal/business/user"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/events"
+	"yoharsh14/krant-backend/internal/lifecycle"
+	"yoharsh14/krant-backend/internal/models"
+)
+
+// newTestApp assembles the application from cfg with deps swapped in. Media
+// is stored in a temporary directory; the default mongo client connects
+// lazily, so only routes reaching a mongo repository need a database.
+func newTestApp(t *testing.T, cfg config.Config, deps Dependencies) *application {
+	t.Helper()
+	cfg.Media.Dir = t.TempDir()
+	app, err := newApplication(cfg, lifecycle.New(slog.Default()), deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { app.DB.Disconnect(context.Background()) })
+	return app
+}
+
+func TestServesCategoriesFromMemory(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.AdminToken = "secret"
+	cfg.Idempotency.Store = config.IdempotencyStoreMemory
+	app := newTestApp(t, cfg, Dependencies{
+		CategoryRepository: category.NewMemoryRepository(),
+		Outbox:             events.NewMemoryStore(),
+	})
+	srv := httptest.NewServer(app.mount())
+	defer srv.Close()
+
+	do := func(method, path, body string, header http.Header) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key := range header {
+			req.Header.Set(key, header.Get(key))
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	create := http.Header{
+		"Authorization":   {"Bearer secret"},
+		"Content-Type":    {"application/json"},
+		"Idempotency-Key": {"create-markets"},
+	}
+	created := do("POST", "/v1/admin/categories", `{"name": "Markets", "slug": "markets"}`, create)
+	if created.StatusCode != http.StatusCreated {
+		t.Fatalf("POST /v1/admin/categories = %d, want 201", created.StatusCode)
+	}
+	retried := do("POST", "/v1/admin/categories", `{"name": "Markets", "slug": "markets"}`, create)
+	if retried.StatusCode != http.StatusCreated || retried.Header.Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retried POST = %d, replayed %q; want the stored 201", retried.StatusCode, retried.Header.Get("Idempotent-Replayed"))
+	}
+	if reused := do("POST", "/v1/admin/categories", `{"name": "Crypto", "slug": "crypto"}`, create); reused.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("POST reusing the key = %d, want 422", reused.StatusCode)
+	}
+
+	got := do("GET", "/v1/categories/markets", "", nil)
+	etag := got.Header.Get("ETag")
+	if got.StatusCode != http.StatusOK || etag == "" || got.Header.Get("API-Version") != "v1" {
+		t.Fatalf("GET /v1/categories/markets = %d, ETag %q, API-Version %q; want 200 with both", got.StatusCode, etag, got.Header.Get("API-Version"))
+	}
+	if again := do("GET", "/v1/categories/markets", "", http.Header{"If-None-Match": {etag}}); again.StatusCode != http.StatusNotModified {
+		t.Errorf("conditional GET = %d, want 304", again.StatusCode)
+	}
+
+	// only routes served before versioning have unversioned aliases
+	if unversioned := do("GET", "/categories/markets", "", nil); unversioned.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /categories/markets = %d, want 404", unversioned.StatusCode)
+	}
+}
+
+func TestUsersAreListedToAdminsOnly(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.AdminToken = "secret"
+	app := newTestApp(t, cfg, Dependencies{
+		UserRepository: user.NewMemoryRepository(),
+		Outbox:         events.NewMemoryStore(),
+	})
+	srv := httptest.NewServer(app.mount())
+	defer srv.Close()
+
+	get := func(path, token string) int {
+		t.Helper()
+		req, err := http.NewRequest("GET", srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	for _, path := range []string{"/v1/users", "/users"} {
+		if status := get(path, ""); status != http.StatusNotFound && status != http.StatusMethodNotAllowed {
+			t.Errorf("GET %s = %d, want no public listing", path, status)
+		}
+	}
+	if status := get("/v1/admin/users", ""); status != http.StatusUnauthorized {
+		t.Errorf("GET /v1/admin/users without the token = %d, want 401", status)
+	}
+	if status := get("/v1/admin/users", "secret"); status != http.StatusOK {
+		t.Errorf("GET /v1/admin/users = %d, want 200", status)
+	}
+}
+
+func TestSourceRegistry(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.AdminToken = "secret"
+	cfg.Idempotency.Store = config.IdempotencyStoreMemory
+	cfg.RateLimit.Enabled = false
+	users := user.NewMemoryRepository()
+	app := newTestApp(t, cfg, Dependencies{
+		SourceRepository: source.NewMemoryRepository(),
+		NewsRepository:   news.NewMemoryRepository(),
+		UserRepository:   users,
+		Outbox:           events.NewMemoryStore(),
+	})
+	srv := httptest.NewServer(app.mount())
+	defer srv.Close()
+
+	// do sends body, as the admin when admin is set, and decodes the answer
+	// into out unless it is nil
+	do := func(method, path, body string, admin bool, out any) int {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if admin {
+			req.Header.Set("Authorization", "Bearer secret")
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if out != nil && resp.StatusCode < 300 {
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				t.Fatalf("%s %s: %v", method, path, err)
+			}
+		}
+		return resp.StatusCode
+	}
+	type page struct {
+		News []struct {
+			Title string `json:"title"`
+		} `json:"news"`
+	}
+	titles := func(path string) []string {
+		t.Helper()
+		var p page
+		if status := do("GET", path, "", strings.HasPrefix(path, "/v1/users/"), &p); status != http.StatusOK {
+			t.Fatalf("GET %s = %d, want 200", path, status)
+		}
+		var titles []string
+		for _, n := range p.News {
+			titles = append(titles, n.Title)
+		}
+		return titles
+	}
+
+	wire := `{"name": "Wire", "url": "https://wire.example", "default_categories": ["economy"], "rate_limit": 2}`
+	if status := do("POST", "/v1/admin/sources", wire, false, nil); status != http.StatusUnauthorized {
+		t.Fatalf("POST /v1/admin/sources without the token = %d, want 401", status)
+	}
+	var wireSource, daily struct {
+		ID string `json:"id"`
+	}
+	if status := do("POST", "/v1/admin/sources", wire, true, &wireSource); status != http.StatusCreated {
+		t.Fatalf("POST /v1/admin/sources = %d, want 201", status)
+	}
+	if status := do("POST", "/v1/admin/sources", `{"name": "Daily", "url": "https://daily.example", "default_categories": ["stocks"]}`, true, &daily); status != http.StatusCreated {
+		t.Fatalf("POST /v1/admin/sources = %d, want 201", status)
+	}
+
+	publish := func(sourceID, title string) int {
+		t.Helper()
+		var created struct {
+			ID string `json:"id"`
+		}
+		body := `{"title": "` + title + `", "description": "d", "content": "<p>c</p>", "source_id": "` + sourceID + `", "trader_relevance": ["day_trader"]}`
+		if status := do("POST", "/v1/news", body, true, &created); status != http.StatusCreated {
+			return status
+		}
+		return do("PATCH", "/v1/news/"+created.ID, `{"status": "published"}`, true, nil)
+	}
+	if status := do("POST", "/v1/news", `{"title": "Anonymous"}`, false, nil); status != http.StatusUnauthorized {
+		t.Errorf("POST /v1/news without a token = %d, want 401", status)
+	}
+	for _, n := range []struct{ source, title string }{{wireSource.ID, "Rates hold"}, {wireSource.ID, "Oil slides"}, {daily.ID, "Stocks open higher"}} {
+		if status := publish(n.source, n.title); status != http.StatusOK {
+			t.Fatalf("publishing %q = %d, want 200", n.title, status)
+		}
+	}
+	if status := publish(wireSource.ID, "One too many"); status != http.StatusTooManyRequests {
+		t.Errorf("ingesting past the source's hourly limit = %d, want 429", status)
+	}
+
+	reader := &models.User{GoogleID: "g-1", Email: "ada@example.com", Name: "Ada", TraderType: models.TraderTypeDayTrader}
+	if err := users.Create(context.Background(), reader); err != nil {
+		t.Fatal(err)
+	}
+	feed := "/v1/users/" + reader.ID.Hex() + "/feed?sort=title"
+	if status := do("GET", feed, "", false, nil); status != http.StatusUnauthorized {
+		t.Errorf("GET %s without the token = %d, want 401", feed, status)
+	}
+	if got := titles(feed); len(got) != 3 {
+		t.Fatalf("feed = %v, want every article", got)
+	}
+
+	// muting hides a source from the user's feed only
+	muted := "/v1/users/" + reader.ID.Hex() + "/muted-sources/" + wireSource.ID
+	if status := do("PUT", muted, "", true, nil); status != http.StatusOK {
+		t.Fatalf("PUT %s = %d, want 200", muted, status)
+	}
+	if got := titles(feed); len(got) != 1 || got[0] != "Stocks open higher" {
+		t.Errorf("feed with Wire muted = %v, want only Daily's article", got)
+	}
+	if got := titles("/v1/news"); len(got) != 3 {
+		t.Errorf("news with Wire muted by a user = %v, want every article", got)
+	}
+	if status := do("DELETE", muted, "", true, nil); status != http.StatusOK {
+		t.Fatalf("DELETE %s = %d, want 200", muted, status)
+	}
+	if got := titles(feed); len(got) != 3 {
+		t.Errorf("feed after unmuting = %v, want every article", got)
+	}
+
+	// blocking hides a source from everyone and refuses its articles
+	if status := do("POST", "/v1/admin/sources/"+daily.ID+"/block", `{"reason": "spam"}`, true, nil); status != http.StatusOK {
+		t.Fatalf("blocking Daily = %d, want 200", status)
+	}
+	if got := titles(feed); len(got) != 2 || slices.Contains(got, "Stocks open higher") {
+		t.Errorf("feed with Daily blocked = %v, want Wire's articles", got)
+	}
+	if got := titles("/v1/news"); len(got) != 2 {
+		t.Errorf("news with Daily blocked = %v, want Wire's articles", got)
+	}
+	if status := publish(daily.ID, "Blocked piece"); status != http.StatusUnprocessableEntity {
+		t.Errorf("ingesting from a blocked source = %d, want 422", status)
+	}
+	if status := do("POST", "/v1/admin/sources/"+daily.ID+"/unblock", "", true, nil); status != http.StatusOK {
+		t.Fatalf("unblocking Daily = %d, want 200", status)
+	}
+	if got := titles(feed); len(got) != 3 {
+		t.Errorf("feed after unblocking = %v, want every article", got)
+	}
+}
+
+func TestServesFilesUnderTheMediaBaseURL(t *testing.T) {
+	for base, want := range map[string]map[string]int{
+		"/static/": {"/static/news/a.txt": http.StatusOK, "/media/files/news/a.txt": http.StatusNotFound},
+		// another server serves them
+		"https://cdn.example.com/media": {"/media/news/a.txt": http.StatusNotFound, "/media/files/news/a.txt": http.StatusNotFound},
+	} {
+		cfg := config.Default()
+		cfg.Media.BaseURL = base
+		app := newTestApp(t, cfg, Dependencies{})
+		if err := app.Storage.Put(context.Background(), "news/a.txt", strings.NewReader("a"), "text/plain"); err != nil {
+			t.Fatal(err)
+		}
+		router := app.mount()
+		for path, status := range want {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+			if rec.Code != status {
+				t.Errorf("MEDIA_BASE_URL %s: GET %s = %d, want %d", base, path, rec.Code, status)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"yoharsh14/krant-backend/internal/health"
+
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+)
+
+// readiness builds the checks behind /readyz
+func (app *application) readiness() *health.Checker {
+	checker := health.NewChecker(app.config.Health.CheckTimeout)
+
+	checker.Add("shutdown", func(ctx context.Context) (any, error) {
+		if app.lifecycle.ShuttingDown() {
+			return nil, errors.New("shutting down")
+		}
+		return nil, nil
+	})
+
+	checker.Add("mongo", func(ctx context.Context) (any, error) {
+		return nil, app.DB.Ping(ctx, readpref.Primary())
+	})
+
+	checker.Add("workers", func(ctx context.Context) (any, error) {
+		if !app.lifecycle.Started() {
+			return nil, errors.New("not every component has started")
+		}
+		return nil, nil
+	})
+
+	checker.Add("ingestion", func(ctx context.Context) (any, error) {
+		last, err := app.NewsService.LastIngestedAt(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if last.IsZero() {
+			return map[string]any{"last_ingested_at": nil}, nil
+		}
+
+		lag := app.Now().Sub(last).Truncate(time.Second)
+		detail := map[string]any{
+			"last_ingested_at": last,
+			"lag":              lag.String(),
+			"lag_seconds":      int64(lag.Seconds()),
+		}
+		if limit := app.config.Health.MaxIngestionLag; limit > 0 && lag > limit {
+			return detail, fmt.Errorf("ingestion is %s behind, limit is %s", lag, limit)
+		}
+		return detail, nil
+	})
+
+	return checker
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/health"
+)
+
+func TestReadinessFailsWhileTheServerDrains(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Addr = "127.0.0.1:0"
+	cfg.Server.DrainDelay = time.Second
+	cfg.Health.CheckTimeout = 100 * time.Millisecond // there is no MongoDB to ping
+	cfg.Idempotency.Store = config.IdempotencyStoreMemory
+	app := newTestApp(t, cfg, Dependencies{})
+	probes := httptest.NewServer(app.mount())
+	defer probes.Close()
+
+	shutdown := func() (int, string) {
+		t.Helper()
+		resp, err := http.Get(probes.URL + "/readyz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var body health.Response
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, body.Checks["shutdown"].Status
+	}
+
+	app.lifecycle.Append(app.serverHook(http.NotFoundHandler()))
+	if err := app.lifecycle.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, status := shutdown(); status != health.StatusOK {
+		t.Fatalf("shutdown check before stopping = %q, want ok", status)
+	}
+
+	start := time.Now()
+	stopped := make(chan error, 1)
+	go func() { stopped <- app.lifecycle.Stop(context.Background()) }()
+
+	time.Sleep(50 * time.Millisecond)
+	if code, status := shutdown(); code != http.StatusServiceUnavailable || status != health.StatusUnavailable {
+		t.Errorf("/readyz while draining = %d with the shutdown check %q, want 503 and unavailable", code, status)
+	}
+	select {
+	case <-stopped:
+		t.Fatal("the server stopped before the drain delay ran out")
+	default:
+	}
+	if err := <-stopped; err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took < cfg.Server.DrainDelay {
+		t.Errorf("stopping took %s, want at least the %s drain delay", took, cfg.Server.DrainDelay)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"yoharsh14/krant-backend/internal/events"
+	"yoharsh14/krant-backend/internal/metrics"
+	"yoharsh14/krant-backend/internal/models"
+)
+
+// subscribeMetrics counts the domain events the metrics are about. An event
+// relayed again after a crash is counted twice, which counters can bear.
+func subscribeMetrics(bus *events.Bus) {
+	bus.Subscribe("metrics", events.UserCreated, func(context.Context, events.Event) error {
+		metrics.Signups.Inc()
+		return nil
+	})
+	bus.Subscribe("metrics", events.NewsPublished, func(context.Context, events.Event) error {
+		metrics.NewsPublished.Inc()
+		return nil
+	})
+	bus.Subscribe("metrics", events.NotificationCreated, func(_ context.Context, event events.Event) error {
+		var notification models.Notification
+		if err := event.Decode(&notification); err != nil {
+			return err
+		}
+		metrics.NotificationsSent.With(notification.Type).Inc()
+		return nil
+	})
+}
+
+// ingestionLag computes krant_ingestion_lag_seconds on scrape: the age of the
+// newest article, or NaN when there is none or it cannot be read
+func (app *application) ingestionLag() func(ctx context.Context) float64 {
+	return func(ctx context.Context) float64 {
+		ctx, cancel := context.WithTimeout(ctx, app.config.Health.CheckTimeout)
+		defer cancel()
+		last, err := app.NewsService.LastIngestedAt(ctx)
+		if err != nil {
+			slog.Warn("could not read the ingestion lag", "error", err)
+			return math.NaN()
+		}
+		if last.IsZero() {
+			return math.NaN()
+		}
+		return app.Now().Sub(last).Seconds()
+	}
+}
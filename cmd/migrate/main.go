@@ -0,0 +1,123 @@
+// Command migrate applies or rolls back database migrations on demand.
+//
+//	migrate up            apply every pending migration
+//	migrate to <version>  apply pending migrations up to version
+//	migrate down [n]      roll back the last n applied migrations (default 1)
+//	migrate status        list migrations and when they were applied
+//
+// It reads the same configuration as the server.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/migrations"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate up | to <version> | down [n] | status")
+	flag.PrintDefaults()
+}
+
+func main() {
+	timeout := flag.Duration("timeout", 10*time.Minute, "give up after this long")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := run(*timeout, flag.Args()); err != nil {
+		slog.Error("migrate failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(timeout time.Duration, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	client, err := mongo.Connect(options.Client().
+		ApplyURI(cfg.Database.URI).
+		SetServerAPIOptions(options.ServerAPI(options.ServerAPIVersion1)).
+		SetConnectTimeout(cfg.Database.ConnectTimeout))
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect(context.Background())
+
+	runner, err := migrations.NewRunner(client.Database(cfg.Database.Name), migrations.All(), slog.Default())
+	if err != nil {
+		return err
+	}
+
+	switch cmd, rest := args[0], args[1:]; cmd {
+	case "up":
+		applied, err := runner.Up(ctx)
+		report("applied", applied)
+		return err
+	case "to":
+		if len(rest) != 1 {
+			return fmt.Errorf("to needs a version")
+		}
+		version, err := strconv.Atoi(rest[0])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", rest[0])
+		}
+		applied, err := runner.UpTo(ctx, version)
+		report("applied", applied)
+		return err
+	case "down":
+		steps := 1
+		if len(rest) > 0 {
+			if steps, err = strconv.Atoi(rest[0]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", rest[0])
+			}
+		}
+		rolledBack, err := runner.Down(ctx, steps)
+		report("rolled back", rolledBack)
+		return err
+	case "status":
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	default:
+		usage()
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+}
+
+func report(what string, versions []int) {
+	if len(versions) == 0 {
+		fmt.Printf("nothing %s\n", what)
+		return
+	}
+	fmt.Printf("%s %v\n", what, versions)
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"net/http"
+	"yoharsh14/krant-backend/internal/buildinfo"
+	"yoharsh14/krant-backend/internal/business/user"
+	"yoharsh14/krant-backend/internal/business/webhook"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/content"
+	"yoharsh14/krant-backend/internal/health"
+	"yoharsh14/krant-backend/internal/idempotency"
+	"yoharsh14/krant-backend/internal/logging"
+	"yoharsh14/krant-backend/internal/media"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/openapi"
+	"yoharsh14/krant-backend/internal/routes"
+)
+
+// apiSpec describes every route registered by mount, the stored files
+// under the path of files. A route without a description fails
+// TestEveryRouteIsDescribed.
+func apiSpec(files config.Media) *openapi.Spec {
+	spec := openapi.NewSpec("Krant API", buildinfo.Get().Version)
+	spec.CommonErrors(http.StatusTooManyRequests) // rate limits, see ratelimit
+	spec.Mutating([]openapi.Parameter{{
+		Name:        idempotency.Header,
+		In:          "header",
+		Description: "Random key per operation, up to 255 characters. Retries with the same key get the first response, replayed.",
+		Schema:      &openapi.Schema{Type: "string"},
+	}}, http.StatusConflict, http.StatusUnprocessableEntity)
+	formats := make([]string, 0, len(content.ValidFormats()))
+	for _, f := range content.ValidFormats() {
+		formats = append(formats, string(f))
+	}
+	format := openapi.Enum("format", "Rendering of the article body, html if empty.", formats...)
+	notFound := []int{http.StatusBadRequest, http.StatusNotFound}
+
+	spec.Describe("GET", "/", openapi.Route{Summary: "Check the server answers", Tags: []string{"meta"}, ContentType: "text/plain"})
+	spec.Describe("GET", "/openapi.json", openapi.Route{Summary: "This document", Description: "The OpenAPI 3.1 document of the API.", Tags: []string{"meta"}})
+	spec.Describe("GET", "/docs", openapi.Route{Summary: "API reference page", Tags: []string{"meta"}, ContentType: "text/html"})
+	spec.Describe("GET", "/healthz", openapi.Route{Summary: "Liveness probe", Tags: []string{"meta"}, Response: health.Response{}})
+	spec.Describe("GET", "/readyz", openapi.Route{
+		Summary:     "Readiness probe",
+		Description: "Answers 503 with the same body when a check fails.",
+		Tags:        []string{"meta"},
+		Response:    health.Response{},
+	})
+	spec.Describe("GET", "/metrics", openapi.Route{Summary: "Prometheus metrics", Tags: []string{"meta"}, ContentType: "text/plain"})
+	spec.Describe("GET", "/version", openapi.Route{Summary: "Build information", Tags: []string{"meta"}, Response: buildinfo.Info{}})
+
+	// media
+	spec.Describe("POST", "/v1/media/images", openapi.Route{
+		Summary:     "Upload an image",
+		Description: "Send the image as the multipart \"file\" part, or a JSON body with a URL to fetch it from.",
+		Tags:        []string{"media"},
+		Ingest:      true,
+		Query:       []openapi.Parameter{openapi.Enum("preset", "Thumbnail widths to generate, news if empty.", media.PresetNews.Name, media.PresetAvatar.Name)},
+		Body: struct {
+			URL string `json:"url" binding:"required"`
+		}{},
+		Upload:   true,
+		Status:   http.StatusCreated,
+		Response: models.ImageResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusForbidden, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity},
+	})
+	spec.Describe("GET", "/v1/media/images/{id}", openapi.Route{Summary: "Get an image", Tags: []string{"media"}, Response: models.ImageResponse{}, Errors: notFound})
+	if prefix, ok := files.MountPath(); ok {
+		spec.Describe("GET", prefix+"/*", openapi.Route{Summary: "Download a stored file", Tags: []string{"media"}, ContentType: "application/octet-stream", Errors: []int{http.StatusNotFound}})
+		spec.Describe("HEAD", prefix+"/*", openapi.Route{Summary: "Check a stored file", Tags: []string{"media"}, Errors: []int{http.StatusNotFound}})
+	}
+
+	// sources
+	sourceList := openapi.Success(struct {
+		Sources    []models.SourceResponse `json:"sources"`
+		TotalCount int64                   `json:"total_count"`
+		HasMore    bool                    `json:"has_more"`
+		NextCursor string                  `json:"next_cursor"`
+	}{})
+	spec.Describe("GET", "/v1/sources", openapi.Route{Summary: "List sources", Tags: []string{"sources"}, Query: openapi.PageParams(), Response: sourceList, Errors: []int{http.StatusBadRequest}})
+	spec.Describe("GET", "/v1/sources/{id}", openapi.Route{Summary: "Get a source", Tags: []string{"sources"}, Response: models.SourceResponse{}, Errors: notFound})
+
+	// categories
+	categoryList := openapi.Items(models.CategoryListResponse{}, models.CategoryResponse{})
+	spec.Describe("GET", "/v1/categories", openapi.Route{Summary: "List active categories", Tags: []string{"categories"}, Conditional: true, Query: openapi.QueryParams(), Response: categoryList, Errors: []int{http.StatusBadRequest}})
+	spec.Describe("GET", "/v1/categories/{id}", openapi.Route{Summary: "Get a category", Tags: []string{"categories"}, Conditional: true, Response: models.CategoryResponse{}, Errors: notFound})
+
+	// users
+	spec.Describe("POST", "/v1/users", openapi.Route{
+		Summary:  "Create a user",
+		Tags:     []string{"users"},
+		Body:     user.CreateUserInput{},
+		Status:   http.StatusCreated,
+		Response: models.SuccessResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType},
+	})
+	spec.Describe("PUT", "/v1/users/{id}/muted-sources/{sourceID}", openapi.Route{Summary: "Mute a source", Tags: []string{"users"}, Admin: true, Response: models.SuccessResponse{}, Errors: notFound})
+	spec.Describe("DELETE", "/v1/users/{id}/muted-sources/{sourceID}", openapi.Route{Summary: "Unmute a source", Tags: []string{"users"}, Admin: true, Response: models.SuccessResponse{}, Errors: notFound})
+
+	// news
+	newsList := openapi.Items(models.NewsListResponse{}, models.NewsResponse{})
+	spec.Describe("POST", "/v1/news", openapi.Route{
+		Summary:  "Create an article",
+		Tags:     []string{"news"},
+		Ingest:   true,
+		Body:     models.CreateNewsInput{},
+		Status:   http.StatusCreated,
+		Response: models.NewsResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity, http.StatusTooManyRequests},
+	})
+	spec.Describe("GET", "/v1/news", openapi.Route{
+		Summary:     "List articles",
+		Tags:        []string{"news"},
+		Conditional: true,
+		Query: append(openapi.QueryParams(), format,
+			openapi.Parameter{Name: "status", In: "query", Description: "Legacy, same as filter[status].", Schema: &openapi.Schema{Type: "string", Enum: models.ValidNewsStatuses()}},
+		),
+		Response: newsList,
+		Errors:   []int{http.StatusBadRequest},
+	})
+	spec.Describe("GET", "/v1/news/{id}", openapi.Route{Summary: "Get an article", Tags: []string{"news"}, Conditional: true, Query: []openapi.Parameter{format}, Response: models.NewsResponse{}, Errors: notFound})
+	spec.Describe("PATCH", "/v1/news/{id}", openapi.Route{
+		Summary:  "Update an article",
+		Tags:     []string{"news"},
+		Ingest:   true,
+		Body:     models.UpdateNewsInput{},
+		Response: models.NewsResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity},
+	})
+	spec.Describe("GET", "/v1/users/{id}/feed", openapi.Route{
+		Summary:     "A user's feed",
+		Description: "Published articles without blocked sources and the sources the user muted.",
+		Tags:        []string{"news", "users"},
+		Admin:       true,
+		Conditional: true,
+		Query:       append(openapi.QueryParams(), format),
+		Response:    newsList,
+		Errors:      notFound,
+	})
+
+	// notifications
+	spec.Describe("GET", "/v1/users/{id}/notifications", openapi.Route{
+		Summary:  "List a user's notifications",
+		Tags:     []string{"notifications"},
+		Admin:    true,
+		Query:    openapi.QueryParams(),
+		Response: openapi.Items(models.NotificationListResponse{}, models.NotificationResponse{}),
+		Errors:   []int{http.StatusBadRequest},
+	})
+	spec.Describe("POST", "/v1/users/{id}/notifications/read", openapi.Route{
+		Summary: "Mark every notification read",
+		Tags:    []string{"notifications"},
+		Admin:   true,
+		Response: openapi.Success(struct {
+			Marked int64 `json:"marked"`
+		}{}),
+		Errors: []int{http.StatusBadRequest},
+	})
+	spec.Describe("POST", "/v1/users/{id}/notifications/{notificationID}/read", openapi.Route{Summary: "Mark a notification read", Tags: []string{"notifications"}, Admin: true, Response: models.SuccessResponse{}, Errors: notFound})
+
+	// admin
+	bodyErrors := []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType}
+	spec.Describe("GET", "/v1/admin/sources", openapi.Route{Summary: "List all sources, blocked included", Tags: []string{"admin"}, Admin: true, Query: openapi.PageParams(), Response: sourceList, Errors: []int{http.StatusBadRequest}})
+	spec.Describe("POST", "/v1/admin/sources", openapi.Route{
+		Summary:  "Register a source",
+		Tags:     []string{"admin"},
+		Admin:    true,
+		Body:     models.CreateSourceInput{},
+		Status:   http.StatusCreated,
+		Response: models.SourceResponse{},
+		Errors:   append(bodyErrors, http.StatusConflict),
+	})
+	spec.Describe("PATCH", "/v1/admin/sources/{id}", openapi.Route{Summary: "Update a source", Tags: []string{"admin"}, Admin: true, Body: models.UpdateSourceInput{}, Response: models.SourceResponse{}, Errors: append(bodyErrors, http.StatusNotFound)})
+	spec.Describe("POST", "/v1/admin/sources/{id}/block", openapi.Route{
+		Summary:      "Block a source",
+		Tags:         []string{"admin"},
+		Admin:        true,
+		Body:         models.BlockSourceInput{},
+		OptionalBody: true,
+		Response:     models.SourceResponse{},
+		Errors:       append(bodyErrors, http.StatusNotFound),
+	})
+	spec.Describe("POST", "/v1/admin/sources/{id}/unblock", openapi.Route{Summary: "Unblock a source", Tags: []string{"admin"}, Admin: true, Response: models.SourceResponse{}, Errors: notFound})
+	spec.Describe("GET", "/v1/admin/categories", openapi.Route{Summary: "List all categories, inactive included", Tags: []string{"admin"}, Admin: true, Query: openapi.QueryParams(), Response: categoryList, Errors: []int{http.StatusBadRequest}})
+	spec.Describe("POST", "/v1/admin/categories", openapi.Route{
+		Summary:  "Create a category",
+		Tags:     []string{"admin"},
+		Admin:    true,
+		Body:     models.CreateCategoryInput{},
+		Status:   http.StatusCreated,
+		Response: models.CategoryResponse{},
+		Errors:   append(bodyErrors, http.StatusNotFound, http.StatusConflict),
+	})
+	spec.Describe("PATCH", "/v1/admin/categories/{id}", openapi.Route{Summary: "Update a category", Tags: []string{"admin"}, Admin: true, Body: models.UpdateCategoryInput{}, Response: models.CategoryResponse{}, Errors: append(bodyErrors, http.StatusNotFound)})
+	spec.Describe("POST", "/v1/admin/notifications", openapi.Route{
+		Summary:  "Send a notification to a user",
+		Tags:     []string{"admin"},
+		Admin:    true,
+		Body:     models.CreateNotificationInput{},
+		Status:   http.StatusCreated,
+		Response: models.NotificationResponse{},
+		Errors:   bodyErrors,
+	})
+	webhookList := openapi.Items(models.WebhookListResponse{}, models.WebhookResponse{})
+	deliveryList := openapi.Items(models.WebhookDeliveryListResponse{}, models.WebhookDeliveryResponse{})
+	spec.Describe("GET", "/v1/admin/webhooks", openapi.Route{Summary: "List webhooks", Tags: []string{"admin"}, Admin: true, Query: openapi.QueryParams(), Response: webhookList, Errors: []int{http.StatusBadRequest}})
+	spec.Describe("POST", "/v1/admin/webhooks", openapi.Route{
+		Summary: "Register a webhook",
+		Description: "The endpoint is sent a signed POST for each event it subscribed to. The response carries the signing secret, which is not shown again. " +
+			"Each delivery has the headers " + webhook.IDHeader + " (the event id, the same on retries), " + webhook.EventHeader + " and " + webhook.SignatureHeader +
+			": t=<unix seconds>,v1=<hex HMAC-SHA256 of \"<t>.<body>\" keyed with the secret>. Any 2xx answer acknowledges it; anything else is retried with exponential backoff until it is dead-lettered.",
+		Tags:     []string{"admin"},
+		Admin:    true,
+		Body:     models.CreateWebhookInput{},
+		Status:   http.StatusCreated,
+		Response: models.WebhookResponse{},
+		Errors:   bodyErrors,
+	})
+	spec.Describe("GET", "/v1/admin/webhooks/{id}", openapi.Route{Summary: "Get a webhook", Tags: []string{"admin"}, Admin: true, Response: models.WebhookResponse{}, Errors: notFound})
+	spec.Describe("PATCH", "/v1/admin/webhooks/{id}", openapi.Route{
+		Summary:     "Update a webhook",
+		Description: "With rotate_secret the response carries the new signing secret.",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Body:        models.UpdateWebhookInput{},
+		Response:    models.WebhookResponse{},
+		Errors:      append(bodyErrors, http.StatusNotFound),
+	})
+	spec.Describe("DELETE", "/v1/admin/webhooks/{id}", openapi.Route{Summary: "Delete a webhook", Tags: []string{"admin"}, Admin: true, Response: models.SuccessResponse{}, Errors: notFound})
+	spec.Describe("GET", "/v1/admin/webhooks/deliveries", openapi.Route{
+		Summary:     "List webhook deliveries",
+		Description: "The delivery log, e.g. filter[status]=dead for the dead letters or filter[webhook_id]=<id> for one webhook.",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Query:       openapi.QueryParams(),
+		Response:    deliveryList,
+		Errors:      []int{http.StatusBadRequest},
+	})
+	spec.Describe("GET", "/v1/admin/webhooks/deliveries/{id}", openapi.Route{Summary: "Get a webhook delivery", Tags: []string{"admin"}, Admin: true, Response: models.WebhookDeliveryResponse{}, Errors: notFound})
+	spec.Describe("POST", "/v1/admin/webhooks/deliveries/{id}/replay", openapi.Route{
+		Summary:     "Replay a webhook delivery",
+		Description: "Sends a delivery that succeeded or was dead-lettered again, with the same payload and event id; its attempts start over. Pending deliveries answer 409.",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Status:      http.StatusAccepted,
+		Response:    models.WebhookDeliveryResponse{},
+		Errors:      append(notFound, http.StatusConflict),
+	})
+	spec.Describe("GET", "/v1/admin/users", openapi.Route{
+		Summary: "List users",
+		Tags:    []string{"admin"},
+		Admin:   true,
+		Query: append(openapi.QueryParams(),
+			openapi.Parameter{Name: "trader_type", In: "query", Description: "Legacy, same as filter[trader_type].", Schema: &openapi.Schema{Type: "string"}},
+			openapi.Parameter{Name: "interest", In: "query", Description: "Legacy, same as filter[interests].", Schema: &openapi.Schema{Type: "string"}},
+		),
+		Response: openapi.Items(models.UserListResponse{}, models.UserResponse{}),
+		Errors:   []int{http.StatusBadRequest},
+	})
+	jobList := openapi.Items(models.JobListResponse{}, models.JobResponse{})
+	spec.Describe("GET", "/v1/admin/jobs", openapi.Route{
+		Summary:     "List background jobs",
+		Description: "The jobs of every queue, e.g. filter[status]=dead for the dead letters or filter[queue]=fanout for one queue.",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Query:       openapi.QueryParams(),
+		Response:    jobList,
+		Errors:      []int{http.StatusBadRequest},
+	})
+	spec.Describe("GET", "/v1/admin/jobs/{id}", openapi.Route{Summary: "Get a background job", Tags: []string{"admin"}, Admin: true, Response: models.JobResponse{}, Errors: notFound})
+	spec.Describe("POST", "/v1/admin/jobs/{id}/retry", openapi.Route{
+		Summary:     "Retry a dead background job",
+		Description: "Makes a dead-lettered job due at once, with the same payload; its attempts start over. Jobs that are not dead answer 409.",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Status:      http.StatusAccepted,
+		Response:    models.JobResponse{},
+		Errors:      append(notFound, http.StatusConflict),
+	})
+	spec.Describe("GET", "/v1/admin/log-levels", openapi.Route{Summary: "Show the log levels", Tags: []string{"admin"}, Admin: true, Response: logging.Settings{}})
+	spec.Describe("PUT", "/v1/admin/log-levels", openapi.Route{
+		Summary:     "Change the log levels",
+		Description: "Replaces the default level and every package override until the next restart. Packages are named by the last element of their import path, e.g. news.",
+		Tags:        []string{"admin"},
+		Admin:       true,
+		Body:        logging.Settings{},
+		Response:    logging.Settings{},
+		Errors:      bodyErrors,
+	})
+
+	// the routes from before /v1
+	for _, alias := range routes.Legacy {
+		spec.Alias(alias.Method, alias.Pattern, "/"+routes.V1.Name+alias.Successor)
+	}
+	return spec
+}
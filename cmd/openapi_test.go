@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/openapi"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// testRouter builds the real router. The mongo client never connects, since
+// nothing is served.
+func testRouter(t *testing.T) chi.Routes {
+	t.Helper()
+	return newTestApp(t, config.Default(), Dependencies{}).mount().(chi.Routes)
+}
+
+func TestEveryRouteIsDescribed(t *testing.T) {
+	doc, err := apiSpec(config.Default().Media).Build(testRouter(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operationIDs := map[string]string{}
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			if op.Summary == "" {
+				t.Errorf("%s %s has no summary", method, path)
+			}
+			if other, ok := operationIDs[op.OperationID]; ok {
+				t.Errorf("%s %s and %s share the operation id %s", method, path, other, op.OperationID)
+			}
+			operationIDs[op.OperationID] = method + " " + path
+		}
+	}
+}
+
+func TestReferencedSchemasExist(t *testing.T) {
+	doc, err := apiSpec(config.Default().Media).Build(testRouter(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const prefix = `"$ref":"#/components/schemas/`
+	for rest := string(raw); ; {
+		i := strings.Index(rest, prefix)
+		if i < 0 {
+			break
+		}
+		rest = rest[i+len(prefix):]
+		name := rest[:strings.IndexByte(rest, '"')]
+		if doc.Components.Schemas[name] == nil {
+			t.Errorf("schema %s is referenced but not defined", name)
+		}
+	}
+}
+
+func TestPartialUpdateInputs(t *testing.T) {
+	doc, err := apiSpec(config.Default().Media).Build(testRouter(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"UpdateNewsInput", "UpdateSourceInput", "UpdateCategoryInput"} {
+		schema := doc.Components.Schemas[name]
+		if schema == nil {
+			t.Errorf("%s is not in the document", name)
+			continue
+		}
+		if len(schema.Required) != 0 || len(schema.Properties) == 0 {
+			t.Errorf("%s: %d properties, required %v; want every property optional", name, len(schema.Properties), schema.Required)
+		}
+	}
+	status := doc.Components.Schemas["UpdateNewsInput"].Properties["status"]
+	if status == nil || len(status.Enum) == 0 {
+		t.Errorf("UpdateNewsInput.status = %+v, want the news statuses as enum", status)
+	}
+	if email := doc.Components.Schemas["CreateUserInput"].Properties["email"]; email.Format != "email" {
+		t.Errorf("CreateUserInput.email format = %q, want email", email.Format)
+	}
+}
+
+func TestServesDocument(t *testing.T) {
+	router := testRouter(t)
+	for path, contentType := range map[string]string{"/openapi.json": "application/json", "/docs": "text/html; charset=utf-8"} {
+		rec := httptest.NewRecorder()
+		router.(http.Handler).ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != contentType {
+			t.Errorf("GET %s = %d %q, want 200 %q", path, rec.Code, rec.Header().Get("Content-Type"), contentType)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.(http.Handler).ServeHTTP(rec, httptest.NewRequest("GET", "/openapi.json", nil))
+	var doc openapi.Document
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != openapi.Version || len(doc.Paths) == 0 {
+		t.Errorf("served document: openapi %q with %d paths", doc.OpenAPI, len(doc.Paths))
+	}
+}
@@ -0,0 +1,120 @@
+// Package apperr defines the typed errors the domain layer returns.
+//
+// Services declare their errors as package level values built here, e.g.
+//
+//	var ErrUserNotFound = apperr.NotFound("user not found")
+//
+// and callers keep matching them with errors.Is. The HTTP layer reads the
+// Kind to pick a status code and the Code to tell clients what went wrong,
+// so handlers no longer need a switch per error.
+package apperr
+
+import (
+	"errors"
+	"maps"
+)
+
+// Kind classifies an error by what the client can do about it
+type Kind string
+
+const (
+	KindNotFound      Kind = "not_found"
+	KindConflict      Kind = "conflict"
+	KindValidation    Kind = "validation"
+	KindUnauthorized  Kind = "unauthorized"
+	KindForbidden     Kind = "forbidden"
+	KindTooLarge      Kind = "too_large"
+	KindUnsupported   Kind = "unsupported_media_type"
+	KindRateLimited   Kind = "rate_limited"
+	KindUnprocessable Kind = "unprocessable"
+)
+
+// defaultCodes are the codes errors carry unless WithCode says otherwise
+var defaultCodes = map[Kind]string{
+	KindNotFound:      "not_found",
+	KindConflict:      "conflict",
+	KindValidation:    "invalid_request",
+	KindUnauthorized:  "unauthorized",
+	KindForbidden:     "forbidden",
+	KindTooLarge:      "body_too_large",
+	KindUnsupported:   "unsupported_media_type",
+	KindRateLimited:   "rate_limited",
+	KindUnprocessable: "unprocessable_entity",
+}
+
+// Error is a domain error meant to be shown to the client
+type Error struct {
+	Kind    Kind
+	Code    string            // machine-readable, e.g. "invalid_cursor"
+	Message string            // human-readable
+	Fields  map[string]string // field name to problem, for validation errors
+}
+
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Code: defaultCodes[kind], Message: message}
+}
+
+// NotFound reports a missing resource
+func NotFound(message string) *Error { return New(KindNotFound, message) }
+
+// Conflict reports a clash with existing state, e.g. a taken slug
+func Conflict(message string) *Error { return New(KindConflict, message) }
+
+// Validation reports bad input
+func Validation(message string) *Error { return New(KindValidation, message) }
+
+// Unauthorized reports missing or wrong credentials
+func Unauthorized(message string) *Error { return New(KindUnauthorized, message) }
+
+// Forbidden reports valid credentials that do not allow the action
+func Forbidden(message string) *Error { return New(KindForbidden, message) }
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is matches errors of the same kind and code, so a copy made by WithField
+// still matches the value it was made from
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && t.Code == e.Code
+}
+
+// WithCode returns a copy of e with a more specific code
+func (e *Error) WithCode(code string) *Error {
+	c := e.clone()
+	c.Code = code
+	return c
+}
+
+// WithField returns a copy of e reporting a problem with one input field
+func (e *Error) WithField(field, problem string) *Error {
+	c := e.clone()
+	if c.Fields == nil {
+		c.Fields = map[string]string{}
+	}
+	c.Fields[field] = problem
+	return c
+}
+
+func (e *Error) clone() *Error {
+	c := *e
+	c.Fields = maps.Clone(e.Fields)
+	return &c
+}
+
+// As returns the typed error in err's chain, if any
+func As(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
+
+// KindOf returns the kind of the typed error in err's chain, or "" for
+// untyped errors
+func KindOf(err error) Kind {
+	if e, ok := As(err); ok {
+		return e.Kind
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"yoharsh14/krant-backend/internal/apperr"
+	"yoharsh14/krant-backend/internal/json"
+)
+
+// AdminSubject and IngestSubject are the subjects of requests carrying the
+// admin and the ingest token
+const (
+	AdminSubject  = "admin"
+	IngestSubject = "ingest"
+)
+
+type subjectKey struct{}
+
+// Subject returns who the request ctx belongs to authenticated as, if anyone
+func Subject(ctx context.Context) (string, bool) {
+	subject, ok := ctx.Value(subjectKey{}).(string)
+	return subject, ok
+}
+
+// RequireAdmin only lets through requests that present the admin token as
+// "Authorization: Bearer <token>". An empty token disables the admin
+// routes entirely instead of leaving them open.
+func RequireAdmin(token string) func(http.Handler) http.Handler {
+	return require("admin", credential{AdminSubject, token})
+}
+
+// RequireIngest lets through requests that present the ingest token, held
+// by the feeds writing articles, or the admin token. With both empty the
+// routes are disabled.
+func RequireIngest(ingestToken, adminToken string) func(http.Handler) http.Handler {
+	return require("ingest", credential{IngestSubject, ingestToken}, credential{AdminSubject, adminToken})
+}
+
+// credential is a token and the subject of the requests presenting it
+type credential struct {
+	subject, token string
+}
+
+// require lets through requests presenting one of the credentials' tokens
+// and sets the subject of the first one matching. Empty tokens are never
+// accepted.
+func require(name string, credentials ...credential) func(http.Handler) http.Handler {
+	enabled := false
+	for _, c := range credentials {
+		enabled = enabled || c.token != ""
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !enabled {
+				json.Error(w, r, apperr.Forbidden(name+" access is disabled"))
+				return
+			}
+
+			given, ok := bearerToken(r)
+			if ok {
+				for _, c := range credentials {
+					if c.token != "" && subtle.ConstantTimeCompare([]byte(given), []byte(c.token)) == 1 {
+						next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), subjectKey{}, c.subject)))
+						return
+					}
+				}
+			}
+			json.Error(w, r, apperr.Unauthorized("missing or invalid "+name+" token"))
+		})
+	}
+}
+
+// bearerToken extracts the token from the Authorization header
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
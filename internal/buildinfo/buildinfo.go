@@ -0,0 +1,67 @@
+// Package buildinfo exposes the build metadata of the running binary.
+//
+// The variables are set at link time:
+//
+//	go build -ldflags "-X yoharsh14/krant-backend/internal/buildinfo.Version=v1.2.0 \
+//	  -X yoharsh14/krant-backend/internal/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X yoharsh14/krant-backend/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd
+//
+// When they are not set the VCS stamp the go tool embeds is used instead.
+package buildinfo
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"yoharsh14/krant-backend/internal/json"
+)
+
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // built from a dirty tree
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build metadata
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = s.Value
+			}
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = s.Value
+			}
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	return info
+}
+
+// Handler serves the build metadata
+func Handler(w http.ResponseWriter, r *http.Request) {
+	json.Write(w, http.StatusOK, Get())
+}
@@ -0,0 +1,53 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// memoryRepository is the in-memory Repository, meant for tests
+type memoryRepository struct {
+	coll *repository.Memory
+}
+
+// NewMemoryRepository returns an empty in-memory Repository
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		coll: repository.NewMemory(),
+	}
+}
+
+func (r *memoryRepository) Create(ctx context.Context, activity *models.UserActivity) error {
+	prepareNew(activity)
+	return r.coll.InsertOne(ctx, activity)
+}
+
+func (r *memoryRepository) FindByID(ctx context.Context, id bson.ObjectID) (*models.UserActivity, error) {
+	var activity models.UserActivity
+	err := r.coll.FindOne(ctx, bson.M{"_id": id}, &activity)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // Activity not found
+		}
+		return nil, err
+	}
+	return &activity, nil
+}
+
+func (r *memoryRepository) Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.UserActivity, models.PageInfo, error) {
+	return repository.FindMemoryPage[models.UserActivity](ctx, r.coll, q, pagination)
+}
+
+func (r *memoryRepository) FindByUser(ctx context.Context, userID bson.ObjectID, pagination models.PaginationParams) ([]models.UserActivity, models.PageInfo, error) {
+	return r.Find(ctx, query.Where(bson.M{"user_id": userID}, newestFirst), pagination)
+}
+
+func (r *memoryRepository) CountByNews(ctx context.Context, newsID bson.ObjectID, activityType string) (int64, error) {
+	return r.coll.CountDocuments(ctx, bson.M{"news_id": newsID, "activity_type": activityType})
+}
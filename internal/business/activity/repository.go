@@ -0,0 +1,90 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Repository stores user activities (views, bookmarks, shares).
+// NewRepository is backed by MongoDB and NewMemoryRepository keeps
+// everything in memory; both behave the same. Lookups return nil, nil when
+// nothing matches.
+type Repository interface {
+	Create(ctx context.Context, activity *models.UserActivity) error
+	FindByID(ctx context.Context, id bson.ObjectID) (*models.UserActivity, error)
+	Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.UserActivity, models.PageInfo, error)
+	FindByUser(ctx context.Context, userID bson.ObjectID, pagination models.PaginationParams) ([]models.UserActivity, models.PageInfo, error)
+	CountByNews(ctx context.Context, newsID bson.ObjectID, activityType string) (int64, error)
+}
+
+// newestFirst is the order activities are listed in
+var newestFirst = query.Sort{Field: "created_at", Desc: true}
+
+type mongoRepository struct {
+	coll *mongo.Collection
+}
+
+func NewRepository(db *mongo.Database) Repository {
+	return &mongoRepository{
+		coll: db.Collection("activities"),
+	}
+}
+
+// ============================================================================
+// CREATE OPERATIONS
+// ============================================================================
+
+// Create records a new activity
+func (r *mongoRepository) Create(ctx context.Context, activity *models.UserActivity) error {
+	prepareNew(activity)
+
+	_, err := r.coll.InsertOne(ctx, activity)
+	return err
+}
+
+// prepareNew fills in the ID and timestamp of an activity about to be created
+func prepareNew(activity *models.UserActivity) {
+	if activity.ID.IsZero() {
+		activity.ID = bson.NewObjectID()
+	}
+	activity.CreatedAt = time.Now()
+}
+
+// ============================================================================
+// READ OPERATIONS
+// ============================================================================
+
+// FindByID retrieves an activity by its ObjectID
+func (r *mongoRepository) FindByID(ctx context.Context, id bson.ObjectID) (*models.UserActivity, error) {
+	var activity models.UserActivity
+	err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&activity)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // Activity not found
+		}
+		return nil, err
+	}
+	return &activity, nil
+}
+
+// Find retrieves a page of activities matching the query
+func (r *mongoRepository) Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.UserActivity, models.PageInfo, error) {
+	return repository.FindPage[models.UserActivity](ctx, r.coll, q, pagination)
+}
+
+// FindByUser retrieves a user's activities, newest first
+func (r *mongoRepository) FindByUser(ctx context.Context, userID bson.ObjectID, pagination models.PaginationParams) ([]models.UserActivity, models.PageInfo, error) {
+	return r.Find(ctx, query.Where(bson.M{"user_id": userID}, newestFirst), pagination)
+}
+
+// CountByNews counts the activities of a type on an article
+func (r *mongoRepository) CountByNews(ctx context.Context, newsID bson.ObjectID, activityType string) (int64, error) {
+	return r.coll.CountDocuments(ctx, bson.M{"news_id": newsID, "activity_type": activityType})
+}
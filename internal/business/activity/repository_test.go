@@ -0,0 +1,76 @@
+package activity
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/repository/repositorytest"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewMemoryRepository() })
+}
+
+func TestMongoRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewRepository(repositorytest.Database(t)) })
+}
+
+// testRepository is the contract every Repository implementation must meet
+func testRepository(t *testing.T, newRepo func(t *testing.T) Repository) {
+	ctx := context.Background()
+	activityID := func(a models.UserActivity) bson.ObjectID { return a.ID }
+	user, other := bson.NewObjectID(), bson.NewObjectID()
+	article := bson.NewObjectID()
+
+	seed := func(t *testing.T, r Repository) []*models.UserActivity {
+		t.Helper()
+		activities := []*models.UserActivity{
+			{UserID: user, NewsID: article, ActivityType: models.ActivityTypeView, Metadata: models.ActivityMetadata{Device: "mobile", TimeSpent: 40}},
+			{UserID: other, NewsID: article, ActivityType: models.ActivityTypeView},
+			{UserID: user, NewsID: article, ActivityType: models.ActivityTypeBookmark},
+			{UserID: user, NewsID: bson.NewObjectID(), ActivityType: models.ActivityTypeView},
+		}
+		for _, a := range activities {
+			if err := r.Create(ctx, a); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+		return activities
+	}
+
+	t.Run("lookups", func(t *testing.T) {
+		r := newRepo(t)
+		activities := seed(t, r)
+
+		got, err := r.FindByID(ctx, activities[0].ID)
+		if err != nil || got == nil || got.Metadata.TimeSpent != 40 || got.CreatedAt.IsZero() {
+			t.Errorf("FindByID = %+v, %v", got, err)
+		}
+		if a, err := r.FindByID(ctx, bson.NewObjectID()); a != nil || err != nil {
+			t.Errorf("FindByID(missing) = %v, %v, want nil, nil", a, err)
+		}
+	})
+
+	t.Run("per user and per article", func(t *testing.T) {
+		r := newRepo(t)
+		activities := seed(t, r)
+
+		walked := repositorytest.AllPages(t, 2, func(p models.PaginationParams) ([]models.UserActivity, models.PageInfo, error) {
+			return r.FindByUser(ctx, user, p)
+		})
+		want := []bson.ObjectID{activities[3].ID, activities[2].ID, activities[0].ID}
+		if got := repositorytest.IDs(walked, activityID); !slices.Equal(got, want) {
+			t.Errorf("FindByUser pages = %v, want %v", got, want)
+		}
+
+		if n, err := r.CountByNews(ctx, article, models.ActivityTypeView); err != nil || n != 2 {
+			t.Errorf("CountByNews(view) = %d, %v, want 2", n, err)
+		}
+		if n, err := r.CountByNews(ctx, article, models.ActivityTypeShare); err != nil || n != 0 {
+			t.Errorf("CountByNews(share) = %d, %v, want 0", n, err)
+		}
+	})
+}
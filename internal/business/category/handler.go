@@ -0,0 +1,129 @@
+package category
+
+import (
+	"net/http"
+	"time"
+	"yoharsh14/krant-backend/internal/json"
+	"yoharsh14/krant-backend/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type Handler interface {
+	ListCategories(w http.ResponseWriter, r *http.Request)
+	GetCategory(w http.ResponseWriter, r *http.Request)
+
+	// admin only
+	ListAllCategories(w http.ResponseWriter, r *http.Request)
+	CreateCategory(w http.ResponseWriter, r *http.Request)
+	UpdateCategory(w http.ResponseWriter, r *http.Request)
+}
+
+type h struct {
+	service Service
+}
+
+func NewHandler(service Service) Handler {
+	return &h{
+		service: service,
+	}
+}
+
+// ListCategories lists the active categories
+func (h *h) ListCategories(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, false)
+}
+
+// ListAllCategories lists every category, inactive ones included
+func (h *h) ListAllCategories(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, true)
+}
+
+// list writes a page of categories, in display order unless ?sort= says
+// otherwise
+func (h *h) list(w http.ResponseWriter, r *http.Request, includeInactive bool) {
+	values := r.URL.Query()
+	q, err := listSchema.Parse(values)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	pagination := models.ParsePagination(values)
+
+	categories, info, err := h.service.ListCategories(r.Context(), includeInactive, q, pagination)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	resp := models.CategoryListResponse{
+		Categories: make([]any, 0, len(categories)),
+		PageMeta:   models.NewPageMeta(pagination, info),
+	}
+	for i := range categories {
+		item, err := q.Select(categories[i].ToResponse())
+		if err != nil {
+			json.Error(w, r, err)
+			return
+		}
+		resp.Categories = append(resp.Categories, item)
+	}
+	// no Last-Modified: a category leaving the page does not move the newest
+	// updated_at, so only the ETag tells such pages apart
+	json.WriteConditional(w, r, resp, time.Time{})
+}
+
+// GetCategory looks a category up by {id}, which may also be its slug
+func (h *h) GetCategory(w http.ResponseWriter, r *http.Request) {
+	var (
+		category *models.Category
+		err      error
+	)
+	param := chi.URLParam(r, "id")
+	if id, perr := bson.ObjectIDFromHex(param); perr == nil {
+		category, err = h.service.GetCategory(r.Context(), id)
+	} else {
+		category, err = h.service.GetCategoryBySlug(r.Context(), param)
+	}
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.WriteConditional(w, r, category.ToResponse(), category.UpdatedAt)
+}
+
+func (h *h) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var input models.CreateCategoryInput
+	if err := json.Read(r, &input); err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	category, err := h.service.CreateCategory(r.Context(), input)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusCreated, category.ToResponse())
+}
+
+func (h *h) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	id, ok := json.ObjectID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var input models.UpdateCategoryInput
+	if err := json.Read(r, &input); err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	category, err := h.service.UpdateCategory(r.Context(), id, input)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusOK, category.ToResponse())
+}
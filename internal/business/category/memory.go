@@ -0,0 +1,68 @@
+package category
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// memoryRepository is the in-memory Repository, meant for tests
+type memoryRepository struct {
+	coll *repository.Memory
+}
+
+// NewMemoryRepository returns an empty in-memory Repository. Slugs are
+// unique, like the index of the categories collection.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		coll: repository.NewMemory("slug"),
+	}
+}
+
+func (r *memoryRepository) Create(ctx context.Context, category *models.Category) error {
+	prepareNew(category)
+	return r.coll.InsertOne(ctx, category)
+}
+
+func (r *memoryRepository) FindByID(ctx context.Context, id bson.ObjectID) (*models.Category, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *memoryRepository) FindBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	return r.findOne(ctx, bson.M{"slug": slug})
+}
+
+func (r *memoryRepository) findOne(ctx context.Context, filter bson.M) (*models.Category, error) {
+	var category models.Category
+	err := r.coll.FindOne(ctx, filter, &category)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // Category not found
+		}
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *memoryRepository) Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.Category, models.PageInfo, error) {
+	return repository.FindMemoryPage[models.Category](ctx, r.coll, q, pagination)
+}
+
+func (r *memoryRepository) Update(ctx context.Context, id bson.ObjectID, update bson.M) error {
+	update["updated_at"] = time.Now()
+
+	matched, err := r.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package category
+
+import "yoharsh14/krant-backend/internal/query"
+
+// listSchema is what clients may filter, sort and select on in category listings
+var listSchema = query.NewSchema(query.Sort{Field: "order"},
+	query.Field{Name: "id", Path: "_id", Kind: query.ObjectID, Ops: []query.Op{query.Eq, query.In}},
+	query.Field{Name: "name", Ops: []query.Op{query.Eq, query.Contains}, Sortable: true},
+	query.Field{Name: "slug", Ops: []query.Op{query.Eq, query.In}},
+	query.Field{Name: "description"},
+	query.Field{Name: "icon"},
+	query.Field{Name: "color"},
+	query.Field{Name: "order", Kind: query.Int, Ops: query.Range, Sortable: true},
+	query.Field{Name: "is_active", Kind: query.Bool, Ops: []query.Op{query.Eq}},
+	query.Field{Name: "parent_category", Kind: query.ObjectID, Ops: []query.Op{query.Eq, query.Exists}},
+	query.Field{Name: "created_at", Kind: query.Time, Ops: query.Range, Sortable: true, Hidden: true},
+)
@@ -0,0 +1,112 @@
+package category
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Repository stores categories. NewRepository is backed by MongoDB and
+// NewMemoryRepository keeps everything in memory; both behave the same.
+// Lookups return nil, nil when nothing matches; updates of a missing category
+// return mongo.ErrNoDocuments.
+type Repository interface {
+	Create(ctx context.Context, category *models.Category) error
+	FindByID(ctx context.Context, id bson.ObjectID) (*models.Category, error)
+	FindBySlug(ctx context.Context, slug string) (*models.Category, error)
+	Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.Category, models.PageInfo, error)
+	Update(ctx context.Context, id bson.ObjectID, update bson.M) error
+}
+
+type mongoRepository struct {
+	coll *mongo.Collection
+}
+
+func NewRepository(db *mongo.Database) Repository {
+	return &mongoRepository{
+		coll: db.Collection("categories"),
+	}
+}
+
+// ============================================================================
+// CREATE OPERATIONS
+// ============================================================================
+
+// Create inserts a new category into the database
+func (r *mongoRepository) Create(ctx context.Context, category *models.Category) error {
+	prepareNew(category)
+
+	_, err := r.coll.InsertOne(ctx, category)
+	return err
+}
+
+// prepareNew fills in the ID and timestamps of a category about to be created
+func prepareNew(category *models.Category) {
+	if category.ID.IsZero() {
+		category.ID = bson.NewObjectID()
+	}
+
+	now := time.Now()
+	category.CreatedAt = now
+	category.UpdatedAt = now
+}
+
+// ============================================================================
+// READ OPERATIONS
+// ============================================================================
+
+// FindByID retrieves a category by its ObjectID
+func (r *mongoRepository) FindByID(ctx context.Context, id bson.ObjectID) (*models.Category, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// FindBySlug retrieves a category by its slug
+func (r *mongoRepository) FindBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	return r.findOne(ctx, bson.M{"slug": slug})
+}
+
+func (r *mongoRepository) findOne(ctx context.Context, filter bson.M) (*models.Category, error) {
+	var category models.Category
+	err := r.coll.FindOne(ctx, filter).Decode(&category)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // Category not found
+		}
+		return nil, err
+	}
+	return &category, nil
+}
+
+// Find retrieves a page of categories matching the query
+func (r *mongoRepository) Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.Category, models.PageInfo, error) {
+	return repository.FindPage[models.Category](ctx, r.coll, q, pagination)
+}
+
+// ============================================================================
+// UPDATE OPERATIONS
+// ============================================================================
+
+// Update updates a category's fields
+func (r *mongoRepository) Update(ctx context.Context, id bson.ObjectID, update bson.M) error {
+	filter := bson.M{"_id": id}
+
+	// Always update the updated_at timestamp
+	update["updated_at"] = time.Now()
+
+	result, err := r.coll.UpdateOne(ctx, filter, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package category
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/repository/repositorytest"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewMemoryRepository() })
+}
+
+func TestMongoRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewRepository(repositorytest.Database(t)) })
+}
+
+// testRepository is the contract every Repository implementation must meet
+func testRepository(t *testing.T, newRepo func(t *testing.T) Repository) {
+	ctx := context.Background()
+	categoryID := func(c models.Category) bson.ObjectID { return c.ID }
+
+	seed := func(t *testing.T, r Repository) []*models.Category {
+		t.Helper()
+		categories := []*models.Category{
+			{Name: "Stocks", Slug: "stocks", Order: 2, IsActive: true},
+			{Name: "Crypto", Slug: "crypto", Order: 1, IsActive: true},
+			{Name: "Forex", Slug: "forex", Order: 3},
+		}
+		for _, c := range categories {
+			if err := r.Create(ctx, c); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+		parent := categories[0].ID
+		smallCaps := &models.Category{Name: "Small caps", Slug: "small-caps", Order: 2, IsActive: true, ParentCategory: &parent}
+		if err := r.Create(ctx, smallCaps); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		return append(categories, smallCaps)
+	}
+
+	t.Run("lookups and not found", func(t *testing.T) {
+		r := newRepo(t)
+		categories := seed(t, r)
+
+		bySlug, err := r.FindBySlug(ctx, "crypto")
+		if err != nil || bySlug == nil || bySlug.ID != categories[1].ID || bySlug.CreatedAt.IsZero() {
+			t.Errorf("FindBySlug = %v, %v", bySlug, err)
+		}
+		if c, err := r.FindBySlug(ctx, "bonds"); c != nil || err != nil {
+			t.Errorf("FindBySlug(missing) = %v, %v, want nil, nil", c, err)
+		}
+		if c, err := r.FindByID(ctx, bson.NewObjectID()); c != nil || err != nil {
+			t.Errorf("FindByID(missing) = %v, %v, want nil, nil", c, err)
+		}
+		if err := r.Update(ctx, bson.NewObjectID(), bson.M{"name": "x"}); !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Errorf("Update(missing) = %v, want ErrNoDocuments", err)
+		}
+	})
+
+	t.Run("unique slug", func(t *testing.T) {
+		r := newRepo(t)
+		categories := seed(t, r)
+
+		if err := r.Create(ctx, &models.Category{Name: "Other", Slug: "stocks"}); !mongo.IsDuplicateKeyError(err) {
+			t.Errorf("Create with taken slug = %v, want duplicate key error", err)
+		}
+		if err := r.Update(ctx, categories[1].ID, bson.M{"slug": "forex"}); !mongo.IsDuplicateKeyError(err) {
+			t.Errorf("Update to taken slug = %v, want duplicate key error", err)
+		}
+	})
+
+	t.Run("filters and order", func(t *testing.T) {
+		r := newRepo(t)
+		categories := seed(t, r)
+
+		cases := []struct {
+			params url.Values
+			want   []int
+		}{
+			{url.Values{}, []int{1, 0, 3, 2}},
+			{url.Values{"filter[is_active]": {"true"}}, []int{1, 0, 3}},
+			{url.Values{"filter[parent_category][exists]": {"false"}, "sort": {"-name"}}, []int{0, 2, 1}},
+			{url.Values{"filter[parent_category]": {categories[0].ID.Hex()}}, []int{3}},
+			{url.Values{"filter[order][gte]": {"2"}, "filter[name][contains]": {"s"}}, []int{0, 3}},
+		}
+		for _, c := range cases {
+			q, err := listSchema.Parse(c.params)
+			if err != nil {
+				t.Fatalf("%v: %v", c.params, err)
+			}
+			found, info, err := r.Find(ctx, q, models.GetPaginationParams(1, 100))
+			if err != nil {
+				t.Fatalf("%v: %v", c.params, err)
+			}
+			var want []bson.ObjectID
+			for _, i := range c.want {
+				want = append(want, categories[i].ID)
+			}
+			if got := repositorytest.IDs(found, categoryID); !slices.Equal(got, want) || info.TotalCount != int64(len(want)) {
+				t.Errorf("%v: got %v (%d in total), want %v", c.params, got, info.TotalCount, want)
+			}
+		}
+
+		q, _ := listSchema.Parse(url.Values{})
+		walked := repositorytest.AllPages(t, 1, func(p models.PaginationParams) ([]models.Category, models.PageInfo, error) {
+			return r.Find(ctx, q, p)
+		})
+		want := []bson.ObjectID{categories[1].ID, categories[0].ID, categories[3].ID, categories[2].ID}
+		if got := repositorytest.IDs(walked, categoryID); !slices.Equal(got, want) {
+			t.Errorf("cursor pages = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		r := newRepo(t)
+		categories := seed(t, r)
+
+		if err := r.Update(ctx, categories[2].ID, bson.M{"is_active": true, "order": 0}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := r.FindByID(ctx, categories[2].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.IsActive || got.Order != 0 || got.Slug != "forex" || got.UpdatedAt.Before(got.CreatedAt) {
+			t.Errorf("after update = %+v", got)
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package category
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"yoharsh14/krant-backend/internal/apperr"
+	"yoharsh14/krant-backend/internal/cache"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/events"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrCategoryNotFound = apperr.NotFound("category not found")
+	ErrCategoryExists   = apperr.Conflict("a category with this slug already exists")
+	ErrInvalidSlug      = apperr.Validation("slug may only contain lower case letters, digits and dashes").WithField("slug", "may only contain lower case letters, digits and dashes")
+	ErrParentNotFound   = apperr.Validation("parent category not found").WithField("parent_category", "must name an existing category")
+	ErrNoFieldsToUpdate = apperr.Validation("no fields to update").WithCode("no_fields_to_update")
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+type Service interface {
+	CreateCategory(ctx context.Context, input models.CreateCategoryInput) (*models.Category, error)
+	GetCategory(ctx context.Context, id bson.ObjectID) (*models.Category, error)
+	GetCategoryBySlug(ctx context.Context, slug string) (*models.Category, error)
+	UpdateCategory(ctx context.Context, id bson.ObjectID, input models.UpdateCategoryInput) (*models.Category, error)
+	ListCategories(ctx context.Context, includeInactive bool, q query.Query, pagination models.PaginationParams) ([]models.Category, models.PageInfo, error)
+}
+
+type svc struct {
+	r      Repository
+	outbox events.Outbox
+
+	// categories by "id:<hex>" and "slug:<slug>"; pages of active ones by
+	// query and pagination
+	items cache.Cache[models.Category]
+	pages cache.Cache[categoryPage]
+}
+
+type categoryPage struct {
+	categories []models.Category
+	info       models.PageInfo
+}
+
+func NewService(repo Repository, outbox events.Outbox, cacheCfg config.Cache) Service {
+	return &svc{
+		r:      repo,
+		outbox: outbox,
+		items:  cache.New[models.Category]("category", cacheCfg),
+		pages:  cache.New[categoryPage]("category_pages", cacheCfg),
+	}
+}
+
+// CreateCategory adds a category; slugs are unique
+func (s *svc) CreateCategory(ctx context.Context, input models.CreateCategoryInput) (*models.Category, error) {
+	slug := strings.ToLower(strings.TrimSpace(input.Slug))
+	if !slugPattern.MatchString(slug) {
+		return nil, ErrInvalidSlug
+	}
+
+	existing, err := s.r.FindBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrCategoryExists
+	}
+	if err := s.checkParent(ctx, input.ParentCategory); err != nil {
+		return nil, err
+	}
+
+	category := &models.Category{
+		Name:           strings.TrimSpace(input.Name),
+		Slug:           slug,
+		Description:    input.Description,
+		Icon:           input.Icon,
+		Color:          input.Color,
+		Order:          input.Order,
+		IsActive:       true,
+		ParentCategory: input.ParentCategory,
+	}
+	err = s.outbox.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.r.Create(ctx, category); err != nil {
+			return err
+		}
+		return s.outbox.Record(ctx, events.CategoryCreated, category)
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrCategoryExists
+		}
+		return nil, err
+	}
+	s.pages.Clear(ctx)
+	return category, nil
+}
+
+// GetCategory retrieves a category by its ID
+func (s *svc) GetCategory(ctx context.Context, id bson.ObjectID) (*models.Category, error) {
+	return s.cached(ctx, "id:"+id.Hex(), func() (*models.Category, error) {
+		return s.r.FindByID(ctx, id)
+	})
+}
+
+// GetCategoryBySlug retrieves a category by its slug
+func (s *svc) GetCategoryBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	slug = strings.ToLower(slug)
+	return s.cached(ctx, "slug:"+slug, func() (*models.Category, error) {
+		return s.r.FindBySlug(ctx, slug)
+	})
+}
+
+// cached returns the category under key, calling find on a miss. Copies are
+// returned so callers cannot change the cached value.
+func (s *svc) cached(ctx context.Context, key string, find func() (*models.Category, error)) (*models.Category, error) {
+	if category, ok := s.items.Get(ctx, key); ok {
+		return &category, nil
+	}
+	category, err := find()
+	if err != nil {
+		return nil, err
+	}
+	if category == nil {
+		return nil, ErrCategoryNotFound
+	}
+	s.items.Set(ctx, key, *category)
+	return category, nil
+}
+
+// UpdateCategory applies a partial update to a category
+func (s *svc) UpdateCategory(ctx context.Context, id bson.ObjectID, input models.UpdateCategoryInput) (*models.Category, error) {
+	update := bson.M{}
+
+	if input.Name != nil {
+		update["name"] = strings.TrimSpace(*input.Name)
+	}
+	if input.Description != nil {
+		update["description"] = *input.Description
+	}
+	if input.Icon != nil {
+		update["icon"] = *input.Icon
+	}
+	if input.Color != nil {
+		update["color"] = *input.Color
+	}
+	if input.Order != nil {
+		update["order"] = *input.Order
+	}
+	if input.IsActive != nil {
+		update["is_active"] = *input.IsActive
+	}
+
+	if len(update) == 0 {
+		return nil, ErrNoFieldsToUpdate
+	}
+
+	var category *models.Category
+	err := s.outbox.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.r.Update(ctx, id, update); err != nil {
+			return err
+		}
+		var err error
+		if category, err = s.r.FindByID(ctx, id); err != nil {
+			return err
+		}
+		return s.outbox.Record(ctx, events.CategoryUpdated, category)
+	})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+	s.items.Delete(ctx, "id:"+id.Hex())
+	s.items.Delete(ctx, "slug:"+category.Slug)
+	s.pages.Clear(ctx)
+	return category, nil
+}
+
+// ListCategories retrieves a page of categories matching the query. Inactive
+// categories are only included when includeInactive is set.
+func (s *svc) ListCategories(ctx context.Context, includeInactive bool, q query.Query, pagination models.PaginationParams) ([]models.Category, models.PageInfo, error) {
+	if includeInactive {
+		// the admin listing, which should show changes at once
+		return s.r.Find(ctx, q, pagination)
+	}
+
+	q = q.And(bson.M{"is_active": true})
+	key, cacheable := cache.Key(q, pagination)
+	if cacheable {
+		if page, ok := s.pages.Get(ctx, key); ok {
+			return slices.Clone(page.categories), page.info, nil
+		}
+	}
+	categories, info, err := s.r.Find(ctx, q, pagination)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+	if cacheable {
+		s.pages.Set(ctx, key, categoryPage{categories: slices.Clone(categories), info: info})
+	}
+	return categories, info, nil
+}
+
+func (s *svc) checkParent(ctx context.Context, parentID *bson.ObjectID) error {
+	if parentID == nil {
+		return nil
+	}
+	parent, err := s.r.FindByID(ctx, *parentID)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		return ErrParentNotFound
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package job
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSpec is a parsed cron expression. Schedules run in UTC.
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64 // bit n set when n matches
+	anyDay                        bool   // day of month or day of week is *
+}
+
+// cronMacros are the shorthands for common schedules
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// parseCron reads a standard five field cron expression: minute (0-59),
+// hour (0-23), day of month (1-31), month (1-12) and day of week (0-7,
+// Sunday being 0 or 7). Each field is *, a number, a range a-b or a list of
+// them, each optionally stepped with /n; the macros such as @daily stand in
+// for a whole expression. As in cron, a day matches when either day field
+// does, unless one of them is *.
+func parseCron(expr string) (*cronSpec, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q: want 5 fields, got %d", expr, len(fields))
+	}
+
+	var (
+		spec cronSpec
+		err  error
+	)
+	bounds := []struct {
+		bits      *uint64
+		low, high int
+	}{
+		{&spec.minute, 0, 59},
+		{&spec.hour, 0, 23},
+		{&spec.dom, 1, 31},
+		{&spec.month, 1, 12},
+		{&spec.dow, 0, 7},
+	}
+	for i, b := range bounds {
+		if *b.bits, err = parseCronField(fields[i], b.low, b.high); err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+	}
+	if spec.dow&(1<<7) != 0 {
+		spec.dow |= 1 // Sunday
+	}
+	spec.anyDay = fields[2] == "*" || fields[4] == "*"
+	return &spec, nil
+}
+
+// parseCronField returns the bits of the values a field matches
+func parseCronField(field string, low, high int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		values, stepText, stepped := strings.Cut(part, "/")
+		step := 1
+		if stepped {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+		}
+
+		lo, hi := low, high
+		switch {
+		case values == "*":
+		case strings.Contains(values, "-"):
+			from, to, _ := strings.Cut(values, "-")
+			var err error
+			if lo, err = cronValue(from, low, high); err != nil {
+				return 0, err
+			}
+			if hi, err = cronValue(to, low, high); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q", values)
+			}
+		default:
+			n, err := cronValue(values, low, high)
+			if err != nil {
+				return 0, err
+			}
+			lo = n
+			if !stepped {
+				hi = n
+			}
+		}
+
+		for n := lo; n <= hi; n += step {
+			bits |= 1 << uint(n)
+		}
+	}
+	return bits, nil
+}
+
+func cronValue(s string, low, high int) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < low || n > high {
+		return 0, fmt.Errorf("%q is not a number from %d to %d", s, low, high)
+	}
+	return n, nil
+}
+
+// next returns the first time the schedule matches after t, to the minute,
+// or the zero time when it matches none in the next five years, e.g. on
+// February 30
+func (c *cronSpec) next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	for end := t.AddDate(5, 0, 0); t.Before(end); {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, time.UTC)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c *cronSpec) matchesDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.anyDay {
+		return dom && dow
+	}
+	return dom || dow
+}
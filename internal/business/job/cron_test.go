@@ -0,0 +1,66 @@
+package job
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// a Monday
+	from := time.Date(2026, 3, 2, 9, 30, 15, 0, time.UTC)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", at(3, 2, 9, 31)},
+		{"@hourly", at(3, 2, 10, 0)},
+		{"@daily", at(3, 3, 0, 0)},
+		{"@weekly", at(3, 8, 0, 0)},
+		{"@monthly", at(4, 1, 0, 0)},
+		{"*/15 * * * *", at(3, 2, 9, 45)},
+		{"0 9-17/4 * * *", at(3, 2, 13, 0)},
+		{"30 9 * * 1", at(3, 9, 9, 30)},
+		{"0 8 * * 1-5", at(3, 3, 8, 0)},
+		{"0 0 * * 7", at(3, 8, 0, 0)},
+		{"0 12 15 * *", at(3, 15, 12, 0)},
+		{"0 0 1,15 2 *", time.Date(2027, 2, 1, 0, 0, 0, 0, time.UTC)},
+		// either day field matches when neither is *
+		{"0 0 13 * 5", at(3, 6, 0, 0)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, c := range cases {
+		spec, err := parseCron(c.expr)
+		if err != nil {
+			t.Errorf("parseCron(%q) = %v", c.expr, err)
+			continue
+		}
+		if got := spec.next(from); !got.Equal(c.want) {
+			t.Errorf("%q: next = %v, want %v", c.expr, got, c.want)
+		}
+	}
+}
+
+func TestCronRejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@often",
+	} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("parseCron(%q) succeeded", expr)
+		}
+	}
+}
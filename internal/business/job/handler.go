@@ -0,0 +1,86 @@
+package job
+
+import (
+	"net/http"
+	"yoharsh14/krant-backend/internal/json"
+	"yoharsh14/krant-backend/internal/models"
+)
+
+// Handler serves the admin routes of the background jobs
+type Handler interface {
+	ListJobs(w http.ResponseWriter, r *http.Request)
+	GetJob(w http.ResponseWriter, r *http.Request)
+	RetryJob(w http.ResponseWriter, r *http.Request)
+}
+
+type h struct {
+	service Service
+}
+
+func NewHandler(service Service) Handler {
+	return &h{
+		service: service,
+	}
+}
+
+// ListJobs lists the jobs, newest first unless ?sort= says otherwise;
+// ?filter[status]=dead lists the dead letters
+func (h *h) ListJobs(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	q, err := listSchema.Parse(values)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	pagination := models.ParsePagination(values)
+
+	jobs, info, err := h.service.ListJobs(r.Context(), q, pagination)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	resp := models.JobListResponse{
+		Jobs:     make([]any, 0, len(jobs)),
+		PageMeta: models.NewPageMeta(pagination, info),
+	}
+	for i := range jobs {
+		item, err := q.Select(jobs[i].ToResponse())
+		if err != nil {
+			json.Error(w, r, err)
+			return
+		}
+		resp.Jobs = append(resp.Jobs, item)
+	}
+	json.Write(w, http.StatusOK, resp)
+}
+
+// GetJob looks a job up by {id}
+func (h *h) GetJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := json.ObjectID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	job, err := h.service.GetJob(r.Context(), id)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusOK, job.ToResponse())
+}
+
+// RetryJob queues the dead job in {id} again
+func (h *h) RetryJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := json.ObjectID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	job, err := h.service.RetryJob(r.Context(), id)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusAccepted, job.ToResponse())
+}
@@ -0,0 +1,81 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// memoryRepository is the in-memory Repository, meant for tests
+type memoryRepository struct {
+	coll *repository.Memory
+}
+
+// NewMemoryRepository returns an empty in-memory Repository
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		coll: repository.NewMemory("unique_key"),
+	}
+}
+
+func (r *memoryRepository) Create(ctx context.Context, job *models.Job) error {
+	prepareNew(job)
+	return r.coll.InsertOne(ctx, job)
+}
+
+func (r *memoryRepository) FindByID(ctx context.Context, id bson.ObjectID) (*models.Job, error) {
+	var job models.Job
+	err := r.coll.FindOne(ctx, bson.M{"_id": id}, &job)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // Job not found
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *memoryRepository) Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.Job, models.PageInfo, error) {
+	return repository.FindMemoryPage[models.Job](ctx, r.coll, q, pagination)
+}
+
+func (r *memoryRepository) CountDue(ctx context.Context, queue string, now time.Time) (int64, error) {
+	return r.coll.CountDocuments(ctx, waiting(queue, now))
+}
+
+func (r *memoryRepository) Claim(ctx context.Context, queue string, now time.Time, visibility time.Duration) (*models.Job, error) {
+	var job models.Job
+	err := r.coll.FindOneAndUpdate(ctx, dueAt(queue, now), bson.D{{Key: "run_at", Value: 1}}, claim(now, visibility), &job)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // nothing due
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *memoryRepository) Finish(ctx context.Context, claimed *models.Job, update bson.M) error {
+	return r.updateOne(ctx, sameClaim(claimed), finish(update))
+}
+
+func (r *memoryRepository) Retry(ctx context.Context, id bson.ObjectID, now time.Time) error {
+	return r.updateOne(ctx, retryable(id), reset(now))
+}
+
+func (r *memoryRepository) updateOne(ctx context.Context, filter, update bson.M) error {
+	matched, err := r.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
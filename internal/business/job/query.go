@@ -0,0 +1,24 @@
+package job
+
+import (
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+)
+
+// listSchema is what clients may filter, sort and select on in job listings
+var listSchema = query.NewSchema(query.Sort{Field: "created_at", Desc: true},
+	query.Field{Name: "id", Path: "_id", Kind: query.ObjectID, Ops: []query.Op{query.Eq, query.In}},
+	query.Field{Name: "queue", Ops: []query.Op{query.Eq, query.In}},
+	query.Field{Name: "type", Ops: []query.Op{query.Eq, query.In}},
+	query.Field{Name: "payload"},
+	query.Field{Name: "status", Ops: []query.Op{query.Eq, query.Ne, query.In}, Valid: models.IsValidJobStatus},
+	query.Field{Name: "attempts", Kind: query.Int, Ops: query.Range, Sortable: true},
+	query.Field{Name: "max_attempts", Kind: query.Int},
+	query.Field{Name: "run_at", Kind: query.Time, Ops: query.Range, Sortable: true},
+	query.Field{Name: "schedule", Ops: []query.Op{query.Eq}},
+	query.Field{Name: "last_error", Ops: []query.Op{query.Contains}},
+	query.Field{Name: "started_at", Kind: query.Time, Ops: query.Range, Sortable: true},
+	query.Field{Name: "finished_at", Kind: query.Time, Ops: query.Range, Sortable: true},
+	query.Field{Name: "created_at", Kind: query.Time, Ops: query.Range, Sortable: true},
+	query.Field{Name: "updated_at", Kind: query.Time},
+)
@@ -0,0 +1,170 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Repository stores the jobs of every queue. NewRepository is backed by
+// MongoDB and NewMemoryRepository keeps everything in memory; both behave
+// the same. Lookups return nil, nil when nothing matches; updates of a
+// missing job, or of one that changed since it was claimed, return
+// mongo.ErrNoDocuments.
+type Repository interface {
+	Create(ctx context.Context, job *models.Job) error
+	FindByID(ctx context.Context, id bson.ObjectID) (*models.Job, error)
+	Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.Job, models.PageInfo, error)
+	CountDue(ctx context.Context, queue string, now time.Time) (int64, error)
+	Claim(ctx context.Context, queue string, now time.Time, visibility time.Duration) (*models.Job, error)
+	Finish(ctx context.Context, claimed *models.Job, update bson.M) error
+	Retry(ctx context.Context, id bson.ObjectID, now time.Time) error
+}
+
+type mongoRepository struct {
+	coll *mongo.Collection
+}
+
+func NewRepository(db *mongo.Database) Repository {
+	return &mongoRepository{
+		coll: db.Collection("jobs"),
+	}
+}
+
+// Create adds a job to its queue. Another job with the same unique key is a
+// duplicate key error.
+func (r *mongoRepository) Create(ctx context.Context, job *models.Job) error {
+	prepareNew(job)
+
+	_, err := r.coll.InsertOne(ctx, job)
+	return err
+}
+
+// prepareNew fills in the ID and timestamps of a job about to be created
+func prepareNew(job *models.Job) {
+	if job.ID.IsZero() {
+		job.ID = bson.NewObjectID()
+	}
+
+	now := time.Now()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+}
+
+// FindByID retrieves a job by its ObjectID
+func (r *mongoRepository) FindByID(ctx context.Context, id bson.ObjectID) (*models.Job, error) {
+	var job models.Job
+	err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // Job not found
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Find retrieves a page of jobs matching the query
+func (r *mongoRepository) Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.Job, models.PageInfo, error) {
+	return repository.FindPage[models.Job](ctx, r.coll, q, pagination)
+}
+
+// CountDue counts the jobs of a queue that are due and waiting for a worker
+func (r *mongoRepository) CountDue(ctx context.Context, queue string, now time.Time) (int64, error) {
+	return r.coll.CountDocuments(ctx, waiting(queue, now))
+}
+
+func waiting(queue string, now time.Time) bson.M {
+	return bson.M{"queue": queue, "status": models.JobPending, "run_at": bson.M{"$lte": now}}
+}
+
+// Claim takes the job of a queue that has been due longest and marks it
+// running. The job is hidden from other claims for visibility: a worker that
+// dies mid-run thus leaves it to be claimed again once that runs out. The
+// claim counts as an attempt. It returns nil, nil when nothing is due.
+func (r *mongoRepository) Claim(ctx context.Context, queue string, now time.Time, visibility time.Duration) (*models.Job, error) {
+	var job models.Job
+	err := r.coll.FindOneAndUpdate(ctx, dueAt(queue, now), claim(now, visibility),
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "run_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&job)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // nothing due
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// dueAt matches the jobs of queue that are due, and the running ones whose
+// worker has not finished within the visibility timeout
+func dueAt(queue string, now time.Time) bson.M {
+	return bson.M{
+		"queue":  queue,
+		"status": bson.M{"$in": bson.A{models.JobPending, models.JobRunning}},
+		"run_at": bson.M{"$lte": now},
+	}
+}
+
+func claim(now time.Time, visibility time.Duration) bson.M {
+	return bson.M{
+		"$set": bson.M{"status": models.JobRunning, "run_at": now.Add(visibility), "started_at": now, "updated_at": now},
+		"$inc": bson.M{"attempts": 1},
+	}
+}
+
+// Finish sets the fields in update on a claimed job, ending its run. It
+// returns mongo.ErrNoDocuments when the job was claimed again since, after
+// its visibility timeout ran out.
+func (r *mongoRepository) Finish(ctx context.Context, claimed *models.Job, update bson.M) error {
+	result, err := r.coll.UpdateOne(ctx, sameClaim(claimed), finish(update))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func sameClaim(claimed *models.Job) bson.M {
+	return bson.M{"_id": claimed.ID, "status": models.JobRunning, "attempts": claimed.Attempts}
+}
+
+func finish(update bson.M) bson.M {
+	update["updated_at"] = time.Now()
+	return bson.M{"$set": update}
+}
+
+// Retry makes a dead job due at now, with its attempts starting over. It
+// returns mongo.ErrNoDocuments when there is no such job or it is not dead.
+func (r *mongoRepository) Retry(ctx context.Context, id bson.ObjectID, now time.Time) error {
+	result, err := r.coll.UpdateOne(ctx, retryable(id), reset(now))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func retryable(id bson.ObjectID) bson.M {
+	return bson.M{"_id": id, "status": models.JobDead}
+}
+
+func reset(now time.Time) bson.M {
+	return bson.M{
+		"$set":   bson.M{"status": models.JobPending, "attempts": 0, "run_at": now, "updated_at": now},
+		"$unset": bson.M{"finished_at": ""},
+	}
+}
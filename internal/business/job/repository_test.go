@@ -0,0 +1,178 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/repository/repositorytest"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewMemoryRepository() })
+}
+
+func TestMongoRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewRepository(repositorytest.Database(t)) })
+}
+
+// testRepository is the contract every Repository implementation must meet
+func testRepository(t *testing.T, newRepo func(t *testing.T) Repository) {
+	ctx := context.Background()
+	now := time.Now().Add(time.Second).Truncate(time.Millisecond)
+
+	newJob := func(queue string, runAt time.Time) *models.Job {
+		return &models.Job{
+			Queue:       queue,
+			Type:        "digest",
+			Payload:     `{"user":"u1"}`,
+			Status:      models.JobPending,
+			MaxAttempts: 3,
+			RunAt:       runAt,
+		}
+	}
+
+	t.Run("claim takes the due jobs of a queue, oldest first", func(t *testing.T) {
+		r := newRepo(t)
+		later, first, other := newJob(QueueDefault, now.Add(time.Hour)), newJob(QueueDefault, now.Add(-time.Minute)), newJob(QueueFanout, now)
+		second := newJob(QueueDefault, now)
+		for _, job := range []*models.Job{later, second, first, other} {
+			if err := r.Create(ctx, job); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if n, err := r.CountDue(ctx, QueueDefault, now); err != nil || n != 2 {
+			t.Errorf("CountDue = %d, %v; want 2", n, err)
+		}
+		for _, want := range []*models.Job{first, second} {
+			claimed, err := r.Claim(ctx, QueueDefault, now, time.Minute)
+			if err != nil || claimed == nil || claimed.ID != want.ID {
+				t.Fatalf("Claim = %+v, %v; want job %s", claimed, err, want.ID.Hex())
+			}
+			if claimed.Status != models.JobRunning || claimed.Attempts != 1 ||
+				!claimed.RunAt.Equal(now.Add(time.Minute)) || claimed.StartedAt == nil {
+				t.Errorf("claimed job = %+v; want it running, first attempt, hidden for a minute", claimed)
+			}
+		}
+		if none, err := r.Claim(ctx, QueueDefault, now, time.Minute); none != nil || err != nil {
+			t.Errorf("Claim with nothing due = %+v, %v; want nil, nil", none, err)
+		}
+		if n, err := r.CountDue(ctx, QueueDefault, now); err != nil || n != 0 {
+			t.Errorf("CountDue with all claimed = %d, %v; want 0", n, err)
+		}
+
+		again, err := r.Claim(ctx, QueueDefault, now.Add(time.Minute), time.Minute)
+		if err != nil || again == nil || (again.ID != first.ID && again.ID != second.ID) || again.Attempts != 2 {
+			t.Errorf("Claim after the visibility timeout = %+v, %v; want a claimed job again, second attempt", again, err)
+		}
+	})
+
+	t.Run("finish only the current claim", func(t *testing.T) {
+		r := newRepo(t)
+		job := newJob(QueueDefault, now)
+		if err := r.Create(ctx, job); err != nil {
+			t.Fatal(err)
+		}
+		stale, err := r.Claim(ctx, QueueDefault, now, time.Minute)
+		if err != nil || stale == nil {
+			t.Fatalf("Claim = %v, %v", stale, err)
+		}
+		current, err := r.Claim(ctx, QueueDefault, now.Add(time.Minute), time.Minute)
+		if err != nil || current == nil {
+			t.Fatalf("Claim = %v, %v", current, err)
+		}
+
+		if err := r.Finish(ctx, stale, bson.M{"status": models.JobSucceeded}); !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Errorf("Finish of a claim taken over = %v, want mongo.ErrNoDocuments", err)
+		}
+		if err := r.Finish(ctx, current, bson.M{"status": models.JobDead, "last_error": "boom", "finished_at": now}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := r.FindByID(ctx, job.ID)
+		if err != nil || got == nil || got.Status != models.JobDead || got.LastError != "boom" || got.FinishedAt == nil {
+			t.Errorf("FindByID = %+v, %v; want it dead", got, err)
+		}
+	})
+
+	t.Run("retry only dead jobs", func(t *testing.T) {
+		r := newRepo(t)
+		job := newJob(QueueDefault, now)
+		if err := r.Create(ctx, job); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Retry(ctx, job.ID, now); !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Errorf("Retry of a pending job = %v, want mongo.ErrNoDocuments", err)
+		}
+		claimed, err := r.Claim(ctx, QueueDefault, now, time.Minute)
+		if err != nil || claimed == nil {
+			t.Fatalf("Claim = %v, %v", claimed, err)
+		}
+		if err := r.Finish(ctx, claimed, bson.M{"status": models.JobDead, "finished_at": now}); err != nil {
+			t.Fatal(err)
+		}
+
+		later := now.Add(time.Hour)
+		if err := r.Retry(ctx, job.ID, later); err != nil {
+			t.Fatal(err)
+		}
+		got, err := r.FindByID(ctx, job.ID)
+		if err != nil || got == nil || got.Status != models.JobPending || got.Attempts != 0 ||
+			!got.RunAt.Equal(later) || got.FinishedAt != nil {
+			t.Errorf("FindByID after Retry = %+v, %v; want it pending from the start", got, err)
+		}
+		if err := r.Retry(ctx, bson.NewObjectID(), now); !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Errorf("Retry of a missing job = %v, want mongo.ErrNoDocuments", err)
+		}
+	})
+
+	t.Run("unique keys", func(t *testing.T) {
+		r := newRepo(t)
+		for _, job := range []*models.Job{newJob(QueueDefault, now), newJob(QueueDefault, now)} {
+			if err := r.Create(ctx, job); err != nil {
+				t.Fatalf("Create without a unique key = %v", err)
+			}
+		}
+		keyed := newJob(QueueDefault, now)
+		keyed.UniqueKey = "schedule:digest:2026-01-01T00:00:00Z"
+		if err := r.Create(ctx, keyed); err != nil {
+			t.Fatal(err)
+		}
+		again := newJob(QueueDefault, now)
+		again.UniqueKey = keyed.UniqueKey
+		if err := r.Create(ctx, again); !mongo.IsDuplicateKeyError(err) {
+			t.Errorf("Create with a taken unique key = %v, want a duplicate key error", err)
+		}
+	})
+
+	t.Run("find", func(t *testing.T) {
+		r := newRepo(t)
+		dead := newJob(QueueFanout, now)
+		for _, job := range []*models.Job{newJob(QueueDefault, now), dead} {
+			if err := r.Create(ctx, job); err != nil {
+				t.Fatal(err)
+			}
+		}
+		claimed, err := r.Claim(ctx, QueueFanout, now, time.Minute)
+		if err != nil || claimed == nil {
+			t.Fatalf("Claim = %v, %v", claimed, err)
+		}
+		if err := r.Finish(ctx, claimed, bson.M{"status": models.JobDead}); err != nil {
+			t.Fatal(err)
+		}
+
+		q, err := listSchema.Parse(url.Values{"filter[status]": {models.JobDead}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		jobs, _, err := r.Find(ctx, q, models.PaginationParams{Page: 1, Limit: 10})
+		if err != nil || len(jobs) != 1 || jobs[0].ID != dead.ID {
+			t.Errorf("Find dead = %+v, %v; want the fanout job", jobs, err)
+		}
+	})
+}
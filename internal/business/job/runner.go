@@ -0,0 +1,279 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/metrics"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/retry"
+	"yoharsh14/krant-backend/internal/tracing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var errNoHandler = errors.New("no handler is registered for this job type")
+
+// Runner runs the jobs of the queues that have handlers registered, and
+// enqueues the runs of the recurring schedules. Any number of runners, in
+// any number of instances, may share a repository: each job is claimed by
+// one of them at a time. Register and Schedule must be called before Run.
+type Runner struct {
+	r         Repository
+	cfg       config.Jobs
+	handlers  map[string]handler  // by job type
+	schedules map[string]schedule // by name
+	now       func() time.Time
+}
+
+type handler struct {
+	queue string
+	run   func(ctx context.Context, job *models.Job) error
+}
+
+// schedule enqueues a job with the same payload on every tick of a cron
+// expression
+type schedule struct {
+	spec    *cronSpec
+	queue   string
+	jobType string
+	payload json.RawMessage
+}
+
+func NewRunner(repo Repository, cfg config.Jobs) *Runner {
+	return &Runner{
+		r:         repo,
+		cfg:       cfg,
+		handlers:  map[string]handler{},
+		schedules: map[string]schedule{},
+		now:       time.Now,
+	}
+}
+
+// Register makes the runner run the jobs of type t with fn, which gets their
+// decoded payload. A job fails when fn returns an error or panics, or does
+// not return within the visibility timeout, whose deadline its context
+// carries. It panics when t already has a handler.
+func Register[T any](r *Runner, t Type[T], fn func(ctx context.Context, payload T) error) {
+	if _, ok := r.handlers[t.Name]; ok {
+		panic("job: handler for " + t.Name + " registered twice")
+	}
+	r.handlers[t.Name] = handler{
+		queue: t.queue(),
+		run: func(ctx context.Context, job *models.Job) error {
+			var payload T
+			if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+				return fmt.Errorf("decoding payload: %w", err)
+			}
+			return fn(ctx, payload)
+		},
+	}
+}
+
+// Schedule makes the runner enqueue a job of type t with payload on every
+// tick of spec, a cron expression read in UTC. Only one job is pending per
+// schedule: the next is enqueued when a run succeeds or dies, so ticks
+// missed while no runner was up, or while a run took longer, are skipped
+// rather than caught up on.
+func Schedule[T any](r *Runner, name, spec string, t Type[T], payload T) error {
+	if _, ok := r.schedules[name]; ok {
+		return fmt.Errorf("job schedule %s is defined twice", name)
+	}
+	cron, err := parseCron(spec)
+	if err != nil {
+		return fmt.Errorf("job schedule %s: %w", name, err)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("job schedule %s: encoding payload: %w", name, err)
+	}
+	r.schedules[name] = schedule{spec: cron, queue: t.queue(), jobType: t.Name, payload: body}
+	return nil
+}
+
+// Run works the queues until ctx is done, with the number of workers
+// JOB_QUEUE_CONCURRENCY sets for each, or concurrency. Each worker claims due
+// jobs one by one and waits for poll when none is due. A run under way when
+// ctx is done is finished, within the visibility timeout.
+func (r *Runner) Run(ctx context.Context, concurrency int, poll time.Duration) {
+	for _, name := range slices.Sorted(maps.Keys(r.schedules)) {
+		if err := r.enqueueNext(ctx, name, r.now()); err != nil {
+			slog.ErrorContext(ctx, "job schedule failed", "schedule", name, "error", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	queues := r.queues()
+	for _, queue := range queues {
+		workers := concurrency
+		if n, ok := r.cfg.Concurrency[queue]; ok {
+			workers = n
+		}
+		for range workers {
+			wg.Go(func() {
+				for ctx.Err() == nil {
+					ran, err := r.RunNext(ctx, queue)
+					if err != nil && ctx.Err() == nil {
+						slog.ErrorContext(ctx, "job run failed", "queue", queue, "error", err)
+					}
+					if ran && err == nil {
+						continue
+					}
+					select {
+					case <-ctx.Done():
+					case <-time.After(poll):
+					}
+				}
+			})
+		}
+	}
+	wg.Go(func() {
+		for ctx.Err() == nil {
+			r.measure(ctx, queues)
+			select {
+			case <-ctx.Done():
+			case <-time.After(poll):
+			}
+		}
+	})
+	wg.Wait()
+}
+
+// queues lists the queues that have handlers registered
+func (r *Runner) queues() []string {
+	var queues []string
+	for _, h := range r.handlers {
+		if !slices.Contains(queues, h.queue) {
+			queues = append(queues, h.queue)
+		}
+	}
+	slices.Sort(queues)
+	return queues
+}
+
+// measure sets the depth gauges of the queues
+func (r *Runner) measure(ctx context.Context, queues []string) {
+	for _, queue := range queues {
+		n, err := r.r.CountDue(ctx, queue, r.now())
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.WarnContext(ctx, "counting due jobs failed", "queue", queue, "error", err)
+			}
+			return
+		}
+		metrics.JobQueueDepth.With(queue).Set(float64(n))
+	}
+}
+
+// RunNext runs the job of queue that has been due longest and reports
+// whether there was one
+func (r *Runner) RunNext(ctx context.Context, queue string) (bool, error) {
+	job, err := r.r.Claim(ctx, queue, r.now(), r.cfg.VisibilityTimeout)
+	if err != nil || job == nil {
+		return false, err
+	}
+	return true, tracing.Job(context.WithoutCancel(ctx), job.Type, func(ctx context.Context) error {
+		return r.run(ctx, job)
+	})
+}
+
+// run calls the handler of a claimed job and records how it went: succeeded,
+// otherwise retried after a backoff or, once out of attempts, dead. A job
+// of a schedule has its next run enqueued once it succeeded or died.
+func (r *Runner) run(ctx context.Context, job *models.Job) error {
+	err := r.call(ctx, job)
+
+	now := r.now()
+	var result string
+	update := bson.M{}
+	switch {
+	case err == nil:
+		result = models.JobSucceeded
+		update["status"] = models.JobSucceeded
+		update["finished_at"] = now
+		update["last_error"] = ""
+	case job.Attempts >= job.MaxAttempts:
+		result = models.JobDead
+		update["status"] = models.JobDead
+		update["finished_at"] = now
+		update["last_error"] = err.Error()
+	default:
+		result = "retried"
+		update["status"] = models.JobPending
+		update["run_at"] = now.Add(retry.Backoff(r.cfg.RetryBase, r.cfg.RetryMax, job.Attempts))
+		update["last_error"] = err.Error()
+	}
+
+	if err := r.r.Finish(ctx, job, update); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// claimed again after the visibility timeout ran out
+			slog.WarnContext(ctx, "job claimed again while it ran", "job_id", job.ID.Hex(), "type", job.Type)
+			return nil
+		}
+		return err
+	}
+	metrics.JobsRun.With(job.Queue, job.Type, result).Inc()
+	if result == models.JobDead {
+		slog.WarnContext(ctx, "job dead-lettered",
+			"job_id", job.ID.Hex(), "queue", job.Queue, "type", job.Type,
+			"attempts", job.Attempts, "error", update["last_error"])
+	}
+
+	if job.Schedule != "" && result != "retried" {
+		return r.enqueueNext(ctx, job.Schedule, now)
+	}
+	return nil
+}
+
+// call runs the handler of a job, within the visibility timeout, turning a
+// panic into an error
+func (r *Runner) call(ctx context.Context, job *models.Job) (err error) {
+	h, ok := r.handlers[job.Type]
+	if !ok {
+		return errNoHandler
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.VisibilityTimeout)
+	defer cancel()
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return h.run(ctx, job)
+}
+
+// enqueueNext enqueues the run of a schedule at its first tick after t. The
+// tick is part of the job's unique key, so runners enqueueing it at once
+// enqueue it once.
+func (r *Runner) enqueueNext(ctx context.Context, name string, t time.Time) error {
+	s, ok := r.schedules[name]
+	if !ok {
+		return nil // dropped from this build, its runs end here
+	}
+	tick := s.spec.next(t)
+	if tick.IsZero() {
+		return fmt.Errorf("job schedule %s never runs", name)
+	}
+
+	job, err := newJob(s.queue, s.jobType, s.payload, EnqueueOptions{
+		RunAt:     tick,
+		UniqueKey: "schedule:" + name + ":" + tick.Format(time.RFC3339),
+	}, r.cfg, r.now())
+	if err != nil {
+		return err
+	}
+	job.Schedule = name
+	if err := r.r.Create(ctx, job); err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"yoharsh14/krant-backend/internal/clocktest"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type digest struct {
+	User string `json:"user"`
+}
+
+var digestJob = Type[digest]{Name: "digest"}
+
+type fixture struct {
+	repo    Repository
+	service Service
+	runner  *Runner
+	clock   *clocktest.Clock
+}
+
+func newFixture(t *testing.T) fixture {
+	cfg := config.Jobs{
+		VisibilityTimeout: time.Minute,
+		MaxAttempts:       2,
+		RetryBase:         time.Minute,
+		RetryMax:          time.Hour,
+	}
+	repo := NewMemoryRepository()
+	c := clocktest.At(time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC))
+	s := NewService(repo, cfg)
+	s.(*svc).now = c.Now
+	r := NewRunner(repo, cfg)
+	r.now = c.Now
+	return fixture{repo: repo, service: s, runner: r, clock: c}
+}
+
+// runDue runs every job of the default queue that is due now
+func (f fixture) runDue(t *testing.T) {
+	t.Helper()
+	for {
+		ran, err := f.runner.RunNext(context.Background(), QueueDefault)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ran {
+			return
+		}
+	}
+}
+
+func (f fixture) find(t *testing.T, id bson.ObjectID) *models.Job {
+	t.Helper()
+	job, err := f.service.GetJob(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func TestRunsJobsWithTheirPayload(t *testing.T) {
+	f := newFixture(t)
+	var got []digest
+	Register(f.runner, digestJob, func(ctx context.Context, payload digest) error {
+		got = append(got, payload)
+		return nil
+	})
+
+	job, err := Enqueue(context.Background(), f.service, digestJob, digest{User: "u1"}, EnqueueOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Queue != QueueDefault || job.MaxAttempts != 2 || !job.RunAt.Equal(f.clock.Now()) {
+		t.Errorf("enqueued %+v; want it due now in the default queue with the default attempts", job)
+	}
+	f.runDue(t)
+
+	if len(got) != 1 || got[0].User != "u1" {
+		t.Errorf("handler got %+v, want the payload once", got)
+	}
+	if job = f.find(t, job.ID); job.Status != models.JobSucceeded || job.FinishedAt == nil {
+		t.Errorf("job = %+v, want it succeeded", job)
+	}
+}
+
+func TestDelayedJobsWaitUntilDue(t *testing.T) {
+	f := newFixture(t)
+	calls := 0
+	Register(f.runner, digestJob, func(ctx context.Context, payload digest) error {
+		calls++
+		return nil
+	})
+
+	_, err := Enqueue(context.Background(), f.service, digestJob, digest{}, EnqueueOptions{RunAt: f.clock.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.runDue(t)
+	if calls != 0 {
+		t.Fatalf("ran %d times before the job was due", calls)
+	}
+	f.clock.Advance(time.Hour)
+	f.runDue(t)
+	if calls != 1 {
+		t.Errorf("ran %d times once due, want 1", calls)
+	}
+}
+
+func TestFailedJobsAreRetriedThenDead(t *testing.T) {
+	f := newFixture(t)
+	calls := 0
+	Register(f.runner, digestJob, func(ctx context.Context, payload digest) error {
+		calls++
+		return errors.New("mail server down")
+	})
+	job, err := Enqueue(context.Background(), f.service, digestJob, digest{}, EnqueueOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.runDue(t)
+	job = f.find(t, job.ID)
+	if job.Status != models.JobPending || job.Attempts != 1 || job.LastError != "mail server down" ||
+		!job.RunAt.After(f.clock.Now()) || job.RunAt.After(f.clock.Now().Add(time.Minute)) {
+		t.Fatalf("job after a failure = %+v; want it pending, retried within a minute", job)
+	}
+	f.runDue(t)
+	if calls != 1 {
+		t.Fatalf("retried before the backoff ran out")
+	}
+
+	f.clock.Advance(time.Minute)
+	f.runDue(t)
+	if job = f.find(t, job.ID); job.Status != models.JobDead || job.Attempts != 2 || job.FinishedAt == nil {
+		t.Fatalf("job out of attempts = %+v; want it dead", job)
+	}
+
+	job, err = f.service.RetryJob(context.Background(), job.ID)
+	if err != nil || job.Status != models.JobPending || job.Attempts != 0 {
+		t.Fatalf("RetryJob = %+v, %v; want it pending from the start", job, err)
+	}
+	if _, err := f.service.RetryJob(context.Background(), job.ID); !errors.Is(err, ErrJobNotDead) {
+		t.Errorf("RetryJob of a pending job = %v, want ErrJobNotDead", err)
+	}
+	if _, err := f.service.RetryJob(context.Background(), bson.NewObjectID()); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("RetryJob of a missing job = %v, want ErrJobNotFound", err)
+	}
+	f.runDue(t)
+	if calls != 3 {
+		t.Errorf("handler ran %d times, want a third run after the retry", calls)
+	}
+}
+
+func TestPanicsAndUnknownTypesFailTheJob(t *testing.T) {
+	f := newFixture(t)
+	Register(f.runner, digestJob, func(ctx context.Context, payload digest) error {
+		panic("nil map")
+	})
+	panicked, err := Enqueue(context.Background(), f.service, digestJob, digest{}, EnqueueOptions{MaxAttempts: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown, err := f.service.Enqueue(context.Background(), QueueDefault, "retired", nil, EnqueueOptions{MaxAttempts: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.runDue(t)
+
+	if job := f.find(t, panicked.ID); job.Status != models.JobDead || job.LastError != "panic: nil map" {
+		t.Errorf("job whose handler panicked = %+v, want it dead with the panic", job)
+	}
+	if job := f.find(t, unknown.ID); job.Status != models.JobDead || job.LastError != errNoHandler.Error() {
+		t.Errorf("job without a handler = %+v, want it dead", job)
+	}
+}
+
+func TestUniqueKeys(t *testing.T) {
+	f := newFixture(t)
+	opts := EnqueueOptions{UniqueKey: "digest:u1"}
+	if _, err := Enqueue(context.Background(), f.service, digestJob, digest{User: "u1"}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Enqueue(context.Background(), f.service, digestJob, digest{User: "u1"}, opts); !errors.Is(err, ErrJobExists) {
+		t.Errorf("Enqueue with a taken unique key = %v, want ErrJobExists", err)
+	}
+}
+
+func TestSchedulesEnqueueTheirNextRun(t *testing.T) {
+	f := newFixture(t)
+	var got []digest
+	Register(f.runner, digestJob, func(ctx context.Context, payload digest) error {
+		got = append(got, payload)
+		return nil
+	})
+	if err := Schedule(f.runner, "hourly-digest", "@hourly", digestJob, digest{User: "all"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Schedule(f.runner, "hourly-digest", "@daily", digestJob, digest{}); err == nil {
+		t.Error("Schedule with a taken name succeeded")
+	}
+	if err := Schedule(f.runner, "broken", "61 * * * *", digestJob, digest{}); err == nil {
+		t.Error("Schedule with an invalid expression succeeded")
+	}
+
+	// started twice, as by two instances: the first run is enqueued once
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f.runner.Run(ctx, 1, time.Second)
+	f.runner.Run(ctx, 1, time.Second)
+
+	pending := func() []models.Job {
+		t.Helper()
+		q, err := listSchema.Parse(map[string][]string{"filter[status]": {models.JobPending}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		jobs, _, err := f.repo.Find(context.Background(), q, models.PaginationParams{Page: 1, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return jobs
+	}
+	jobs := pending()
+	if len(jobs) != 1 || jobs[0].Schedule != "hourly-digest" || !jobs[0].RunAt.Equal(time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("pending jobs = %+v; want one run at 10:00", jobs)
+	}
+
+	// the runner was down through 10:00 and 11:00: the late run goes ahead
+	// once, the next is at 12:00
+	f.clock.Advance(2*time.Hour + 10*time.Minute)
+	f.runDue(t)
+	if len(got) != 1 || got[0].User != "all" {
+		t.Errorf("handler got %+v, want the scheduled payload once", got)
+	}
+	jobs = pending()
+	if len(jobs) != 1 || !jobs[0].RunAt.Equal(time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("pending jobs = %+v; want one run at 12:00", jobs)
+	}
+}
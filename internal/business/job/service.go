@@ -0,0 +1,148 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"yoharsh14/krant-backend/internal/apperr"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// The queues jobs are put in; each has its own workers, so a busy queue does
+// not hold up the others
+const (
+	QueueDefault = "default"
+	QueueFanout  = "fanout"
+)
+
+var (
+	ErrJobNotFound = apperr.NotFound("job not found")
+	ErrJobNotDead  = apperr.Conflict("only dead jobs can be retried").WithCode("job_not_dead")
+	ErrJobExists   = apperr.Conflict("a job with this unique key already exists").WithCode("job_exists")
+)
+
+// Type is a kind of job, whose payload is a T. Enqueue adds jobs of a type
+// and Register sets the handler that runs them.
+type Type[T any] struct {
+	Name  string
+	Queue string // QueueDefault when empty
+}
+
+func (t Type[T]) queue() string {
+	if t.Queue == "" {
+		return QueueDefault
+	}
+	return t.Queue
+}
+
+// EnqueueOptions tune a job being enqueued; the zero value runs it at once
+type EnqueueOptions struct {
+	RunAt       time.Time // when it is due, now when zero
+	MaxAttempts int       // JOB_MAX_ATTEMPTS when zero
+	UniqueKey   string    // when set, enqueueing another job with it fails with ErrJobExists
+}
+
+type Service interface {
+	Enqueue(ctx context.Context, queue, jobType string, payload any, opts EnqueueOptions) (*models.Job, error)
+	GetJob(ctx context.Context, id bson.ObjectID) (*models.Job, error)
+	ListJobs(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.Job, models.PageInfo, error)
+	RetryJob(ctx context.Context, id bson.ObjectID) (*models.Job, error)
+}
+
+type svc struct {
+	r   Repository
+	cfg config.Jobs
+	now func() time.Time
+}
+
+func NewService(repo Repository, cfg config.Jobs) Service {
+	return &svc{
+		r:   repo,
+		cfg: cfg,
+		now: time.Now,
+	}
+}
+
+// Enqueue is Service.Enqueue for a job type, checking the payload's type
+func Enqueue[T any](ctx context.Context, s Service, t Type[T], payload T, opts EnqueueOptions) (*models.Job, error) {
+	return s.Enqueue(ctx, t.queue(), t.Name, payload, opts)
+}
+
+// Enqueue adds a job to a queue, with its payload encoded as JSON for the
+// handler of its type
+func (s *svc) Enqueue(ctx context.Context, queue, jobType string, payload any, opts EnqueueOptions) (*models.Job, error) {
+	job, err := newJob(queue, jobType, payload, opts, s.cfg, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.r.Create(ctx, job); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrJobExists
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// newJob builds a pending job, applying the defaults of opts
+func newJob(queue, jobType string, payload any, opts EnqueueOptions, cfg config.Jobs, now time.Time) (*models.Job, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s payload: %w", jobType, err)
+	}
+	job := &models.Job{
+		Queue:       queue,
+		Type:        jobType,
+		Payload:     string(body),
+		Status:      models.JobPending,
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       opts.RunAt,
+		UniqueKey:   opts.UniqueKey,
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = cfg.MaxAttempts
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = now
+	}
+	return job, nil
+}
+
+// GetJob retrieves a job by its ID
+func (s *svc) GetJob(ctx context.Context, id bson.ObjectID) (*models.Job, error) {
+	job, err := s.r.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// ListJobs retrieves a page of jobs matching the query
+func (s *svc) ListJobs(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.Job, models.PageInfo, error) {
+	return s.r.Find(ctx, q, pagination)
+}
+
+// RetryJob makes a dead job due at once, with its attempts starting over
+func (s *svc) RetryJob(ctx context.Context, id bson.ObjectID) (*models.Job, error) {
+	err := s.r.Retry(ctx, id, s.now())
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, err := s.GetJob(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrJobNotDead
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetJob(ctx, id)
+}
@@ -0,0 +1,178 @@
+package news
+
+import (
+	"net/http"
+	"time"
+	"yoharsh14/krant-backend/internal/content"
+	"yoharsh14/krant-backend/internal/json"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+)
+
+type Handler interface {
+	CreateNews(w http.ResponseWriter, r *http.Request)
+	GetNews(w http.ResponseWriter, r *http.Request)
+	UpdateNews(w http.ResponseWriter, r *http.Request)
+	ListNews(w http.ResponseWriter, r *http.Request)
+	ListFeed(w http.ResponseWriter, r *http.Request)
+}
+
+type h struct {
+	service Service
+}
+
+func NewHandler(service Service) Handler {
+	return &h{
+		service: service,
+	}
+}
+
+func (h *h) CreateNews(w http.ResponseWriter, r *http.Request) {
+	var input models.CreateNewsInput
+	if err := json.Read(r, &input); err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	news, err := h.service.CreateNews(r.Context(), input)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusCreated, news.ToResponse(false))
+}
+
+// GetNews returns one article, rendering its body in the format asked for
+// with ?format=html|text|markdown
+func (h *h) GetNews(w http.ResponseWriter, r *http.Request) {
+	id, ok := json.ObjectID(w, r, "id")
+	if !ok {
+		return
+	}
+	format, err := content.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	news, err := h.service.GetNews(r.Context(), id)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	resp, err := render(news, format)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.WriteConditional(w, r, resp, news.UpdatedAt)
+}
+
+func (h *h) UpdateNews(w http.ResponseWriter, r *http.Request) {
+	id, ok := json.ObjectID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var input models.UpdateNewsInput
+	if err := json.Read(r, &input); err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	news, err := h.service.UpdateNews(r.Context(), id, input)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusOK, news.ToResponse(false))
+}
+
+// ListNews lists articles, newest first unless ?sort= says otherwise. The
+// legacy ?status= parameter still filters by status.
+func (h *h) ListNews(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, func(q query.Query, pagination models.PaginationParams) ([]models.News, models.PageInfo, error) {
+		return h.service.ListNews(r.Context(), q, pagination)
+	})
+}
+
+// ListFeed lists the published articles for the user in {id}, leaving out
+// muted and blocked sources
+func (h *h) ListFeed(w http.ResponseWriter, r *http.Request) {
+	userID, ok := json.ObjectID(w, r, "id")
+	if !ok {
+		return
+	}
+	h.list(w, r, func(q query.Query, pagination models.PaginationParams) ([]models.News, models.PageInfo, error) {
+		return h.service.ListFeed(r.Context(), userID, q, pagination)
+	})
+}
+
+// list writes a page of articles. filter[...], sort and fields follow the
+// query package syntax; ?cursor= continues after the next_cursor of a
+// previous page and ?page= keeps the legacy offset mode.
+func (h *h) list(w http.ResponseWriter, r *http.Request, find func(query.Query, models.PaginationParams) ([]models.News, models.PageInfo, error)) {
+	values := r.URL.Query()
+	if status := values.Get("status"); status != "" {
+		values.Add("filter[status]", status)
+	}
+
+	format, err := content.ParseFormat(values.Get("format"))
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	q, err := listSchema.Parse(values)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	pagination := models.ParsePagination(values)
+	list, info, err := find(q, pagination)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	resp := models.NewsListResponse{
+		News:     make([]any, 0, len(list)),
+		PageMeta: models.NewPageMeta(pagination, info),
+	}
+
+	for i := range list {
+		item, err := render(&list[i], format)
+		if err != nil {
+			json.Error(w, r, err)
+			return
+		}
+		selected, err := q.Select(item)
+		if err != nil {
+			json.Error(w, r, err)
+			return
+		}
+		resp.News = append(resp.News, selected)
+	}
+	// no Last-Modified: an article leaving the page does not move the newest
+	// updated_at, so only the ETag tells such pages apart
+	json.WriteConditional(w, r, resp, time.Time{})
+}
+
+// render builds the response with the body converted to the requested format
+func render(news *models.News, format content.Format) (models.NewsResponse, error) {
+	resp := news.ToResponse(false)
+	if format == content.FormatText {
+		// the plain text version is stored, no need to re-parse the body
+		resp.Content = news.ContentText
+	} else {
+		body, err := content.Render(news.Content, format)
+		if err != nil {
+			return models.NewsResponse{}, err
+		}
+		resp.Content = body
+	}
+	resp.ContentFormat = string(format)
+	return resp, nil
+}
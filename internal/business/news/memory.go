@@ -0,0 +1,85 @@
+package news
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// memoryRepository is the in-memory Repository, meant for tests
+type memoryRepository struct {
+	coll   *repository.Memory
+	quotas *repository.Memory
+}
+
+// NewMemoryRepository returns an empty in-memory Repository
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		coll:   repository.NewMemory(),
+		quotas: repository.NewMemory(),
+	}
+}
+
+func (r *memoryRepository) Create(ctx context.Context, news *models.News) error {
+	prepareNew(news)
+	return r.coll.InsertOne(ctx, news)
+}
+
+func (r *memoryRepository) FindByID(ctx context.Context, id bson.ObjectID) (*models.News, error) {
+	var news models.News
+	err := r.coll.FindOne(ctx, bson.M{"_id": id}, &news)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // News not found
+		}
+		return nil, err
+	}
+	return &news, nil
+}
+
+func (r *memoryRepository) Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.News, models.PageInfo, error) {
+	return repository.FindMemoryPage[models.News](ctx, r.coll, q, pagination)
+}
+
+func (r *memoryRepository) LatestCreatedAt(ctx context.Context) (time.Time, error) {
+	var latest latestCreated
+	err := r.coll.FindOneSorted(ctx, bson.M{}, bson.D{{Key: "created_at", Value: -1}}, &latest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, nil
+	}
+	return latest.CreatedAt, err
+}
+
+func (r *memoryRepository) TakeIngestionSlot(ctx context.Context, sourceID bson.ObjectID, hour time.Time, limit int) (bool, error) {
+	id := quotaID(sourceID, hour)
+	for range 2 {
+		matched, err := r.quotas.UpdateOne(ctx, bson.M{"_id": id, "count": bson.M{"$lt": limit}}, bson.M{"$inc": bson.M{"count": 1}})
+		if err != nil || matched > 0 {
+			return matched > 0, err
+		}
+		err = r.quotas.InsertOne(ctx, ingestionQuota{ID: id, SourceID: sourceID, Hour: hour, Count: 1})
+		if !mongo.IsDuplicateKeyError(err) {
+			return err == nil, err
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryRepository) Update(ctx context.Context, id bson.ObjectID, update bson.M) error {
+	update["updated_at"] = time.Now()
+
+	matched, err := r.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
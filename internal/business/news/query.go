@@ -0,0 +1,28 @@
+package news
+
+import (
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+)
+
+// listSchema is what clients may filter, sort and select on in news listings
+var listSchema = query.NewSchema(query.Sort{Field: "published_at", Desc: true},
+	query.Field{Name: "id", Path: "_id", Kind: query.ObjectID, Ops: []query.Op{query.Eq, query.In}},
+	query.Field{Name: "title", Ops: []query.Op{query.Contains}, Sortable: true},
+	query.Field{Name: "description"},
+	query.Field{Name: "content", Needs: []string{"content_text"}},
+	query.Field{Name: "word_count", Kind: query.Int, Ops: query.Range},
+	query.Field{Name: "reading_time", Kind: query.Int, Ops: query.Range, Sortable: true},
+	query.Field{Name: "author", Ops: []query.Op{query.Eq, query.In}},
+	query.Field{Name: "source_id", Kind: query.ObjectID, Ops: []query.Op{query.Eq, query.In, query.Nin}, Hidden: true},
+	query.Field{Name: "source"},
+	query.Field{Name: "image"},
+	query.Field{Name: "categories", Ops: []query.Op{query.Eq, query.In, query.Nin}},
+	query.Field{Name: "tags", Ops: []query.Op{query.Eq, query.In, query.Nin}},
+	query.Field{Name: "trader_relevance", Ops: []query.Op{query.Eq, query.In}, Valid: models.IsValidTraderType},
+	query.Field{Name: "status", Ops: []query.Op{query.Eq, query.Ne, query.In}, Valid: models.IsValidNewsStatus},
+	query.Field{Name: "published_at", Kind: query.Time, Ops: query.Range, Sortable: true},
+	query.Field{Name: "metrics"},
+	query.Field{Name: "views", Path: "metrics.views", Kind: query.Int, Ops: query.Range, Sortable: true, Hidden: true},
+	query.Field{Name: "created_at", Kind: query.Time, Ops: query.Range, Sortable: true},
+)
@@ -0,0 +1,182 @@
+package news
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Repository stores news articles. NewRepository is backed by MongoDB and
+// NewMemoryRepository keeps everything in memory; both behave the same.
+// Lookups return nil, nil when nothing matches; updates of a missing article
+// return mongo.ErrNoDocuments.
+//
+// TakeIngestionSlot counts an article against its source's quota for the
+// hour starting at hour and reports whether it was within limit, which must
+// be positive. The check and the count are one conditional write, so
+// concurrent ingestions cannot both take the last slot.
+type Repository interface {
+	Create(ctx context.Context, news *models.News) error
+	FindByID(ctx context.Context, id bson.ObjectID) (*models.News, error)
+	Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.News, models.PageInfo, error)
+	LatestCreatedAt(ctx context.Context) (time.Time, error)
+	TakeIngestionSlot(ctx context.Context, sourceID bson.ObjectID, hour time.Time, limit int) (bool, error)
+	Update(ctx context.Context, id bson.ObjectID, update bson.M) error
+}
+
+type mongoRepository struct {
+	coll   *mongo.Collection
+	quotas *mongo.Collection
+}
+
+func NewRepository(db *mongo.Database) Repository {
+	return &mongoRepository{
+		coll:   db.Collection("news"),
+		quotas: db.Collection("ingestion_quotas"),
+	}
+}
+
+// ingestionQuota counts the articles ingested from a source in an hour
+type ingestionQuota struct {
+	ID       string        `bson:"_id"` // see quotaID
+	SourceID bson.ObjectID `bson:"source_id"`
+	Hour     time.Time     `bson:"hour"`
+	Count    int           `bson:"count"`
+}
+
+// quotaID keys the quota of a source for the hour starting at hour
+func quotaID(sourceID bson.ObjectID, hour time.Time) string {
+	return sourceID.Hex() + "/" + hour.UTC().Format(time.RFC3339)
+}
+
+// ============================================================================
+// CREATE OPERATIONS
+// ============================================================================
+
+// Create inserts a new news article into the database
+func (r *mongoRepository) Create(ctx context.Context, news *models.News) error {
+	prepareNew(news)
+
+	_, err := r.coll.InsertOne(ctx, news)
+	return err
+}
+
+// prepareNew fills in the ID, timestamps and defaults of an article about to
+// be created
+func prepareNew(news *models.News) {
+	if news.ID.IsZero() {
+		news.ID = bson.NewObjectID()
+	}
+
+	now := time.Now()
+	news.CreatedAt = now
+	news.UpdatedAt = now
+
+	if news.Categories == nil {
+		news.Categories = []string{}
+	}
+	if news.Tags == nil {
+		news.Tags = []string{}
+	}
+	if news.TraderRelevance == nil {
+		news.TraderRelevance = []string{}
+	}
+	if news.Status == "" {
+		news.Status = models.NewsStatusDraft
+	}
+}
+
+// ============================================================================
+// READ OPERATIONS
+// ============================================================================
+
+// FindByID retrieves a news article by its ObjectID
+func (r *mongoRepository) FindByID(ctx context.Context, id bson.ObjectID) (*models.News, error) {
+	var news models.News
+	err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&news)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // News not found
+		}
+		return nil, err
+	}
+	return &news, nil
+}
+
+// Find retrieves a page of news articles matching the query
+func (r *mongoRepository) Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.News, models.PageInfo, error) {
+	return repository.FindPage[models.News](ctx, r.coll, q, pagination)
+}
+
+// latestCreated decodes the projection read by LatestCreatedAt
+type latestCreated struct {
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// LatestCreatedAt returns when the newest article was stored, or the zero
+// time when there are none
+func (r *mongoRepository) LatestCreatedAt(ctx context.Context) (time.Time, error) {
+	var latest latestCreated
+	findOptions := options.FindOne().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetProjection(bson.M{"created_at": 1})
+
+	err := r.coll.FindOne(ctx, bson.M{}, findOptions).Decode(&latest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, nil
+	}
+	return latest.CreatedAt, err
+}
+
+// ============================================================================
+// QUOTA OPERATIONS
+// ============================================================================
+
+// TakeIngestionSlot increments the source's quota for the hour unless it
+// reached limit, creating it on the first article of the hour
+func (r *mongoRepository) TakeIngestionSlot(ctx context.Context, sourceID bson.ObjectID, hour time.Time, limit int) (bool, error) {
+	filter := bson.M{"_id": quotaID(sourceID, hour), "count": bson.M{"$lt": limit}}
+	update := bson.M{
+		"$inc":         bson.M{"count": 1},
+		"$setOnInsert": bson.M{"source_id": sourceID, "hour": hour},
+	}
+	// a full quota does not match, so the upsert collides with it; so does
+	// one another ingestion created meanwhile, which the second try takes
+	for range 2 {
+		_, err := r.quotas.UpdateOne(ctx, filter, update, options.UpdateOne().SetUpsert(true))
+		if !mongo.IsDuplicateKeyError(err) {
+			return err == nil, err
+		}
+	}
+	return false, nil
+}
+
+// ============================================================================
+// UPDATE OPERATIONS
+// ============================================================================
+
+// Update updates a news article's fields
+func (r *mongoRepository) Update(ctx context.Context, id bson.ObjectID, update bson.M) error {
+	filter := bson.M{"_id": id}
+
+	// Always update the updated_at timestamp
+	update["updated_at"] = time.Now()
+
+	result, err := r.coll.UpdateOne(ctx, filter, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
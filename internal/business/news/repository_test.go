@@ -0,0 +1,224 @@
+package news
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository/repositorytest"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewMemoryRepository() })
+}
+
+func TestMongoRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewRepository(repositorytest.Database(t)) })
+}
+
+// testRepository is the contract every Repository implementation must meet
+func testRepository(t *testing.T, newRepo func(t *testing.T) Repository) {
+	ctx := context.Background()
+	newsID := func(n models.News) bson.ObjectID { return n.ID }
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	sourceA, sourceB := bson.NewObjectID(), bson.NewObjectID()
+
+	seed := func(t *testing.T, r Repository) []*models.News {
+		t.Helper()
+		articles := []*models.News{
+			{Title: "Rates hold", SourceID: sourceA, Status: models.NewsStatusPublished, Categories: []string{"economy"}, PublishedAt: base},
+			{Title: "Bitcoin rallies", SourceID: sourceB, Status: models.NewsStatusPublished, Categories: []string{"crypto"}, PublishedAt: base.Add(time.Hour)},
+			{Title: "Draft piece", SourceID: sourceA, PublishedAt: base.Add(2 * time.Hour)},
+			{Title: "Oil slides", SourceID: sourceA, Status: models.NewsStatusArchived, Categories: []string{"commodities", "economy"}, PublishedAt: base.Add(3 * time.Hour)},
+			{Title: "Stocks open higher", SourceID: sourceB, Status: models.NewsStatusPublished, Categories: []string{"stocks"}, PublishedAt: base.Add(4 * time.Hour)},
+		}
+		for _, n := range articles {
+			if err := r.Create(ctx, n); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+		return articles
+	}
+
+	t.Run("create fills in defaults", func(t *testing.T) {
+		r := newRepo(t)
+		n := &models.News{Title: "Plain"}
+		if err := r.Create(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+		got, err := r.FindByID(ctx, n.ID)
+		if err != nil || got == nil {
+			t.Fatalf("FindByID = %v, %v", got, err)
+		}
+		if got.Status != models.NewsStatusDraft || got.Categories == nil || got.Tags == nil || got.CreatedAt.IsZero() {
+			t.Errorf("defaults not stored: %+v", got)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		r := newRepo(t)
+		missing := bson.NewObjectID()
+
+		if n, err := r.FindByID(ctx, missing); n != nil || err != nil {
+			t.Errorf("FindByID = %v, %v, want nil, nil", n, err)
+		}
+		if err := r.Update(ctx, missing, bson.M{"title": "x"}); !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Errorf("Update = %v, want ErrNoDocuments", err)
+		}
+		if latest, err := r.LatestCreatedAt(ctx); err != nil || !latest.IsZero() {
+			t.Errorf("LatestCreatedAt on empty = %v, %v, want zero time", latest, err)
+		}
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		r := newRepo(t)
+		articles := seed(t, r)
+		pagination := models.GetPaginationParams(1, 100)
+
+		cases := []struct {
+			params url.Values
+			want   []int
+		}{
+			{url.Values{}, []int{4, 3, 2, 1, 0}},
+			{url.Values{"filter[status]": {"published"}}, []int{4, 1, 0}},
+			{url.Values{"filter[status][ne]": {"published"}}, []int{3, 2}},
+			{url.Values{"filter[categories]": {"economy"}}, []int{3, 0}},
+			{url.Values{"filter[categories][in]": {"crypto,stocks"}, "sort": {"published_at"}}, []int{1, 4}},
+			{url.Values{"filter[title][contains]": {"RA"}}, []int{2, 1, 0}},
+			{url.Values{"filter[published_at][gte]": {base.Add(time.Hour).Format(time.RFC3339)}, "filter[published_at][lt]": {base.Add(3 * time.Hour).Format(time.RFC3339)}}, []int{2, 1}},
+			{url.Values{"filter[source_id][nin]": {sourceB.Hex()}}, []int{3, 2, 0}},
+			{url.Values{"filter[id]": {articles[2].ID.Hex()}}, []int{2}},
+		}
+		for _, c := range cases {
+			q, err := listSchema.Parse(c.params)
+			if err != nil {
+				t.Fatalf("%v: %v", c.params, err)
+			}
+			found, _, err := r.Find(ctx, q, pagination)
+			if err != nil {
+				t.Fatalf("%v: %v", c.params, err)
+			}
+			var want []bson.ObjectID
+			for _, i := range c.want {
+				want = append(want, articles[i].ID)
+			}
+			if got := repositorytest.IDs(found, newsID); !slices.Equal(got, want) {
+				t.Errorf("%v: got %v, want %v", c.params, got, want)
+			}
+		}
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		r := newRepo(t)
+		articles := seed(t, r)
+		latest := query.Sort{Field: "published_at", Desc: true}
+
+		page, info, err := r.Find(ctx, query.Where(bson.M{}, latest), models.GetPaginationParams(3, 2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := repositorytest.IDs(page, newsID); !slices.Equal(got, []bson.ObjectID{articles[0].ID}) {
+			t.Errorf("last page = %v, want the oldest article", got)
+		}
+		if info.TotalCount != 5 || info.HasMore || info.NextCursor != "" {
+			t.Errorf("page info = %+v, want 5 in total and nothing more", info)
+		}
+
+		filter := bson.M{"status": bson.M{"$ne": models.NewsStatusDraft}}
+		for _, sort := range []query.Sort{latest, {Field: "title"}, {Field: "status", Desc: true}} {
+			all, _, err := r.Find(ctx, query.Where(filter, sort), models.GetPaginationParams(1, 100))
+			if err != nil {
+				t.Fatal(err)
+			}
+			walked := repositorytest.AllPages(t, 1, func(p models.PaginationParams) ([]models.News, models.PageInfo, error) {
+				return r.Find(ctx, query.Where(filter, sort), p)
+			})
+			if got, want := repositorytest.IDs(walked, newsID), repositorytest.IDs(all, newsID); !slices.Equal(got, want) || len(got) != 4 {
+				t.Errorf("cursor pages by %+v = %v, want %v", sort, got, want)
+			}
+		}
+
+		_, info, err = r.Find(ctx, query.Where(bson.M{}, latest), models.GetPaginationParams(1, 2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = r.Find(ctx, query.Where(bson.M{}, query.Sort{Field: "title"}), models.GetCursorParams(info.NextCursor, 2))
+		if !errors.Is(err, models.ErrInvalidCursor) {
+			t.Errorf("cursor reused with another sort: %v, want ErrInvalidCursor", err)
+		}
+	})
+
+	t.Run("counts", func(t *testing.T) {
+		r := newRepo(t)
+		articles := seed(t, r)
+
+		latest, err := r.LatestCreatedAt(ctx)
+		if err != nil || latest.Sub(articles[4].CreatedAt).Abs() > time.Millisecond {
+			t.Errorf("LatestCreatedAt = %v, %v, want %v", latest, err, articles[4].CreatedAt)
+		}
+	})
+
+	t.Run("ingestion quotas", func(t *testing.T) {
+		r := newRepo(t)
+
+		// concurrent ingestions take exactly limit slots between them
+		var wg sync.WaitGroup
+		var taken atomic.Int32
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ok, err := r.TakeIngestionSlot(ctx, sourceA, base, 3)
+				if err != nil {
+					t.Errorf("TakeIngestionSlot: %v", err)
+				}
+				if ok {
+					taken.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		if taken.Load() != 3 {
+			t.Errorf("took %d slots of 3", taken.Load())
+		}
+
+		// quotas are kept per source and per hour
+		for _, c := range []struct {
+			source bson.ObjectID
+			hour   time.Time
+		}{{sourceB, base}, {sourceA, base.Add(time.Hour)}} {
+			if ok, err := r.TakeIngestionSlot(ctx, c.source, c.hour, 3); err != nil || !ok {
+				t.Errorf("TakeIngestionSlot(%s, %s) = %v, %v; want a fresh quota", c.source.Hex(), c.hour, ok, err)
+			}
+		}
+		// a raised limit frees more slots in the same hour
+		if ok, err := r.TakeIngestionSlot(ctx, sourceA, base, 4); err != nil || !ok {
+			t.Errorf("TakeIngestionSlot with a raised limit = %v, %v; want a slot", ok, err)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		r := newRepo(t)
+		articles := seed(t, r)
+
+		if err := r.Update(ctx, articles[2].ID, bson.M{"status": models.NewsStatusPublished, "metrics.views": 7}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := r.FindByID(ctx, articles[2].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != models.NewsStatusPublished || got.Metrics.Views != 7 || got.Title != "Draft piece" {
+			t.Errorf("after update = %+v", got)
+		}
+	})
+}
@@ -0,0 +1,367 @@
+package news
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"yoharsh14/krant-backend/internal/apperr"
+	"yoharsh14/krant-backend/internal/business/source"
+	"yoharsh14/krant-backend/internal/business/user"
+	"yoharsh14/krant-backend/internal/cache"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/content"
+	"yoharsh14/krant-backend/internal/events"
+	"yoharsh14/krant-backend/internal/media"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrNewsNotFound     = apperr.NotFound("news not found")
+	ErrInvalidStatus    = apperr.Validation("invalid news status").WithField("status", "must be one of "+strings.Join(models.ValidNewsStatuses(), ", "))
+	ErrNoFieldsToUpdate = apperr.Validation("no fields to update").WithCode("no_fields_to_update")
+	ErrNoCategories     = apperr.Validation("at least one category is required").WithField("categories", "must not be empty when the source has no default categories")
+	ErrImageNotFound    = apperr.Validation("image not found").WithField("image_id", "must name an uploaded image")
+	ErrRateLimited      = apperr.New(apperr.KindRateLimited, "source has reached its ingestion rate limit")
+	// ErrSourceRejected wraps why the article's source was refused: it is
+	// unknown, blocked or inactive
+	ErrSourceRejected = apperr.New(apperr.KindUnprocessable, "source rejected").WithCode("source_rejected")
+)
+
+type Service interface {
+	CreateNews(ctx context.Context, input models.CreateNewsInput) (*models.News, error)
+	GetNews(ctx context.Context, id bson.ObjectID) (*models.News, error)
+	UpdateNews(ctx context.Context, id bson.ObjectID, input models.UpdateNewsInput) (*models.News, error)
+	ListNews(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.News, models.PageInfo, error)
+	ListFeed(ctx context.Context, userID bson.ObjectID, q query.Query, pagination models.PaginationParams) ([]models.News, models.PageInfo, error)
+	LastIngestedAt(ctx context.Context) (time.Time, error)
+	Forget(ctx context.Context, id bson.ObjectID)
+}
+
+type svc struct {
+	r       Repository
+	images  media.Service
+	sources source.Service
+	users   user.Service
+	outbox  events.Outbox
+
+	// articles by id; pages of ListNews by query, blocked sources and
+	// pagination, so blocking or unblocking a source moves to other keys
+	articles cache.Cache[models.News]
+	pages    cache.Cache[newsPage]
+}
+
+type newsPage struct {
+	news []models.News
+	info models.PageInfo
+}
+
+// cloneNews deep-copies a page of articles, so the cached one and the one
+// handed out share nothing
+func cloneNews(news []models.News) []models.News {
+	if news == nil {
+		return nil
+	}
+	c := make([]models.News, len(news))
+	for i := range news {
+		c[i] = *news[i].Clone()
+	}
+	return c
+}
+
+func NewService(repo Repository, images media.Service, sources source.Service, users user.Service, outbox events.Outbox, cacheCfg config.Cache) Service {
+	return &svc{
+		r:        repo,
+		images:   images,
+		sources:  sources,
+		users:    users,
+		outbox:   outbox,
+		articles: cache.New[models.News]("news", cacheCfg),
+		pages:    cache.New[newsPage]("news_pages", cacheCfg),
+	}
+}
+
+// CreateNews resolves the article's source, runs the body through the
+// content pipeline and stores it
+func (s *svc) CreateNews(ctx context.Context, input models.CreateNewsInput) (*models.News, error) {
+	src, err := s.sources.ResolveForIngestion(ctx, input.SourceID, input.Source)
+	if errors.Is(err, source.ErrSourceNotFound) || errors.Is(err, source.ErrSourceBlocked) || errors.Is(err, source.ErrSourceInactive) {
+		return nil, fmt.Errorf("%w: %w", ErrSourceRejected, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	categories := input.Categories
+	if len(categories) == 0 {
+		categories = src.DefaultCategories
+	}
+	if len(categories) == 0 {
+		return nil, ErrNoCategories
+	}
+
+	doc, err := content.Process(input.Content)
+	if err != nil {
+		return nil, err
+	}
+	image, err := s.resolveImage(ctx, input.ImageID, input.ImageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	news := &models.News{
+		Title:           content.NormalizeText(input.Title),
+		Description:     content.NormalizeText(input.Description),
+		Content:         doc.HTML,
+		ContentText:     doc.Text,
+		WordCount:       doc.WordCount,
+		ReadingTime:     doc.ReadingTime,
+		Author:          content.NormalizeText(input.Author),
+		SourceID:        src.ID,
+		Source:          src.Snapshot(),
+		ImageURL:        input.ImageURL,
+		Image:           image,
+		Categories:      categories,
+		Tags:            input.Tags,
+		TraderRelevance: input.TraderRelevance,
+		PublishedAt:     input.PublishedAt,
+		Status:          models.NewsStatusDraft,
+	}
+
+	// only an article that is about to be stored counts against the quota
+	if err := s.takeIngestionSlot(ctx, src); err != nil {
+		return nil, err
+	}
+	if err := s.r.Create(ctx, news); err != nil {
+		return nil, err
+	}
+	s.pages.Clear(ctx)
+	return news, nil
+}
+
+// GetNews retrieves a news article by its ID
+func (s *svc) GetNews(ctx context.Context, id bson.ObjectID) (*models.News, error) {
+	key := id.Hex()
+	if news, ok := s.articles.Get(ctx, key); ok {
+		return news.Clone(), nil // callers cannot change the cached one
+	}
+	news, err := s.r.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if news == nil {
+		return nil, ErrNewsNotFound
+	}
+	s.articles.Set(ctx, key, *news.Clone())
+	return news, nil
+}
+
+// UpdateNews applies a partial update, reprocessing the body when it changes
+func (s *svc) UpdateNews(ctx context.Context, id bson.ObjectID, input models.UpdateNewsInput) (*models.News, error) {
+	if input.Status != nil && !models.IsValidNewsStatus(*input.Status) {
+		return nil, ErrInvalidStatus
+	}
+
+	update := bson.M{}
+
+	if input.Title != nil {
+		update["title"] = content.NormalizeText(*input.Title)
+	}
+	if input.Description != nil {
+		update["description"] = content.NormalizeText(*input.Description)
+	}
+	if input.Content != nil {
+		doc, err := content.Process(*input.Content)
+		if err != nil {
+			return nil, err
+		}
+		update["content"] = doc.HTML
+		update["content_text"] = doc.Text
+		update["word_count"] = doc.WordCount
+		update["reading_time"] = doc.ReadingTime
+	}
+	if input.Author != nil {
+		update["author"] = content.NormalizeText(*input.Author)
+	}
+	if input.SourceID != nil {
+		src, err := s.sources.ResolveForIngestion(ctx, *input.SourceID, models.NewsSource{})
+		if err != nil {
+			return nil, err
+		}
+		update["source_id"] = src.ID
+		update["source"] = src.Snapshot()
+	}
+	if input.ImageID != nil || input.ImageURL != nil {
+		var imageID, imageURL string
+		if input.ImageID != nil {
+			imageID = *input.ImageID
+		}
+		if input.ImageURL != nil {
+			imageURL = *input.ImageURL
+			update["image_url"] = imageURL
+		}
+		image, err := s.resolveImage(ctx, imageID, imageURL)
+		if err != nil {
+			return nil, err
+		}
+		// a remote image that could not be fetched keeps the current one;
+		// only clearing both fields removes it
+		if image != nil || (imageID == "" && imageURL == "") {
+			update["image"] = image
+		}
+	}
+	if input.Categories != nil {
+		update["categories"] = *input.Categories
+	}
+	if input.Tags != nil {
+		update["tags"] = *input.Tags
+	}
+	if input.TraderRelevance != nil {
+		update["trader_relevance"] = *input.TraderRelevance
+	}
+	if input.Status != nil {
+		update["status"] = *input.Status
+	}
+
+	if len(update) == 0 {
+		return nil, ErrNoFieldsToUpdate
+	}
+
+	var news *models.News
+	err := s.outbox.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.r.Update(ctx, id, update); err != nil {
+			return err
+		}
+		var err error
+		if news, err = s.r.FindByID(ctx, id); err != nil {
+			return err
+		}
+		// subscribers only hear of published articles
+		switch {
+		case input.Status != nil && *input.Status == models.NewsStatusPublished:
+			return s.outbox.Record(ctx, events.NewsPublished, news)
+		case news.Status == models.NewsStatusPublished:
+			return s.outbox.Record(ctx, events.NewsUpdated, news)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNewsNotFound
+		}
+		return nil, err
+	}
+	s.articles.Delete(ctx, id.Hex())
+	s.pages.Clear(ctx)
+	return news, nil
+}
+
+// ListNews retrieves a page of news articles matching the query, leaving out
+// blocked sources
+func (s *svc) ListNews(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.News, models.PageInfo, error) {
+	blocked, err := s.sources.BlockedSourceIDs(ctx)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+
+	q = q.And(excludeSources(blocked))
+	key, cacheable := cache.Key(q, pagination)
+	if cacheable {
+		if page, ok := s.pages.Get(ctx, key); ok {
+			return cloneNews(page.news), page.info, nil
+		}
+	}
+	news, info, err := s.r.Find(ctx, q, pagination)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+	if cacheable {
+		s.pages.Set(ctx, key, newsPage{news: cloneNews(news), info: info})
+	}
+	return news, info, nil
+}
+
+// ListFeed retrieves a user's feed: published articles without the
+// globally blocked sources and the sources the user muted
+func (s *svc) ListFeed(ctx context.Context, userID bson.ObjectID, q query.Query, pagination models.PaginationParams) ([]models.News, models.PageInfo, error) {
+	muted, err := s.users.GetMutedSources(ctx, userID)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+	blocked, err := s.sources.BlockedSourceIDs(ctx)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+
+	q = q.And(bson.M{"status": models.NewsStatusPublished}).And(excludeSources(append(blocked, muted...)))
+	return s.r.Find(ctx, q, pagination)
+}
+
+// excludeSources filters out articles from the given sources
+func excludeSources(ids []bson.ObjectID) bson.M {
+	if len(ids) == 0 {
+		return nil
+	}
+	return bson.M{"source_id": bson.M{"$nin": ids}}
+}
+
+// LastIngestedAt returns when the newest article arrived, or the zero time
+// when none has
+func (s *svc) LastIngestedAt(ctx context.Context) (time.Time, error) {
+	return s.r.LatestCreatedAt(ctx)
+}
+
+// Forget drops the cached copies of an article, e.g. once another instance
+// changed it
+func (s *svc) Forget(ctx context.Context, id bson.ObjectID) {
+	s.articles.Delete(ctx, id.Hex())
+	s.pages.Clear(ctx)
+}
+
+// takeIngestionSlot counts an article against its source's quota for the
+// current hour, refusing it once the quota is used up
+func (s *svc) takeIngestionSlot(ctx context.Context, src *models.Source) error {
+	if src.RateLimit <= 0 {
+		return nil
+	}
+	ok, err := s.r.TakeIngestionSlot(ctx, src.ID, time.Now().Truncate(time.Hour), src.RateLimit)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// resolveImage returns the image to attach to an article. An uploaded image
+// must exist, while a remote image that cannot be fetched is logged and
+// skipped so a broken feed image never blocks an article.
+func (s *svc) resolveImage(ctx context.Context, imageID, imageURL string) (*models.Image, error) {
+	if s.images == nil {
+		return nil, nil
+	}
+	if imageID != "" {
+		image, err := s.images.GetImage(ctx, imageID)
+		if errors.Is(err, media.ErrImageNotFound) {
+			return nil, ErrImageNotFound
+		}
+		return image, err
+	}
+	if imageURL == "" {
+		return nil, nil
+	}
+
+	image, err := s.images.Fetch(ctx, imageURL, media.PresetNews)
+	if err != nil {
+		slog.WarnContext(ctx, "could not fetch news image", "error", err)
+		return nil, nil
+	}
+	return image, nil
+}
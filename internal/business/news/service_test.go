@@ -0,0 +1,155 @@
+package news
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"yoharsh14/krant-backend/internal/business/source"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/events"
+	"yoharsh14/krant-backend/internal/media"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// images serves the images it holds by ID and fetches those by URL, failing
+// for the URLs it does not hold
+type images struct {
+	byID  map[string]*models.Image
+	byURL map[string]*models.Image
+}
+
+func (i images) Upload(ctx context.Context, r io.Reader, preset media.Preset) (*models.Image, error) {
+	return nil, errors.New("not supported")
+}
+
+func (i images) Fetch(ctx context.Context, url string, preset media.Preset) (*models.Image, error) {
+	if image, ok := i.byURL[url]; ok {
+		return image, nil
+	}
+	return nil, errors.New("connection refused")
+}
+
+func (i images) GetImage(ctx context.Context, id string) (*models.Image, error) {
+	if image, ok := i.byID[id]; ok {
+		return image, nil
+	}
+	return nil, media.ErrImageNotFound
+}
+
+// sources resolves every article to the one source it holds
+type sources struct {
+	source.Service
+	src *models.Source
+}
+
+func (s sources) ResolveForIngestion(ctx context.Context, sourceID string, fallback models.NewsSource) (*models.Source, error) {
+	return s.src, nil
+}
+
+func (s sources) BlockedSourceIDs(ctx context.Context) ([]bson.ObjectID, error) {
+	return nil, nil
+}
+
+func newTestService(repo Repository, imgs images) Service {
+	src := &models.Source{Name: "Wire", Domain: "wire.example", DefaultCategories: []string{"economy"}}
+	return NewService(repo, imgs, sources{src: src}, nil, events.NewMemoryStore(), config.Default().Cache)
+}
+
+func TestFailedImageFetchesKeepTheCurrentImage(t *testing.T) {
+	ctx := context.Background()
+	current := &models.Image{ID: "current"}
+	fetched := &models.Image{ID: "fetched"}
+	s := newTestService(NewMemoryRepository(), images{
+		byID:  map[string]*models.Image{"current": current},
+		byURL: map[string]*models.Image{"https://img.example/new.png": fetched},
+	})
+	news, err := s.CreateNews(ctx, models.CreateNewsInput{Title: "Rates hold", Content: "<p>Unchanged.</p>", ImageID: "current"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	update := func(input models.UpdateNewsInput) *models.News {
+		t.Helper()
+		news, err := s.UpdateNews(ctx, news.ID, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return news
+	}
+	ptr := func(s string) *string { return &s }
+
+	if got := update(models.UpdateNewsInput{ImageURL: ptr("https://img.example/gone.png")}); got.Image == nil || got.Image.ID != "current" {
+		t.Errorf("image after a failed fetch = %+v, want the current one kept", got.Image)
+	}
+	if got := update(models.UpdateNewsInput{ImageURL: ptr("https://img.example/new.png")}); got.Image == nil || got.Image.ID != "fetched" {
+		t.Errorf("image after a fetch = %+v, want the fetched one", got.Image)
+	}
+	if got := update(models.UpdateNewsInput{ImageID: ptr(""), ImageURL: ptr("")}); got.Image != nil {
+		t.Errorf("image after clearing it = %+v, want none", got.Image)
+	}
+}
+
+func TestRejectedArticlesLeaveTheQuota(t *testing.T) {
+	ctx := context.Background()
+	src := &models.Source{ID: bson.NewObjectID(), Name: "Wire", Domain: "wire.example", DefaultCategories: []string{"economy"}, RateLimit: 1}
+	s := NewService(NewMemoryRepository(), images{}, sources{src: src}, nil, events.NewMemoryStore(), config.Default().Cache)
+
+	if _, err := s.CreateNews(ctx, models.CreateNewsInput{Title: "Rates hold", Content: "<p>Unchanged.</p>", ImageID: "missing"}); !errors.Is(err, ErrImageNotFound) {
+		t.Fatalf("CreateNews with a missing image = %v, want ErrImageNotFound", err)
+	}
+	if _, err := s.CreateNews(ctx, models.CreateNewsInput{Title: "Rates hold", Content: "<p>Unchanged.</p>"}); err != nil {
+		t.Fatalf("CreateNews after a rejected article = %v, want the quota's slot still free", err)
+	}
+	if _, err := s.CreateNews(ctx, models.CreateNewsInput{Title: "Rates rise", Content: "<p>Up.</p>"}); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("CreateNews past the quota = %v, want ErrRateLimited", err)
+	}
+}
+
+func TestCallersCannotChangeCachedArticles(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(NewMemoryRepository(), images{
+		byID: map[string]*models.Image{"chart": {ID: "chart", Variants: []models.ImageVariant{{URL: "/media/chart-320.webp"}}}},
+	})
+	created, err := s.CreateNews(ctx, models.CreateNewsInput{Title: "Rates hold", Content: "<p>Unchanged.</p>", ImageID: "chart", Tags: []string{"rates"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first read fills the cache, the second is served from it
+	for range 2 {
+		news, err := s.GetNews(ctx, created.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		news.Tags[0] = "changed"
+		news.Image.Variants[0].URL = "changed"
+		news.Image.ID = "changed"
+	}
+	news, err := s.GetNews(ctx, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if news.Tags[0] != "rates" || news.Image.ID != "chart" || news.Image.Variants[0].URL != "/media/chart-320.webp" {
+		t.Errorf("cached article = %+v, image %+v; want it unchanged", news, news.Image)
+	}
+
+	for range 2 {
+		page, _, err := s.ListNews(ctx, query.Query{}, models.PaginationParams{Page: 1, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		page[0].Tags[0] = "changed"
+		page[0].Image.Variants[0].URL = "changed"
+	}
+	page, _, err := s.ListNews(ctx, query.Query{}, models.PaginationParams{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page[0].Tags[0] != "rates" || page[0].Image.Variants[0].URL != "/media/chart-320.webp" {
+		t.Errorf("cached page = %+v, image %+v; want it unchanged", page[0], page[0].Image)
+	}
+}
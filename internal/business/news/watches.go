@@ -0,0 +1,21 @@
+package news
+
+import (
+	"context"
+	"yoharsh14/krant-backend/internal/changes"
+)
+
+// Watch has the consumer drop the service's cached copies of the articles
+// any instance writes, so changes made elsewhere show before the cache TTL
+// runs out.
+func Watch(consumer *changes.Consumer, service Service) {
+	consumer.Watch(changes.Watch{
+		Name:       "news_cache",
+		Collection: "news",
+		PollField:  "updated_at",
+		Handle: func(ctx context.Context, change changes.Change) error {
+			service.Forget(ctx, change.ID)
+			return nil
+		},
+	})
+}
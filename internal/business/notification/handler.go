@@ -0,0 +1,120 @@
+package notification
+
+import (
+	"net/http"
+	"yoharsh14/krant-backend/internal/json"
+	"yoharsh14/krant-backend/internal/models"
+)
+
+type Handler interface {
+	ListNotifications(w http.ResponseWriter, r *http.Request)
+	MarkRead(w http.ResponseWriter, r *http.Request)
+	MarkAllRead(w http.ResponseWriter, r *http.Request)
+
+	// admin only
+	CreateNotification(w http.ResponseWriter, r *http.Request)
+}
+
+type h struct {
+	service Service
+}
+
+func NewHandler(service Service) Handler {
+	return &h{
+		service: service,
+	}
+}
+
+// ListNotifications lists the notifications of the user in {id}, newest
+// first unless ?sort= says otherwise
+func (h *h) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := json.ObjectID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	values := r.URL.Query()
+	q, err := listSchema.Parse(values)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	pagination := models.ParsePagination(values)
+
+	notifications, info, err := h.service.ListNotifications(r.Context(), userID, q, pagination)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	unread, err := h.service.UnreadCount(r.Context(), userID)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	resp := models.NotificationListResponse{
+		Notifications: make([]any, 0, len(notifications)),
+		UnreadCount:   unread,
+		PageMeta:      models.NewPageMeta(pagination, info),
+	}
+	for i := range notifications {
+		item, err := q.Select(notifications[i].ToResponse())
+		if err != nil {
+			json.Error(w, r, err)
+			return
+		}
+		resp.Notifications = append(resp.Notifications, item)
+	}
+	json.Write(w, http.StatusOK, resp)
+}
+
+// MarkRead marks the notification in {notificationID} as read
+func (h *h) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := json.ObjectID(w, r, "id")
+	if !ok {
+		return
+	}
+	id, ok := json.ObjectID(w, r, "notificationID")
+	if !ok {
+		return
+	}
+
+	if err := h.service.MarkRead(r.Context(), userID, id); err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusOK, models.SuccessResponse{Success: true})
+}
+
+// MarkAllRead marks every notification of the user in {id} as read
+func (h *h) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := json.ObjectID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	count, err := h.service.MarkAllRead(r.Context(), userID)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Data:    map[string]any{"marked": count},
+	})
+}
+
+func (h *h) CreateNotification(w http.ResponseWriter, r *http.Request) {
+	var input models.CreateNotificationInput
+	if err := json.Read(r, &input); err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	notification, err := h.service.CreateNotification(r.Context(), input)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusCreated, notification.ToResponse())
+}
@@ -0,0 +1,62 @@
+package notification
+
+import (
+	"context"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// memoryRepository is the in-memory Repository, meant for tests
+type memoryRepository struct {
+	coll *repository.Memory
+}
+
+// NewMemoryRepository returns an empty in-memory Repository
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		coll: repository.NewMemory(),
+	}
+}
+
+func (r *memoryRepository) Create(ctx context.Context, notification *models.Notification) error {
+	if notification.ID.IsZero() {
+		notification.ID = bson.NewObjectID()
+	}
+	notification.CreatedAt = time.Now()
+
+	return r.coll.InsertOne(ctx, notification)
+}
+
+func (r *memoryRepository) Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.Notification, models.PageInfo, error) {
+	return repository.FindMemoryPage[models.Notification](ctx, r.coll, q, pagination)
+}
+
+func (r *memoryRepository) CountUnread(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	return r.coll.CountDocuments(ctx, bson.M{"user_id": userID, "is_read": false})
+}
+
+func (r *memoryRepository) MarkRead(ctx context.Context, userID, id bson.ObjectID) error {
+	matched, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "user_id": userID},
+		bson.M{"$set": bson.M{"is_read": true}},
+	)
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *memoryRepository) MarkAllRead(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	return r.coll.UpdateMany(ctx,
+		bson.M{"user_id": userID, "is_read": false},
+		bson.M{"$set": bson.M{"is_read": true}},
+	)
+}
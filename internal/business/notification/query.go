@@ -0,0 +1,17 @@
+package notification
+
+import (
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+)
+
+// listSchema is what clients may filter, sort and select on in notification listings
+var listSchema = query.NewSchema(query.Sort{Field: "created_at", Desc: true},
+	query.Field{Name: "id", Path: "_id", Kind: query.ObjectID, Ops: []query.Op{query.Eq, query.In}},
+	query.Field{Name: "title", Ops: []query.Op{query.Contains}},
+	query.Field{Name: "message"},
+	query.Field{Name: "type", Ops: []query.Op{query.Eq, query.Ne, query.In}, Valid: models.IsValidNotificationType},
+	query.Field{Name: "news_id", Kind: query.ObjectID, Ops: []query.Op{query.Eq, query.Exists}},
+	query.Field{Name: "is_read", Kind: query.Bool, Ops: []query.Op{query.Eq}},
+	query.Field{Name: "created_at", Kind: query.Time, Ops: query.Range, Sortable: true},
+)
@@ -0,0 +1,96 @@
+package notification
+
+import (
+	"context"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Repository stores notifications. NewRepository is backed by MongoDB and
+// NewMemoryRepository keeps everything in memory; both behave the same.
+// MarkRead returns mongo.ErrNoDocuments when the user has no such
+// notification.
+type Repository interface {
+	Create(ctx context.Context, notification *models.Notification) error
+	Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.Notification, models.PageInfo, error)
+	CountUnread(ctx context.Context, userID bson.ObjectID) (int64, error)
+	MarkRead(ctx context.Context, userID, id bson.ObjectID) error
+	MarkAllRead(ctx context.Context, userID bson.ObjectID) (int64, error)
+}
+
+type mongoRepository struct {
+	coll *mongo.Collection
+}
+
+func NewRepository(db *mongo.Database) Repository {
+	return &mongoRepository{
+		coll: db.Collection("notifications"),
+	}
+}
+
+// ============================================================================
+// CREATE OPERATIONS
+// ============================================================================
+
+// Create inserts a new notification into the database
+func (r *mongoRepository) Create(ctx context.Context, notification *models.Notification) error {
+	if notification.ID.IsZero() {
+		notification.ID = bson.NewObjectID()
+	}
+	notification.CreatedAt = time.Now()
+
+	_, err := r.coll.InsertOne(ctx, notification)
+	return err
+}
+
+// ============================================================================
+// READ OPERATIONS
+// ============================================================================
+
+// Find retrieves a page of notifications matching the query
+func (r *mongoRepository) Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.Notification, models.PageInfo, error) {
+	return repository.FindPage[models.Notification](ctx, r.coll, q, pagination)
+}
+
+// CountUnread counts a user's unread notifications
+func (r *mongoRepository) CountUnread(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	return r.coll.CountDocuments(ctx, bson.M{"user_id": userID, "is_read": false})
+}
+
+// ============================================================================
+// UPDATE OPERATIONS
+// ============================================================================
+
+// MarkRead marks one of a user's notifications as read
+func (r *mongoRepository) MarkRead(ctx context.Context, userID, id bson.ObjectID) error {
+	result, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "user_id": userID},
+		bson.M{"$set": bson.M{"is_read": true}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// MarkAllRead marks every notification of a user as read
+func (r *mongoRepository) MarkAllRead(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	result, err := r.coll.UpdateMany(ctx,
+		bson.M{"user_id": userID, "is_read": false},
+		bson.M{"$set": bson.M{"is_read": true}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,120 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository/repositorytest"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewMemoryRepository() })
+}
+
+func TestMongoRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewRepository(repositorytest.Database(t)) })
+}
+
+// testRepository is the contract every Repository implementation must meet
+func testRepository(t *testing.T, newRepo func(t *testing.T) Repository) {
+	ctx := context.Background()
+	notificationID := func(n models.Notification) bson.ObjectID { return n.ID }
+	alice, bob := bson.NewObjectID(), bson.NewObjectID()
+
+	seed := func(t *testing.T, r Repository) []*models.Notification {
+		t.Helper()
+		newsID := bson.NewObjectID()
+		notifications := []*models.Notification{
+			{UserID: alice, Title: "Welcome", Type: models.NotificationTypeSystem},
+			{UserID: alice, Title: "Breaking", Type: models.NotificationTypeNewsAlert, NewsID: &newsID},
+			{UserID: bob, Title: "Welcome", Type: models.NotificationTypeSystem},
+			{UserID: alice, Title: "Market open", Type: models.NotificationTypeNewsAlert, IsRead: true},
+		}
+		for _, n := range notifications {
+			if err := r.Create(ctx, n); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+		return notifications
+	}
+
+	forUser := func(userID bson.ObjectID, params url.Values) query.Query {
+		t.Helper()
+		q, err := listSchema.Parse(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return q.And(bson.M{"user_id": userID})
+	}
+
+	t.Run("list per user", func(t *testing.T) {
+		r := newRepo(t)
+		notifications := seed(t, r)
+
+		cases := []struct {
+			params url.Values
+			want   []int
+		}{
+			{url.Values{}, []int{3, 1, 0}},
+			{url.Values{"filter[is_read]": {"false"}}, []int{1, 0}},
+			{url.Values{"filter[type]": {"news_alert"}, "sort": {"created_at"}}, []int{1, 3}},
+			{url.Values{"filter[news_id][exists]": {"true"}}, []int{1}},
+		}
+		for _, c := range cases {
+			found, _, err := r.Find(ctx, forUser(alice, c.params), models.GetPaginationParams(1, 100))
+			if err != nil {
+				t.Fatalf("%v: %v", c.params, err)
+			}
+			var want []bson.ObjectID
+			for _, i := range c.want {
+				want = append(want, notifications[i].ID)
+			}
+			if got := repositorytest.IDs(found, notificationID); !slices.Equal(got, want) {
+				t.Errorf("%v: got %v, want %v", c.params, got, want)
+			}
+		}
+
+		walked := repositorytest.AllPages(t, 2, func(p models.PaginationParams) ([]models.Notification, models.PageInfo, error) {
+			return r.Find(ctx, forUser(alice, url.Values{}), p)
+		})
+		want := []bson.ObjectID{notifications[3].ID, notifications[1].ID, notifications[0].ID}
+		if got := repositorytest.IDs(walked, notificationID); !slices.Equal(got, want) {
+			t.Errorf("cursor pages = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("read state", func(t *testing.T) {
+		r := newRepo(t)
+		notifications := seed(t, r)
+
+		if n, err := r.CountUnread(ctx, alice); err != nil || n != 2 {
+			t.Errorf("CountUnread = %d, %v, want 2", n, err)
+		}
+		if err := r.MarkRead(ctx, alice, notifications[0].ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.MarkRead(ctx, alice, notifications[2].ID); !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Errorf("MarkRead of another user's notification = %v, want ErrNoDocuments", err)
+		}
+		if err := r.MarkRead(ctx, alice, bson.NewObjectID()); !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Errorf("MarkRead(missing) = %v, want ErrNoDocuments", err)
+		}
+
+		if n, err := r.MarkAllRead(ctx, alice); err != nil || n != 1 {
+			t.Errorf("MarkAllRead = %d, %v, want 1 changed", n, err)
+		}
+		if n, err := r.CountUnread(ctx, alice); err != nil || n != 0 {
+			t.Errorf("CountUnread after MarkAllRead = %d, %v, want 0", n, err)
+		}
+		if n, err := r.CountUnread(ctx, bob); err != nil || n != 1 {
+			t.Errorf("CountUnread of another user = %d, %v, want 1", n, err)
+		}
+	})
+}
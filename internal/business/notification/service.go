@@ -0,0 +1,95 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"yoharsh14/krant-backend/internal/apperr"
+	"yoharsh14/krant-backend/internal/events"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrNotificationNotFound = apperr.NotFound("notification not found")
+	ErrInvalidType          = apperr.Validation("invalid notification type").WithField("type", "must be one of "+strings.Join(models.ValidNotificationTypes(), ", "))
+	ErrNotificationExists   = apperr.Conflict("a notification with this id already exists")
+)
+
+type Service interface {
+	CreateNotification(ctx context.Context, input models.CreateNotificationInput) (*models.Notification, error)
+	ListNotifications(ctx context.Context, userID bson.ObjectID, q query.Query, pagination models.PaginationParams) ([]models.Notification, models.PageInfo, error)
+	UnreadCount(ctx context.Context, userID bson.ObjectID) (int64, error)
+	MarkRead(ctx context.Context, userID, id bson.ObjectID) error
+	MarkAllRead(ctx context.Context, userID bson.ObjectID) (int64, error)
+}
+
+type svc struct {
+	r      Repository
+	outbox events.Outbox
+}
+
+func NewService(repo Repository, outbox events.Outbox) Service {
+	return &svc{
+		r:      repo,
+		outbox: outbox,
+	}
+}
+
+// CreateNotification stores a new unread notification for a user. One
+// with the ID of an existing notification is not stored again.
+func (s *svc) CreateNotification(ctx context.Context, input models.CreateNotificationInput) (*models.Notification, error) {
+	if !models.IsValidNotificationType(input.Type) {
+		return nil, ErrInvalidType
+	}
+
+	notification := &models.Notification{
+		ID:      input.ID,
+		UserID:  input.UserID,
+		Title:   input.Title,
+		Message: input.Message,
+		Type:    input.Type,
+		NewsID:  input.NewsID,
+	}
+	err := s.outbox.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.r.Create(ctx, notification); err != nil {
+			return err
+		}
+		return s.outbox.Record(ctx, events.NotificationCreated, notification)
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrNotificationExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	return notification, nil
+}
+
+// ListNotifications retrieves a page of a user's notifications
+func (s *svc) ListNotifications(ctx context.Context, userID bson.ObjectID, q query.Query, pagination models.PaginationParams) ([]models.Notification, models.PageInfo, error) {
+	return s.r.Find(ctx, q.And(bson.M{"user_id": userID}), pagination)
+}
+
+// UnreadCount counts a user's unread notifications
+func (s *svc) UnreadCount(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	return s.r.CountUnread(ctx, userID)
+}
+
+// MarkRead marks one of a user's notifications as read
+func (s *svc) MarkRead(ctx context.Context, userID, id bson.ObjectID) error {
+	err := s.r.MarkRead(ctx, userID, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotificationNotFound
+	}
+	return err
+}
+
+// MarkAllRead marks every notification of a user as read and returns how
+// many were unread
+func (s *svc) MarkAllRead(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	return s.r.MarkAllRead(ctx, userID)
+}
@@ -0,0 +1,30 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"yoharsh14/krant-backend/internal/events"
+	"yoharsh14/krant-backend/internal/models"
+)
+
+// Subscribe sends the notifications that follow from domain events. Each
+// takes the ID of its event, so one relayed again is not sent twice.
+func Subscribe(bus *events.Bus, service Service) {
+	bus.Subscribe("notifications", events.UserCreated, func(ctx context.Context, event events.Event) error {
+		var user models.User
+		if err := event.Decode(&user); err != nil {
+			return err
+		}
+		_, err := service.CreateNotification(ctx, models.CreateNotificationInput{
+			ID:      event.ID,
+			UserID:  user.ID,
+			Title:   "Welcome to Krant",
+			Message: "Your account is ready. Your feed follows the interests in your profile.",
+			Type:    models.NotificationTypeSystem,
+		})
+		if errors.Is(err, ErrNotificationExists) {
+			return nil
+		}
+		return err
+	})
+}
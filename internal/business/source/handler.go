@@ -0,0 +1,154 @@
+package source
+
+import (
+	"net/http"
+	"yoharsh14/krant-backend/internal/json"
+	"yoharsh14/krant-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type Handler interface {
+	ListSources(w http.ResponseWriter, r *http.Request)
+	GetSource(w http.ResponseWriter, r *http.Request)
+
+	// admin only
+	ListAllSources(w http.ResponseWriter, r *http.Request)
+	CreateSource(w http.ResponseWriter, r *http.Request)
+	UpdateSource(w http.ResponseWriter, r *http.Request)
+	BlockSource(w http.ResponseWriter, r *http.Request)
+	UnblockSource(w http.ResponseWriter, r *http.Request)
+}
+
+type h struct {
+	service Service
+}
+
+func NewHandler(service Service) Handler {
+	return &h{
+		service: service,
+	}
+}
+
+// ListSources lists the sources visible to users
+func (h *h) ListSources(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, false)
+}
+
+// ListAllSources lists every source, blocked ones included
+func (h *h) ListAllSources(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, true)
+}
+
+func (h *h) list(w http.ResponseWriter, r *http.Request, includeBlocked bool) {
+	pagination := models.ParsePagination(r.URL.Query())
+	sources, info, err := h.service.ListSources(r.Context(), includeBlocked, pagination)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	resp := make([]models.SourceResponse, 0, len(sources))
+	for i := range sources {
+		resp = append(resp, sources[i].ToResponse())
+	}
+	json.Write(w, http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Data: map[string]any{
+			"sources":     resp,
+			"total_count": info.TotalCount,
+			"has_more":    info.HasMore,
+			"next_cursor": info.NextCursor,
+		},
+	})
+}
+
+func (h *h) GetSource(w http.ResponseWriter, r *http.Request) {
+	id, ok := sourceID(w, r)
+	if !ok {
+		return
+	}
+
+	source, err := h.service.GetSource(r.Context(), id)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusOK, source.ToResponse())
+}
+
+func (h *h) CreateSource(w http.ResponseWriter, r *http.Request) {
+	var input models.CreateSourceInput
+	if err := json.Read(r, &input); err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	source, err := h.service.CreateSource(r.Context(), input)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusCreated, source.ToResponse())
+}
+
+func (h *h) UpdateSource(w http.ResponseWriter, r *http.Request) {
+	id, ok := sourceID(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.UpdateSourceInput
+	if err := json.Read(r, &input); err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	source, err := h.service.UpdateSource(r.Context(), id, input)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusOK, source.ToResponse())
+}
+
+func (h *h) BlockSource(w http.ResponseWriter, r *http.Request) {
+	id, ok := sourceID(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.BlockSourceInput
+	if r.ContentLength != 0 {
+		if err := json.Read(r, &input); err != nil {
+			json.Error(w, r, err)
+			return
+		}
+	}
+
+	source, err := h.service.BlockSource(r.Context(), id, input.Reason)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusOK, source.ToResponse())
+}
+
+func (h *h) UnblockSource(w http.ResponseWriter, r *http.Request) {
+	id, ok := sourceID(w, r)
+	if !ok {
+		return
+	}
+
+	source, err := h.service.UnblockSource(r.Context(), id)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusOK, source.ToResponse())
+}
+
+// sourceID parses the {id} URL parameter, answering 400 when it is malformed
+func sourceID(w http.ResponseWriter, r *http.Request) (bson.ObjectID, bool) {
+	return json.ObjectID(w, r, "id")
+}
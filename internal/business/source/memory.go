@@ -0,0 +1,79 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// memoryRepository is the in-memory Repository, meant for tests
+type memoryRepository struct {
+	coll *repository.Memory
+}
+
+// NewMemoryRepository returns an empty in-memory Repository. Domains are
+// unique, like the index of the sources collection.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		coll: repository.NewMemory("domain"),
+	}
+}
+
+func (r *memoryRepository) Create(ctx context.Context, source *models.Source) error {
+	prepareNew(source)
+	return r.coll.InsertOne(ctx, source)
+}
+
+func (r *memoryRepository) FindByID(ctx context.Context, id bson.ObjectID) (*models.Source, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *memoryRepository) FindByDomain(ctx context.Context, domain string) (*models.Source, error) {
+	return r.findOne(ctx, bson.M{"domain": domain})
+}
+
+func (r *memoryRepository) findOne(ctx context.Context, filter bson.M) (*models.Source, error) {
+	var source models.Source
+	err := r.coll.FindOne(ctx, filter, &source)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // Source not found
+		}
+		return nil, err
+	}
+	return &source, nil
+}
+
+func (r *memoryRepository) FindAll(ctx context.Context, includeBlocked bool, pagination models.PaginationParams) ([]models.Source, models.PageInfo, error) {
+	return repository.FindMemoryPage[models.Source](ctx, r.coll, listing(includeBlocked), pagination)
+}
+
+func (r *memoryRepository) FindBlockedIDs(ctx context.Context) ([]bson.ObjectID, error) {
+	blocked, err := repository.FindMemoryAll[models.Source](ctx, r.coll, bson.M{"is_blocked": true}, nil)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]bson.ObjectID, 0, len(blocked))
+	for _, source := range blocked {
+		ids = append(ids, source.ID)
+	}
+	return ids, nil
+}
+
+func (r *memoryRepository) Update(ctx context.Context, id bson.ObjectID, update bson.M) error {
+	update["updated_at"] = time.Now()
+
+	matched, err := r.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Repository stores sources. NewRepository is backed by MongoDB and
+// NewMemoryRepository keeps everything in memory; both behave the same.
+// Lookups return nil, nil when nothing matches; updates of a missing source
+// return mongo.ErrNoDocuments.
+type Repository interface {
+	Create(ctx context.Context, source *models.Source) error
+	FindByID(ctx context.Context, id bson.ObjectID) (*models.Source, error)
+	FindByDomain(ctx context.Context, domain string) (*models.Source, error)
+	FindAll(ctx context.Context, includeBlocked bool, pagination models.PaginationParams) ([]models.Source, models.PageInfo, error)
+	FindBlockedIDs(ctx context.Context) ([]bson.ObjectID, error)
+	Update(ctx context.Context, id bson.ObjectID, update bson.M) error
+}
+
+type mongoRepository struct {
+	coll *mongo.Collection
+}
+
+func NewRepository(db *mongo.Database) Repository {
+	return &mongoRepository{
+		coll: db.Collection("sources"),
+	}
+}
+
+// ============================================================================
+// CREATE OPERATIONS
+// ============================================================================
+
+// Create inserts a new source into the database
+func (r *mongoRepository) Create(ctx context.Context, source *models.Source) error {
+	prepareNew(source)
+
+	_, err := r.coll.InsertOne(ctx, source)
+	return err
+}
+
+// prepareNew fills in the ID, timestamps and defaults of a source about to
+// be created
+func prepareNew(source *models.Source) {
+	if source.ID.IsZero() {
+		source.ID = bson.NewObjectID()
+	}
+
+	now := time.Now()
+	source.CreatedAt = now
+	source.UpdatedAt = now
+
+	if source.DefaultCategories == nil {
+		source.DefaultCategories = []string{}
+	}
+}
+
+// ============================================================================
+// READ OPERATIONS
+// ============================================================================
+
+// FindByID retrieves a source by its ObjectID
+func (r *mongoRepository) FindByID(ctx context.Context, id bson.ObjectID) (*models.Source, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// FindByDomain retrieves a source by its normalized domain
+func (r *mongoRepository) FindByDomain(ctx context.Context, domain string) (*models.Source, error) {
+	return r.findOne(ctx, bson.M{"domain": domain})
+}
+
+func (r *mongoRepository) findOne(ctx context.Context, filter bson.M) (*models.Source, error) {
+	var source models.Source
+	err := r.coll.FindOne(ctx, filter).Decode(&source)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // Source not found
+		}
+		return nil, err
+	}
+	return &source, nil
+}
+
+// FindAll retrieves sources ordered by name. Blocked sources are only
+// included when includeBlocked is set.
+func (r *mongoRepository) FindAll(ctx context.Context, includeBlocked bool, pagination models.PaginationParams) ([]models.Source, models.PageInfo, error) {
+	return repository.FindPage[models.Source](ctx, r.coll, listing(includeBlocked), pagination)
+}
+
+// listing is the query of FindAll
+func listing(includeBlocked bool) query.Query {
+	filter := bson.M{}
+	if !includeBlocked {
+		filter["is_blocked"] = false
+	}
+	return query.Where(filter, query.Sort{Field: "name"})
+}
+
+// FindBlockedIDs returns the IDs of every globally blocked source
+func (r *mongoRepository) FindBlockedIDs(ctx context.Context) ([]bson.ObjectID, error) {
+	findOptions := options.Find().SetProjection(bson.M{"_id": 1})
+
+	cursor, err := r.coll.Find(ctx, bson.M{"is_blocked": true}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		ID bson.ObjectID `bson:"_id"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	ids := make([]bson.ObjectID, 0, len(results))
+	for _, res := range results {
+		ids = append(ids, res.ID)
+	}
+	return ids, nil
+}
+
+// ============================================================================
+// UPDATE OPERATIONS
+// ============================================================================
+
+// Update updates a source's fields
+func (r *mongoRepository) Update(ctx context.Context, id bson.ObjectID, update bson.M) error {
+	filter := bson.M{"_id": id}
+
+	// Always update the updated_at timestamp
+	update["updated_at"] = time.Now()
+
+	result, err := r.coll.UpdateOne(ctx, filter, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/repository/repositorytest"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewMemoryRepository() })
+}
+
+func TestMongoRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewRepository(repositorytest.Database(t)) })
+}
+
+// testRepository is the contract every Repository implementation must meet
+func testRepository(t *testing.T, newRepo func(t *testing.T) Repository) {
+	ctx := context.Background()
+
+	seed := func(t *testing.T, r Repository) []*models.Source {
+		t.Helper()
+		sources := []*models.Source{
+			{Name: "Wire", Domain: "wire.example", IsActive: true},
+			{Name: "Daily", Domain: "daily.example", IsActive: true, IsBlocked: true, BlockedReason: "spam"},
+			{Name: "Almanac", Domain: "almanac.example"},
+		}
+		for _, s := range sources {
+			if err := r.Create(ctx, s); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+		return sources
+	}
+	names := func(sources []models.Source) []string {
+		var names []string
+		for _, s := range sources {
+			names = append(names, s.Name)
+		}
+		return names
+	}
+
+	t.Run("lookups and not found", func(t *testing.T) {
+		r := newRepo(t)
+		sources := seed(t, r)
+
+		byDomain, err := r.FindByDomain(ctx, "wire.example")
+		if err != nil || byDomain == nil || byDomain.ID != sources[0].ID || byDomain.CreatedAt.IsZero() || byDomain.DefaultCategories == nil {
+			t.Errorf("FindByDomain = %+v, %v", byDomain, err)
+		}
+		if s, err := r.FindByDomain(ctx, "missing.example"); s != nil || err != nil {
+			t.Errorf("FindByDomain(missing) = %v, %v, want nil, nil", s, err)
+		}
+		if s, err := r.FindByID(ctx, bson.NewObjectID()); s != nil || err != nil {
+			t.Errorf("FindByID(missing) = %v, %v, want nil, nil", s, err)
+		}
+		if err := r.Create(ctx, &models.Source{Name: "Wire again", Domain: "wire.example"}); !mongo.IsDuplicateKeyError(err) {
+			t.Errorf("Create with a taken domain = %v, want a duplicate key error", err)
+		}
+	})
+
+	t.Run("listing and blocked sources", func(t *testing.T) {
+		r := newRepo(t)
+		sources := seed(t, r)
+
+		all, _, err := r.FindAll(ctx, true, models.PaginationParams{Page: 1, Limit: 10})
+		if err != nil || !slices.Equal(names(all), []string{"Almanac", "Daily", "Wire"}) {
+			t.Errorf("FindAll(includeBlocked) = %v, %v", names(all), err)
+		}
+		visible, _, err := r.FindAll(ctx, false, models.PaginationParams{Page: 1, Limit: 10})
+		if err != nil || !slices.Equal(names(visible), []string{"Almanac", "Wire"}) {
+			t.Errorf("FindAll = %v, %v", names(visible), err)
+		}
+		blocked, err := r.FindBlockedIDs(ctx)
+		if err != nil || !slices.Equal(blocked, []bson.ObjectID{sources[1].ID}) {
+			t.Errorf("FindBlockedIDs = %v, %v", blocked, err)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		r := newRepo(t)
+		sources := seed(t, r)
+
+		if err := r.Update(ctx, sources[1].ID, bson.M{"is_blocked": false, "blocked_reason": ""}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := r.FindByID(ctx, sources[1].ID)
+		if err != nil || got.IsBlocked || got.BlockedReason != "" {
+			t.Errorf("after Update = %+v, %v", got, err)
+		}
+		if blocked, err := r.FindBlockedIDs(ctx); err != nil || len(blocked) != 0 {
+			t.Errorf("FindBlockedIDs after unblocking = %v, %v", blocked, err)
+		}
+		if err := r.Update(ctx, bson.NewObjectID(), bson.M{"name": "x"}); !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Errorf("Update(missing) = %v, want mongo.ErrNoDocuments", err)
+		}
+	})
+}
@@ -0,0 +1,259 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"yoharsh14/krant-backend/internal/apperr"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrSourceNotFound   = apperr.NotFound("source not found")
+	ErrSourceBlocked    = apperr.Forbidden("source is blocked").WithCode("source_blocked")
+	ErrSourceInactive   = apperr.Forbidden("source is not active").WithCode("source_inactive")
+	ErrSourceExists     = apperr.Conflict("a source with this domain already exists")
+	ErrInvalidURL       = apperr.Validation("invalid source url").WithField("url", "must be an absolute http(s) url")
+	ErrInvalidTrust     = apperr.Validation("trust score must be between 0 and 100").WithField("trust_score", "must be between 0 and 100")
+	ErrInvalidRateLimit = apperr.Validation("rate limit cannot be negative").WithField("rate_limit", "must not be negative")
+	ErrNoFieldsToUpdate = apperr.Validation("no fields to update").WithCode("no_fields_to_update")
+)
+
+type Service interface {
+	CreateSource(ctx context.Context, input models.CreateSourceInput) (*models.Source, error)
+	GetSource(ctx context.Context, id bson.ObjectID) (*models.Source, error)
+	UpdateSource(ctx context.Context, id bson.ObjectID, input models.UpdateSourceInput) (*models.Source, error)
+	ListSources(ctx context.Context, includeBlocked bool, pagination models.PaginationParams) ([]models.Source, models.PageInfo, error)
+	BlockSource(ctx context.Context, id bson.ObjectID, reason string) (*models.Source, error)
+	UnblockSource(ctx context.Context, id bson.ObjectID) (*models.Source, error)
+	BlockedSourceIDs(ctx context.Context) ([]bson.ObjectID, error)
+	ResolveForIngestion(ctx context.Context, sourceID string, fallback models.NewsSource) (*models.Source, error)
+}
+
+type svc struct {
+	r            Repository
+	autoRegister bool // register unknown domains on ingestion
+}
+
+func NewService(repo Repository, features config.Features) Service {
+	return &svc{
+		r:            repo,
+		autoRegister: features.SourceAutoRegister,
+	}
+}
+
+// CreateSource registers a new source, keyed by the domain of its URL
+func (s *svc) CreateSource(ctx context.Context, input models.CreateSourceInput) (*models.Source, error) {
+	domain, err := NormalizeDomain(input.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	trust := float64(models.DefaultTrustScore)
+	if input.TrustScore != nil {
+		trust = *input.TrustScore
+	}
+	if !models.IsValidTrustScore(trust) {
+		return nil, ErrInvalidTrust
+	}
+	if input.RateLimit < 0 {
+		return nil, ErrInvalidRateLimit
+	}
+
+	existing, err := s.r.FindByDomain(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrSourceExists
+	}
+
+	source := &models.Source{
+		Name:              strings.TrimSpace(input.Name),
+		Domain:            domain,
+		URL:               input.URL,
+		LogoURL:           input.LogoURL,
+		TrustScore:        trust,
+		DefaultCategories: input.DefaultCategories,
+		IsActive:          true,
+		RateLimit:         input.RateLimit,
+	}
+	if err := s.r.Create(ctx, source); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrSourceExists
+		}
+		return nil, err
+	}
+	return source, nil
+}
+
+// GetSource retrieves a source by its ID
+func (s *svc) GetSource(ctx context.Context, id bson.ObjectID) (*models.Source, error) {
+	source, err := s.r.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, ErrSourceNotFound
+	}
+	return source, nil
+}
+
+// UpdateSource applies a partial update to a source
+func (s *svc) UpdateSource(ctx context.Context, id bson.ObjectID, input models.UpdateSourceInput) (*models.Source, error) {
+	update := bson.M{}
+
+	if input.Name != nil {
+		update["name"] = strings.TrimSpace(*input.Name)
+	}
+	if input.LogoURL != nil {
+		update["logo_url"] = *input.LogoURL
+	}
+	if input.TrustScore != nil {
+		if !models.IsValidTrustScore(*input.TrustScore) {
+			return nil, ErrInvalidTrust
+		}
+		update["trust_score"] = *input.TrustScore
+	}
+	if input.DefaultCategories != nil {
+		update["default_categories"] = *input.DefaultCategories
+	}
+	if input.IsActive != nil {
+		update["is_active"] = *input.IsActive
+	}
+	if input.RateLimit != nil {
+		if *input.RateLimit < 0 {
+			return nil, ErrInvalidRateLimit
+		}
+		update["rate_limit"] = *input.RateLimit
+	}
+
+	if len(update) == 0 {
+		return nil, ErrNoFieldsToUpdate
+	}
+	return s.update(ctx, id, update)
+}
+
+// ListSources retrieves a page of sources ordered by name
+func (s *svc) ListSources(ctx context.Context, includeBlocked bool, pagination models.PaginationParams) ([]models.Source, models.PageInfo, error) {
+	return s.r.FindAll(ctx, includeBlocked, pagination)
+}
+
+// BlockSource hides a source and its articles for every user
+func (s *svc) BlockSource(ctx context.Context, id bson.ObjectID, reason string) (*models.Source, error) {
+	return s.update(ctx, id, bson.M{
+		"is_blocked":     true,
+		"blocked_reason": strings.TrimSpace(reason),
+	})
+}
+
+// UnblockSource lifts a global block
+func (s *svc) UnblockSource(ctx context.Context, id bson.ObjectID) (*models.Source, error) {
+	return s.update(ctx, id, bson.M{
+		"is_blocked":     false,
+		"blocked_reason": "",
+	})
+}
+
+// BlockedSourceIDs returns the IDs of every globally blocked source
+func (s *svc) BlockedSourceIDs(ctx context.Context) ([]bson.ObjectID, error) {
+	return s.r.FindBlockedIDs(ctx)
+}
+
+// ResolveForIngestion finds the source an incoming article belongs to.
+// Articles either name a registered source by ID or carry the source URL,
+// in which case unknown domains are registered on the fly with the
+// default trust score, unless auto registration is switched off. Blocked and inactive sources are refused.
+func (s *svc) ResolveForIngestion(ctx context.Context, sourceID string, fallback models.NewsSource) (*models.Source, error) {
+	var (
+		source *models.Source
+		err    error
+	)
+
+	if sourceID != "" {
+		id, perr := bson.ObjectIDFromHex(sourceID)
+		if perr != nil {
+			return nil, ErrSourceNotFound
+		}
+		source, err = s.GetSource(ctx, id)
+	} else {
+		source, err = s.findOrRegister(ctx, fallback)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if source.IsBlocked {
+		return nil, ErrSourceBlocked
+	}
+	if !source.IsActive {
+		return nil, ErrSourceInactive
+	}
+	return source, nil
+}
+
+func (s *svc) findOrRegister(ctx context.Context, ref models.NewsSource) (*models.Source, error) {
+	domain, err := NormalizeDomain(ref.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := s.r.FindByDomain(ctx, domain)
+	if err != nil || source != nil {
+		return source, err
+	}
+	if !s.autoRegister {
+		return nil, ErrSourceNotFound
+	}
+
+	name := strings.TrimSpace(ref.Name)
+	if name == "" {
+		name = domain
+	}
+	source = &models.Source{
+		Name:       name,
+		Domain:     domain,
+		URL:        ref.URL,
+		LogoURL:    ref.LogoURL,
+		TrustScore: models.DefaultTrustScore,
+		IsActive:   true,
+	}
+	if err := s.r.Create(ctx, source); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			// registered concurrently by another ingestion
+			return s.r.FindByDomain(ctx, domain)
+		}
+		return nil, err
+	}
+	return source, nil
+}
+
+func (s *svc) update(ctx context.Context, id bson.ObjectID, update bson.M) (*models.Source, error) {
+	if err := s.r.Update(ctx, id, update); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrSourceNotFound
+		}
+		return nil, err
+	}
+	return s.GetSource(ctx, id)
+}
+
+// NormalizeDomain extracts the lower case host of a URL without port or
+// leading "www."
+func NormalizeDomain(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL != "" && !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return "", ErrInvalidURL
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www."), nil
+}
@@ -0,0 +1,66 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/models"
+)
+
+func TestResolveForIngestion(t *testing.T) {
+	ctx := context.Background()
+	s := NewService(NewMemoryRepository(), config.Features{SourceAutoRegister: true})
+	wire, err := s.CreateSource(ctx, models.CreateSourceInput{Name: "Wire", URL: "https://www.Wire.example/markets"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateSource(ctx, models.CreateSourceInput{Name: "Wire", URL: "wire.example"}); !errors.Is(err, ErrSourceExists) {
+		t.Errorf("CreateSource with a taken domain = %v, want ErrSourceExists", err)
+	}
+
+	// by ID, or by the domain of the article's source URL
+	if got, err := s.ResolveForIngestion(ctx, wire.ID.Hex(), models.NewsSource{}); err != nil || got.ID != wire.ID {
+		t.Errorf("ResolveForIngestion by ID = %v, %v", got, err)
+	}
+	if got, err := s.ResolveForIngestion(ctx, "", models.NewsSource{URL: "http://wire.example:8080/a"}); err != nil || got.ID != wire.ID {
+		t.Errorf("ResolveForIngestion by domain = %v, %v", got, err)
+	}
+	if _, err := s.ResolveForIngestion(ctx, "nope", models.NewsSource{}); !errors.Is(err, ErrSourceNotFound) {
+		t.Errorf("ResolveForIngestion of a malformed ID = %v, want ErrSourceNotFound", err)
+	}
+
+	// unknown domains are registered once
+	first, err := s.ResolveForIngestion(ctx, "", models.NewsSource{URL: "https://daily.example/x"})
+	if err != nil || first.Name != "daily.example" || first.TrustScore != models.DefaultTrustScore {
+		t.Fatalf("ResolveForIngestion of a new domain = %+v, %v", first, err)
+	}
+	if again, err := s.ResolveForIngestion(ctx, "", models.NewsSource{URL: "https://daily.example/y"}); err != nil || again.ID != first.ID {
+		t.Errorf("ResolveForIngestion registered %v again, %v", again, err)
+	}
+	closed := NewService(NewMemoryRepository(), config.Features{})
+	if _, err := closed.ResolveForIngestion(ctx, "", models.NewsSource{URL: "https://daily.example"}); !errors.Is(err, ErrSourceNotFound) {
+		t.Errorf("ResolveForIngestion without auto registration = %v, want ErrSourceNotFound", err)
+	}
+
+	// blocked and inactive sources are refused until they are let back in
+	if _, err := s.BlockSource(ctx, wire.ID, " spam "); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ResolveForIngestion(ctx, wire.ID.Hex(), models.NewsSource{}); !errors.Is(err, ErrSourceBlocked) {
+		t.Errorf("ResolveForIngestion of a blocked source = %v, want ErrSourceBlocked", err)
+	}
+	if ids, err := s.BlockedSourceIDs(ctx); err != nil || len(ids) != 1 || ids[0] != wire.ID {
+		t.Errorf("BlockedSourceIDs = %v, %v", ids, err)
+	}
+	if unblocked, err := s.UnblockSource(ctx, wire.ID); err != nil || unblocked.IsBlocked || unblocked.BlockedReason != "" {
+		t.Errorf("UnblockSource = %+v, %v", unblocked, err)
+	}
+	inactive := false
+	if _, err := s.UpdateSource(ctx, wire.ID, models.UpdateSourceInput{IsActive: &inactive}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ResolveForIngestion(ctx, wire.ID.Hex(), models.NewsSource{}); !errors.Is(err, ErrSourceInactive) {
+		t.Errorf("ResolveForIngestion of an inactive source = %v, want ErrSourceInactive", err)
+	}
+}
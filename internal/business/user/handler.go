@@ -1,9 +1,11 @@
 package user
 
 import (
-	"log"
 	"net/http"
 	"yoharsh14/krant-backend/internal/json"
+	"yoharsh14/krant-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 type Handler interface {
@@ -11,6 +13,8 @@ type Handler interface {
 	FindUserByNameAndEmail (w http.ResponseWriter,r *http.Request)
 	UpdateUser (w http.ResponseWriter,r *http.Request)
 	ListAllUser (w http.ResponseWriter,r *http.Request)
+	MuteSource (w http.ResponseWriter,r *http.Request)
+	UnmuteSource (w http.ResponseWriter,r *http.Request)
 }
 
 type h struct {
@@ -23,22 +27,18 @@ func NewHandler(service Service) Handler{
 	}
 }
 
-func (h *h)CreateUser(w http.ResponseWriter,r *http.Request){
+// CreateUser registers a user from the profile setup form
+func (h *h) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var user CreateUserInput
-	log.Println(r.Body)
-
-	if err := json.Read(r,&user);err!=nil{
-		log.Println("Error occured in reading User date",err)
-		json.Write(w,http.StatusNotAcceptable,err)
+	if err := json.Read(r, &user); err != nil {
+		json.Error(w, r, err)
+		return
 	}
-	err :=h.service.CreateUser(r.Context(),user)
-
-	if err!=nil{
-			log.Println("Error has occured", err)
-		json.Write(w, http.StatusInternalServerError, err)
-	}else{
-		json.Write(w,http.StatusCreated,nil)
+	if err := h.service.CreateUser(r.Context(), user); err != nil {
+		json.Error(w, r, err)
+		return
 	}
+	json.Write(w, http.StatusCreated, models.SuccessResponse{Success: true})
 }
 func (h *h)FindUserByNameAndEmail (w http.ResponseWriter,r *http.Request){
 	json.Write(w,200,nil)
@@ -46,6 +46,85 @@ func (h *h)FindUserByNameAndEmail (w http.ResponseWriter,r *http.Request){
 func (h *h)UpdateUser (w http.ResponseWriter,r *http.Request){
 	json.Write(w,200,nil)
 }
-func (h *h)ListAllUser (w http.ResponseWriter,r *http.Request){
-	json.Write(w,200,nil)
+// legacyFilters maps the pre-query-language parameters to their filters
+var legacyFilters = map[string]string{
+	"trader_type": "filter[trader_type]",
+	"interest":    "filter[interests]",
+}
+
+// ListAllUser lists users, newest first unless ?sort= says otherwise.
+// ?cursor= continues after the next_cursor of a previous page; ?page= keeps
+// the legacy offset mode. Legacy ?trader_type= and ?interest= still filter.
+func (h *h) ListAllUser(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	for param, filter := range legacyFilters {
+		if v := values.Get(param); v != "" {
+			values.Add(filter, v)
+		}
+	}
+	q, err := listSchema.Parse(values)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	pagination := models.ParsePagination(values)
+
+	users, info, err := h.service.ListAllUser(r.Context(), q, pagination)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	resp := models.UserListResponse{
+		Users:    make([]any, 0, len(users)),
+		PageMeta: models.NewPageMeta(pagination, info),
+	}
+	for i := range users {
+		item, err := q.Select(users[i].ToResponse())
+		if err != nil {
+			json.Error(w, r, err)
+			return
+		}
+		resp.Users = append(resp.Users, item)
+	}
+	json.Write(w, http.StatusOK, resp)
+}
+
+// MuteSource hides a source from the user's feed
+func (h *h) MuteSource(w http.ResponseWriter, r *http.Request) {
+	userID, sourceID, ok := muteParams(w, r)
+	if !ok {
+		return
+	}
+	h.writeMuteResult(w, r, h.service.MuteSource(r.Context(), userID, sourceID))
+}
+
+// UnmuteSource shows a muted source in the user's feed again
+func (h *h) UnmuteSource(w http.ResponseWriter, r *http.Request) {
+	userID, sourceID, ok := muteParams(w, r)
+	if !ok {
+		return
+	}
+	h.writeMuteResult(w, r, h.service.UnmuteSource(r.Context(), userID, sourceID))
+}
+
+func (h *h) writeMuteResult(w http.ResponseWriter, r *http.Request, err error) {
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusOK, models.SuccessResponse{Success: true})
+}
+
+// muteParams parses the {id} and {sourceID} URL parameters
+func muteParams(w http.ResponseWriter, r *http.Request) (bson.ObjectID, bson.ObjectID, bool) {
+	userID, ok := json.ObjectID(w, r, "id")
+	if !ok {
+		return bson.ObjectID{}, bson.ObjectID{}, false
+	}
+	sourceID, ok := json.ObjectID(w, r, "sourceID")
+	if !ok {
+		return bson.ObjectID{}, bson.ObjectID{}, false
+	}
+	return userID, sourceID, true
 }
\ No newline at end of file
@@ -0,0 +1,143 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// memoryRepository is the in-memory Repository, meant for tests
+type memoryRepository struct {
+	coll *repository.Memory
+}
+
+// NewMemoryRepository returns an empty in-memory Repository. Email and
+// Google ID are unique, like the indexes of the users collection.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		coll: repository.NewMemory("email", "google_id"),
+	}
+}
+
+// ============================================================================
+// CREATE OPERATIONS
+// ============================================================================
+
+func (r *memoryRepository) Create(ctx context.Context, user *models.User) error {
+	prepareNew(user)
+	return r.coll.InsertOne(ctx, user)
+}
+
+// ============================================================================
+// READ OPERATIONS
+// ============================================================================
+
+func (r *memoryRepository) FindByID(ctx context.Context, id bson.ObjectID) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *memoryRepository) FindByGoogleID(ctx context.Context, googleID string) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"google_id": googleID})
+}
+
+func (r *memoryRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *memoryRepository) findOne(ctx context.Context, filter bson.M) (*models.User, error) {
+	var user models.User
+	err := r.coll.FindOne(ctx, filter, &user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // User not found
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *memoryRepository) Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.User, models.PageInfo, error) {
+	return repository.FindMemoryPage[models.User](ctx, r.coll, q, pagination)
+}
+
+func (r *memoryRepository) FindAll(ctx context.Context, pagination models.PaginationParams) ([]models.User, models.PageInfo, error) {
+	return r.Find(ctx, query.Where(bson.M{}, newestFirst), pagination)
+}
+
+func (r *memoryRepository) FindByTraderType(ctx context.Context, traderType string, pagination models.PaginationParams) ([]models.User, models.PageInfo, error) {
+	return r.Find(ctx, query.Where(bson.M{"trader_type": traderType}, newestFirst), pagination)
+}
+
+func (r *memoryRepository) FindByInterest(ctx context.Context, interest string, pagination models.PaginationParams) ([]models.User, models.PageInfo, error) {
+	return r.Find(ctx, query.Where(bson.M{"interests": interest}, newestFirst), pagination)
+}
+
+// ============================================================================
+// UPDATE OPERATIONS
+// ============================================================================
+
+func (r *memoryRepository) Update(ctx context.Context, id bson.ObjectID, update bson.M) error {
+	update["updated_at"] = time.Now()
+	return r.updateOne(ctx, id, bson.M{"$set": update})
+}
+
+func (r *memoryRepository) UpdateProfile(ctx context.Context, id bson.ObjectID, input UpdateUserInput) error {
+	update, err := profileUpdate(input)
+	if err != nil {
+		return err
+	}
+	return r.Update(ctx, id, update)
+}
+
+func (r *memoryRepository) UpdatePreferences(ctx context.Context, id bson.ObjectID, input UpdatePreferencesInput) error {
+	update, err := preferencesUpdate(input)
+	if err != nil {
+		return err
+	}
+	return r.Update(ctx, id, update)
+}
+
+func (r *memoryRepository) updateOne(ctx context.Context, id bson.ObjectID, update bson.M) error {
+	matched, err := r.coll.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ============================================================================
+// MUTED SOURCE OPERATIONS
+// ============================================================================
+
+func (r *memoryRepository) AddMutedSource(ctx context.Context, userID, sourceID bson.ObjectID) error {
+	return r.updateOne(ctx, userID, bson.M{
+		"$addToSet": bson.M{"muted_sources": sourceID},
+		"$set":      bson.M{"updated_at": time.Now()},
+	})
+}
+
+func (r *memoryRepository) RemoveMutedSource(ctx context.Context, userID, sourceID bson.ObjectID) error {
+	return r.updateOne(ctx, userID, bson.M{
+		"$pull": bson.M{"muted_sources": sourceID},
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+}
+
+func (r *memoryRepository) GetMutedSources(ctx context.Context, userID bson.ObjectID) ([]bson.ObjectID, error) {
+	var result struct {
+		MutedSources []bson.ObjectID `bson:"muted_sources"`
+	}
+	if err := r.coll.FindOne(ctx, bson.M{"_id": userID}, &result); err != nil {
+		return nil, err
+	}
+	return result.MutedSources, nil
+}
@@ -0,0 +1,23 @@
+package user
+
+import (
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+)
+
+// newestFirst is the default order of user listings
+var newestFirst = query.Sort{Field: "created_at", Desc: true}
+
+// listSchema is what clients may filter, sort and select on in user listings
+var listSchema = query.NewSchema(newestFirst,
+	query.Field{Name: "id", Path: "_id", Kind: query.ObjectID, Ops: []query.Op{query.Eq, query.In}},
+	query.Field{Name: "email", Ops: []query.Op{query.Eq}}, // exact only, so addresses cannot be enumerated
+	query.Field{Name: "name", Ops: []query.Op{query.Eq, query.Contains}, Sortable: true},
+	query.Field{Name: "trader_type", Ops: []query.Op{query.Eq, query.Ne, query.In}, Valid: models.IsValidTraderType},
+	query.Field{Name: "interests", Ops: []query.Op{query.Eq, query.In, query.Nin}},
+	query.Field{Name: "created_at", Kind: query.Time, Ops: query.Range, Sortable: true},
+	query.Field{Name: "profile_image"},
+	query.Field{Name: "avatar"},
+	query.Field{Name: "muted_sources"},
+	query.Field{Name: "preferences"},
+)
@@ -5,65 +5,52 @@ import (
 	"errors"
 	"time"
 	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
-// type Repository interface {
-// 	 Create(ctx context.Context, user *models.User) (error)
-// }
-
-
-//// CREATE
-// Create(ctx context.Context, user *models.User) error
-
-// // READ
-// FindByID(ctx context.Context, id bson.ObjectID) (*models.User, error)
-// FindByGoogleID(ctx context.Context, googleID string) (*models.User, error)
-// FindByEmail(ctx context.Context, email string) (*models.User, error)
-// FindAll(ctx context.Context, pagination models.PaginationParams) ([]models.User, int64, error)
-// FindByTraderType(ctx context.Context, traderType string, pagination models.PaginationParams) ([]models.User, int64, error)
-// FindByInterest(ctx context.Context, interest string, pagination models.PaginationParams) ([]models.User, int64, error)
-
-// // UPDATE
-// Update(ctx context.Context, id bson.ObjectID, update bson.M) error
-// UpdateProfile(ctx context.Context, id bson.ObjectID, input UpdateUserInput) error
-// UpdatePreferences(ctx context.Context, id bson.ObjectID, input UpdatePreferencesInput) error
-// UpdateLastLogin(ctx context.Context, id bson.ObjectID) error
-
-// // BOOKMARKS
-// AddBookmark(ctx context.Context, userID, newsID bson.ObjectID) error
-// RemoveBookmark(ctx context.Context, userID, newsID bson.ObjectID) error
-// HasBookmarked(ctx context.Context, userID, newsID bson.ObjectID) (bool, error)
-// GetBookmarkedNews(ctx context.Context, userID bson.ObjectID) ([]bson.ObjectID, error)
-
-// // READ HISTORY
-// AddToReadHistory(ctx context.Context, userID, newsID bson.ObjectID) error
-// GetReadHistory(ctx context.Context, userID bson.ObjectID, limit int) ([]models.ReadHistoryItem, error)
-
-// // DELETE
-// Delete(ctx context.Context, id bson.ObjectID) error
-
-// // AGGREGATION / STATS
-// GetUserStats(ctx context.Context, userID bson.ObjectID) (map[string]interface{}, error)
-// CountByTraderType(ctx context.Context) (map[string]int64, error)
-
-// // SEARCH
-// Search(ctx context.Context, query string, pagination models.PaginationParams) ([]models.User, int64, error)
+// Repository stores users. NewRepository is backed by MongoDB and
+// NewMemoryRepository keeps everything in memory; both behave the same.
+// Lookups return nil, nil when nothing matches; updates of a missing user
+// return mongo.ErrNoDocuments.
+type Repository interface {
+	// CREATE
+	Create(ctx context.Context, user *models.User) error
+
+	// READ
+	FindByID(ctx context.Context, id bson.ObjectID) (*models.User, error)
+	FindByGoogleID(ctx context.Context, googleID string) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.User, models.PageInfo, error)
+	FindAll(ctx context.Context, pagination models.PaginationParams) ([]models.User, models.PageInfo, error)
+	FindByTraderType(ctx context.Context, traderType string, pagination models.PaginationParams) ([]models.User, models.PageInfo, error)
+	FindByInterest(ctx context.Context, interest string, pagination models.PaginationParams) ([]models.User, models.PageInfo, error)
+
+	// UPDATE
+	Update(ctx context.Context, id bson.ObjectID, update bson.M) error
+	UpdateProfile(ctx context.Context, id bson.ObjectID, input UpdateUserInput) error
+	UpdatePreferences(ctx context.Context, id bson.ObjectID, input UpdatePreferencesInput) error
+
+	// MUTED SOURCES
+	AddMutedSource(ctx context.Context, userID, sourceID bson.ObjectID) error
+	RemoveMutedSource(ctx context.Context, userID, sourceID bson.ObjectID) error
+	GetMutedSources(ctx context.Context, userID bson.ObjectID) ([]bson.ObjectID, error)
+}
 
-// // EXISTENCE CHECKS
-// Exists(ctx context.Context, id bson.ObjectID) (bool, error)
-// EmailExists(ctx context.Context, email string) (bool, error)
-// GoogleIDExists(ctx context.Context, googleID string) (bool, error)
+// errNoFieldsToUpdate is returned by the partial updates when the input
+// sets nothing
+var errNoFieldsToUpdate = errors.New("no fields to update")
 
-type Repository struct {
+type mongoRepository struct {
 	coll *mongo.Collection
 }
 
-func NewRepository(db *mongo.Database) *Repository {
-	return &Repository{
+func NewRepository(db *mongo.Database) Repository {
+	return &mongoRepository{
 		coll: db.Collection("users"),
 	}
 }
@@ -74,7 +61,20 @@ func NewRepository(db *mongo.Database) *Repository {
 // ============================================================================
 
 // Create inserts a new user into the database
-func (r *Repository) Create(ctx context.Context, user *models.User) error {
+func (r *mongoRepository) Create(ctx context.Context, user *models.User) error {
+	prepareNew(user)
+
+	_, err := r.coll.InsertOne(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// prepareNew fills in the ID, timestamps and defaults of a user about to be
+// created
+func prepareNew(user *models.User) {
 	// Generate new ObjectID if not set
 	if user.ID.IsZero() {
 		user.ID = bson.NewObjectID()
@@ -96,6 +96,9 @@ func (r *Repository) Create(ctx context.Context, user *models.User) error {
 	if user.ReadHistory == nil {
 		user.ReadHistory = []models.ReadHistoryItem{}
 	}
+	if user.MutedSources == nil {
+		user.MutedSources = []bson.ObjectID{}
+	}
 
 	// Set default preferences
 	if user.Preferences.Theme == "" {
@@ -104,13 +107,6 @@ func (r *Repository) Create(ctx context.Context, user *models.User) error {
 	if user.Preferences.Language == "" {
 		user.Preferences.Language = "en"
 	}
-
-	_, err := r.coll.InsertOne(ctx, user)
-	if err != nil {
-		return err
-	}
-
-	return nil
 }
 
 // ============================================================================
@@ -118,7 +114,7 @@ func (r *Repository) Create(ctx context.Context, user *models.User) error {
 // ============================================================================
 
 // FindByID retrieves a user by their ObjectID
-func (r *Repository) FindByID(ctx context.Context, id bson.ObjectID) (*models.User, error) {
+func (r *mongoRepository) FindByID(ctx context.Context, id bson.ObjectID) (*models.User, error) {
 	var user models.User
 	err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&user)
 	if err != nil {
@@ -131,7 +127,7 @@ func (r *Repository) FindByID(ctx context.Context, id bson.ObjectID) (*models.Us
 }
 
 // FindByGoogleID retrieves a user by their Google OAuth ID
-func (r *Repository) FindByGoogleID(ctx context.Context, googleID string) (*models.User, error) {
+func (r *mongoRepository) FindByGoogleID(ctx context.Context, googleID string) (*models.User, error) {
 	var user models.User
 	err := r.coll.FindOne(ctx, bson.M{"google_id": googleID}).Decode(&user)
 	if err != nil {
@@ -144,7 +140,7 @@ func (r *Repository) FindByGoogleID(ctx context.Context, googleID string) (*mode
 }
 
 // FindByEmail retrieves a user by their email address
-func (r *Repository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+func (r *mongoRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
 	err := r.coll.FindOne(ctx, bson.M{"email": email}).Decode(&user)
 	if err != nil {
@@ -156,89 +152,25 @@ func (r *Repository) FindByEmail(ctx context.Context, email string) (*models.Use
 	return &user, nil
 }
 
-// FindAll retrieves all users with pagination
-func (r *Repository) FindAll(ctx context.Context, pagination models.PaginationParams) ([]models.User, int64, error) {
-	// Count total documents
-	totalCount, err := r.coll.CountDocuments(ctx, bson.M{})
-	if err != nil {
-		return nil, 0, err
-	}
-
-	// Find with pagination
-	findOptions := options.Find().
-		SetSkip(int64(pagination.GetSkip())).
-		SetLimit(int64(pagination.Limit)).
-		SetSort(bson.D{{Key: "created_at", Value: -1}}) // Newest first
-
-	cursor, err := r.coll.Find(ctx, bson.M{}, findOptions)
-	if err != nil {
-		return nil, 0, err
-	}
-	defer cursor.Close(ctx)
-
-	var users []models.User
-	if err = cursor.All(ctx, &users); err != nil {
-		return nil, 0, err
-	}
+// Find retrieves a page of users matching the query
+func (r *mongoRepository) Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.User, models.PageInfo, error) {
+	return repository.FindPage[models.User](ctx, r.coll, q, pagination)
+}
 
-	return users, totalCount, nil
+// FindAll retrieves all users with pagination, newest first
+func (r *mongoRepository) FindAll(ctx context.Context, pagination models.PaginationParams) ([]models.User, models.PageInfo, error) {
+	return r.Find(ctx, query.Where(bson.M{}, newestFirst), pagination)
 }
 
 // FindByTraderType retrieves users by trader type with pagination
-func (r *Repository) FindByTraderType(ctx context.Context, traderType string, pagination models.PaginationParams) ([]models.User, int64, error) {
-	filter := bson.M{"trader_type": traderType}
-
-	totalCount, err := r.coll.CountDocuments(ctx, filter)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	findOptions := options.Find().
-		SetSkip(int64(pagination.GetSkip())).
-		SetLimit(int64(pagination.Limit)).
-		SetSort(bson.D{{Key: "created_at", Value: -1}})
-
-	cursor, err := r.coll.Find(ctx, filter, findOptions)
-	if err != nil {
-		return nil, 0, err
-	}
-	defer cursor.Close(ctx)
-
-	var users []models.User
-	if err = cursor.All(ctx, &users); err != nil {
-		return nil, 0, err
-	}
-
-	return users, totalCount, nil
+func (r *mongoRepository) FindByTraderType(ctx context.Context, traderType string, pagination models.PaginationParams) ([]models.User, models.PageInfo, error) {
+	return r.Find(ctx, query.Where(bson.M{"trader_type": traderType}, newestFirst), pagination)
 }
 
 // FindByInterest retrieves users interested in a specific category
-func (r *Repository) FindByInterest(ctx context.Context, interest string, pagination models.PaginationParams) ([]models.User, int64, error) {
-	// $in operator checks if the array contains the value
-	filter := bson.M{"interests": interest}
-
-	totalCount, err := r.coll.CountDocuments(ctx, filter)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	findOptions := options.Find().
-		SetSkip(int64(pagination.GetSkip())).
-		SetLimit(int64(pagination.Limit)).
-		SetSort(bson.D{{Key: "created_at", Value: -1}})
-
-	cursor, err := r.coll.Find(ctx, filter, findOptions)
-	if err != nil {
-		return nil, 0, err
-	}
-	defer cursor.Close(ctx)
-
-	var users []models.User
-	if err = cursor.All(ctx, &users); err != nil {
-		return nil, 0, err
-	}
-
-	return users, totalCount, nil
+func (r *mongoRepository) FindByInterest(ctx context.Context, interest string, pagination models.PaginationParams) ([]models.User, models.PageInfo, error) {
+	// Matching a scalar against an array field checks if the array contains it
+	return r.Find(ctx, query.Where(bson.M{"interests": interest}, newestFirst), pagination)
 }
 
 // ============================================================================
@@ -246,7 +178,7 @@ func (r *Repository) FindByInterest(ctx context.Context, interest string, pagina
 // ============================================================================
 
 // Update updates a user's information
-func (r *Repository) Update(ctx context.Context, id bson.ObjectID, update bson.M) error {
+func (r *mongoRepository) Update(ctx context.Context, id bson.ObjectID, update bson.M) error {
 	filter := bson.M{"_id": id}
 
 	// Always update the updated_at timestamp
@@ -267,7 +199,25 @@ func (r *Repository) Update(ctx context.Context, id bson.ObjectID, update bson.M
 }
 
 // UpdateProfile updates user's profile information
-func (r *Repository) UpdateProfile(ctx context.Context, id bson.ObjectID, input UpdateUserInput) error {
+func (r *mongoRepository) UpdateProfile(ctx context.Context, id bson.ObjectID, input UpdateUserInput) error {
+	update, err := profileUpdate(input)
+	if err != nil {
+		return err
+	}
+	return r.Update(ctx, id, update)
+}
+
+// UpdatePreferences updates user's preferences
+func (r *mongoRepository) UpdatePreferences(ctx context.Context, id bson.ObjectID, input UpdatePreferencesInput) error {
+	update, err := preferencesUpdate(input)
+	if err != nil {
+		return err
+	}
+	return r.Update(ctx, id, update)
+}
+
+// profileUpdate builds the $set document for a profile update
+func profileUpdate(input UpdateUserInput) (bson.M, error) {
 	update := bson.M{}
 
 	if input.Name != nil {
@@ -284,14 +234,13 @@ func (r *Repository) UpdateProfile(ctx context.Context, id bson.ObjectID, input
 	}
 
 	if len(update) == 0 {
-		return errors.New("no fields to update")
+		return nil, errNoFieldsToUpdate
 	}
-
-	return r.Update(ctx, id, update)
+	return update, nil
 }
 
-// UpdatePreferences updates user's preferences
-func (r *Repository) UpdatePreferences(ctx context.Context, id bson.ObjectID, input UpdatePreferencesInput) error {
+// preferencesUpdate builds the $set document for a preferences update
+func preferencesUpdate(input UpdatePreferencesInput) (bson.M, error) {
 	update := bson.M{}
 
 	if input.NotificationEnabled != nil {
@@ -305,14 +254,78 @@ func (r *Repository) UpdatePreferences(ctx context.Context, id bson.ObjectID, in
 	}
 
 	if len(update) == 0 {
-		return errors.New("no fields to update")
+		return nil, errNoFieldsToUpdate
 	}
+	return update, nil
+}
 
-	return r.Update(ctx, id, update)
+// ============================================================================
+// MUTED SOURCE OPERATIONS
+// ============================================================================
+
+// AddMutedSource hides a source from the user's feed
+func (r *mongoRepository) AddMutedSource(ctx context.Context, userID, sourceID bson.ObjectID) error {
+	filter := bson.M{"_id": userID}
+	update := bson.M{
+		"$addToSet": bson.M{"muted_sources": sourceID}, // $addToSet prevents duplicates
+		"$set":      bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := r.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// RemoveMutedSource shows a previously muted source again
+func (r *mongoRepository) RemoveMutedSource(ctx context.Context, userID, sourceID bson.ObjectID) error {
+	filter := bson.M{"_id": userID}
+	update := bson.M{
+		"$pull": bson.M{"muted_sources": sourceID},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := r.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// GetMutedSources retrieves the IDs of the sources a user has muted
+func (r *mongoRepository) GetMutedSources(ctx context.Context, userID bson.ObjectID) ([]bson.ObjectID, error) {
+	projection := bson.M{"muted_sources": 1}
+
+	var result struct {
+		MutedSources []bson.ObjectID `bson:"muted_sources"`
+	}
+
+	err := r.coll.FindOne(
+		ctx,
+		bson.M{"_id": userID},
+		options.FindOne().SetProjection(projection),
+	).Decode(&result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result.MutedSources, nil
 }
 
 // UpdateLastLogin updates the user's last login timestamp
-// func (r *Repository) UpdateLastLogin(ctx context.Context, id bson.ObjectID) error {
+// func (r *mongoRepository) UpdateLastLogin(ctx context.Context, id bson.ObjectID) error {
 // 	filter := bson.M{"_id": id}
 // 	update := bson.M{
 // 		"$set": bson.M{
@@ -329,7 +342,7 @@ func (r *Repository) UpdatePreferences(ctx context.Context, id bson.ObjectID, in
 // // ============================================================================
 
 // // AddBookmark adds a news article to user's bookmarks
-// func (r *Repository) AddBookmark(ctx context.Context, userID, newsID bson.ObjectID) error {
+// func (r *mongoRepository) AddBookmark(ctx context.Context, userID, newsID bson.ObjectID) error {
 // 	filter := bson.M{"_id": userID}
 // 	update := bson.M{
 // 		"$addToSet": bson.M{"bookmarked_news": newsID}, // $addToSet prevents duplicates
@@ -349,7 +362,7 @@ func (r *Repository) UpdatePreferences(ctx context.Context, id bson.ObjectID, in
 // }
 
 // // RemoveBookmark removes a news article from user's bookmarks
-// func (r *Repository) RemoveBookmark(ctx context.Context, userID, newsID bson.ObjectID) error {
+// func (r *mongoRepository) RemoveBookmark(ctx context.Context, userID, newsID bson.ObjectID) error {
 // 	filter := bson.M{"_id": userID}
 // 	update := bson.M{
 // 		"$pull": bson.M{"bookmarked_news": newsID}, // $pull removes the element
@@ -369,7 +382,7 @@ func (r *Repository) UpdatePreferences(ctx context.Context, id bson.ObjectID, in
 // }
 
 // // HasBookmarked checks if a user has bookmarked a specific news article
-// func (r *Repository) HasBookmarked(ctx context.Context, userID, newsID bson.ObjectID) (bool, error) {
+// func (r *mongoRepository) HasBookmarked(ctx context.Context, userID, newsID bson.ObjectID) (bool, error) {
 // 	filter := bson.M{
 // 		"_id":             userID,
 // 		"bookmarked_news": newsID,
@@ -384,7 +397,7 @@ func (r *Repository) UpdatePreferences(ctx context.Context, id bson.ObjectID, in
 // }
 
 // // GetBookmarkedNews retrieves all bookmarked news IDs for a user
-// func (r *Repository) GetBookmarkedNews(ctx context.Context, userID bson.ObjectID) ([]bson.ObjectID, error) {
+// func (r *mongoRepository) GetBookmarkedNews(ctx context.Context, userID bson.ObjectID) ([]bson.ObjectID, error) {
 // 	// Use projection to only return the bookmarked_news field
 // 	projection := bson.M{"bookmarked_news": 1}
 
@@ -413,7 +426,7 @@ func (r *Repository) UpdatePreferences(ctx context.Context, id bson.ObjectID, in
 // // ============================================================================
 
 // // AddToReadHistory adds a news article to user's read history
-// func (r *Repository) AddToReadHistory(ctx context.Context, userID, newsID bson.ObjectID) error {
+// func (r *mongoRepository) AddToReadHistory(ctx context.Context, userID, newsID bson.ObjectID) error {
 // 	filter := bson.M{"_id": userID}
 
 // 	readItem := models.ReadHistoryItem{
@@ -444,7 +457,7 @@ func (r *Repository) UpdatePreferences(ctx context.Context, id bson.ObjectID, in
 // }
 
 // // GetReadHistory retrieves user's read history
-// func (r *Repository) GetReadHistory(ctx context.Context, userID bson.ObjectID, limit int) ([]models.ReadHistoryItem, error) {
+// func (r *mongoRepository) GetReadHistory(ctx context.Context, userID bson.ObjectID, limit int) ([]models.ReadHistoryItem, error) {
 // 	projection := bson.M{"read_history": 1}
 
 // 	var result struct {
@@ -478,7 +491,7 @@ func (r *Repository) UpdatePreferences(ctx context.Context, id bson.ObjectID, in
 // // ============================================================================
 
 // // Delete removes a user from the database
-// func (r *Repository) Delete(ctx context.Context, id bson.ObjectID) error {
+// func (r *mongoRepository) Delete(ctx context.Context, id bson.ObjectID) error {
 // 	filter := bson.M{"_id": id}
 
 // 	result, err := r.coll.DeleteOne(ctx, filter)
@@ -498,7 +511,7 @@ func (r *Repository) UpdatePreferences(ctx context.Context, id bson.ObjectID, in
 // // ============================================================================
 
 // // GetUserStats retrieves statistics about a user
-// func (r *Repository) GetUserStats(ctx context.Context, userID bson.ObjectID) (map[string]interface{}, error) {
+// func (r *mongoRepository) GetUserStats(ctx context.Context, userID bson.ObjectID) (map[string]interface{}, error) {
 // 	var user models.User
 // 	err := r.coll.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
 // 	if err != nil {
@@ -519,7 +532,7 @@ func (r *Repository) UpdatePreferences(ctx context.Context, id bson.ObjectID, in
 // }
 
 // // CountByTraderType counts users by trader type
-// func (r *Repository) CountByTraderType(ctx context.Context) (map[string]int64, error) {
+// func (r *mongoRepository) CountByTraderType(ctx context.Context) (map[string]int64, error) {
 // 	pipeline := []bson.M{
 // 		{
 // 			"$group": bson.M{
@@ -555,7 +568,7 @@ func (r *Repository) UpdatePreferences(ctx context.Context, id bson.ObjectID, in
 // // ============================================================================
 
 // // Search searches users by name or email
-// func (r *Repository) Search(ctx context.Context, query string, pagination models.PaginationParams) ([]models.User, int64, error) {
+// func (r *mongoRepository) Search(ctx context.Context, query string, pagination models.PaginationParams) ([]models.User, int64, error) {
 // 	// Case-insensitive regex search
 // 	filter := bson.M{
 // 		"$or": []bson.M{
@@ -593,7 +606,7 @@ func (r *Repository) UpdatePreferences(ctx context.Context, id bson.ObjectID, in
 // // ============================================================================
 
 // // Exists checks if a user exists by ID
-// func (r *Repository) Exists(ctx context.Context, id bson.ObjectID) (bool, error) {
+// func (r *mongoRepository) Exists(ctx context.Context, id bson.ObjectID) (bool, error) {
 // 	count, err := r.coll.CountDocuments(ctx, bson.M{"_id": id})
 // 	if err != nil {
 // 		return false, err
@@ -602,7 +615,7 @@ func (r *Repository) UpdatePreferences(ctx context.Context, id bson.ObjectID, in
 // }
 
 // // EmailExists checks if an email is already registered
-// func (r *Repository) EmailExists(ctx context.Context, email string) (bool, error) {
+// func (r *mongoRepository) EmailExists(ctx context.Context, email string) (bool, error) {
 // 	count, err := r.coll.CountDocuments(ctx, bson.M{"email": email})
 // 	if err != nil {
 // 		return false, err
@@ -611,7 +624,7 @@ func (r *Repository) UpdatePreferences(ctx context.Context, id bson.ObjectID, in
 // }
 
 // // GoogleIDExists checks if a Google ID is already registered
-// func (r *Repository) GoogleIDExists(ctx context.Context, googleID string) (bool, error) {
+// func (r *mongoRepository) GoogleIDExists(ctx context.Context, googleID string) (bool, error) {
 // 	count, err := r.coll.CountDocuments(ctx, bson.M{"google_id": googleID})
 // 	if err != nil {
 // 		return false, err
@@ -0,0 +1,263 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository/repositorytest"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewMemoryRepository() })
+}
+
+func TestMongoRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewRepository(repositorytest.Database(t)) })
+}
+
+// testRepository is the contract every Repository implementation must meet
+func testRepository(t *testing.T, newRepo func(t *testing.T) Repository) {
+	ctx := context.Background()
+	userID := func(u models.User) bson.ObjectID { return u.ID }
+
+	seed := func(t *testing.T, r Repository) []*models.User {
+		t.Helper()
+		users := []*models.User{
+			{GoogleID: "g1", Email: "ada@example.com", Name: "Ada", TraderType: "day_trader", Interests: []string{"stocks", "crypto"}},
+			{GoogleID: "g2", Email: "bob@example.com", Name: "Bob", TraderType: "swing_trader", Interests: []string{"forex"}},
+			{GoogleID: "g3", Email: "cy@example.com", Name: "Cy", TraderType: "day_trader", Interests: []string{"crypto"}},
+			{GoogleID: "g4", Email: "dee@example.com", Name: "Dee", TraderType: "beginner"},
+			{GoogleID: "g5", Email: "eve@example.com", Name: "Eve", TraderType: "day_trader", Interests: []string{"stocks"}},
+		}
+		for _, u := range users {
+			if err := r.Create(ctx, u); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+		return users
+	}
+
+	t.Run("create fills in defaults", func(t *testing.T) {
+		r := newRepo(t)
+		u := &models.User{GoogleID: "g", Email: "new@example.com", Name: "New"}
+		if err := r.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+		got, err := r.FindByID(ctx, u.ID)
+		if err != nil || got == nil {
+			t.Fatalf("FindByID = %v, %v", got, err)
+		}
+		if got.Preferences.Theme != "light" || got.Preferences.Language != "en" {
+			t.Errorf("preferences = %+v, want light/en defaults", got.Preferences)
+		}
+		if got.Interests == nil || got.MutedSources == nil || got.CreatedAt.IsZero() {
+			t.Errorf("defaults not stored: %+v", got)
+		}
+	})
+
+	t.Run("lookups", func(t *testing.T) {
+		r := newRepo(t)
+		users := seed(t, r)
+
+		byGoogle, err := r.FindByGoogleID(ctx, "g2")
+		if err != nil || byGoogle == nil || byGoogle.ID != users[1].ID {
+			t.Errorf("FindByGoogleID = %v, %v", byGoogle, err)
+		}
+		byEmail, err := r.FindByEmail(ctx, "cy@example.com")
+		if err != nil || byEmail == nil || byEmail.ID != users[2].ID {
+			t.Errorf("FindByEmail = %v, %v", byEmail, err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		r := newRepo(t)
+		missing := bson.NewObjectID()
+
+		if u, err := r.FindByID(ctx, missing); u != nil || err != nil {
+			t.Errorf("FindByID = %v, %v, want nil, nil", u, err)
+		}
+		if u, err := r.FindByEmail(ctx, "nobody@example.com"); u != nil || err != nil {
+			t.Errorf("FindByEmail = %v, %v, want nil, nil", u, err)
+		}
+		if err := r.Update(ctx, missing, bson.M{"name": "x"}); !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Errorf("Update = %v, want ErrNoDocuments", err)
+		}
+		if err := r.AddMutedSource(ctx, missing, bson.NewObjectID()); !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Errorf("AddMutedSource = %v, want ErrNoDocuments", err)
+		}
+		if _, err := r.GetMutedSources(ctx, missing); !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Errorf("GetMutedSources = %v, want ErrNoDocuments", err)
+		}
+	})
+
+	t.Run("unique email and google id", func(t *testing.T) {
+		r := newRepo(t)
+		seed(t, r)
+
+		err := r.Create(ctx, &models.User{GoogleID: "other", Email: "ada@example.com"})
+		if !mongo.IsDuplicateKeyError(err) {
+			t.Errorf("duplicate email: %v, want duplicate key error", err)
+		}
+		err = r.Create(ctx, &models.User{GoogleID: "g1", Email: "other@example.com"})
+		if !mongo.IsDuplicateKeyError(err) {
+			t.Errorf("duplicate google id: %v, want duplicate key error", err)
+		}
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		r := newRepo(t)
+		users := seed(t, r)
+		pagination := models.GetPaginationParams(1, 100)
+
+		dayTraders, _, err := r.FindByTraderType(ctx, "day_trader", pagination)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []bson.ObjectID{users[4].ID, users[2].ID, users[0].ID}
+		if got := repositorytest.IDs(dayTraders, userID); !slices.Equal(got, want) {
+			t.Errorf("FindByTraderType = %v, want %v newest first", got, want)
+		}
+
+		crypto, _, err := r.FindByInterest(ctx, "crypto", pagination)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = []bson.ObjectID{users[2].ID, users[0].ID}
+		if got := repositorytest.IDs(crypto, userID); !slices.Equal(got, want) {
+			t.Errorf("FindByInterest = %v, want %v", got, want)
+		}
+
+		q, err := listSchema.Parse(url.Values{
+			"filter[trader_type][ne]": {"day_trader"},
+			"sort":                    {"name"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		found, _, err := r.Find(ctx, q, pagination)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = []bson.ObjectID{users[1].ID, users[3].ID}
+		if got := repositorytest.IDs(found, userID); !slices.Equal(got, want) {
+			t.Errorf("Find(%v) = %v, want %v", q.Filter, got, want)
+		}
+
+		// partial matches on email would let addresses be guessed a letter at a time
+		if _, err := listSchema.Parse(url.Values{"filter[email][contains]": {"example"}}); err == nil {
+			t.Error("filter[email][contains] was accepted")
+		}
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		r := newRepo(t)
+		users := seed(t, r)
+
+		page, info, err := r.FindAll(ctx, models.GetPaginationParams(2, 2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []bson.ObjectID{users[2].ID, users[1].ID}
+		if got := repositorytest.IDs(page, userID); !slices.Equal(got, want) {
+			t.Errorf("page 2 = %v, want %v", got, want)
+		}
+		if info.TotalCount != 5 || !info.HasMore {
+			t.Errorf("page info = %+v, want 5 in total and more to come", info)
+		}
+
+		for _, sort := range []query.Sort{newestFirst, {Field: "name"}, {Field: "trader_type", Desc: true}} {
+			all, _, err := r.Find(ctx, query.Where(bson.M{}, sort), models.GetPaginationParams(1, 100))
+			if err != nil {
+				t.Fatal(err)
+			}
+			walked := repositorytest.AllPages(t, 2, func(p models.PaginationParams) ([]models.User, models.PageInfo, error) {
+				return r.Find(ctx, query.Where(bson.M{}, sort), p)
+			})
+			if got, want := repositorytest.IDs(walked, userID), repositorytest.IDs(all, userID); !slices.Equal(got, want) {
+				t.Errorf("cursor pages by %+v = %v, want %v", sort, got, want)
+			}
+		}
+
+		_, _, err = r.FindAll(ctx, models.GetCursorParams("not-a-cursor", 2))
+		if !errors.Is(err, models.ErrInvalidCursor) {
+			t.Errorf("bad cursor: %v, want ErrInvalidCursor", err)
+		}
+	})
+
+	t.Run("projection", func(t *testing.T) {
+		r := newRepo(t)
+		seed(t, r)
+
+		q, err := listSchema.Parse(url.Values{"fields": {"name"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		found, _, err := r.Find(ctx, q, models.GetPaginationParams(1, 1))
+		if err != nil || len(found) != 1 {
+			t.Fatalf("Find = %v, %v", found, err)
+		}
+		if found[0].Name == "" || found[0].Email != "" {
+			t.Errorf("projected user = %+v, want only the name set", found[0])
+		}
+	})
+
+	t.Run("updates", func(t *testing.T) {
+		r := newRepo(t)
+		users := seed(t, r)
+		id := users[0].ID
+
+		name, theme := "Ada L.", "dark"
+		if err := r.UpdateProfile(ctx, id, UpdateUserInput{Name: &name}); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.UpdatePreferences(ctx, id, UpdatePreferencesInput{Theme: &theme}); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.UpdateProfile(ctx, id, UpdateUserInput{}); err == nil {
+			t.Error("empty profile update succeeded")
+		}
+
+		got, err := r.FindByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != name || got.Preferences.Theme != theme || got.Preferences.Language != "en" {
+			t.Errorf("after updates = %+v", got)
+		}
+		if got.UpdatedAt.Before(got.CreatedAt) {
+			t.Errorf("updated_at %v before created_at %v", got.UpdatedAt, got.CreatedAt)
+		}
+	})
+
+	t.Run("muted sources", func(t *testing.T) {
+		r := newRepo(t)
+		users := seed(t, r)
+		id := users[0].ID
+		a, b := bson.NewObjectID(), bson.NewObjectID()
+
+		for _, source := range []bson.ObjectID{a, b, a} {
+			if err := r.AddMutedSource(ctx, id, source); err != nil {
+				t.Fatal(err)
+			}
+		}
+		muted, err := r.GetMutedSources(ctx, id)
+		if err != nil || !slices.Equal(muted, []bson.ObjectID{a, b}) {
+			t.Errorf("muted = %v, %v, want %v once each", muted, err, []bson.ObjectID{a, b})
+		}
+
+		if err := r.RemoveMutedSource(ctx, id, a); err != nil {
+			t.Fatal(err)
+		}
+		muted, err = r.GetMutedSources(ctx, id)
+		if err != nil || !slices.Equal(muted, []bson.ObjectID{b}) {
+			t.Errorf("muted = %v, %v, want %v", muted, err, []bson.ObjectID{b})
+		}
+	})
+}
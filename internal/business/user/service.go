@@ -2,17 +2,34 @@ package user
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
 	"time"
+	"yoharsh14/krant-backend/internal/apperr"
+	"yoharsh14/krant-backend/internal/business/source"
+	"yoharsh14/krant-backend/internal/events"
+	"yoharsh14/krant-backend/internal/media"
 	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
 
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrUserNotFound      = apperr.NotFound("user not found")
+	ErrUserExists        = apperr.Conflict("a user with this email or google id already exists")
+	ErrInvalidTraderType = apperr.Validation("invalid trader type").WithField("trader_type", "must be one of day_trader, swing_trader, long_term_investor, beginner")
 )
 
 type Service interface {
 	CreateUser(ctx context.Context,input CreateUserInput) (error)
 	FetchByUserNameAndEmail(ctx context.Context) (models.UserResponse,error)
 	UpdateUser(ctx context.Context) (models.UserResponse,error)
-	ListAllUser(ctx context.Context) ([]models.UserResponse,error) 
+	ListAllUser(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.User, models.PageInfo, error)
+	MuteSource(ctx context.Context, userID, sourceID bson.ObjectID) error
+	UnmuteSource(ctx context.Context, userID, sourceID bson.ObjectID) error
+	GetMutedSources(ctx context.Context, userID bson.ObjectID) ([]bson.ObjectID, error)
 	// GetUserByID(ctx context.Context, id bson.ObjectID) (*models.User, error)
 	// GetUserByGoogleID(ctx context.Context, googleID string) (*models.User, error)
 	// GetUserByEmail(ctx context.Context, email string) (*models.User, error)
@@ -35,32 +52,51 @@ type Service interface {
 }
 
 type svc struct{
-	r *Repository
+	r       Repository
+	images  media.Service
+	sources source.Service
+	outbox  events.Outbox
 }
 
-func NewService(repo *Repository) Service{
+func NewService(repo Repository, images media.Service, sources source.Service, outbox events.Outbox) Service{
 	return &svc{
-		r:repo,
+		r:       repo,
+		images:  images,
+		sources: sources,
+		outbox:  outbox,
 	}
 }
 
 func (s *svc) CreateUser(ctx context.Context,input CreateUserInput) (error){
+	if !models.IsValidTraderType(input.TraderType) {
+		return ErrInvalidTraderType
+	}
 	user := &models.User{
 		GoogleID: input.GoogleID,
 		Email: input.Email,
 		Name: input.Name,
-		ProfileImage: input.TraderType,
+		ProfileImage: input.ProfileImage,
 		TraderType:input.TraderType,
 		Interests: input.Interests,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		LastLogin:time.Now(),
 	}
-	err :=s.r.Create(context.TODO(),user)
+	user.Avatar = s.fetchAvatar(ctx, input.ProfileImage)
+
+	err := s.outbox.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.r.Create(ctx, user); err != nil {
+			return err
+		}
+		return s.outbox.Record(ctx, events.UserCreated, user)
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrUserExists
+	}
 	if err !=nil{
 		return err
 	}else{
-		log.Println("User create with documentId")
+		slog.InfoContext(ctx, "user created", "user_id", user.ID.Hex())
 	}
 	return nil
 }
@@ -72,9 +108,59 @@ func (s*svc) UpdateUser(ctx context.Context) (models.UserResponse,error){
 	return models.UserResponse{},nil
 	
 }
-func (s*svc) ListAllUser(ctx context.Context) ([]models.UserResponse,error){
-	return []models.UserResponse{},nil
-} 
+// ListAllUser retrieves a page of users matching the query
+func (s *svc) ListAllUser(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.User, models.PageInfo, error) {
+	return s.r.Find(ctx, q, pagination)
+}
+
+// ============================================================================
+// MUTED SOURCES
+// ============================================================================
+
+// MuteSource hides every article from a source in the user's feed
+func (s *svc) MuteSource(ctx context.Context, userID, sourceID bson.ObjectID) error {
+	if _, err := s.sources.GetSource(ctx, sourceID); err != nil {
+		return err
+	}
+
+	err := s.r.AddMutedSource(ctx, userID, sourceID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// UnmuteSource shows a muted source in the user's feed again
+func (s *svc) UnmuteSource(ctx context.Context, userID, sourceID bson.ObjectID) error {
+	err := s.r.RemoveMutedSource(ctx, userID, sourceID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// GetMutedSources retrieves the sources a user has muted
+func (s *svc) GetMutedSources(ctx context.Context, userID bson.ObjectID) ([]bson.ObjectID, error) {
+	ids, err := s.r.GetMutedSources(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	return ids, err
+}
+
+// fetchAvatar stores resized copies of the profile picture. Failing to
+// fetch it is not worth failing the signup, the raw URL is still kept.
+func (s *svc) fetchAvatar(ctx context.Context, url string) *models.Image {
+	if s.images == nil || url == "" {
+		return nil
+	}
+	img, err := s.images.Fetch(ctx, url, media.PresetAvatar)
+	if err != nil {
+		slog.WarnContext(ctx, "could not fetch profile image", "error", err)
+		return nil
+	}
+	return img
+}
 
 // // GetUserByID retrieves a user by their ID
 // func (s *svc) GetUserByID(ctx context.Context, id bson.ObjectID) (*models.User, error) {
@@ -6,7 +6,7 @@ type CreateUserInput struct {
 	Email        string   `json:"email" binding:"required,email"`
 	Name         string   `json:"name" binding:"required"`
 	ProfileImage string   `json:"profile_image"`
-	TraderType   string   `json:"trader_type" binding:"required"`
+	TraderType   string   `json:"trader_type" binding:"required,trader_type"`
 	Interests    []string `json:"interests" binding:"required,min=1"`
 }
 //Purpose: Validates data when creating a new user (during profile setup).
@@ -20,7 +20,7 @@ type CreateUserInput struct {
 type UpdateUserInput struct {
 	Name         *string   `json:"name,omitempty"`
 	ProfileImage *string   `json:"profile_image,omitempty"`
-	TraderType   *string   `json:"trader_type,omitempty"`
+	TraderType   *string   `json:"trader_type,omitempty" binding:"omitempty,trader_type"`
 	Interests    *[]string `json:"interests,omitempty"`
 }
 // if instead of pointer we just use string there will be aProblem:
@@ -31,9 +31,10 @@ type UpdateUserInput struct {
 // UpdatePreferencesInput represents input for updating user preferences
 type UpdatePreferencesInput struct {
 	NotificationEnabled *bool   `json:"notification_enabled,omitempty"`
-	Theme               *string `json:"theme,omitempty"`
+	Theme               *string `json:"theme,omitempty" binding:"omitempty,oneof=light dark"`
 	Language            *string `json:"language,omitempty"`
 }
 
 
 
+
@@ -0,0 +1,176 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+	"yoharsh14/krant-backend/internal/buildinfo"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/metrics"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/retry"
+	"yoharsh14/krant-backend/internal/tracing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// leaseSlack is how long a claim outlives the attempt's timeout, to record
+// its outcome
+const leaseSlack = 30 * time.Second
+
+var (
+	errWebhookDeleted  = errors.New("webhook was deleted")
+	errWebhookDisabled = errors.New("webhook is disabled")
+)
+
+// Dispatcher sends the queued deliveries and schedules the retries of those
+// that fail. Any number of dispatchers, in any number of instances, may share
+// a repository: each delivery is claimed by one of them at a time.
+type Dispatcher struct {
+	r      Repository
+	client *http.Client
+	cfg    config.Webhooks
+	now    func() time.Time
+}
+
+func NewDispatcher(repo Repository, cfg config.Webhooks) *Dispatcher {
+	return &Dispatcher{
+		r: repo,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			// a redirect is answered, not followed, so it counts as a failure
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		cfg: cfg,
+		now: time.Now,
+	}
+}
+
+// Run delivers with concurrency workers until ctx is done. Each worker
+// claims due deliveries one by one and waits for poll when none is due. An
+// attempt under way when ctx is done is finished, within the timeout.
+func (d *Dispatcher) Run(ctx context.Context, concurrency int, poll time.Duration) {
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Go(func() {
+			for ctx.Err() == nil {
+				delivered, err := d.DeliverNext(ctx)
+				if err != nil && ctx.Err() == nil {
+					slog.ErrorContext(ctx, "webhook dispatch failed", "error", err)
+				}
+				if delivered && err == nil {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+				case <-time.After(poll):
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// DeliverNext makes one attempt at the delivery that has been due longest
+// and reports whether there was one
+func (d *Dispatcher) DeliverNext(ctx context.Context) (bool, error) {
+	delivery, err := d.r.ClaimDelivery(ctx, d.now(), d.cfg.Timeout+leaseSlack)
+	if err != nil || delivery == nil {
+		return false, err
+	}
+	return true, tracing.Job(context.WithoutCancel(ctx), "webhook_delivery", func(ctx context.Context) error {
+		return d.attempt(ctx, delivery)
+	})
+}
+
+// attempt sends a claimed delivery and records how it went: succeeded on a
+// 2xx response, otherwise retried after a backoff or, once out of attempts,
+// dead. Deliveries whose webhook is gone or disabled are dead at once.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	webhook, err := d.r.FindByID(ctx, delivery.WebhookID)
+	if err != nil {
+		return err // tried again when the claim runs out
+	}
+
+	started := d.now()
+	var status int
+	switch {
+	case webhook == nil:
+		err = errWebhookDeleted
+	case !webhook.IsActive:
+		err = errWebhookDisabled
+	default:
+		status, err = d.send(ctx, webhook, delivery)
+	}
+	now := d.now()
+	attempt := models.WebhookAttempt{At: started, StatusCode: status, Duration: now.Sub(started)}
+
+	var result string
+	update := bson.M{}
+	switch {
+	case err == nil:
+		result = models.WebhookDeliverySucceeded
+		update["status"] = models.WebhookDeliverySucceeded
+		update["delivered_at"] = now
+	case webhook == nil || !webhook.IsActive || delivery.Attempts >= d.cfg.MaxAttempts:
+		result = models.WebhookDeliveryDead
+		update["status"] = models.WebhookDeliveryDead
+	default:
+		result = "retried"
+		update["next_attempt_at"] = now.Add(retry.Backoff(d.cfg.RetryBase, d.cfg.RetryMax, delivery.Attempts))
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	if err := d.r.RecordAttempt(ctx, delivery, attempt, update); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// claimed again after the lease ran out, or replayed meanwhile
+			slog.WarnContext(ctx, "webhook delivery changed during its attempt", "delivery_id", delivery.ID.Hex())
+			return nil
+		}
+		return err
+	}
+	metrics.WebhookDeliveries.With(delivery.Event, result).Inc()
+	if result == models.WebhookDeliveryDead {
+		slog.WarnContext(ctx, "webhook delivery dead-lettered",
+			"delivery_id", delivery.ID.Hex(), "webhook_id", delivery.WebhookID.Hex(),
+			"event", delivery.Event, "attempts", delivery.Attempts, "error", attempt.Error)
+	}
+	return nil
+}
+
+// send posts the delivery's payload to the webhook, signed with its secret,
+// and returns the response status
+func (d *Dispatcher) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "krant-webhooks/"+buildinfo.Get().Version)
+	req.Header.Set(IDHeader, delivery.EventID)
+	req.Header.Set(DeliveryHeader, delivery.ID.Hex())
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, d.now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // so the connection is reused
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,314 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"yoharsh14/krant-backend/internal/clocktest"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// receiver is a partner endpoint answering with the statuses it is given in
+// turn, the last one from then on
+type receiver struct {
+	*httptest.Server
+	statuses []int
+
+	mu       sync.Mutex
+	requests []received
+}
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	rc := &receiver{statuses: statuses}
+	rc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rc.mu.Lock()
+		rc.requests = append(rc.requests, received{header: r.Header.Clone(), body: body})
+		status := rc.statuses[min(len(rc.requests), len(rc.statuses))-1]
+		rc.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+func (rc *receiver) received() []received {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]received(nil), rc.requests...)
+}
+
+type fixture struct {
+	service    Service
+	dispatcher *Dispatcher
+	clock      *clocktest.Clock
+}
+
+func newFixture(t *testing.T) fixture {
+	repo := NewMemoryRepository()
+	c := clocktest.New()
+	s := NewService(repo).(*svc)
+	s.now = c.Now
+	d := NewDispatcher(repo, config.Webhooks{
+		Timeout:     time.Second,
+		MaxAttempts: 3,
+		RetryBase:   time.Minute,
+		RetryMax:    time.Hour,
+	})
+	d.now = c.Now
+	return fixture{service: s, dispatcher: d, clock: c}
+}
+
+// register adds a webhook on the receiver for events
+func (f fixture) register(t *testing.T, rc *receiver, events ...string) *models.Webhook {
+	t.Helper()
+	webhook, err := f.service.CreateWebhook(context.Background(), models.CreateWebhookInput{URL: rc.URL, Events: events})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return webhook
+}
+
+// publish publishes a new event of the given type and returns its ID
+func (f fixture) publish(t *testing.T, event string, data any) string {
+	t.Helper()
+	id := bson.NewObjectID().Hex()
+	err := f.service.Publish(context.Background(), models.WebhookEvent{ID: id, Type: event, CreatedAt: f.clock.Now(), Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// deliverDue makes every attempt that is due now
+func (f fixture) deliverDue(t *testing.T) {
+	t.Helper()
+	for {
+		delivered, err := f.dispatcher.DeliverNext(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !delivered {
+			return
+		}
+	}
+}
+
+// only returns the one delivery of the log
+func (f fixture) only(t *testing.T) models.WebhookDelivery {
+	t.Helper()
+	deliveries, _, err := f.service.ListDeliveries(context.Background(), query.Query{Sort: query.Sort{Field: "created_at"}}, models.GetPaginationParams(1, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("%d deliveries in the log, want 1", len(deliveries))
+	}
+	return deliveries[0]
+}
+
+func TestDeliversSignedEventsToSubscribers(t *testing.T) {
+	f := newFixture(t)
+	news, categories := newReceiver(t, http.StatusOK), newReceiver(t, http.StatusOK)
+	webhook := f.register(t, news, models.WebhookEventNewsPublished)
+	f.register(t, categories, models.WebhookEventCategoryCreated)
+
+	f.publish(t, models.WebhookEventNewsPublished, map[string]string{"id": "n1", "title": "Markets rally"})
+	f.deliverDue(t)
+
+	if got := categories.received(); len(got) != 0 {
+		t.Errorf("category subscriber got %d requests, want none", len(got))
+	}
+	got := news.received()
+	if len(got) != 1 {
+		t.Fatalf("news subscriber got %d requests, want 1", len(got))
+	}
+	req := got[0]
+	if err := Verify(webhook.Secret, req.header.Get(SignatureHeader), req.body, f.clock.Now(), 5*time.Minute); err != nil {
+		t.Errorf("Verify = %v, want a valid signature", err)
+	}
+	if err := Verify("another secret", req.header.Get(SignatureHeader), req.body, f.clock.Now(), 5*time.Minute); err == nil {
+		t.Error("Verify with another secret succeeded")
+	}
+	if err := Verify(webhook.Secret, req.header.Get(SignatureHeader), req.body, f.clock.Now().Add(time.Hour), 5*time.Minute); err == nil {
+		t.Error("Verify an hour later succeeded, want it too old")
+	}
+
+	var event struct {
+		ID   string            `json:"id"`
+		Type string            `json:"type"`
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(req.body, &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != models.WebhookEventNewsPublished || event.Data["title"] != "Markets rally" ||
+		req.header.Get(IDHeader) != event.ID || req.header.Get(EventHeader) != event.Type {
+		t.Errorf("delivered %s with headers %v; want the event and its id and type as headers", req.body, req.header)
+	}
+
+	delivery := f.only(t)
+	if delivery.Status != models.WebhookDeliverySucceeded || delivery.DeliveredAt == nil || len(delivery.History) != 1 ||
+		delivery.History[0].StatusCode != http.StatusOK {
+		t.Errorf("delivery = %+v, want succeeded after one attempt", delivery)
+	}
+}
+
+func TestPublishingAnEventAgainQueuesNothing(t *testing.T) {
+	f := newFixture(t)
+	rc := newReceiver(t, http.StatusOK)
+	f.register(t, rc, models.WebhookEventNewsPublished)
+
+	event := models.WebhookEvent{ID: bson.NewObjectID().Hex(), Type: models.WebhookEventNewsPublished, CreatedAt: f.clock.Now()}
+	for range 2 {
+		if err := f.service.Publish(context.Background(), event); err != nil {
+			t.Fatalf("Publish = %v, want a repeat to be skipped", err)
+		}
+	}
+	f.deliverDue(t)
+	if delivery := f.only(t); delivery.EventID != event.ID {
+		t.Errorf("delivery of event %s, want %s", delivery.EventID, event.ID)
+	}
+	if n := len(rc.received()); n != 1 {
+		t.Errorf("%d requests, want the event delivered once", n)
+	}
+}
+
+func TestRetriesWithBackoffUntilDelivered(t *testing.T) {
+	f := newFixture(t)
+	rc := newReceiver(t, http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusNoContent)
+	f.register(t, rc, models.WebhookEventNewsUpdated)
+
+	f.publish(t, models.WebhookEventNewsUpdated, map[string]string{"id": "n1"})
+	f.deliverDue(t)
+
+	delivery := f.only(t)
+	wait := delivery.NextAttemptAt.Sub(f.clock.Now())
+	if delivery.Status != models.WebhookDeliveryPending || wait <= 0 || wait > time.Minute {
+		t.Fatalf("after a 503: %s, next attempt in %v; want pending, retried within a minute", delivery.Status, wait)
+	}
+
+	f.deliverDue(t) // not due yet
+	if n := len(rc.received()); n != 1 {
+		t.Fatalf("%d requests before the retry is due, want 1", n)
+	}
+
+	f.clock.Advance(wait)
+	f.deliverDue(t)
+	delivery = f.only(t)
+	second := delivery.NextAttemptAt.Sub(f.clock.Now())
+	if second <= wait || second > 2*time.Minute {
+		t.Errorf("after the second failure, next attempt in %v; want it doubled from %v", second, wait)
+	}
+
+	f.clock.Advance(second)
+	f.deliverDue(t)
+	delivery = f.only(t)
+	if delivery.Status != models.WebhookDeliverySucceeded || delivery.Attempts != 3 || len(delivery.History) != 3 {
+		t.Fatalf("delivery = %s after %d attempts, %d in history; want succeeded after 3", delivery.Status, delivery.Attempts, len(delivery.History))
+	}
+	if ids := rc.received(); ids[0].header.Get(IDHeader) != ids[2].header.Get(IDHeader) {
+		t.Error("the retry has another event id, want the same so receivers can tell")
+	}
+}
+
+func TestDeadLettersAndReplays(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	rc := newReceiver(t, http.StatusInternalServerError)
+	webhook := f.register(t, rc, models.WebhookEventCategoryCreated)
+
+	f.publish(t, models.WebhookEventCategoryCreated, map[string]string{"id": "c1"})
+	for range 3 {
+		f.deliverDue(t)
+		f.clock.Advance(time.Hour)
+	}
+	delivery := f.only(t)
+	if delivery.Status != models.WebhookDeliveryDead || delivery.Attempts != 3 {
+		t.Fatalf("delivery = %s after %d attempts; want dead after 3", delivery.Status, delivery.Attempts)
+	}
+	f.clock.Advance(time.Hour)
+	f.deliverDue(t)
+	if n := len(rc.received()); n != 3 {
+		t.Errorf("%d requests, want no more after the delivery died", n)
+	}
+
+	// the partner fixes the endpoint and the admin replays the dead letter
+	rc.mu.Lock()
+	rc.statuses = []int{http.StatusOK}
+	rc.mu.Unlock()
+	replayed, err := f.service.ReplayDelivery(ctx, delivery.ID)
+	if err != nil || replayed.Status != models.WebhookDeliveryPending || replayed.Attempts != 0 {
+		t.Fatalf("ReplayDelivery = %+v, %v; want it pending again with no attempts", replayed, err)
+	}
+	if _, err := f.service.ReplayDelivery(ctx, delivery.ID); err != ErrDeliveryPending {
+		t.Errorf("ReplayDelivery while pending = %v, want ErrDeliveryPending", err)
+	}
+	f.deliverDue(t)
+	delivery = f.only(t)
+	if delivery.Status != models.WebhookDeliverySucceeded || len(delivery.History) != 4 {
+		t.Fatalf("replayed delivery = %s with %d attempts in history; want succeeded, history kept", delivery.Status, len(delivery.History))
+	}
+	got := rc.received()
+	if string(got[3].body) != string(got[0].body) {
+		t.Error("the replay sent another payload, want the original")
+	}
+
+	// a deleted webhook's deliveries are given up without a request
+	if err := f.service.DeleteWebhook(ctx, webhook.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.ReplayDelivery(ctx, delivery.ID); err != nil {
+		t.Fatal(err)
+	}
+	f.deliverDue(t)
+	delivery = f.only(t)
+	if last := delivery.History[len(delivery.History)-1]; delivery.Status != models.WebhookDeliveryDead || last.Error != errWebhookDeleted.Error() {
+		t.Errorf("delivery to a deleted webhook = %s, last error %q; want dead at once", delivery.Status, last.Error)
+	}
+	if n := len(rc.received()); n != 4 {
+		t.Errorf("%d requests, want none to a deleted webhook", n)
+	}
+}
+
+func TestRunStopsWithItsContext(t *testing.T) {
+	f := newFixture(t)
+	rc := newReceiver(t, http.StatusOK)
+	f.register(t, rc, models.WebhookEventNewsPublished)
+	f.publish(t, models.WebhookEventNewsPublished, map[string]string{"id": "n1"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		f.dispatcher.Run(ctx, 2, 10*time.Millisecond)
+		close(done)
+	}()
+	deadline := time.After(5 * time.Second)
+	for len(rc.received()) == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("Run delivered nothing")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after its context was done")
+	}
+}
@@ -0,0 +1,197 @@
+package webhook
+
+import (
+	"net/http"
+	"yoharsh14/krant-backend/internal/json"
+	"yoharsh14/krant-backend/internal/models"
+)
+
+// Handler serves the admin routes of webhooks and their delivery log
+type Handler interface {
+	ListWebhooks(w http.ResponseWriter, r *http.Request)
+	GetWebhook(w http.ResponseWriter, r *http.Request)
+	CreateWebhook(w http.ResponseWriter, r *http.Request)
+	UpdateWebhook(w http.ResponseWriter, r *http.Request)
+	DeleteWebhook(w http.ResponseWriter, r *http.Request)
+
+	ListDeliveries(w http.ResponseWriter, r *http.Request)
+	GetDelivery(w http.ResponseWriter, r *http.Request)
+	ReplayDelivery(w http.ResponseWriter, r *http.Request)
+}
+
+type h struct {
+	service Service
+}
+
+func NewHandler(service Service) Handler {
+	return &h{
+		service: service,
+	}
+}
+
+// ListWebhooks lists the webhooks, newest first unless ?sort= says otherwise
+func (h *h) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	q, err := listSchema.Parse(values)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	pagination := models.ParsePagination(values)
+
+	webhooks, info, err := h.service.ListWebhooks(r.Context(), q, pagination)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	resp := models.WebhookListResponse{
+		Webhooks: make([]any, 0, len(webhooks)),
+		PageMeta: models.NewPageMeta(pagination, info),
+	}
+	for i := range webhooks {
+		item, err := q.Select(webhooks[i].ToResponse())
+		if err != nil {
+			json.Error(w, r, err)
+			return
+		}
+		resp.Webhooks = append(resp.Webhooks, item)
+	}
+	json.Write(w, http.StatusOK, resp)
+}
+
+// GetWebhook looks a webhook up by {id}
+func (h *h) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := json.ObjectID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	webhook, err := h.service.GetWebhook(r.Context(), id)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusOK, webhook.ToResponse())
+}
+
+// CreateWebhook registers a webhook and answers with its secret, which is
+// not shown again
+func (h *h) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var input models.CreateWebhookInput
+	if err := json.Read(r, &input); err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	webhook, err := h.service.CreateWebhook(r.Context(), input)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	resp := webhook.ToResponse()
+	resp.Secret = webhook.Secret
+	json.Write(w, http.StatusCreated, resp)
+}
+
+// UpdateWebhook applies a partial update to the webhook in {id}, answering
+// with the new secret when it was rotated
+func (h *h) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := json.ObjectID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var input models.UpdateWebhookInput
+	if err := json.Read(r, &input); err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	webhook, err := h.service.UpdateWebhook(r.Context(), id, input)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	resp := webhook.ToResponse()
+	if input.RotateSecret {
+		resp.Secret = webhook.Secret
+	}
+	json.Write(w, http.StatusOK, resp)
+}
+
+// DeleteWebhook removes the webhook in {id}
+func (h *h) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := json.ObjectID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteWebhook(r.Context(), id); err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusOK, models.SuccessResponse{Success: true})
+}
+
+// ListDeliveries lists the delivery log, newest first unless ?sort= says
+// otherwise; ?filter[status]=dead lists the dead letters
+func (h *h) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	q, err := deliverySchema.Parse(values)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	pagination := models.ParsePagination(values)
+
+	deliveries, info, err := h.service.ListDeliveries(r.Context(), q, pagination)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+
+	resp := models.WebhookDeliveryListResponse{
+		Deliveries: make([]any, 0, len(deliveries)),
+		PageMeta:   models.NewPageMeta(pagination, info),
+	}
+	for i := range deliveries {
+		item, err := q.Select(deliveries[i].ToResponse())
+		if err != nil {
+			json.Error(w, r, err)
+			return
+		}
+		resp.Deliveries = append(resp.Deliveries, item)
+	}
+	json.Write(w, http.StatusOK, resp)
+}
+
+// GetDelivery looks a delivery up by {id}
+func (h *h) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	id, ok := json.ObjectID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.GetDelivery(r.Context(), id)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusOK, delivery.ToResponse())
+}
+
+// ReplayDelivery queues the delivery in {id} again
+func (h *h) ReplayDelivery(w http.ResponseWriter, r *http.Request) {
+	id, ok := json.ObjectID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.ReplayDelivery(r.Context(), id)
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusAccepted, delivery.ToResponse())
+}
@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// memoryRepository is the in-memory Repository, meant for tests
+type memoryRepository struct {
+	webhooks   *repository.Memory
+	deliveries *repository.Memory
+}
+
+// NewMemoryRepository returns an empty in-memory Repository
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		webhooks:   repository.NewMemory(),
+		deliveries: repository.NewMemory("webhook_id,event_id"),
+	}
+}
+
+func (r *memoryRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	prepareNew(webhook)
+	return r.webhooks.InsertOne(ctx, webhook)
+}
+
+func (r *memoryRepository) FindByID(ctx context.Context, id bson.ObjectID) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := r.webhooks.FindOne(ctx, bson.M{"_id": id}, &webhook)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // Webhook not found
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *memoryRepository) Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.Webhook, models.PageInfo, error) {
+	return repository.FindMemoryPage[models.Webhook](ctx, r.webhooks, q, pagination)
+}
+
+func (r *memoryRepository) FindSubscribed(ctx context.Context, event string) ([]models.Webhook, error) {
+	return repository.FindMemoryAll[models.Webhook](ctx, r.webhooks, subscribedTo(event), nil)
+}
+
+func (r *memoryRepository) Update(ctx context.Context, id bson.ObjectID, update bson.M) error {
+	update["updated_at"] = time.Now()
+
+	matched, err := r.webhooks.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *memoryRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	deleted, err := r.webhooks.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *memoryRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	prepareDelivery(delivery)
+	return r.deliveries.InsertOne(ctx, delivery)
+}
+
+func (r *memoryRepository) FindDelivery(ctx context.Context, id bson.ObjectID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.deliveries.FindOne(ctx, bson.M{"_id": id}, &delivery)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // Delivery not found
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *memoryRepository) FindDeliveries(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.WebhookDelivery, models.PageInfo, error) {
+	return repository.FindMemoryPage[models.WebhookDelivery](ctx, r.deliveries, q, pagination)
+}
+
+func (r *memoryRepository) ClaimDelivery(ctx context.Context, now time.Time, lease time.Duration) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.deliveries.FindOneAndUpdate(ctx, dueAt(now), bson.D{{Key: "next_attempt_at", Value: 1}}, claim(now, lease), &delivery)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // nothing due
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *memoryRepository) RecordAttempt(ctx context.Context, claimed *models.WebhookDelivery, attempt models.WebhookAttempt, update bson.M) error {
+	matched, err := r.deliveries.UpdateOne(ctx, sameClaim(claimed), recordAttempt(attempt, update))
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *memoryRepository) ResetDelivery(ctx context.Context, id bson.ObjectID, now time.Time) error {
+	matched, err := r.deliveries.UpdateOne(ctx, resettable(id), reset(now))
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
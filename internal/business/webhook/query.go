@@ -0,0 +1,33 @@
+package webhook
+
+import (
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+)
+
+// listSchema is what clients may filter, sort and select on in webhook listings
+var listSchema = query.NewSchema(query.Sort{Field: "created_at", Desc: true},
+	query.Field{Name: "id", Path: "_id", Kind: query.ObjectID, Ops: []query.Op{query.Eq, query.In}},
+	query.Field{Name: "url", Ops: []query.Op{query.Eq, query.Contains}},
+	query.Field{Name: "events", Ops: []query.Op{query.Eq, query.In}, Valid: models.IsValidWebhookEvent},
+	query.Field{Name: "description", Ops: []query.Op{query.Contains}},
+	query.Field{Name: "is_active", Kind: query.Bool, Ops: []query.Op{query.Eq}},
+	query.Field{Name: "created_at", Kind: query.Time, Ops: query.Range, Sortable: true},
+	query.Field{Name: "updated_at", Kind: query.Time, Ops: query.Range, Sortable: true},
+)
+
+// deliverySchema is what clients may filter, sort and select on in the
+// delivery log
+var deliverySchema = query.NewSchema(query.Sort{Field: "created_at", Desc: true},
+	query.Field{Name: "id", Path: "_id", Kind: query.ObjectID, Ops: []query.Op{query.Eq, query.In}},
+	query.Field{Name: "webhook_id", Kind: query.ObjectID, Ops: []query.Op{query.Eq, query.In}},
+	query.Field{Name: "event_id", Ops: []query.Op{query.Eq}},
+	query.Field{Name: "event", Ops: []query.Op{query.Eq, query.In}, Valid: models.IsValidWebhookEvent},
+	query.Field{Name: "payload"},
+	query.Field{Name: "status", Ops: []query.Op{query.Eq, query.Ne, query.In}, Valid: models.IsValidWebhookDeliveryStatus},
+	query.Field{Name: "attempts", Kind: query.Int, Ops: query.Range, Sortable: true},
+	query.Field{Name: "history"},
+	query.Field{Name: "next_attempt_at", Kind: query.Time, Ops: query.Range, Sortable: true},
+	query.Field{Name: "delivered_at", Kind: query.Time, Ops: query.Range, Sortable: true},
+	query.Field{Name: "created_at", Kind: query.Time, Ops: query.Range, Sortable: true},
+)
@@ -0,0 +1,257 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Repository stores webhooks and their deliveries. NewRepository is backed
+// by MongoDB and NewMemoryRepository keeps everything in memory; both behave
+// the same. Lookups return nil, nil when nothing matches; updates and
+// deletes of a missing document return mongo.ErrNoDocuments.
+type Repository interface {
+	Create(ctx context.Context, webhook *models.Webhook) error
+	FindByID(ctx context.Context, id bson.ObjectID) (*models.Webhook, error)
+	Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.Webhook, models.PageInfo, error)
+	FindSubscribed(ctx context.Context, event string) ([]models.Webhook, error)
+	Update(ctx context.Context, id bson.ObjectID, update bson.M) error
+	Delete(ctx context.Context, id bson.ObjectID) error
+
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	FindDelivery(ctx context.Context, id bson.ObjectID) (*models.WebhookDelivery, error)
+	FindDeliveries(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.WebhookDelivery, models.PageInfo, error)
+	ClaimDelivery(ctx context.Context, now time.Time, lease time.Duration) (*models.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, claimed *models.WebhookDelivery, attempt models.WebhookAttempt, update bson.M) error
+	ResetDelivery(ctx context.Context, id bson.ObjectID, now time.Time) error
+}
+
+type mongoRepository struct {
+	webhooks   *mongo.Collection
+	deliveries *mongo.Collection
+}
+
+func NewRepository(db *mongo.Database) Repository {
+	return &mongoRepository{
+		webhooks:   db.Collection("webhooks"),
+		deliveries: db.Collection("webhook_deliveries"),
+	}
+}
+
+// ============================================================================
+// WEBHOOKS
+// ============================================================================
+
+// Create inserts a new webhook into the database
+func (r *mongoRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	prepareNew(webhook)
+
+	_, err := r.webhooks.InsertOne(ctx, webhook)
+	return err
+}
+
+// prepareNew fills in the ID and timestamps of a webhook about to be created
+func prepareNew(webhook *models.Webhook) {
+	if webhook.ID.IsZero() {
+		webhook.ID = bson.NewObjectID()
+	}
+
+	now := time.Now()
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+}
+
+// FindByID retrieves a webhook by its ObjectID
+func (r *mongoRepository) FindByID(ctx context.Context, id bson.ObjectID) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := r.webhooks.FindOne(ctx, bson.M{"_id": id}).Decode(&webhook)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // Webhook not found
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// Find retrieves a page of webhooks matching the query
+func (r *mongoRepository) Find(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.Webhook, models.PageInfo, error) {
+	return repository.FindPage[models.Webhook](ctx, r.webhooks, q, pagination)
+}
+
+// FindSubscribed retrieves the active webhooks subscribed to event
+func (r *mongoRepository) FindSubscribed(ctx context.Context, event string) ([]models.Webhook, error) {
+	cursor, err := r.webhooks.Find(ctx, subscribedTo(event))
+	if err != nil {
+		return nil, err
+	}
+	var webhooks []models.Webhook
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func subscribedTo(event string) bson.M {
+	return bson.M{"events": event, "is_active": true}
+}
+
+// Update updates a webhook's fields
+func (r *mongoRepository) Update(ctx context.Context, id bson.ObjectID, update bson.M) error {
+	update["updated_at"] = time.Now()
+
+	result, err := r.webhooks.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Delete removes a webhook. Its deliveries stay in the log.
+func (r *mongoRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	result, err := r.webhooks.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ============================================================================
+// DELIVERIES
+// ============================================================================
+
+// CreateDelivery queues a delivery. A webhook has one delivery per event:
+// another of the same event ID is a duplicate key error.
+func (r *mongoRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	prepareDelivery(delivery)
+
+	_, err := r.deliveries.InsertOne(ctx, delivery)
+	return err
+}
+
+// prepareDelivery fills in the ID and timestamps of a delivery about to be
+// queued
+func prepareDelivery(delivery *models.WebhookDelivery) {
+	if delivery.ID.IsZero() {
+		delivery.ID = bson.NewObjectID()
+	}
+
+	now := time.Now()
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+	if delivery.History == nil {
+		delivery.History = []models.WebhookAttempt{}
+	}
+}
+
+// FindDelivery retrieves a delivery by its ObjectID
+func (r *mongoRepository) FindDelivery(ctx context.Context, id bson.ObjectID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.deliveries.FindOne(ctx, bson.M{"_id": id}).Decode(&delivery)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // Delivery not found
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// FindDeliveries retrieves a page of deliveries matching the query
+func (r *mongoRepository) FindDeliveries(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.WebhookDelivery, models.PageInfo, error) {
+	return repository.FindPage[models.WebhookDelivery](ctx, r.deliveries, q, pagination)
+}
+
+// ClaimDelivery takes the pending delivery that has been due longest and
+// hides it from other claims for lease, by moving its next attempt past it.
+// A dispatcher that dies mid-attempt thus leaves it to be claimed again once
+// the lease runs out. The claim counts as an attempt. It returns nil, nil
+// when nothing is due.
+func (r *mongoRepository) ClaimDelivery(ctx context.Context, now time.Time, lease time.Duration) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.deliveries.FindOneAndUpdate(ctx, dueAt(now), claim(now, lease),
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&delivery)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // nothing due
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func dueAt(now time.Time) bson.M {
+	return bson.M{"status": models.WebhookDeliveryPending, "next_attempt_at": bson.M{"$lte": now}}
+}
+
+func claim(now time.Time, lease time.Duration) bson.M {
+	return bson.M{
+		"$set": bson.M{"next_attempt_at": now.Add(lease), "updated_at": now},
+		"$inc": bson.M{"attempts": 1},
+	}
+}
+
+// RecordAttempt adds attempt to the history of a claimed delivery and sets
+// the fields in update. It returns mongo.ErrNoDocuments when the delivery was
+// claimed again since, after its lease ran out, or replayed meanwhile.
+func (r *mongoRepository) RecordAttempt(ctx context.Context, claimed *models.WebhookDelivery, attempt models.WebhookAttempt, update bson.M) error {
+	result, err := r.deliveries.UpdateOne(ctx, sameClaim(claimed), recordAttempt(attempt, update))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func sameClaim(claimed *models.WebhookDelivery) bson.M {
+	return bson.M{"_id": claimed.ID, "status": models.WebhookDeliveryPending, "attempts": claimed.Attempts}
+}
+
+func recordAttempt(attempt models.WebhookAttempt, update bson.M) bson.M {
+	update["updated_at"] = time.Now()
+	return bson.M{"$set": update, "$push": bson.M{"history": attempt}}
+}
+
+// ResetDelivery makes a delivery that is no longer pending due at now, with
+// its attempts starting over; its history is kept. It returns
+// mongo.ErrNoDocuments when there is no such delivery or it is still
+// pending.
+func (r *mongoRepository) ResetDelivery(ctx context.Context, id bson.ObjectID, now time.Time) error {
+	result, err := r.deliveries.UpdateOne(ctx, resettable(id), reset(now))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func resettable(id bson.ObjectID) bson.M {
+	return bson.M{"_id": id, "status": bson.M{"$ne": models.WebhookDeliveryPending}}
+}
+
+func reset(now time.Time) bson.M {
+	return bson.M{
+		"$set":   bson.M{"status": models.WebhookDeliveryPending, "attempts": 0, "next_attempt_at": now, "updated_at": now},
+		"$unset": bson.M{"delivered_at": ""},
+	}
+}
@@ -0,0 +1,148 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/repository/repositorytest"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewMemoryRepository() })
+}
+
+func TestMongoRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewRepository(repositorytest.Database(t)) })
+}
+
+// testRepository is the contract every Repository implementation must meet
+func testRepository(t *testing.T, newRepo func(t *testing.T) Repository) {
+	ctx := context.Background()
+	webhookID := func(w models.Webhook) bson.ObjectID { return w.ID }
+
+	t.Run("subscriptions", func(t *testing.T) {
+		r := newRepo(t)
+		webhooks := []*models.Webhook{
+			{URL: "https://a.example/hook", Events: []string{models.WebhookEventNewsPublished, models.WebhookEventNewsUpdated}, IsActive: true},
+			{URL: "https://b.example/hook", Events: []string{models.WebhookEventCategoryCreated}, IsActive: true},
+			{URL: "https://c.example/hook", Events: []string{models.WebhookEventNewsPublished}, IsActive: false},
+		}
+		for _, w := range webhooks {
+			if err := r.Create(ctx, w); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+
+		found, err := r.FindSubscribed(ctx, models.WebhookEventNewsPublished)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := repositorytest.IDs(found, webhookID), []bson.ObjectID{webhooks[0].ID}; !slices.Equal(got, want) {
+			t.Errorf("FindSubscribed(news.published) = %v, want the active subscriber %v", got, want)
+		}
+
+		if err := r.Update(ctx, webhooks[2].ID, bson.M{"is_active": true}); err != nil {
+			t.Fatal(err)
+		}
+		if found, _ := r.FindSubscribed(ctx, models.WebhookEventNewsPublished); len(found) != 2 {
+			t.Errorf("FindSubscribed after enabling = %d webhooks, want 2", len(found))
+		}
+
+		if err := r.Delete(ctx, webhooks[1].ID); err != nil {
+			t.Fatal(err)
+		}
+		if w, err := r.FindByID(ctx, webhooks[1].ID); err != nil || w != nil {
+			t.Errorf("FindByID(deleted) = %v, %v, want nil, nil", w, err)
+		}
+		if err := r.Delete(ctx, webhooks[1].ID); !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Errorf("Delete(deleted) = %v, want ErrNoDocuments", err)
+		}
+		if err := r.Update(ctx, webhooks[1].ID, bson.M{"is_active": false}); !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Errorf("Update(deleted) = %v, want ErrNoDocuments", err)
+		}
+	})
+
+	t.Run("claims", func(t *testing.T) {
+		r := newRepo(t)
+		now := time.Now().Truncate(time.Millisecond)
+		hook := bson.NewObjectID()
+		deliveries := []*models.WebhookDelivery{
+			{WebhookID: hook, Event: models.WebhookEventNewsPublished, Status: models.WebhookDeliveryPending, NextAttemptAt: now.Add(-time.Minute)},
+			{WebhookID: hook, Event: models.WebhookEventNewsUpdated, Status: models.WebhookDeliveryPending, NextAttemptAt: now.Add(-time.Hour)},
+			{WebhookID: hook, Event: models.WebhookEventNewsUpdated, Status: models.WebhookDeliveryPending, NextAttemptAt: now.Add(time.Hour)},
+			{WebhookID: hook, Event: models.WebhookEventNewsUpdated, Status: models.WebhookDeliveryDead, NextAttemptAt: now.Add(-time.Hour)},
+		}
+		for _, d := range deliveries {
+			d.EventID = bson.NewObjectID().Hex()
+			if err := r.CreateDelivery(ctx, d); err != nil {
+				t.Fatalf("CreateDelivery: %v", err)
+			}
+		}
+		duplicate := &models.WebhookDelivery{WebhookID: hook, EventID: deliveries[0].EventID, Event: models.WebhookEventNewsPublished, Status: models.WebhookDeliveryPending}
+		if err := r.CreateDelivery(ctx, duplicate); !mongo.IsDuplicateKeyError(err) {
+			t.Errorf("CreateDelivery of the same event to the same webhook = %v, want a duplicate key error", err)
+		}
+
+		var claimed []*models.WebhookDelivery
+		for {
+			d, err := r.ClaimDelivery(ctx, now, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d == nil {
+				break
+			}
+			claimed = append(claimed, d)
+		}
+		if len(claimed) != 2 || claimed[0].ID != deliveries[1].ID || claimed[1].ID != deliveries[0].ID {
+			t.Fatalf("claimed %d deliveries, want the two due ones, longest due first", len(claimed))
+		}
+		first := claimed[0]
+		if first.Attempts != 1 || !first.NextAttemptAt.Equal(now.Add(time.Minute)) {
+			t.Errorf("claimed delivery: attempts %d, next attempt %v; want 1 and the end of the lease", first.Attempts, first.NextAttemptAt)
+		}
+
+		// the leases run out: claimed again, so the first claim is stale
+		again, err := r.ClaimDelivery(ctx, now.Add(2*time.Minute), time.Minute)
+		if err != nil || again == nil || again.Attempts != 2 {
+			t.Fatalf("ClaimDelivery after the lease = %+v, %v; want a claimed delivery, second attempt", again, err)
+		}
+		stale := claimed[0]
+		if again.ID != stale.ID {
+			stale = claimed[1] // their leases ran out together
+		}
+		attempt := models.WebhookAttempt{At: now, StatusCode: 500, Error: "unexpected status 500"}
+		if err := r.RecordAttempt(ctx, stale, attempt, bson.M{"next_attempt_at": now}); !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Errorf("RecordAttempt of a stale claim = %v, want ErrNoDocuments", err)
+		}
+		delivered := now.Add(3 * time.Minute)
+		if err := r.RecordAttempt(ctx, again, models.WebhookAttempt{At: now, StatusCode: 204},
+			bson.M{"status": models.WebhookDeliverySucceeded, "delivered_at": delivered}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := r.FindDelivery(ctx, again.ID)
+		if err != nil || got.Status != models.WebhookDeliverySucceeded || len(got.History) != 1 || got.History[0].StatusCode != 204 {
+			t.Fatalf("FindDelivery after success = %+v, %v; want succeeded with one attempt", got, err)
+		}
+
+		if err := r.ResetDelivery(ctx, deliveries[2].ID, now); !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Errorf("ResetDelivery(pending) = %v, want ErrNoDocuments", err)
+		}
+		if err := r.ResetDelivery(ctx, again.ID, now); err != nil {
+			t.Fatal(err)
+		}
+		got, _ = r.FindDelivery(ctx, again.ID)
+		if got.Status != models.WebhookDeliveryPending || got.Attempts != 0 || got.DeliveredAt != nil || len(got.History) != 1 {
+			t.Errorf("reset delivery = %+v; want pending, no attempts, history kept", got)
+		}
+		if d, err := r.ClaimDelivery(ctx, now, time.Minute); err != nil || d == nil || d.ID != again.ID {
+			t.Errorf("ClaimDelivery after reset = %+v, %v; want the reset delivery", d, err)
+		}
+	})
+}
@@ -0,0 +1,206 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+	"yoharsh14/krant-backend/internal/apperr"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrWebhookNotFound  = apperr.NotFound("webhook not found")
+	ErrDeliveryNotFound = apperr.NotFound("webhook delivery not found")
+	ErrDeliveryPending  = apperr.Conflict("webhook delivery is still pending, it can be replayed once it succeeded or was given up")
+	ErrNoFieldsToUpdate = apperr.Validation("no fields to update").WithCode("no_fields_to_update")
+)
+
+type Service interface {
+	CreateWebhook(ctx context.Context, input models.CreateWebhookInput) (*models.Webhook, error)
+	GetWebhook(ctx context.Context, id bson.ObjectID) (*models.Webhook, error)
+	ListWebhooks(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.Webhook, models.PageInfo, error)
+	UpdateWebhook(ctx context.Context, id bson.ObjectID, input models.UpdateWebhookInput) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id bson.ObjectID) error
+
+	GetDelivery(ctx context.Context, id bson.ObjectID) (*models.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.WebhookDelivery, models.PageInfo, error)
+	ReplayDelivery(ctx context.Context, id bson.ObjectID) (*models.WebhookDelivery, error)
+
+	Publish(ctx context.Context, event models.WebhookEvent) error
+}
+
+type svc struct {
+	r   Repository
+	now func() time.Time
+}
+
+func NewService(repo Repository) Service {
+	return &svc{
+		r:   repo,
+		now: time.Now,
+	}
+}
+
+// CreateWebhook registers an endpoint with a new signing secret, which is
+// only ever returned here and by a rotation
+func (s *svc) CreateWebhook(ctx context.Context, input models.CreateWebhookInput) (*models.Webhook, error) {
+	webhook := &models.Webhook{
+		URL:         strings.TrimSpace(input.URL),
+		Events:      dedupe(input.Events),
+		Secret:      newSecret(),
+		Description: strings.TrimSpace(input.Description),
+		IsActive:    true,
+	}
+	if err := s.r.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// GetWebhook retrieves a webhook by its ID
+func (s *svc) GetWebhook(ctx context.Context, id bson.ObjectID) (*models.Webhook, error) {
+	webhook, err := s.r.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if webhook == nil {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// ListWebhooks retrieves a page of webhooks matching the query
+func (s *svc) ListWebhooks(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.Webhook, models.PageInfo, error) {
+	return s.r.Find(ctx, q, pagination)
+}
+
+// UpdateWebhook applies a partial update to a webhook. Deliveries already
+// queued go to the new URL, signed with the new secret.
+func (s *svc) UpdateWebhook(ctx context.Context, id bson.ObjectID, input models.UpdateWebhookInput) (*models.Webhook, error) {
+	update := bson.M{}
+
+	if input.URL != nil {
+		update["url"] = strings.TrimSpace(*input.URL)
+	}
+	if input.Events != nil {
+		update["events"] = dedupe(*input.Events)
+	}
+	if input.Description != nil {
+		update["description"] = strings.TrimSpace(*input.Description)
+	}
+	if input.IsActive != nil {
+		update["is_active"] = *input.IsActive
+	}
+	var secret string
+	if input.RotateSecret {
+		secret = newSecret()
+		update["secret"] = secret
+	}
+
+	if len(update) == 0 {
+		return nil, ErrNoFieldsToUpdate
+	}
+
+	if err := s.r.Update(ctx, id, update); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return s.GetWebhook(ctx, id)
+}
+
+// DeleteWebhook removes a webhook. Its pending deliveries are given up when
+// they come due; the delivery log keeps them.
+func (s *svc) DeleteWebhook(ctx context.Context, id bson.ObjectID) error {
+	err := s.r.Delete(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrWebhookNotFound
+	}
+	return err
+}
+
+// GetDelivery retrieves a delivery by its ID
+func (s *svc) GetDelivery(ctx context.Context, id bson.ObjectID) (*models.WebhookDelivery, error) {
+	delivery, err := s.r.FindDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil {
+		return nil, ErrDeliveryNotFound
+	}
+	return delivery, nil
+}
+
+// ListDeliveries retrieves a page of the delivery log
+func (s *svc) ListDeliveries(ctx context.Context, q query.Query, pagination models.PaginationParams) ([]models.WebhookDelivery, models.PageInfo, error) {
+	return s.r.FindDeliveries(ctx, q, pagination)
+}
+
+// ReplayDelivery queues a delivery that succeeded or was given up again, with
+// the same payload and event ID, for the dispatcher to send at once. Its
+// attempts start over.
+func (s *svc) ReplayDelivery(ctx context.Context, id bson.ObjectID) (*models.WebhookDelivery, error) {
+	err := s.r.ResetDelivery(ctx, id, s.now())
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, err := s.GetDelivery(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrDeliveryPending
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetDelivery(ctx, id)
+}
+
+// Publish queues a delivery of the event to every active webhook subscribed
+// to its type. The event's ID identifies it to receivers, so publishing it
+// again queues nothing for the webhooks that already have a delivery of it.
+func (s *svc) Publish(ctx context.Context, event models.WebhookEvent) error {
+	webhooks, err := s.r.FindSubscribed(ctx, event.Type)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	var errs []error
+	for _, webhook := range webhooks {
+		delivery := &models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			Event:         event.Type,
+			Payload:       string(body),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: now,
+		}
+		if err := s.r.CreateDelivery(ctx, delivery); err != nil && !mongo.IsDuplicateKeyError(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// dedupe drops repeated events, keeping their order
+func dedupe(events []string) []string {
+	seen := make(map[string]bool, len(events))
+	out := make([]string, 0, len(events))
+	for _, e := range events {
+		if !seen[e] {
+			seen[e] = true
+			out = append(out, e)
+		}
+	}
+	return out
+}
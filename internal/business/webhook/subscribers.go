@@ -0,0 +1,41 @@
+package webhook
+
+import (
+	"context"
+	"yoharsh14/krant-backend/internal/events"
+	"yoharsh14/krant-backend/internal/models"
+)
+
+// Subscribe publishes the domain events partners can subscribe to, which
+// have the same names as their webhook events. Each webhook event takes the
+// ID of its domain event, so one relayed again queues no second delivery.
+func Subscribe(bus *events.Bus, service Service) {
+	news := func(ctx context.Context, event events.Event) error {
+		var news models.News
+		if err := event.Decode(&news); err != nil {
+			return err
+		}
+		return publish(ctx, service, event, news.ToResponse(false))
+	}
+	category := func(ctx context.Context, event events.Event) error {
+		var category models.Category
+		if err := event.Decode(&category); err != nil {
+			return err
+		}
+		return publish(ctx, service, event, category.ToResponse())
+	}
+
+	bus.Subscribe("webhooks", events.NewsPublished, news)
+	bus.Subscribe("webhooks", events.NewsUpdated, news)
+	bus.Subscribe("webhooks", events.CategoryCreated, category)
+	bus.Subscribe("webhooks", events.CategoryUpdated, category)
+}
+
+func publish(ctx context.Context, service Service, event events.Event, data any) error {
+	return service.Publish(ctx, models.WebhookEvent{
+		ID:        event.ID.Hex(),
+		Type:      event.Type,
+		CreatedAt: event.OccurredAt,
+		Data:      data,
+	})
+}
@@ -0,0 +1,47 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/events"
+	"yoharsh14/krant-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestPublishesRelayedDomainEvents(t *testing.T) {
+	f := newFixture(t)
+	rc := newReceiver(t, http.StatusOK)
+	f.register(t, rc, models.WebhookEventNewsPublished)
+
+	outbox, bus := events.NewMemoryStore(), events.NewBus()
+	Subscribe(bus, f.service)
+	relay := events.NewRelay(outbox, bus, config.Default().Events)
+
+	news := models.News{ID: bson.NewObjectID(), Title: "Markets rally", Status: models.NewsStatusPublished}
+	if err := outbox.Record(context.Background(), events.NewsPublished, news); err != nil {
+		t.Fatal(err)
+	}
+	if relayed, err := relay.RelayNext(context.Background()); !relayed || err != nil {
+		t.Fatalf("RelayNext = %v, %v; want the event relayed", relayed, err)
+	}
+	f.deliverDue(t)
+
+	got := rc.received()
+	if len(got) != 1 {
+		t.Fatalf("%d requests, want 1", len(got))
+	}
+	var event struct {
+		Type string              `json:"type"`
+		Data models.NewsResponse `json:"data"`
+	}
+	if err := json.Unmarshal(got[0].body, &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != models.WebhookEventNewsPublished || event.Data.ID != news.ID.Hex() || event.Data.Title != news.Title {
+		t.Errorf("delivered %s, want the article as news.published", got[0].body)
+	}
+}
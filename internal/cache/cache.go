@@ -0,0 +1,67 @@
+// Package cache keeps recently read values in memory for a short while, so
+// read-heavy lookups such as category trees and article pages skip the
+// database.
+//
+// Services own their caches and delete entries on the paths that change
+// them. The TTL bounds how stale an entry can get through changes a service
+// does not see, such as writes made by another instance, since every
+// instance caches on its own; services that watch their collection, see
+// package changes, drop those entries sooner.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/metrics"
+)
+
+// Cache stores values by key. Implementations are safe for concurrent use;
+// a failing backend reports misses rather than errors.
+type Cache[V any] interface {
+	Get(ctx context.Context, key string) (V, bool)
+	Set(ctx context.Context, key string, value V)
+	Delete(ctx context.Context, keys ...string)
+	// Clear deletes every entry, e.g. all list pages once one item changed
+	Clear(ctx context.Context)
+}
+
+var lookups = metrics.NewCounterVec("cache_lookups_total",
+	"Cache lookups, by cache and result (hit or miss).",
+	"cache", "result")
+
+// New returns the cache called name as configured by cfg: an LRU of
+// cfg.Size entries each kept for cfg.TTL, or a cache that stores nothing
+// when either is zero
+func New[V any](name string, cfg config.Cache) Cache[V] {
+	if cfg.Size <= 0 || cfg.TTL <= 0 {
+		return Nop[V]{}
+	}
+	return NewLRU[V](name, cfg.Size, cfg.TTL)
+}
+
+// Nop is a cache that never holds anything
+type Nop[V any] struct{}
+
+func (Nop[V]) Get(context.Context, string) (V, bool) {
+	var zero V
+	return zero, false
+}
+
+func (Nop[V]) Set(context.Context, string, V)    {}
+func (Nop[V]) Delete(context.Context, ...string) {}
+func (Nop[V]) Clear(context.Context)             {}
+
+// Key derives a key from parts, e.g. a list's query and pagination. Equal
+// parts give equal keys since maps are encoded with sorted keys. It reports
+// false for parts that cannot be encoded, which are best not cached.
+func Key(parts ...any) (string, bool) {
+	data, err := json.Marshal(parts)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16]), true
+}
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU is an in-process cache of a fixed number of entries that evicts the
+// least recently used one when full. Entries expire ttl after they were set.
+type LRU[V any] struct {
+	name string
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List // of *entry[V], most recently used first
+	entries map[string]*list.Element
+}
+
+type entry[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+// NewLRU returns an empty cache called name, which labels its metrics
+func NewLRU[V any](name string, size int, ttl time.Duration) *LRU[V] {
+	return &LRU[V]{
+		name:    name,
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+func (c *LRU[V]) Get(_ context.Context, key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry[V])
+		if c.now().Before(e.expires) {
+			c.order.MoveToFront(el)
+			lookups.With(c.name, "hit").Inc()
+			return e.value, true
+		}
+		c.remove(el)
+	}
+	lookups.With(c.name, "miss").Inc()
+	var zero V
+	return zero, false
+}
+
+func (c *LRU[V]) Set(_ context.Context, key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(c.ttl)
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry[V])
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&entry[V]{key: key, value: value, expires: expires})
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *LRU[V]) Delete(_ context.Context, keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if el, ok := c.entries[key]; ok {
+			c.remove(el)
+		}
+	}
+}
+
+func (c *LRU[V]) Clear(context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.entries)
+}
+
+// remove drops el; the caller holds the lock
+func (c *LRU[V]) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*entry[V]).key)
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+	"yoharsh14/krant-backend/internal/config"
+)
+
+func TestLRUEvictsTheLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU[int]("test", 2, time.Minute)
+	c.Set(ctx, "a", 1)
+	c.Set(ctx, "b", 2)
+	c.Get(ctx, "a") // b is now the least recently used
+	c.Set(ctx, "c", 3)
+
+	if _, ok := c.Get(ctx, "b"); ok {
+		t.Error("b survived, want it evicted")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if got, ok := c.Get(ctx, key); !ok || got != want {
+			t.Errorf("Get(%s) = %d, %v; want %d", key, got, ok, want)
+		}
+	}
+
+	// setting a key again replaces its value without growing the cache
+	c.Set(ctx, "a", 10)
+	c.Set(ctx, "d", 4)
+	if got, ok := c.Get(ctx, "a"); !ok || got != 10 {
+		t.Errorf("Get(a) = %d, %v; want 10", got, ok)
+	}
+	if _, ok := c.Get(ctx, "c"); ok {
+		t.Error("c survived, want it evicted")
+	}
+}
+
+func TestLRUExpiresEntries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	c := NewLRU[string]("test", 10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set(ctx, "a", "first")
+	now = now.Add(59 * time.Second)
+	if _, ok := c.Get(ctx, "a"); !ok {
+		t.Fatal("a expired before its TTL")
+	}
+
+	// reading does not extend the TTL, setting does
+	now = now.Add(time.Second)
+	if _, ok := c.Get(ctx, "a"); ok {
+		t.Fatal("a outlived its TTL")
+	}
+	c.Set(ctx, "a", "second")
+	now = now.Add(30 * time.Second)
+	c.Set(ctx, "a", "third")
+	now = now.Add(45 * time.Second)
+	if got, ok := c.Get(ctx, "a"); !ok || got != "third" {
+		t.Errorf("Get(a) = %q, %v; want third", got, ok)
+	}
+	if len(c.entries) != 1 || c.order.Len() != 1 {
+		t.Errorf("holds %d entries in a list of %d, want 1", len(c.entries), c.order.Len())
+	}
+}
+
+func TestLRUDeleteAndClear(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU[int]("test", 10, time.Minute)
+	for i, key := range []string{"a", "b", "c"} {
+		c.Set(ctx, key, i)
+	}
+	c.Delete(ctx, "a", "missing")
+	if _, ok := c.Get(ctx, "a"); ok {
+		t.Error("a survived Delete")
+	}
+	if _, ok := c.Get(ctx, "b"); !ok {
+		t.Error("b was deleted with a")
+	}
+	c.Clear(ctx)
+	if _, ok := c.Get(ctx, "b"); ok || len(c.entries) != 0 || c.order.Len() != 0 {
+		t.Error("entries survived Clear")
+	}
+}
+
+func TestNewStoresNothingWhenDisabled(t *testing.T) {
+	ctx := context.Background()
+	for _, cfg := range []config.Cache{{Size: 0, TTL: time.Minute}, {Size: 10, TTL: 0}} {
+		c := New[int]("test", cfg)
+		c.Set(ctx, "a", 1)
+		if _, ok := c.Get(ctx, "a"); ok {
+			t.Errorf("cache of %+v held a value", cfg)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	a, ok := Key(map[string]int{"x": 1, "y": 2}, 3)
+	b, _ := Key(map[string]int{"y": 2, "x": 1}, 3)
+	c, _ := Key(map[string]int{"x": 1, "y": 2}, 4)
+	if !ok || a != b || a == c {
+		t.Errorf("keys %s, %s, %s; want the first two equal only", a, b, c)
+	}
+	if _, ok := Key(func() {}); ok {
+		t.Error("Key of a func succeeded")
+	}
+}
@@ -0,0 +1,73 @@
+// Package changes lets subsystems react to writes to a collection as they
+// happen, e.g. to drop cached copies of a document another instance changed.
+//
+// A Consumer follows each registered watch through a MongoDB change stream,
+// saving its position so it carries on where it left off after a restart or
+// a lost connection. Standalone servers have no change streams: the consumer
+// then polls the watched collection by a timestamp field, which sees inserts
+// and updates but not deletes.
+//
+// Handlers are best effort: one that fails is logged and the change is not
+// handed to it again. Work that must not be lost belongs in the outbox, see
+// package events.
+package changes
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Operations a change can be
+const (
+	Insert  = "insert"
+	Update  = "update"
+	Replace = "replace"
+	Delete  = "delete"
+)
+
+// Change is a write to a document of a watched collection
+type Change struct {
+	Operation  string
+	Collection string
+	ID         bson.ObjectID // of the document
+	// Document is the document as it is now, nil when it was deleted
+	Document bson.Raw
+	At       time.Time // when the write was made, to the second when streamed
+}
+
+// Decode unmarshals the changed document into out
+func (c Change) Decode(out any) error {
+	return bson.Unmarshal(c.Document, out)
+}
+
+// Handler reacts to a change
+type Handler func(ctx context.Context, change Change) error
+
+// Watch is a registered interest in the changes of a collection
+type Watch struct {
+	// Name identifies the watch's saved position, so it must be unique and
+	// stay the same across releases
+	Name       string
+	Collection string
+	Operations []string // those handed to Handle, all when empty
+	// PollField is the time field a polling consumer finds changed
+	// documents by, e.g. updated_at. A document counts as inserted when its
+	// created_at equals it.
+	PollField string
+	Handle    Handler
+}
+
+// wants reports whether the watch handles operation
+func (w Watch) wants(operation string) bool {
+	if len(w.Operations) == 0 {
+		return true
+	}
+	for _, op := range w.Operations {
+		if op == operation {
+			return true
+		}
+	}
+	return false
+}
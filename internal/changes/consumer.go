@@ -0,0 +1,332 @@
+package changes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/metrics"
+	"yoharsh14/krant-backend/internal/retry"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// MongoDB error codes the consumer acts on
+const (
+	codeNoChangeStreams = 40573 // $changeStream is only supported on replica sets
+	codeFatal           = 280   // ChangeStreamFatalError, e.g. the resume token is gone
+	codeHistoryLost     = 286   // ChangeStreamHistoryLost
+)
+
+const (
+	// pollBatch is how many changed documents a poll hands out at most
+	pollBatch = 100
+	// pollSettle is how old a change must be to be polled, so writes that
+	// commit slightly out of order are not skipped
+	pollSettle = time.Second
+)
+
+var errStreamClosed = errors.New("change stream closed, e.g. as its collection was dropped")
+
+// Consumer hands the changes of the watched collections to their handlers.
+// Every instance runs its own, each change reaching each of them: watches
+// suit work every instance must do, such as dropping its cached copies.
+// Positions are saved per instance, under cfg.Instance or else the host
+// name, so instances sharing a database do not move each other's.
+// Watch must be called before Run.
+type Consumer struct {
+	db        *mongo.Database
+	positions Positions
+	cfg       config.Changes
+	instance  string
+	watches   []Watch
+	now       func() time.Time
+}
+
+func NewConsumer(db *mongo.Database, positions Positions, cfg config.Changes) *Consumer {
+	instance := cfg.Instance
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+	return &Consumer{
+		db:        db,
+		positions: positions,
+		cfg:       cfg,
+		instance:  instance,
+		now:       time.Now,
+	}
+}
+
+// Watch registers w. It panics when its name is taken, as the two would
+// share a position.
+func (c *Consumer) Watch(w Watch) {
+	for _, other := range c.watches {
+		if other.Name == w.Name {
+			panic("changes: watch " + w.Name + " registered twice")
+		}
+	}
+	c.watches = append(c.watches, w)
+}
+
+// Run follows every watch until ctx is done. A watch whose stream or poll
+// fails starts again from its saved position, after a backoff.
+func (c *Consumer) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, w := range c.watches {
+		wg.Go(func() {
+			failures := 0
+			for ctx.Err() == nil {
+				progressed, err := c.follow(ctx, w)
+				if ctx.Err() != nil {
+					return
+				}
+				if progressed {
+					failures = 0
+				}
+				failures++
+				wait := retry.Backoff(c.cfg.RetryBase, c.cfg.RetryMax, failures)
+				slog.WarnContext(ctx, "change watch interrupted, resuming",
+					"watch", w.Name, "error", err, "retry_in", wait)
+				select {
+				case <-ctx.Done():
+				case <-time.After(wait):
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// follow hands out the changes of a watch from its saved position until
+// the stream or poll fails, reporting whether it got past any
+func (c *Consumer) follow(ctx context.Context, w Watch) (bool, error) {
+	position, err := c.positions.Load(ctx, c.instance, w.Name)
+	if err != nil {
+		return false, err
+	}
+	if position == nil {
+		position = &Position{Instance: c.instance, Watch: w.Name}
+	}
+
+	progressed, err := c.stream(ctx, w, position)
+	var serverErr mongo.ServerError
+	switch {
+	case errors.As(err, &serverErr) && serverErr.HasErrorCode(codeNoChangeStreams):
+		slog.InfoContext(ctx, "MongoDB has no change streams, polling instead", "watch", w.Name, "field", w.PollField)
+		return c.poll(ctx, w, position)
+	case errors.As(err, &serverErr) && (serverErr.HasErrorCode(codeHistoryLost) || serverErr.HasErrorCode(codeFatal)):
+		// the oplog moved past the saved position: carry on from now, the
+		// changes in between are lost to this watch
+		slog.ErrorContext(ctx, "change watch position lost, starting from now", "watch", w.Name, "error", err)
+		position.Token = nil
+		if err := c.positions.Save(ctx, position); err != nil {
+			return progressed, err
+		}
+	}
+	return progressed, err
+}
+
+// stream follows a watch through a change stream, saving its resume token
+// after every change and every idle wait that moved it
+func (c *Consumer) stream(ctx context.Context, w Watch, position *Position) (bool, error) {
+	opts := options.ChangeStream().
+		SetFullDocument(options.UpdateLookup).
+		SetMaxAwaitTime(c.cfg.PollInterval)
+	if position.Token != nil {
+		opts.SetStartAfter(position.Token)
+	}
+	operations := w.Operations
+	if len(operations) == 0 {
+		operations = []string{Insert, Update, Replace, Delete}
+	}
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"operationType": bson.M{"$in": operations}}}}}
+
+	stream, err := c.db.Collection(w.Collection).Watch(ctx, pipeline, opts)
+	if err != nil {
+		return false, err
+	}
+	defer stream.Close(context.WithoutCancel(ctx))
+
+	progressed := false
+	for ctx.Err() == nil {
+		if stream.TryNext(ctx) {
+			change, err := fromEvent(stream.Current, w.Collection)
+			if err != nil {
+				return progressed, err
+			}
+			c.handle(ctx, w, change)
+		} else if err := stream.Err(); err != nil {
+			return progressed, err
+		} else if stream.ID() == 0 {
+			return progressed, errStreamClosed
+		}
+
+		token := stream.ResumeToken()
+		if token == nil || bytes.Equal(token, position.Token) {
+			continue
+		}
+		position.Token = token
+		position.PolledAt, position.PolledID = time.Time{}, bson.ObjectID{}
+		if err := c.positions.Save(ctx, position); err != nil {
+			return progressed, err
+		}
+		progressed = true
+	}
+	return progressed, ctx.Err()
+}
+
+// changeEvent is the part of a change stream event the consumer reads
+type changeEvent struct {
+	OperationType string         `bson:"operationType"`
+	ClusterTime   bson.Timestamp `bson:"clusterTime"`
+	DocumentKey   struct {
+		ID bson.ObjectID `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument bson.Raw `bson:"fullDocument"`
+}
+
+// fromEvent reads a change stream event
+func fromEvent(raw bson.Raw, collection string) (Change, error) {
+	var event changeEvent
+	if err := bson.Unmarshal(raw, &event); err != nil {
+		return Change{}, fmt.Errorf("decoding change event: %w", err)
+	}
+	return Change{
+		Operation:  event.OperationType,
+		Collection: collection,
+		ID:         event.DocumentKey.ID,
+		Document:   event.FullDocument,
+		At:         time.Unix(int64(event.ClusterTime.T), 0),
+	}, nil
+}
+
+// poll follows a watch by querying its collection every poll interval for
+// the documents whose PollField moved past the saved position
+func (c *Consumer) poll(ctx context.Context, w Watch, position *Position) (bool, error) {
+	if w.PollField == "" {
+		return false, fmt.Errorf("watch %s has no poll field", w.Name)
+	}
+	if position.PolledAt.IsZero() {
+		// the first poll, or the first since streaming: changes are handed
+		// out from now on, as a new stream would
+		position.PolledAt = c.now().Add(-pollSettle)
+		position.Token = nil
+		if err := c.positions.Save(ctx, position); err != nil {
+			return false, err
+		}
+	}
+
+	progressed := false
+	for ctx.Err() == nil {
+		n, err := c.pollOnce(ctx, w, position)
+		if err != nil {
+			return progressed, err
+		}
+		if n > 0 {
+			progressed = true
+		}
+		if n == pollBatch {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(c.cfg.PollInterval):
+		}
+	}
+	return progressed, ctx.Err()
+}
+
+// pollOnce hands out the next batch of changed documents and saves the
+// position after the last, returning how many there were
+func (c *Consumer) pollOnce(ctx context.Context, w Watch, position *Position) (int, error) {
+	field := w.PollField
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{field: bson.M{"$gt": position.PolledAt, "$lte": c.now().Add(-pollSettle)}},
+			bson.M{field: position.PolledAt, "_id": bson.M{"$gt": position.PolledID}},
+		},
+	}
+	cursor, err := c.db.Collection(w.Collection).Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: field, Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(pollBatch))
+	if err != nil {
+		return 0, err
+	}
+	var docs []bson.Raw
+	if err := cursor.All(ctx, &docs); err != nil {
+		return 0, err
+	}
+
+	for _, doc := range docs {
+		change, err := fromDocument(doc, w)
+		if err != nil {
+			return 0, err
+		}
+		if w.wants(change.Operation) {
+			c.handle(ctx, w, change)
+		}
+		position.PolledAt, position.PolledID = change.At, change.ID
+	}
+	if len(docs) > 0 {
+		if err := c.positions.Save(ctx, position); err != nil {
+			return 0, err
+		}
+	}
+	return len(docs), nil
+}
+
+// fromDocument reads a polled document as an insert when it was created at
+// the time it was found by, as an update otherwise
+func fromDocument(doc bson.Raw, w Watch) (Change, error) {
+	var fields struct {
+		ID        bson.ObjectID `bson:"_id"`
+		CreatedAt time.Time     `bson:"created_at"`
+	}
+	if err := bson.Unmarshal(doc, &fields); err != nil {
+		return Change{}, fmt.Errorf("decoding %s document: %w", w.Collection, err)
+	}
+	at, ok := doc.Lookup(w.PollField).TimeOK()
+	if !ok {
+		return Change{}, fmt.Errorf("%s document %s has no time in %s", w.Collection, fields.ID.Hex(), w.PollField)
+	}
+
+	operation := Update
+	if at.Equal(fields.CreatedAt) {
+		operation = Insert
+	}
+	return Change{
+		Operation:  operation,
+		Collection: w.Collection,
+		ID:         fields.ID,
+		Document:   doc,
+		At:         at,
+	}, nil
+}
+
+// handle hands a change to the watch's handler, logging a failure
+func (c *Consumer) handle(ctx context.Context, w Watch, change Change) {
+	result := "handled"
+	if err := c.call(ctx, w, change); err != nil {
+		result = "failed"
+		slog.ErrorContext(ctx, "change handler failed",
+			"watch", w.Name, "operation", change.Operation, "id", change.ID.Hex(), "error", err)
+	}
+	metrics.ChangesHandled.With(w.Name, result).Inc()
+}
+
+// call runs the handler, turning a panic into an error
+func (c *Consumer) call(ctx context.Context, w Watch, change Change) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return w.Handle(ctx, change)
+}
@@ -0,0 +1,161 @@
+package changes
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/repository/repositorytest"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type article struct {
+	ID        bson.ObjectID `bson:"_id"`
+	Title     string        `bson:"title"`
+	CreatedAt time.Time     `bson:"created_at"`
+	UpdatedAt time.Time     `bson:"updated_at"`
+}
+
+func TestFromEvent(t *testing.T) {
+	id := bson.NewObjectID()
+	raw, err := bson.Marshal(bson.M{
+		"_id":           bson.M{"_data": "8263A1"},
+		"operationType": Update,
+		"clusterTime":   bson.Timestamp{T: 1767225600, I: 3},
+		"documentKey":   bson.M{"_id": id},
+		"fullDocument":  article{ID: id, Title: "Markets rally"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	change, err := fromEvent(raw, "news")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change.Operation != Update || change.Collection != "news" || change.ID != id ||
+		!change.At.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("fromEvent = %+v", change)
+	}
+	var got article
+	if err := change.Decode(&got); err != nil || got.Title != "Markets rally" {
+		t.Errorf("Decode = %+v, %v", got, err)
+	}
+}
+
+func TestFromDocument(t *testing.T) {
+	w := Watch{Name: "news_cache", Collection: "news", PollField: "updated_at"}
+	created := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	cases := []struct {
+		updated time.Time
+		want    string
+	}{
+		{created, Insert},
+		{created.Add(time.Minute), Update},
+	}
+	for _, c := range cases {
+		doc, err := bson.Marshal(article{ID: bson.NewObjectID(), CreatedAt: created, UpdatedAt: c.updated})
+		if err != nil {
+			t.Fatal(err)
+		}
+		change, err := fromDocument(doc, w)
+		if err != nil || change.Operation != c.want || !change.At.Equal(c.updated) {
+			t.Errorf("fromDocument updated at %v = %+v, %v; want %s", c.updated, change, err, c.want)
+		}
+	}
+
+	doc, _ := bson.Marshal(bson.M{"_id": bson.NewObjectID()})
+	if _, err := fromDocument(doc, w); err == nil {
+		t.Error("fromDocument without the poll field succeeded")
+	}
+}
+
+// TestConsumerFollowsMongo runs against whatever MONGO_TEST_URI is: a
+// replica set streams the changes, a standalone server has them polled
+func TestConsumerFollowsMongo(t *testing.T) {
+	db := repositorytest.Database(t)
+	positions := NewMemoryPositions()
+	coll := db.Collection("articles")
+
+	var (
+		mu      sync.Mutex
+		changes []Change
+	)
+	received := func(op string, id bson.ObjectID) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, c := range changes {
+			if c.Operation == op && c.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+	// start runs a consumer until the returned stop is called, once it has
+	// saved where it starts from
+	start := func() (stop func()) {
+		consumer := NewConsumer(db, positions, config.Changes{
+			Instance:     "api-1",
+			PollInterval: 50 * time.Millisecond,
+			RetryBase:    50 * time.Millisecond,
+			RetryMax:     time.Second,
+		})
+		consumer.Watch(Watch{
+			Name:       "articles",
+			Collection: "articles",
+			PollField:  "updated_at",
+			Handle: func(ctx context.Context, change Change) error {
+				mu.Lock()
+				defer mu.Unlock()
+				changes = append(changes, change)
+				return nil
+			},
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			consumer.Run(ctx)
+			close(done)
+		}()
+		eventually(t, "the consumer to save its position", func() bool {
+			p, err := positions.Load(context.Background(), "api-1", "articles")
+			return err == nil && p != nil
+		})
+		return func() {
+			cancel()
+			<-done
+		}
+	}
+	ctx := context.Background()
+
+	stop := start()
+	now := time.Now().Truncate(time.Millisecond)
+	a := article{ID: bson.NewObjectID(), Title: "Markets rally", CreatedAt: now, UpdatedAt: now}
+	if _, err := coll.InsertOne(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the insert", func() bool { return received(Insert, a.ID) })
+	stop()
+
+	// changed while no consumer ran: the next one resumes from the saved
+	// position and sees it
+	if _, err := coll.UpdateOne(ctx, bson.M{"_id": a.ID}, bson.M{"$set": bson.M{"title": "Markets slide", "updated_at": time.Now()}}); err != nil {
+		t.Fatal(err)
+	}
+	stop = start()
+	defer stop()
+	eventually(t, "the update made while stopped", func() bool { return received(Update, a.ID) })
+}
+
+func eventually(t *testing.T, what string, ok func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !ok() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,102 @@
+package changes
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yoharsh14/krant-backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Position is how far a watch of an instance got: the resume token of its
+// change stream, or the last document it polled
+type Position struct {
+	ID        string        `bson:"_id"` // set by Save
+	Instance  string        `bson:"instance"`
+	Watch     string        `bson:"watch"`
+	Token     bson.Raw      `bson:"token,omitempty"`
+	PolledAt  time.Time     `bson:"polled_at,omitempty"`
+	PolledID  bson.ObjectID `bson:"polled_id,omitempty"`
+	UpdatedAt time.Time     `bson:"updated_at"`
+}
+
+// Positions stores the position of each watch per instance, as every
+// instance follows the watches on its own. NewPositions is backed by MongoDB
+// and NewMemoryPositions keeps everything in memory; both behave the same.
+// Load returns nil, nil for a watch that has none yet.
+type Positions interface {
+	Load(ctx context.Context, instance, watch string) (*Position, error)
+	Save(ctx context.Context, position *Position) error
+}
+
+// positionID is the key of the position of a watch of an instance
+func positionID(instance, watch string) string {
+	return instance + "/" + watch
+}
+
+type mongoPositions struct {
+	coll *mongo.Collection
+}
+
+func NewPositions(db *mongo.Database) Positions {
+	return &mongoPositions{
+		coll: db.Collection("change_positions"),
+	}
+}
+
+// Load retrieves the position of a watch of an instance
+func (p *mongoPositions) Load(ctx context.Context, instance, watch string) (*Position, error) {
+	var position Position
+	err := p.coll.FindOne(ctx, bson.M{"_id": positionID(instance, watch)}).Decode(&position)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // not started yet
+		}
+		return nil, err
+	}
+	return &position, nil
+}
+
+// Save replaces the position of its watch and instance
+func (p *mongoPositions) Save(ctx context.Context, position *Position) error {
+	position.ID = positionID(position.Instance, position.Watch)
+	position.UpdatedAt = time.Now()
+	_, err := p.coll.ReplaceOne(ctx, bson.M{"_id": position.ID}, position, options.Replace().SetUpsert(true))
+	return err
+}
+
+// memoryPositions is the in-memory Positions, meant for tests
+type memoryPositions struct {
+	coll *repository.Memory
+}
+
+// NewMemoryPositions returns an empty in-memory Positions
+func NewMemoryPositions() Positions {
+	return &memoryPositions{
+		coll: repository.NewMemory(),
+	}
+}
+
+func (p *memoryPositions) Load(ctx context.Context, instance, watch string) (*Position, error) {
+	var position Position
+	err := p.coll.FindOne(ctx, bson.M{"_id": positionID(instance, watch)}, &position)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // not started yet
+		}
+		return nil, err
+	}
+	return &position, nil
+}
+
+func (p *memoryPositions) Save(ctx context.Context, position *Position) error {
+	position.ID = positionID(position.Instance, position.Watch)
+	position.UpdatedAt = time.Now()
+	if _, err := p.coll.DeleteOne(ctx, bson.M{"_id": position.ID}); err != nil {
+		return err
+	}
+	return p.coll.InsertOne(ctx, position)
+}
@@ -0,0 +1,65 @@
+package changes
+
+import (
+	"context"
+	"testing"
+	"time"
+	"yoharsh14/krant-backend/internal/repository/repositorytest"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestMemoryPositions(t *testing.T) {
+	testPositions(t, func(t *testing.T) Positions { return NewMemoryPositions() })
+}
+
+func TestMongoPositions(t *testing.T) {
+	testPositions(t, func(t *testing.T) Positions { return NewPositions(repositorytest.Database(t)) })
+}
+
+// testPositions is the contract every Positions implementation must meet
+func testPositions(t *testing.T, newPositions func(t *testing.T) Positions) {
+	ctx := context.Background()
+	p := newPositions(t)
+
+	if got, err := p.Load(ctx, "api-1", "news_cache"); got != nil || err != nil {
+		t.Fatalf("Load before any Save = %+v, %v; want nil, nil", got, err)
+	}
+
+	token, err := bson.Marshal(bson.M{"_data": "8263A1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Save(ctx, &Position{Instance: "api-1", Watch: "news_cache", Token: token}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.Load(ctx, "api-1", "news_cache")
+	if err != nil || got == nil || string(got.Token) != string(token) || !got.PolledAt.IsZero() {
+		t.Fatalf("Load = %+v, %v; want the saved token", got, err)
+	}
+
+	polledAt := time.Now().Truncate(time.Millisecond)
+	polledID := bson.NewObjectID()
+	if err := p.Save(ctx, &Position{Instance: "api-1", Watch: "news_cache", PolledAt: polledAt, PolledID: polledID}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = p.Load(ctx, "api-1", "news_cache")
+	if err != nil || got == nil || got.Token != nil || !got.PolledAt.Equal(polledAt) || got.PolledID != polledID {
+		t.Errorf("Load after saving a poll position = %+v, %v; want it replacing the token", got, err)
+	}
+	if other, err := p.Load(ctx, "api-1", "notifications_push"); other != nil || err != nil {
+		t.Errorf("Load of another watch = %+v, %v; want nil, nil", other, err)
+	}
+
+	// instances follow the same watch on their own
+	if other, err := p.Load(ctx, "api-2", "news_cache"); other != nil || err != nil {
+		t.Errorf("Load of another instance's watch = %+v, %v; want nil, nil", other, err)
+	}
+	if err := p.Save(ctx, &Position{Instance: "api-2", Watch: "news_cache", Token: token}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = p.Load(ctx, "api-1", "news_cache")
+	if err != nil || got == nil || got.Token != nil || !got.PolledAt.Equal(polledAt) {
+		t.Errorf("Load after another instance saved = %+v, %v; want the poll position kept", got, err)
+	}
+}
@@ -0,0 +1,80 @@
+// Package clientip finds the address of the client behind the proxies a
+// request came through.
+//
+// Proxy headers are only believed when the request came from a trusted
+// proxy; anyone else could set them to whatever address they like, e.g. to
+// get a fresh rate limit bucket with every request.
+package clientip
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Middleware sets r.RemoteAddr to the client's bare address. A request from
+// one of the trusted proxies is taken to be from the last address in
+// X-Forwarded-For that is not a trusted proxy, else from X-Real-IP; any
+// other request keeps its peer's address.
+func Middleware(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if peer, ok := parse(r.RemoteAddr); ok {
+				addr := peer
+				if isTrusted(trusted, peer) {
+					addr = forwarded(r.Header, trusted, peer)
+				}
+				r.RemoteAddr = addr.String()
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwarded reads the client's address from the headers of a request a
+// trusted proxy sent, walking X-Forwarded-For back from the proxy nearest
+// to us. It returns peer when the headers hold no usable address.
+func forwarded(h http.Header, trusted []netip.Prefix, peer netip.Addr) netip.Addr {
+	hops := strings.Split(strings.Join(h.Values("X-Forwarded-For"), ","), ",")
+	client, found := peer, false
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parse(hops[i])
+		if !ok {
+			break // a hop we cannot read is no better than a forged one
+		}
+		client, found = addr, true
+		if !isTrusted(trusted, addr) {
+			return addr
+		}
+	}
+	if found {
+		return client // every hop is a trusted proxy: the first one it is
+	}
+	if addr, ok := parse(h.Get("X-Real-IP")); ok {
+		return addr
+	}
+	return peer
+}
+
+// parse reads an address with or without a port
+func parse(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+func isTrusted(trusted []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package clientip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.1/32")}
+	cases := []struct {
+		name      string
+		peer      string
+		forwarded []string
+		realIP    string
+		want      string
+	}{
+		{name: "direct", peer: "203.0.113.7:51000", want: "203.0.113.7"},
+		{name: "headers from an untrusted peer", peer: "203.0.113.7:51000", forwarded: []string{"198.51.100.1"}, realIP: "198.51.100.2", want: "203.0.113.7"},
+		{name: "through a trusted proxy", peer: "10.0.0.5:443", forwarded: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "forged first hop", peer: "10.0.0.5:443", forwarded: []string{"1.2.3.4, 198.51.100.1"}, want: "198.51.100.1"},
+		{name: "through a chain of proxies", peer: "10.0.0.5:443", forwarded: []string{"198.51.100.1, 192.0.2.1", "10.1.1.1"}, want: "198.51.100.1"},
+		{name: "only proxies", peer: "10.0.0.5:443", forwarded: []string{"10.2.2.2, 10.1.1.1"}, want: "10.2.2.2"},
+		{name: "garbage hop", peer: "10.0.0.5:443", forwarded: []string{"198.51.100.1, unknown"}, want: "10.0.0.5"},
+		{name: "real ip", peer: "10.0.0.5:443", realIP: "198.51.100.2", want: "198.51.100.2"},
+		{name: "ipv6", peer: "[2001:db8::1]:443", want: "2001:db8::1"},
+		{name: "ipv4 mapped peer", peer: "[::ffff:10.0.0.5]:443", forwarded: []string{"198.51.100.1"}, want: "198.51.100.1"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got string
+			h := Middleware(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = c.peer
+			for _, v := range c.forwarded {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if c.realIP != "" {
+				r.Header.Set("X-Real-IP", c.realIP)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			if got != c.want {
+				t.Errorf("RemoteAddr = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
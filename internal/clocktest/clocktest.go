@@ -0,0 +1,39 @@
+// Package clocktest holds a settable clock for tests of code that reads the
+// time through a now func() time.Time field.
+package clocktest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a time that only moves when it is told to
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// New returns a clock set to the current time, truncated to the millisecond
+// MongoDB stores
+func New() *Clock {
+	return &Clock{now: time.Now().Truncate(time.Millisecond)}
+}
+
+// At returns a clock set to t, for tests that need a known time
+func At(t time.Time) *Clock {
+	return &Clock{now: t}
+}
+
+// Now returns the clock's time
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
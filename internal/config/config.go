@@ -0,0 +1,524 @@
+// Package config holds the typed application configuration.
+//
+// Settings are layered, later layers winning:
+//
+//  1. the defaults below
+//  2. an optional JSON config file (CONFIG_FILE) keyed by the variable names
+//  3. a .env file (ENV_FILE, ".env" by default)
+//  4. the process environment
+//
+// Every setting has exactly one variable name, listed in bindings, which is
+// used by all layers alike.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Environments the server can run in
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+	EnvTest        = "test"
+)
+
+// Config is the complete application configuration
+type Config struct {
+	Env         string
+	Server      Server
+	Database    Database
+	Media       Media
+	Auth        Auth
+	RateLimit   RateLimit
+	Idempotency Idempotency
+	Cache       Cache
+	Log         Log
+	Tracing     Tracing
+	Health      Health
+	Workers     Workers
+	Webhooks    Webhooks
+	Events      Events
+	Jobs        Jobs
+	Changes     Changes
+	Features    Features
+}
+
+// Server configures the HTTP server
+type Server struct {
+	Addr            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	RequestTimeout  time.Duration // deadline put on each request's context
+	ShutdownTimeout time.Duration // how long in-flight requests may drain
+	// DrainDelay is how long the server keeps serving once /readyz reports
+	// shutting down, so load balancers stop routing to it first
+	DrainDelay time.Duration
+	// TrustedProxies are the peers whose X-Forwarded-For and X-Real-IP
+	// headers are believed; requests from anyone else are from their peer
+	TrustedProxies []netip.Prefix
+}
+
+// Database configures the MongoDB connection
+type Database struct {
+	URI            string // secret: may carry credentials
+	Name           string
+	ConnectTimeout time.Duration
+	MigrateOnStart bool // apply pending migrations before serving
+}
+
+// Media configures image storage
+type Media struct {
+	Dir string // where images are stored on disk
+	// BaseURL is the public URL prefix the files are served under. A path is
+	// served by the API itself; a full URL means another server, e.g. a CDN
+	// in front of the media directory, serves them.
+	BaseURL      string
+	FetchTimeout time.Duration
+}
+
+// MountPath returns the path the API serves the files under, and false when
+// BaseURL points at another server
+func (m Media) MountPath() (string, bool) {
+	if !strings.HasPrefix(m.BaseURL, "/") {
+		return "", false
+	}
+	return strings.TrimRight(m.BaseURL, "/"), true
+}
+
+// Auth configures access to protected routes
+type Auth struct {
+	AdminToken  string // secret: bearer token for /admin, empty disables it
+	IngestToken string // secret: bearer token for writing articles and uploading images, besides the admin token
+}
+
+// RateLimit configures per client request rate limiting
+type RateLimit struct {
+	Enabled bool
+	Default Rate // every route
+	Auth    Rate // routes checking credentials or creating accounts
+	Ingest  Rate // article ingestion and image uploads, per credential
+}
+
+// Rate allows Limit requests per Period, in bursts of up to Limit
+type Rate struct {
+	Limit  int
+	Period time.Duration
+}
+
+func (r Rate) String() string {
+	return fmt.Sprintf("%d/%s", r.Limit, r.Period)
+}
+
+// Idempotency configures the replay of retried requests that carry an
+// Idempotency-Key header
+type Idempotency struct {
+	Store string        // mongo, shared by every instance, or memory
+	TTL   time.Duration // how long a key and its response are kept
+}
+
+// Idempotency key stores
+const (
+	IdempotencyStoreMongo  = "mongo"
+	IdempotencyStoreMemory = "memory"
+)
+
+// Cache configures the in-process read caches
+type Cache struct {
+	Size int           // entries per cache, 0 disables caching
+	TTL  time.Duration // how long an entry may be served
+}
+
+// Log configures the application logger
+type Log struct {
+	Level    string            // debug, info, warn, error
+	Format   string            // text, json
+	Packages map[string]string // level by package name, overriding Level
+}
+
+// Tracing configures OpenTelemetry tracing
+type Tracing struct {
+	Exporter    string  // otlp, stdout or none
+	Endpoint    string  // OTLP/HTTP traces URL of the collector
+	ServiceName string  // service.name resource attribute
+	SampleRatio float64 // share of new traces recorded, 0 to 1
+}
+
+// Tracing exporters
+const (
+	TraceExporterOTLP   = "otlp"
+	TraceExporterStdout = "stdout"
+	TraceExporterNone   = "none"
+)
+
+// Health configures the readiness probe
+type Health struct {
+	CheckTimeout    time.Duration // budget for all readiness checks together
+	MaxIngestionLag time.Duration // newest article older than this fails readiness, 0 only reports it
+}
+
+// Workers configures background processing
+type Workers struct {
+	Enabled      bool
+	Concurrency  int
+	PollInterval time.Duration
+}
+
+// Webhooks configures the delivery of events to the registered webhooks
+type Webhooks struct {
+	Timeout     time.Duration // per attempt, for the whole response
+	MaxAttempts int           // a delivery failing this often is dead-lettered
+	RetryBase   time.Duration // wait after the first failure, doubling after each
+	RetryMax    time.Duration // longest wait between attempts
+}
+
+// Events configures the relay of domain events from the outbox to their
+// subscribers
+type Events struct {
+	Lease       time.Duration // how long a claimed event is left to one relay
+	MaxAttempts int           // an event a subscriber still fails after this often is given up
+	RetryBase   time.Duration // wait after the first failure, doubling after each
+	RetryMax    time.Duration // longest wait between attempts
+}
+
+// Jobs configures the background job queues
+type Jobs struct {
+	VisibilityTimeout time.Duration  // how long a run may take before its job is claimed again
+	MaxAttempts       int            // a job failing this often is dead-lettered, unless it sets its own
+	RetryBase         time.Duration  // wait after the first failure, doubling after each
+	RetryMax          time.Duration  // longest wait between attempts
+	Concurrency       map[string]int // workers per queue, WORKER_CONCURRENCY for the others
+}
+
+// Changes configures the consumer of database changes, e.g. invalidating
+// the caches of other instances' writes
+type Changes struct {
+	Enabled bool
+	// Instance tells this instance's saved positions from the others', so it
+	// must stay the same across restarts. Empty means the host name.
+	Instance     string
+	PollInterval time.Duration // idle wait between polls, and for a stream's next change
+	RetryBase    time.Duration // wait after a stream or poll failed, doubling after each
+	RetryMax     time.Duration // longest wait between attempts
+}
+
+// Features toggles optional behaviour
+type Features struct {
+	SourceAutoRegister bool // register unknown article sources by domain on ingestion
+	RemoteImages       bool // fetch images referenced by URL
+}
+
+// Default returns the configuration used when nothing is overridden. It is
+// meant for local development and holds no credentials.
+func Default() Config {
+	return Config{
+		Env: EnvDevelopment,
+		Server: Server{
+			Addr:            ":8082",
+			ReadTimeout:     20 * time.Second,
+			WriteTimeout:    40 * time.Second,
+			IdleTimeout:     2 * time.Minute,
+			RequestTimeout:  60 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+			DrainDelay:      5 * time.Second,
+		},
+		Database: Database{
+			URI:            "mongodb://localhost:27017",
+			Name:           "krant",
+			ConnectTimeout: 10 * time.Second,
+			MigrateOnStart: true,
+		},
+		Media: Media{
+			Dir:          "data/media",
+			BaseURL:      "/media/files",
+			FetchTimeout: 15 * time.Second,
+		},
+		RateLimit: RateLimit{
+			Enabled: true,
+			Default: Rate{Limit: 300, Period: time.Minute},
+			Auth:    Rate{Limit: 30, Period: time.Minute},
+			Ingest:  Rate{Limit: 60, Period: time.Minute},
+		},
+		Idempotency: Idempotency{
+			Store: IdempotencyStoreMongo,
+			TTL:   24 * time.Hour,
+		},
+		Cache: Cache{
+			Size: 1000,
+			TTL:  time.Minute,
+		},
+		Log: Log{
+			Level:  "info",
+			Format: "text",
+		},
+		Tracing: Tracing{
+			Exporter:    TraceExporterNone,
+			Endpoint:    "http://localhost:4318/v1/traces",
+			ServiceName: "krant-backend",
+			SampleRatio: 1,
+		},
+		Health: Health{
+			CheckTimeout: 2 * time.Second,
+		},
+		Workers: Workers{
+			Enabled:      true,
+			Concurrency:  4,
+			PollInterval: 5 * time.Second,
+		},
+		Webhooks: Webhooks{
+			Timeout:     10 * time.Second,
+			MaxAttempts: 10,
+			RetryBase:   30 * time.Second,
+			RetryMax:    6 * time.Hour,
+		},
+		Events: Events{
+			Lease:       time.Minute,
+			MaxAttempts: 10,
+			RetryBase:   5 * time.Second,
+			RetryMax:    10 * time.Minute,
+		},
+		Jobs: Jobs{
+			VisibilityTimeout: 5 * time.Minute,
+			MaxAttempts:       5,
+			RetryBase:         30 * time.Second,
+			RetryMax:          time.Hour,
+		},
+		Changes: Changes{
+			Enabled:      true,
+			PollInterval: time.Second,
+			RetryBase:    time.Second,
+			RetryMax:     time.Minute,
+		},
+		Features: Features{
+			SourceAutoRegister: true,
+			RemoteImages:       true,
+		},
+	}
+}
+
+// Validate checks every setting and reports all problems at once
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Env == EnvDevelopment || c.Env == EnvProduction || c.Env == EnvTest,
+		"APP_ENV must be one of development, production, test, got %q", c.Env)
+
+	check(c.Server.Addr != "", "HTTP_ADDR must not be empty")
+	check(c.Server.ReadTimeout > 0, "HTTP_READ_TIMEOUT must be positive")
+	check(c.Server.WriteTimeout > 0, "HTTP_WRITE_TIMEOUT must be positive")
+	check(c.Server.IdleTimeout > 0, "HTTP_IDLE_TIMEOUT must be positive")
+	check(c.Server.RequestTimeout > 0, "HTTP_REQUEST_TIMEOUT must be positive")
+	check(c.Server.ShutdownTimeout > 0, "HTTP_SHUTDOWN_TIMEOUT must be positive")
+	check(c.Server.DrainDelay >= 0 && c.Server.DrainDelay < c.Server.ShutdownTimeout,
+		"HTTP_DRAIN_DELAY must not be negative and must be below HTTP_SHUTDOWN_TIMEOUT")
+
+	u, err := url.Parse(c.Database.URI)
+	check(err == nil && (u.Scheme == "mongodb" || u.Scheme == "mongodb+srv") && u.Host != "",
+		"MONGO_URI must be a mongodb:// or mongodb+srv:// URI")
+	check(c.Database.Name != "" && !strings.ContainsAny(c.Database.Name, `/\. "$`),
+		"MONGO_DATABASE must be a valid database name, got %q", c.Database.Name)
+	check(c.Database.ConnectTimeout > 0, "MONGO_CONNECT_TIMEOUT must be positive")
+
+	check(c.Media.Dir != "", "MEDIA_DIR must not be empty")
+	check(strings.HasPrefix(c.Media.BaseURL, "/") || strings.HasPrefix(c.Media.BaseURL, "http://") ||
+		strings.HasPrefix(c.Media.BaseURL, "https://"), "MEDIA_BASE_URL must be a path or an http(s) URL")
+	check(c.Media.FetchTimeout > 0, "MEDIA_FETCH_TIMEOUT must be positive")
+
+	check(c.Env != EnvProduction || c.Auth.AdminToken == "" || len(c.Auth.AdminToken) >= 16,
+		"ADMIN_TOKEN must be at least 16 characters in production")
+	check(c.Env != EnvProduction || c.Auth.IngestToken == "" || len(c.Auth.IngestToken) >= 16,
+		"INGEST_TOKEN must be at least 16 characters in production")
+
+	check(c.RateLimit.Default.Limit > 0 && c.RateLimit.Default.Period > 0, "RATE_LIMIT_DEFAULT must be positive")
+	check(c.RateLimit.Auth.Limit > 0 && c.RateLimit.Auth.Period > 0, "RATE_LIMIT_AUTH must be positive")
+	check(c.RateLimit.Ingest.Limit > 0 && c.RateLimit.Ingest.Period > 0, "RATE_LIMIT_INGEST must be positive")
+
+	check(c.Idempotency.Store == IdempotencyStoreMongo || c.Idempotency.Store == IdempotencyStoreMemory,
+		"IDEMPOTENCY_STORE must be mongo or memory, got %q", c.Idempotency.Store)
+	check(c.Idempotency.TTL > 0, "IDEMPOTENCY_TTL must be positive")
+
+	check(c.Cache.Size >= 0, "CACHE_SIZE must not be negative")
+	check(c.Cache.TTL >= 0, "CACHE_TTL must not be negative")
+
+	check(validLevel(c.Log.Level), "LOG_LEVEL must be one of debug, info, warn, error, got %q", c.Log.Level)
+	for _, pkg := range slices.Sorted(maps.Keys(c.Log.Packages)) {
+		level := c.Log.Packages[pkg]
+		check(pkg != "" && validLevel(level),
+			"LOG_PACKAGE_LEVELS must map package names to debug, info, warn or error, got %s=%s", pkg, level)
+	}
+	check(c.Log.Format == "text" || c.Log.Format == "json", "LOG_FORMAT must be text or json, got %q", c.Log.Format)
+
+	check(c.Tracing.Exporter == TraceExporterOTLP || c.Tracing.Exporter == TraceExporterStdout ||
+		c.Tracing.Exporter == TraceExporterNone,
+		"TRACING_EXPORTER must be one of otlp, stdout, none, got %q", c.Tracing.Exporter)
+	u, err = url.Parse(c.Tracing.Endpoint)
+	check(c.Tracing.Exporter != TraceExporterOTLP || err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+		"TRACING_OTLP_ENDPOINT must be an http(s) URL")
+	check(c.Tracing.ServiceName != "", "TRACING_SERVICE_NAME must not be empty")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO must be between 0 and 1")
+
+	check(c.Health.CheckTimeout > 0, "HEALTH_CHECK_TIMEOUT must be positive")
+	check(c.Health.MaxIngestionLag >= 0, "HEALTH_MAX_INGESTION_LAG must not be negative")
+
+	check(c.Workers.Concurrency > 0, "WORKER_CONCURRENCY must be positive")
+	check(c.Workers.PollInterval > 0, "WORKER_POLL_INTERVAL must be positive")
+
+	check(c.Webhooks.Timeout > 0, "WEBHOOK_TIMEOUT must be positive")
+	check(c.Webhooks.MaxAttempts > 0, "WEBHOOK_MAX_ATTEMPTS must be positive")
+	check(c.Webhooks.RetryBase > 0, "WEBHOOK_RETRY_BASE must be positive")
+	check(c.Webhooks.RetryMax >= c.Webhooks.RetryBase, "WEBHOOK_RETRY_MAX must not be below WEBHOOK_RETRY_BASE")
+
+	check(c.Events.Lease > 0, "EVENT_LEASE must be positive")
+	check(c.Events.MaxAttempts > 0, "EVENT_MAX_ATTEMPTS must be positive")
+	check(c.Events.RetryBase > 0, "EVENT_RETRY_BASE must be positive")
+	check(c.Events.RetryMax >= c.Events.RetryBase, "EVENT_RETRY_MAX must not be below EVENT_RETRY_BASE")
+
+	check(c.Jobs.VisibilityTimeout > 0, "JOB_VISIBILITY_TIMEOUT must be positive")
+	check(c.Jobs.MaxAttempts > 0, "JOB_MAX_ATTEMPTS must be positive")
+	check(c.Jobs.RetryBase > 0, "JOB_RETRY_BASE must be positive")
+	check(c.Jobs.RetryMax >= c.Jobs.RetryBase, "JOB_RETRY_MAX must not be below JOB_RETRY_BASE")
+	for _, queue := range slices.Sorted(maps.Keys(c.Jobs.Concurrency)) {
+		n := c.Jobs.Concurrency[queue]
+		check(queue != "" && n > 0, "JOB_QUEUE_CONCURRENCY must map queue names to positive numbers, got %s=%d", queue, n)
+	}
+
+	check(c.Changes.PollInterval > 0, "CHANGE_POLL_INTERVAL must be positive")
+	check(c.Changes.RetryBase > 0, "CHANGE_RETRY_BASE must be positive")
+	check(c.Changes.RetryMax >= c.Changes.RetryBase, "CHANGE_RETRY_MAX must not be below CHANGE_RETRY_BASE")
+
+	return errors.Join(errs...)
+}
+
+// LogValue lets the configuration be logged with slog; secrets are redacted
+func (c Config) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("env", c.Env),
+		slog.Group("server",
+			slog.String("addr", c.Server.Addr),
+			slog.Duration("read_timeout", c.Server.ReadTimeout),
+			slog.Duration("write_timeout", c.Server.WriteTimeout),
+			slog.Duration("idle_timeout", c.Server.IdleTimeout),
+			slog.Duration("request_timeout", c.Server.RequestTimeout),
+			slog.Duration("shutdown_timeout", c.Server.ShutdownTimeout),
+			slog.Duration("drain_delay", c.Server.DrainDelay),
+			slog.Any("trusted_proxies", c.Server.TrustedProxies),
+		),
+		slog.Group("database",
+			slog.String("uri", RedactURI(c.Database.URI)),
+			slog.String("name", c.Database.Name),
+			slog.Duration("connect_timeout", c.Database.ConnectTimeout),
+			slog.Bool("migrate_on_start", c.Database.MigrateOnStart),
+		),
+		slog.Group("media",
+			slog.String("dir", c.Media.Dir),
+			slog.String("base_url", c.Media.BaseURL),
+			slog.Duration("fetch_timeout", c.Media.FetchTimeout),
+		),
+		slog.Group("auth",
+			slog.String("admin_token", redact(c.Auth.AdminToken)),
+			slog.String("ingest_token", redact(c.Auth.IngestToken)),
+		),
+		slog.Group("rate_limit",
+			slog.Bool("enabled", c.RateLimit.Enabled),
+			slog.String("default", c.RateLimit.Default.String()),
+			slog.String("auth", c.RateLimit.Auth.String()),
+			slog.String("ingest", c.RateLimit.Ingest.String()),
+		),
+		slog.Group("idempotency",
+			slog.String("store", c.Idempotency.Store),
+			slog.Duration("ttl", c.Idempotency.TTL),
+		),
+		slog.Group("cache",
+			slog.Int("size", c.Cache.Size),
+			slog.Duration("ttl", c.Cache.TTL),
+		),
+		slog.Group("log",
+			slog.String("level", c.Log.Level),
+			slog.String("format", c.Log.Format),
+			slog.Any("packages", c.Log.Packages),
+		),
+		slog.Group("tracing",
+			slog.String("exporter", c.Tracing.Exporter),
+			slog.String("endpoint", RedactURI(c.Tracing.Endpoint)),
+			slog.String("service_name", c.Tracing.ServiceName),
+			slog.Float64("sample_ratio", c.Tracing.SampleRatio),
+		),
+		slog.Group("health",
+			slog.Duration("check_timeout", c.Health.CheckTimeout),
+			slog.Duration("max_ingestion_lag", c.Health.MaxIngestionLag),
+		),
+		slog.Group("workers",
+			slog.Bool("enabled", c.Workers.Enabled),
+			slog.Int("concurrency", c.Workers.Concurrency),
+			slog.Duration("poll_interval", c.Workers.PollInterval),
+		),
+		slog.Group("webhooks",
+			slog.Duration("timeout", c.Webhooks.Timeout),
+			slog.Int("max_attempts", c.Webhooks.MaxAttempts),
+			slog.Duration("retry_base", c.Webhooks.RetryBase),
+			slog.Duration("retry_max", c.Webhooks.RetryMax),
+		),
+		slog.Group("events",
+			slog.Duration("lease", c.Events.Lease),
+			slog.Int("max_attempts", c.Events.MaxAttempts),
+			slog.Duration("retry_base", c.Events.RetryBase),
+			slog.Duration("retry_max", c.Events.RetryMax),
+		),
+		slog.Group("jobs",
+			slog.Duration("visibility_timeout", c.Jobs.VisibilityTimeout),
+			slog.Int("max_attempts", c.Jobs.MaxAttempts),
+			slog.Duration("retry_base", c.Jobs.RetryBase),
+			slog.Duration("retry_max", c.Jobs.RetryMax),
+			slog.Any("concurrency", c.Jobs.Concurrency),
+		),
+		slog.Group("changes",
+			slog.Bool("enabled", c.Changes.Enabled),
+			slog.String("instance", c.Changes.Instance),
+			slog.Duration("poll_interval", c.Changes.PollInterval),
+			slog.Duration("retry_base", c.Changes.RetryBase),
+			slog.Duration("retry_max", c.Changes.RetryMax),
+		),
+		slog.Group("features",
+			slog.Bool("source_auto_register", c.Features.SourceAutoRegister),
+			slog.Bool("remote_images", c.Features.RemoteImages),
+		),
+	)
+}
+
+func validLevel(level string) bool {
+	return level == "debug" || level == "info" || level == "warn" || level == "error"
+}
+
+const redacted = "[REDACTED]"
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
+
+// RedactURI hides the password of a connection URI
+func RedactURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return redacted
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	return u.String()
+}
@@ -0,0 +1,365 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Variables naming where the other layers are read from
+const (
+	FileVar    = "CONFIG_FILE" // optional JSON config file
+	EnvFileVar = "ENV_FILE"    // .env file, ".env" when unset
+)
+
+const defaultEnvFile = ".env"
+
+// binding ties a variable name to the setting it fills in
+type binding struct {
+	key string
+	set func(string) error
+}
+
+func (c *Config) bindings() []binding {
+	return []binding{
+		{"APP_ENV", setString(&c.Env)},
+
+		{"HTTP_ADDR", setString(&c.Server.Addr)},
+		{"HTTP_READ_TIMEOUT", setDuration(&c.Server.ReadTimeout)},
+		{"HTTP_WRITE_TIMEOUT", setDuration(&c.Server.WriteTimeout)},
+		{"HTTP_IDLE_TIMEOUT", setDuration(&c.Server.IdleTimeout)},
+		{"HTTP_REQUEST_TIMEOUT", setDuration(&c.Server.RequestTimeout)},
+		{"HTTP_SHUTDOWN_TIMEOUT", setDuration(&c.Server.ShutdownTimeout)},
+		{"HTTP_DRAIN_DELAY", setDuration(&c.Server.DrainDelay)},
+		{"TRUSTED_PROXIES", setPrefixes(&c.Server.TrustedProxies)},
+
+		{"MONGO_URI", setString(&c.Database.URI)},
+		{"MONGO_DATABASE", setString(&c.Database.Name)},
+		{"MONGO_CONNECT_TIMEOUT", setDuration(&c.Database.ConnectTimeout)},
+		{"MONGO_MIGRATE_ON_START", setBool(&c.Database.MigrateOnStart)},
+
+		{"MEDIA_DIR", setString(&c.Media.Dir)},
+		{"MEDIA_BASE_URL", setString(&c.Media.BaseURL)},
+		{"MEDIA_FETCH_TIMEOUT", setDuration(&c.Media.FetchTimeout)},
+
+		{"ADMIN_TOKEN", setString(&c.Auth.AdminToken)},
+		{"INGEST_TOKEN", setString(&c.Auth.IngestToken)},
+
+		{"RATE_LIMIT_ENABLED", setBool(&c.RateLimit.Enabled)},
+		{"RATE_LIMIT_DEFAULT", setRate(&c.RateLimit.Default)},
+		{"RATE_LIMIT_AUTH", setRate(&c.RateLimit.Auth)},
+		{"RATE_LIMIT_INGEST", setRate(&c.RateLimit.Ingest)},
+
+		{"IDEMPOTENCY_STORE", setString(&c.Idempotency.Store)},
+		{"IDEMPOTENCY_TTL", setDuration(&c.Idempotency.TTL)},
+
+		{"CACHE_SIZE", setInt(&c.Cache.Size)},
+		{"CACHE_TTL", setDuration(&c.Cache.TTL)},
+
+		{"LOG_LEVEL", setString(&c.Log.Level)},
+		{"LOG_FORMAT", setString(&c.Log.Format)},
+		{"LOG_PACKAGE_LEVELS", setStringMap(&c.Log.Packages)},
+
+		{"TRACING_EXPORTER", setString(&c.Tracing.Exporter)},
+		{"TRACING_OTLP_ENDPOINT", setString(&c.Tracing.Endpoint)},
+		{"TRACING_SERVICE_NAME", setString(&c.Tracing.ServiceName)},
+		{"TRACING_SAMPLE_RATIO", setFloat(&c.Tracing.SampleRatio)},
+
+		{"HEALTH_CHECK_TIMEOUT", setDuration(&c.Health.CheckTimeout)},
+		{"HEALTH_MAX_INGESTION_LAG", setDuration(&c.Health.MaxIngestionLag)},
+
+		{"WORKERS_ENABLED", setBool(&c.Workers.Enabled)},
+		{"WORKER_CONCURRENCY", setInt(&c.Workers.Concurrency)},
+		{"WORKER_POLL_INTERVAL", setDuration(&c.Workers.PollInterval)},
+
+		{"WEBHOOK_TIMEOUT", setDuration(&c.Webhooks.Timeout)},
+		{"WEBHOOK_MAX_ATTEMPTS", setInt(&c.Webhooks.MaxAttempts)},
+		{"WEBHOOK_RETRY_BASE", setDuration(&c.Webhooks.RetryBase)},
+		{"WEBHOOK_RETRY_MAX", setDuration(&c.Webhooks.RetryMax)},
+
+		{"EVENT_LEASE", setDuration(&c.Events.Lease)},
+		{"EVENT_MAX_ATTEMPTS", setInt(&c.Events.MaxAttempts)},
+		{"EVENT_RETRY_BASE", setDuration(&c.Events.RetryBase)},
+		{"EVENT_RETRY_MAX", setDuration(&c.Events.RetryMax)},
+		{"JOB_VISIBILITY_TIMEOUT", setDuration(&c.Jobs.VisibilityTimeout)},
+		{"JOB_MAX_ATTEMPTS", setInt(&c.Jobs.MaxAttempts)},
+		{"JOB_RETRY_BASE", setDuration(&c.Jobs.RetryBase)},
+		{"JOB_RETRY_MAX", setDuration(&c.Jobs.RetryMax)},
+		{"JOB_QUEUE_CONCURRENCY", setIntMap(&c.Jobs.Concurrency)},
+		{"CHANGES_ENABLED", setBool(&c.Changes.Enabled)},
+		{"INSTANCE_ID", setString(&c.Changes.Instance)},
+		{"CHANGE_POLL_INTERVAL", setDuration(&c.Changes.PollInterval)},
+		{"CHANGE_RETRY_BASE", setDuration(&c.Changes.RetryBase)},
+		{"CHANGE_RETRY_MAX", setDuration(&c.Changes.RetryMax)},
+
+		{"FEATURE_SOURCE_AUTO_REGISTER", setBool(&c.Features.SourceAutoRegister)},
+		{"FEATURE_REMOTE_IMAGES", setBool(&c.Features.RemoteImages)},
+	}
+}
+
+// aliases are the names older deployments used
+var aliases = map[string]string{
+	"MONGO_DRIVER": "MONGO_URI",
+}
+
+// Load builds the configuration from all layers and validates it
+func Load() (Config, error) {
+	return load(os.LookupEnv)
+}
+
+func load(lookupEnv func(string) (string, bool)) (Config, error) {
+	cfg := Default()
+	bindings := cfg.bindings()
+
+	if path, ok := lookupEnv(FileVar); ok && path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return Config{}, err
+		}
+		if err := apply(bindings, values, "config file "+path, true); err != nil {
+			return Config{}, err
+		}
+	}
+
+	envFile, explicit := lookupEnv(EnvFileVar)
+	if !explicit || envFile == "" {
+		envFile = defaultEnvFile
+	}
+	values, err := godotenv.Read(envFile)
+	switch {
+	case err == nil:
+		if err := apply(bindings, values, envFile, false); err != nil {
+			return Config{}, err
+		}
+	case errors.Is(err, fs.ErrNotExist) && !explicit:
+		// the default .env file is optional
+	default:
+		return Config{}, fmt.Errorf("reading %s: %w", envFile, err)
+	}
+
+	environ := map[string]string{}
+	for _, b := range bindings {
+		if v, ok := lookupEnv(b.key); ok {
+			environ[b.key] = v
+		}
+	}
+	for alias, key := range aliases {
+		if v, ok := lookupEnv(alias); ok && environ[key] == "" {
+			environ[key] = v
+		}
+	}
+	if err := apply(bindings, environ, "environment", false); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// readFile reads a JSON object of variable names to values
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, v := range raw {
+		switch v := v.(type) {
+		case string:
+			values[key] = v
+		case bool, float64:
+			values[key] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("config file %s: %s must be a string, number or boolean", path, key)
+		}
+	}
+	return values, nil
+}
+
+// apply sets the bound settings found in values. In strict mode unknown keys
+// are errors, so typos in the config file are caught instead of ignored; .env
+// files and the environment may carry unrelated variables.
+func apply(bindings []binding, values map[string]string, origin string, strict bool) error {
+	known := make(map[string]binding, len(bindings))
+	for _, b := range bindings {
+		known[b.key] = b
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		name := key
+		if alias, ok := aliases[key]; ok {
+			name = alias
+		}
+		b, ok := known[name]
+		if !ok {
+			if strict {
+				errs = append(errs, fmt.Errorf("%s: unknown setting %s", origin, key))
+			}
+			continue
+		}
+		if err := b.set(values[key]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", origin, key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func setString(p *string) func(string) error {
+	return func(v string) error {
+		*p = strings.TrimSpace(v)
+		return nil
+	}
+}
+
+func setDuration(p *time.Duration) func(string) error {
+	return func(v string) error {
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", v)
+		}
+		*p = d
+		return nil
+	}
+}
+
+func setInt(p *int) func(string) error {
+	return func(v string) error {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("invalid number %q", v)
+		}
+		*p = n
+		return nil
+	}
+}
+
+func setFloat(p *float64) func(string) error {
+	return func(v string) error {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", v)
+		}
+		*p = f
+		return nil
+	}
+}
+
+// setRate reads a rate as requests/period, e.g. "60/1m"
+func setRate(p *Rate) func(string) error {
+	return func(v string) error {
+		limit, period, ok := strings.Cut(strings.TrimSpace(v), "/")
+		if !ok {
+			return fmt.Errorf("invalid rate %q, want requests/period such as 60/1m", v)
+		}
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return fmt.Errorf("invalid rate %q, want requests/period such as 60/1m", v)
+		}
+		d, err := time.ParseDuration(period)
+		if err != nil {
+			return fmt.Errorf("invalid rate %q, want requests/period such as 60/1m", v)
+		}
+		*p = Rate{Limit: n, Period: d}
+		return nil
+	}
+}
+
+// setStringMap reads a comma separated list of key=value pairs, e.g.
+// "news=debug,json=warn"
+func setStringMap(p *map[string]string) func(string) error {
+	return func(v string) error {
+		m := map[string]string{}
+		for _, pair := range strings.Split(v, ",") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("invalid pair %q, want key=value", strings.TrimSpace(pair))
+			}
+			m[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+		*p = m
+		return nil
+	}
+}
+
+// setIntMap reads a comma separated list of key=number pairs, e.g.
+// "fanout=8,digests=1"
+func setIntMap(p *map[string]int) func(string) error {
+	return func(v string) error {
+		pairs := map[string]string{}
+		if err := setStringMap(&pairs)(v); err != nil {
+			return err
+		}
+		m := make(map[string]int, len(pairs))
+		for key, value := range pairs {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid number %q for %s", value, key)
+			}
+			m[key] = n
+		}
+		*p = m
+		return nil
+	}
+}
+
+// setPrefixes reads a comma separated list of networks and addresses, e.g.
+// "10.0.0.0/8,192.0.2.1"
+func setPrefixes(p *[]netip.Prefix) func(string) error {
+	return func(v string) error {
+		var prefixes []netip.Prefix
+		for _, s := range strings.Split(v, ",") {
+			s = strings.TrimSpace(s)
+			if s == "" {
+				continue
+			}
+			if addr, err := netip.ParseAddr(s); err == nil {
+				prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+				continue
+			}
+			prefix, err := netip.ParsePrefix(s)
+			if err != nil {
+				return fmt.Errorf("invalid network %q, want an address or CIDR such as 10.0.0.0/8", s)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+		}
+		*p = prefixes
+		return nil
+	}
+}
+
+func setBool(p *bool) func(string) error {
+	return func(v string) error {
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", v)
+		}
+		*p = b
+		return nil
+	}
+}
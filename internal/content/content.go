@@ -0,0 +1,109 @@
+package content
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"yoharsh14/krant-backend/internal/apperr"
+)
+
+// WordsPerMinute is the reading speed used to estimate reading time
+const WordsPerMinute = 200
+
+// Format is a rendering of an article body that clients can ask for
+type Format string
+
+// Format constants
+const (
+	FormatHTML     Format = "html"
+	FormatText     Format = "text"
+	FormatMarkdown Format = "markdown"
+)
+
+// ValidFormats returns all supported content formats
+func ValidFormats() []Format {
+	return []Format{
+		FormatHTML,
+		FormatText,
+		FormatMarkdown,
+	}
+}
+
+// ErrInvalidFormat is returned by ParseFormat for unknown formats
+var ErrInvalidFormat = apperr.Validation("unsupported content format").WithCode("invalid_format").WithField("format", "must be one of html, text, markdown")
+
+// ParseFormat converts a client supplied value into a Format.
+// An empty value defaults to HTML.
+func ParseFormat(s string) (Format, error) {
+	if s == "" {
+		return FormatHTML, nil
+	}
+	for _, f := range ValidFormats() {
+		if string(f) == strings.ToLower(s) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q", ErrInvalidFormat, s)
+}
+
+// Document is the result of processing a raw article body
+type Document struct {
+	HTML        string // sanitized and normalized HTML, safe to store and serve
+	Text        string // plain text version used for search and summaries
+	WordCount   int
+	ReadingTime int // minutes
+}
+
+// Process sanitizes raw HTML against the allowlist, strips scripts and
+// tracking pixels, normalizes whitespace and encodings and derives the
+// plain text version and reading statistics.
+func Process(raw string) (Document, error) {
+	nodes, err := sanitize(NormalizeEncoding(raw))
+	if err != nil {
+		return Document{}, err
+	}
+
+	body, err := renderHTML(nodes)
+	if err != nil {
+		return Document{}, err
+	}
+
+	text := renderText(nodes)
+	words := len(strings.Fields(text))
+
+	return Document{
+		HTML:        body,
+		Text:        text,
+		WordCount:   words,
+		ReadingTime: ReadingTime(words),
+	}, nil
+}
+
+// Render converts stored (already sanitized) HTML into the requested format
+func Render(body string, format Format) (string, error) {
+	if format == FormatHTML || format == "" {
+		return body, nil
+	}
+
+	nodes, err := parseFragment(body)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case FormatText:
+		return renderText(nodes), nil
+	case FormatMarkdown:
+		return renderMarkdown(nodes), nil
+	default:
+		return "", fmt.Errorf("unsupported content format %q", format)
+	}
+}
+
+// ReadingTime estimates the minutes needed to read the given number of words
+func ReadingTime(words int) int {
+	if words <= 0 {
+		return 0
+	}
+	return int(math.Ceil(float64(words) / WordsPerMinute))
+}
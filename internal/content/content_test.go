@@ -0,0 +1,129 @@
+package content
+
+import (
+	"testing"
+)
+
+func TestProcess(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"scripts and styles", `<p>Hi <script>alert(1)</script><style>p{color:red}</style>there</p>`, `<p>Hi there</p>`},
+		{"event handlers", `<p onclick="steal()" onmouseover="steal()">Hi</p>`, `<p>Hi</p>`},
+		{"embedded content", `<p>Chart:</p><iframe src="https://ex.com/chart"></iframe><svg><script>x()</script></svg><form><input name="q"></form>`, `<p>Chart:</p>`},
+		{"unknown wrappers", `<div><section><p>Kept</p></section></div>`, `<p>Kept</p>`},
+		{"javascript link", `<a href="javascript:alert(1)">click</a>`, `click`},
+		{"javascript link in capitals", `<a href=" JAVASCRIPT:alert(1)">click</a>`, `click`},
+		{"entity-encoded javascript", `<a href="jav&#x61;script:alert(1)">click</a>`, `click`},
+		{"entity-encoded first letter", `<a href="&#106;avascript:alert(1)">click</a>`, `click`},
+		{"data image", `<p>a<img src="data:image/png;base64,AAAA" alt="x">b</p>`, `<p>ab</p>`},
+		{"tracking parameters", `<a href="https://ex.com/a?utm_source=feed&id=2&fbclid=x">story</a>`,
+			`<a href="https://ex.com/a?id=2" rel="nofollow noopener noreferrer">story</a>`},
+		{"hidden elements", `<p>a<span style="display: none">hidden</span><span style="VISIBILITY:hidden">gone</span>b</p>`, `<p>ab</p>`},
+		{"empty blocks", `<p> </p><p>Text</p><h2><script>x()</script></h2>`, `<p>Text</p>`},
+		{"whitespace", "<p>Markets\n\n   rallied\ttoday</p>", `<p>Markets rallied today</p>`},
+		{"preformatted", "<pre>a\n  b</pre>", "<pre>a\n  b</pre>"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			doc, err := Process(c.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if doc.HTML != c.want {
+				t.Errorf("Process(%q) = %q, want %q", c.in, doc.HTML, c.want)
+			}
+		})
+	}
+}
+
+func TestProcessDropsTrackingPixels(t *testing.T) {
+	cases := []struct {
+		src     string
+		attrs   string
+		tracker bool
+	}{
+		{"https://ex.com/beacon.gif", `width="1" height="1"`, true},
+		{"https://ex.com/beacon.gif", `width="0px"`, true},
+		{"https://stats.g.doubleclick.net/p.gif", "", true},
+		{"//www.google-analytics.com/collect?v=1", "", true},
+		{"https://www.facebook.com/tr?id=1&ev=PageView", "", true},
+		{"https://feeds.feedburner.com/~r/markets/~4/abc", "", true},
+		// only the tracker's own host and paths count
+		{"https://ex.com/photo.jpg", `width="640"`, false},
+		{"https://facebook.com/tracks/photo.jpg", "", false},
+		{"https://feeds.feedburner.com/markets/photo.jpg", "", false},
+		{"https://cdn.ex.com/img?from=doubleclick.net", "", false},
+		{"https://notdoubleclick.net/photo.jpg", "", false},
+		{"https://doubleclick.net.ex.com/photo.jpg", "", false},
+	}
+	for _, c := range cases {
+		doc, err := Process(`<p>a<img src="` + c.src + `" alt="x" ` + c.attrs + `>b</p>`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dropped := doc.HTML == "<p>ab</p>"; dropped != c.tracker {
+			t.Errorf("%s %s: got %q, want tracker %v", c.src, c.attrs, doc.HTML, c.tracker)
+		}
+	}
+}
+
+func TestProcessStatistics(t *testing.T) {
+	doc, err := Process(`<h1>Rates hold</h1><p>The central bank kept rates <strong>unchanged</strong>.</p>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Text != "Rates hold\n\nThe central bank kept rates unchanged." || doc.WordCount != 8 || doc.ReadingTime != 1 {
+		t.Errorf("Process = %+v", doc)
+	}
+}
+
+func TestRender(t *testing.T) {
+	body := `<h2>Title</h2>` +
+		`<p>Some <strong>bold</strong>, <em>em</em> and <a href="https://ex.com/a_(b)">a link</a>.</p>` +
+		`<ul><li>one</li><li>two</li></ul>` +
+		`<blockquote><p>Quoted</p></blockquote>` +
+		`<p><img src="https://ex.com/a%20b.png" alt="a *pic*"></p>`
+	cases := []struct {
+		format Format
+		want   string
+	}{
+		{FormatHTML, body},
+		{"", body},
+		{FormatText, "Title\n\nSome bold, em and a link.\n\none\n\ntwo\n\nQuoted\n\na *pic*"},
+		{FormatMarkdown, "## Title\n\n" +
+			"Some **bold**, _em_ and [a link](https://ex.com/a_%28b%29).\n\n" +
+			"- one\n- two\n\n" +
+			"> Quoted\n\n" +
+			`![a \*pic\*](https://ex.com/a%20b.png)`},
+	}
+	for _, c := range cases {
+		got, err := Render(body, c.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want {
+			t.Errorf("Render(%q) = %q, want %q", c.format, got, c.want)
+		}
+	}
+	if _, err := Render(body, "pdf"); err == nil {
+		t.Error("Render to an unknown format succeeded")
+	}
+}
+
+func TestRenderMarkdownKeepsURLsInTheirLinks(t *testing.T) {
+	// a destination closing early would turn the rest into a second link
+	doc, err := Process(`<a href="https://ex.com/x) ![p](javascript:alert(1)">story</a>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Render(doc.HTML, FormatMarkdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[story](https://ex.com/x%29%20%21%5Bp%5D%28javascript:alert%281%29)"; got != want {
+		t.Errorf("Render = %q, want %q", got, want)
+	}
+}
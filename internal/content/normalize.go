@@ -0,0 +1,58 @@
+package content
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeEncoding repairs invalid UTF-8, converts to NFC, unifies line
+// endings and drops invisible characters that feeds like to smuggle in
+func NormalizeEncoding(s string) string {
+	s = strings.ToValidUTF8(s, "\ufffd")
+	s = norm.NFC.String(s)
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '\n', '\t':
+			return r
+		case '\u00a0', '\u2007', '\u202f':
+			return ' ' // non-breaking spaces
+		case '\ufeff', '\u200b', '\u200c', '\u200d', '\u2060', '\u00ad':
+			return -1 // BOM, zero-width characters and soft hyphens
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// NormalizeText normalizes a plain text value such as a title and
+// collapses all whitespace runs into single spaces
+func NormalizeText(s string) string {
+	return strings.TrimSpace(collapseSpace(NormalizeEncoding(s)))
+}
+
+// collapseSpace replaces every run of whitespace with a single space
+func collapseSpace(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
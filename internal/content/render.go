@@ -0,0 +1,301 @@
+package content
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// renderText flattens the nodes into plain text with one blank line
+// between blocks
+func renderText(nodes []*html.Node) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		writeText(&b, n)
+	}
+	return tidyText(b.String())
+}
+
+func writeText(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		b.WriteString("\n")
+		return
+	case atom.Img:
+		if alt := getAttr(n, "alt"); alt != "" {
+			b.WriteString(alt)
+		}
+		return
+	case atom.Td, atom.Th:
+		b.WriteString(" ")
+	case atom.Li:
+		b.WriteString("\n")
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writeText(b, c)
+		}
+		b.WriteString("\n")
+		return
+	}
+
+	block := blockElements[n.DataAtom]
+	if block {
+		b.WriteString("\n\n")
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeText(b, c)
+	}
+	if block {
+		b.WriteString("\n\n")
+	}
+}
+
+// renderMarkdown converts the nodes into CommonMark
+func renderMarkdown(nodes []*html.Node) string {
+	m := &markdownWriter{}
+	for _, n := range nodes {
+		m.write(n)
+	}
+	return tidyMarkdown(m.b.String())
+}
+
+type markdownWriter struct {
+	b         strings.Builder
+	lists     []listState // innermost last
+	quote     int
+	pre       bool
+	lineStart bool // nothing but markers written on the current line
+}
+
+type listState struct {
+	ordered bool
+	next    int
+}
+
+func (m *markdownWriter) write(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if m.pre {
+			m.b.WriteString(n.Data)
+			return
+		}
+		text := n.Data
+		if m.lineStart {
+			text = strings.TrimLeft(text, " ")
+		}
+		if text != "" {
+			m.b.WriteString(escapeMarkdown(text))
+			m.lineStart = false
+		}
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level, _ := strconv.Atoi(n.Data[1:])
+		m.block()
+		m.b.WriteString(strings.Repeat("#", level) + " ")
+		m.lineStart = true
+		m.children(n)
+		m.block()
+	case atom.P, atom.Figure, atom.Figcaption, atom.Table, atom.Tr:
+		if len(m.lists) > 0 {
+			// paragraphs inside list items stay on the item line
+			m.children(n)
+			return
+		}
+		m.block()
+		m.children(n)
+		m.block()
+	case atom.Br:
+		m.b.WriteString("\\\n" + m.prefix())
+		m.lineStart = true
+	case atom.Hr:
+		m.block()
+		m.b.WriteString("---")
+		m.lineStart = false
+		m.block()
+	case atom.Strong, atom.B:
+		m.wrap(n, "**")
+	case atom.Em, atom.I:
+		m.wrap(n, "_")
+	case atom.S:
+		m.wrap(n, "~~")
+	case atom.Code:
+		if m.pre {
+			m.children(n)
+			return
+		}
+		m.wrap(n, "`")
+	case atom.Pre:
+		m.block()
+		m.b.WriteString("```\n")
+		m.pre = true
+		m.children(n)
+		m.pre = false
+		m.b.WriteString("\n```")
+		m.lineStart = false
+		m.block()
+	case atom.A:
+		m.b.WriteString("[")
+		m.children(n)
+		m.b.WriteString("](" + markdownURL(getAttr(n, "href")) + ")")
+		m.lineStart = false
+	case atom.Img:
+		m.b.WriteString("![" + escapeMarkdown(getAttr(n, "alt")) + "](" + markdownURL(getAttr(n, "src")) + ")")
+		m.lineStart = false
+	case atom.Blockquote:
+		m.quote++
+		m.block()
+		m.children(n)
+		m.quote--
+		m.block()
+	case atom.Ul, atom.Ol:
+		start := 1
+		if v, err := strconv.Atoi(getAttr(n, "start")); err == nil {
+			start = v
+		}
+		if len(m.lists) == 0 {
+			m.block()
+		}
+		m.lists = append(m.lists, listState{ordered: n.DataAtom == atom.Ol, next: start})
+		m.children(n)
+		m.lists = m.lists[:len(m.lists)-1]
+		if len(m.lists) == 0 {
+			m.block()
+		}
+	case atom.Li:
+		m.listItem(n)
+	case atom.Td, atom.Th:
+		m.b.WriteString(" ")
+		m.children(n)
+	default:
+		m.children(n)
+	}
+}
+
+func (m *markdownWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		m.write(c)
+	}
+}
+
+func (m *markdownWriter) wrap(n *html.Node, marker string) {
+	m.b.WriteString(marker)
+	m.lineStart = false
+	m.children(n)
+	m.b.WriteString(marker)
+}
+
+func (m *markdownWriter) listItem(n *html.Node) {
+	if len(m.lists) == 0 {
+		m.children(n)
+		return
+	}
+	depth := len(m.lists) - 1
+	list := &m.lists[depth]
+
+	marker := "- "
+	if list.ordered {
+		marker = strconv.Itoa(list.next) + ". "
+		list.next++
+	}
+
+	m.b.WriteString("\n" + m.prefix() + strings.Repeat("  ", depth) + marker)
+	m.lineStart = true
+	m.children(n)
+}
+
+// block starts a new paragraph, keeping blockquote markers on the
+// separating line so the quote is not split in two
+func (m *markdownWriter) block() {
+	m.b.WriteString("\n" + strings.TrimSpace(m.prefix()) + "\n" + m.prefix())
+	m.lineStart = true
+}
+
+func (m *markdownWriter) prefix() string {
+	return strings.Repeat("> ", m.quote)
+}
+
+var markdownSpecial = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"[", `\[`,
+	"]", `\]`,
+)
+
+func escapeMarkdown(s string) string {
+	return markdownSpecial.Replace(s)
+}
+
+// markdownDestination percent-encodes what would end a link destination
+// early or make it something else, e.g. "x) ![](y"
+var markdownDestination = strings.NewReplacer(
+	" ", "%20",
+	"\t", "%09",
+	"\n", "%0A",
+	"\r", "%0D",
+	"(", "%28",
+	")", "%29",
+	"<", "%3C",
+	">", "%3E",
+	`\`, "%5C",
+)
+
+// markdownURL makes a URL safe to write as a link destination
+func markdownURL(s string) string {
+	return markdownDestination.Replace(s)
+}
+
+// tidyText trims every line and squeezes runs of blank lines
+func tidyText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	s = blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(s)
+}
+
+// tidyMarkdown drops trailing spaces and squeezes runs of lines that hold
+// nothing but blockquote markers (blank lines included)
+func tidyMarkdown(s string) string {
+	var out []string
+	for _, l := range strings.Split(s, "\n") {
+		l = strings.TrimRight(l, " ")
+		if isMarkerOnly(l) && (len(out) == 0 || isMarkerOnly(out[len(out)-1])) {
+			// keep the outermost level so a closing quote really closes
+			if len(out) > 0 && strings.Count(l, ">") < strings.Count(out[len(out)-1], ">") {
+				out[len(out)-1] = l
+			}
+			continue
+		}
+		out = append(out, l)
+	}
+	for len(out) > 0 && isMarkerOnly(out[len(out)-1]) {
+		out = out[:len(out)-1]
+	}
+	return strings.Join(out, "\n")
+}
+
+func isMarkerOnly(line string) bool {
+	return strings.Trim(line, "> ") == ""
+}
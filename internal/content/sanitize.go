@@ -0,0 +1,364 @@
+package content
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedElements maps every element we keep to the attributes it may carry.
+// Elements that are not listed are unwrapped: their children survive.
+var allowedElements = map[atom.Atom][]string{
+	atom.P:          nil,
+	atom.Br:         nil,
+	atom.Hr:         nil,
+	atom.H1:         nil,
+	atom.H2:         nil,
+	atom.H3:         nil,
+	atom.H4:         nil,
+	atom.H5:         nil,
+	atom.H6:         nil,
+	atom.Strong:     nil,
+	atom.B:          nil,
+	atom.Em:         nil,
+	atom.I:          nil,
+	atom.U:          nil,
+	atom.S:          nil,
+	atom.Sub:        nil,
+	atom.Sup:        nil,
+	atom.Blockquote: {"cite"},
+	atom.Q:          {"cite"},
+	atom.Ul:         nil,
+	atom.Ol:         {"start"},
+	atom.Li:         nil,
+	atom.Pre:        nil,
+	atom.Code:       nil,
+	atom.A:          {"href", "title"},
+	atom.Img:        {"src", "alt", "title", "width", "height"},
+	atom.Figure:     nil,
+	atom.Figcaption: nil,
+	atom.Table:      nil,
+	atom.Thead:      nil,
+	atom.Tbody:      nil,
+	atom.Tr:         nil,
+	atom.Th:         {"colspan", "rowspan"},
+	atom.Td:         {"colspan", "rowspan"},
+}
+
+// droppedElements are removed together with everything inside them
+var droppedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Iframe:   true,
+	atom.Frame:    true,
+	atom.Frameset: true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Applet:   true,
+	atom.Form:     true,
+	atom.Input:    true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Textarea: true,
+	atom.Svg:      true,
+	atom.Math:     true,
+	atom.Template: true,
+	atom.Head:     true,
+	atom.Title:    true,
+	atom.Meta:     true,
+	atom.Link:     true,
+	atom.Base:     true,
+	atom.Audio:    true,
+	atom.Video:    true,
+	atom.Canvas:   true,
+}
+
+// urlAttributes are attributes holding URLs whose scheme must be checked
+var urlAttributes = map[string]bool{
+	"href": true,
+	"src":  true,
+	"cite": true,
+}
+
+// allowedSchemes are the URL schemes allowed in link and image attributes
+var allowedSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// tracker is where tracking pixels are served from: a host and its
+// subdomains, under path when it is set
+type tracker struct {
+	host string
+	path string
+}
+
+// trackers only ever serve tracking pixels
+var trackers = []tracker{
+	{host: "doubleclick.net"},
+	{host: "google-analytics.com"},
+	{host: "googletagmanager.com"},
+	{host: "facebook.com", path: "/tr"},
+	{host: "pixel.wp.com"},
+	{host: "stats.wp.com"},
+	{host: "feeds.feedburner.com", path: "/~r"},
+	{host: "feedsportal.com"},
+	{host: "quantserve.com"},
+	{host: "scorecardresearch.com"},
+}
+
+// serves reports whether u is served by the tracker
+func (t tracker) serves(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	if host != t.host && !strings.HasSuffix(host, "."+t.host) {
+		return false
+	}
+	return t.path == "" || u.Path == t.path || strings.HasPrefix(u.Path, t.path+"/")
+}
+
+// trackingParams are query parameters removed from outgoing links
+var trackingParams = []string{"utm_", "fbclid", "gclid", "mc_cid", "mc_eid"}
+
+// blockElements start a new paragraph in the text and markdown renderings
+var blockElements = map[atom.Atom]bool{
+	atom.P:          true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Blockquote: true,
+	atom.Ul:         true,
+	atom.Ol:         true,
+	atom.Li:         true,
+	atom.Pre:        true,
+	atom.Hr:         true,
+	atom.Figure:     true,
+	atom.Figcaption: true,
+	atom.Table:      true,
+	atom.Tr:         true,
+}
+
+// parseFragment parses an HTML fragment as the children of a <body>
+func parseFragment(body string) ([]*html.Node, error) {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	return html.ParseFragment(strings.NewReader(body), context)
+}
+
+// sanitize parses raw HTML and returns the cleaned node list
+func sanitize(raw string) ([]*html.Node, error) {
+	nodes, err := parseFragment(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*html.Node
+	for _, n := range nodes {
+		out = append(out, sanitizeNode(n, false)...)
+	}
+	return trimBlank(out), nil
+}
+
+// sanitizeNode returns the nodes that replace n in the cleaned tree.
+// Disallowed wrappers are unwrapped, dangerous elements vanish entirely.
+func sanitizeNode(n *html.Node, preformatted bool) []*html.Node {
+	switch n.Type {
+	case html.TextNode:
+		text := n.Data
+		if !preformatted {
+			text = collapseSpace(text)
+		}
+		if text == "" {
+			return nil
+		}
+		return []*html.Node{{Type: html.TextNode, Data: text}}
+
+	case html.ElementNode:
+		if droppedElements[n.DataAtom] || isTrackingPixel(n) || isHidden(n) {
+			return nil
+		}
+
+		var children []*html.Node
+		pre := preformatted || n.DataAtom == atom.Pre
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			children = append(children, sanitizeNode(c, pre)...)
+		}
+
+		allowed, ok := allowedElements[n.DataAtom]
+		if !ok {
+			return children
+		}
+
+		clean := &html.Node{
+			Type:     html.ElementNode,
+			Data:     n.DataAtom.String(),
+			DataAtom: n.DataAtom,
+			Attr:     filterAttributes(n, allowed),
+		}
+
+		// links and images without a usable target are not worth keeping
+		if n.DataAtom == atom.A && getAttr(clean, "href") == "" {
+			return children
+		}
+		if n.DataAtom == atom.Img && getAttr(clean, "src") == "" {
+			return nil
+		}
+		if n.DataAtom == atom.A {
+			clean.Attr = append(clean.Attr, html.Attribute{Key: "rel", Val: "nofollow noopener noreferrer"})
+		}
+
+		for _, c := range children {
+			clean.AppendChild(c)
+		}
+		if isEmptyBlock(clean) {
+			return nil
+		}
+		return []*html.Node{clean}
+	}
+
+	// comments, doctypes and anything else are dropped
+	return nil
+}
+
+// filterAttributes keeps only allowlisted attributes with safe values
+func filterAttributes(n *html.Node, allowed []string) []html.Attribute {
+	var attrs []html.Attribute
+	for _, a := range n.Attr {
+		if a.Namespace != "" || !contains(allowed, strings.ToLower(a.Key)) {
+			continue
+		}
+		key := strings.ToLower(a.Key)
+		val := strings.TrimSpace(a.Val)
+
+		if urlAttributes[key] {
+			safe, ok := sanitizeURL(val)
+			if !ok {
+				continue
+			}
+			val = safe
+		}
+		attrs = append(attrs, html.Attribute{Key: key, Val: val})
+	}
+	return attrs
+}
+
+// sanitizeURL validates the scheme of a URL and strips tracking parameters
+func sanitizeURL(raw string) (string, bool) {
+	if raw == "" {
+		return "", false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	if u.Scheme != "" && !allowedSchemes[strings.ToLower(u.Scheme)] {
+		return "", false
+	}
+
+	if u.RawQuery != "" {
+		q := u.Query()
+		stripped := false
+		for key := range q {
+			for _, p := range trackingParams {
+				if strings.HasPrefix(strings.ToLower(key), p) {
+					q.Del(key)
+					stripped = true
+				}
+			}
+		}
+		if stripped {
+			u.RawQuery = q.Encode()
+		}
+	}
+	return u.String(), true
+}
+
+// isTrackingPixel reports whether an <img> is a 1x1 beacon or served by
+// a known tracker
+func isTrackingPixel(n *html.Node) bool {
+	if n.DataAtom != atom.Img {
+		return false
+	}
+	for _, dim := range []string{"width", "height"} {
+		if v, err := strconv.Atoi(strings.TrimSuffix(getAttr(n, dim), "px")); err == nil && v <= 1 {
+			return true
+		}
+	}
+
+	src, err := url.Parse(strings.TrimSpace(getAttr(n, "src")))
+	if err != nil {
+		return false
+	}
+	for _, t := range trackers {
+		if t.serves(src) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHidden reports whether inline styles hide the element from readers
+func isHidden(n *html.Node) bool {
+	style := strings.ReplaceAll(strings.ToLower(getAttr(n, "style")), " ", "")
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+// isEmptyBlock reports whether a block element ended up with no content
+func isEmptyBlock(n *html.Node) bool {
+	if !blockElements[n.DataAtom] || n.DataAtom == atom.Hr {
+		return false
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.TextNode || strings.TrimSpace(c.Data) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// trimBlank removes whitespace-only text nodes between top level blocks
+func trimBlank(nodes []*html.Node) []*html.Node {
+	out := nodes[:0]
+	for _, n := range nodes {
+		if n.Type == html.TextNode && strings.TrimSpace(n.Data) == "" {
+			continue
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// renderHTML serializes the cleaned nodes back into an HTML string
+func renderHTML(nodes []*html.Node) (string, error) {
+	var b strings.Builder
+	for _, n := range nodes {
+		if err := html.Render(&b, n); err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+func getAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
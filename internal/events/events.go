@@ -0,0 +1,123 @@
+// Package events is the domain event bus. A service records an event in the
+// outbox in the same transaction as the change it reports, so there is
+// never a change without its event nor an event without its change; the
+// relay then hands every event to the subscribers of its type in this
+// process, at least once.
+package events
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Event types, with the document their payload holds
+const (
+	UserCreated     = "user.created"     // models.User
+	NewsPublished   = "news.published"   // models.News
+	NewsUpdated     = "news.updated"     // models.News, an article already published
+	CategoryCreated = "category.created" // models.Category
+	CategoryUpdated = "category.updated" // models.Category
+
+	NotificationCreated = "notification.created" // models.Notification
+)
+
+// Event statuses in the outbox
+const (
+	StatusPending    = "pending"    // waiting for, or being handed to, its subscribers
+	StatusDispatched = "dispatched" // every subscriber handled it
+	StatusFailed     = "failed"     // a subscriber still failed after the last attempt
+)
+
+// Event is a change that has been made, as stored in the outbox
+type Event struct {
+	ID         bson.ObjectID `bson:"_id"`
+	Type       string        `bson:"type"`
+	Payload    bson.Raw      `bson:"payload"`
+	OccurredAt time.Time     `bson:"occurred_at"`
+
+	// relay state
+	Status        string     `bson:"status"`
+	Attempts      int        `bson:"attempts"`
+	NextAttemptAt time.Time  `bson:"next_attempt_at"`
+	Handled       []string   `bson:"handled"` // subscribers done with the event
+	Error         string     `bson:"error,omitempty"`
+	DispatchedAt  *time.Time `bson:"dispatched_at,omitempty"`
+	UpdatedAt     time.Time  `bson:"updated_at"`
+}
+
+// Decode unmarshals the event's payload into out, e.g. a *models.News for
+// news.published
+func (e Event) Decode(out any) error {
+	return bson.Unmarshal(e.Payload, out)
+}
+
+// newEvent builds a pending event of the given type, due at once
+func newEvent(eventType string, payload any, now time.Time) (*Event, error) {
+	raw, err := bson.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("event %s: %w", eventType, err)
+	}
+	return &Event{
+		ID:            bson.NewObjectID(),
+		Type:          eventType,
+		Payload:       raw,
+		OccurredAt:    now,
+		Status:        StatusPending,
+		NextAttemptAt: now,
+		Handled:       []string{},
+		UpdatedAt:     now,
+	}, nil
+}
+
+// Handler reacts to an event. It runs at least once for every event of its
+// type and may run again for one it already handled, when the relay stopped
+// before recording that it did, so it must tolerate repeats, e.g. by keying
+// what it writes on the event's ID.
+type Handler func(ctx context.Context, event Event) error
+
+type subscription struct {
+	name      string
+	eventType string
+	handle    Handler
+}
+
+// Bus routes the events the relay takes from the outbox to the subscribers
+// registered in this process
+type Bus struct {
+	mu   sync.RWMutex
+	subs []subscription
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers handle for the events of eventType. The relay
+// remembers which subscribers handled an event by name, so name must be
+// stable across releases and unique for the type; a repeated one panics.
+func (b *Bus) Subscribe(name, eventType string, handle Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if slices.ContainsFunc(b.subs, func(s subscription) bool { return s.name == name && s.eventType == eventType }) {
+		panic(fmt.Sprintf("events: %s is already subscribed to %s", name, eventType))
+	}
+	b.subs = append(b.subs, subscription{name: name, eventType: eventType, handle: handle})
+}
+
+// subscribers returns the subscriptions to eventType in registration order
+func (b *Bus) subscribers(eventType string) []subscription {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var subs []subscription
+	for _, s := range b.subs {
+		if s.eventType == eventType {
+			subs = append(subs, s)
+		}
+	}
+	return subs
+}
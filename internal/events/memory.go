@@ -0,0 +1,79 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"time"
+	"yoharsh14/krant-backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// memoryStore is the in-memory Store, meant for tests
+type memoryStore struct {
+	coll *repository.Memory
+}
+
+// NewMemoryStore returns an empty in-memory Store. Its transactions only
+// run fn: the in-memory repositories have nothing to roll back.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		coll: repository.NewMemory(),
+	}
+}
+
+func (s *memoryStore) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (s *memoryStore) Record(ctx context.Context, eventType string, payload any) error {
+	event, err := newEvent(eventType, payload, time.Now())
+	if err != nil {
+		return err
+	}
+	return s.coll.InsertOne(ctx, event)
+}
+
+func (s *memoryStore) FindByID(ctx context.Context, id bson.ObjectID) (*Event, error) {
+	var event Event
+	err := s.coll.FindOne(ctx, bson.M{"_id": id}, &event)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // Event not found
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (s *memoryStore) Claim(ctx context.Context, now time.Time, lease time.Duration) (*Event, error) {
+	var event Event
+	err := s.coll.FindOneAndUpdate(ctx, dueAt(now), bson.D{{Key: "next_attempt_at", Value: 1}}, claim(now, lease), &event)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // nothing due
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (s *memoryStore) MarkHandled(ctx context.Context, claimed *Event, subscriber string) error {
+	return s.updateClaimed(ctx, claimed, markHandled(subscriber))
+}
+
+func (s *memoryStore) Finish(ctx context.Context, claimed *Event, update bson.M) error {
+	return s.updateClaimed(ctx, claimed, finish(update))
+}
+
+func (s *memoryStore) updateClaimed(ctx context.Context, claimed *Event, update bson.M) error {
+	matched, err := s.coll.UpdateOne(ctx, sameClaim(claimed), update)
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
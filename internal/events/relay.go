@@ -0,0 +1,135 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/metrics"
+	"yoharsh14/krant-backend/internal/retry"
+	"yoharsh14/krant-backend/internal/tracing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Relay hands the events in the outbox to their subscribers on the bus. Any
+// number of relays, in any number of instances, may share a store: each
+// event is claimed by one of them at a time. Every instance should register
+// the same subscribers, as any of them may relay any event.
+type Relay struct {
+	store Store
+	bus   *Bus
+	cfg   config.Events
+	now   func() time.Time
+}
+
+func NewRelay(store Store, bus *Bus, cfg config.Events) *Relay {
+	return &Relay{
+		store: store,
+		bus:   bus,
+		cfg:   cfg,
+		now:   time.Now,
+	}
+}
+
+// Run relays with concurrency workers until ctx is done. Each worker claims
+// due events one by one and waits for poll when none is due. Events are not
+// ordered: with more than one worker, a later event may reach a subscriber
+// first.
+func (r *Relay) Run(ctx context.Context, concurrency int, poll time.Duration) {
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Go(func() {
+			for ctx.Err() == nil {
+				relayed, err := r.RelayNext(ctx)
+				if err != nil && ctx.Err() == nil {
+					slog.ErrorContext(ctx, "event relay failed", "error", err)
+				}
+				if relayed && err == nil {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+				case <-time.After(poll):
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// RelayNext hands the event that has been due longest to its subscribers
+// and reports whether there was one
+func (r *Relay) RelayNext(ctx context.Context) (bool, error) {
+	event, err := r.store.Claim(ctx, r.now(), r.cfg.Lease)
+	if err != nil || event == nil {
+		return false, err
+	}
+	return true, tracing.Job(context.WithoutCancel(ctx), "event_relay", func(ctx context.Context) error {
+		return r.relay(ctx, event)
+	})
+}
+
+// relay runs the subscribers that have not handled a claimed event yet,
+// noting each one that succeeds, and records how it went: dispatched once
+// all have, otherwise retried after a backoff or, once out of attempts,
+// failed
+func (r *Relay) relay(ctx context.Context, event *Event) error {
+	var errs []error
+	for _, sub := range r.bus.subscribers(event.Type) {
+		if slices.Contains(event.Handled, sub.name) {
+			continue
+		}
+		if err := sub.handle(ctx, *event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sub.name, err))
+			continue
+		}
+		if err := r.store.MarkHandled(ctx, event, sub.name); err != nil {
+			return r.lost(ctx, event, err)
+		}
+	}
+
+	now := r.now()
+	var result string
+	update := bson.M{}
+	switch {
+	case len(errs) == 0:
+		result = StatusDispatched
+		update["status"] = StatusDispatched
+		update["dispatched_at"] = now
+		update["error"] = ""
+	case event.Attempts >= r.cfg.MaxAttempts:
+		result = StatusFailed
+		update["status"] = StatusFailed
+		update["error"] = errors.Join(errs...).Error()
+	default:
+		result = "retried"
+		update["next_attempt_at"] = now.Add(retry.Backoff(r.cfg.RetryBase, r.cfg.RetryMax, event.Attempts))
+		update["error"] = errors.Join(errs...).Error()
+	}
+
+	if err := r.store.Finish(ctx, event, update); err != nil {
+		return r.lost(ctx, event, err)
+	}
+	metrics.EventsRelayed.With(event.Type, result).Inc()
+	if result == StatusFailed {
+		slog.ErrorContext(ctx, "event relay given up",
+			"event_id", event.ID.Hex(), "type", event.Type, "attempts", event.Attempts, "error", update["error"])
+	}
+	return nil
+}
+
+// lost handles an update of a claimed event that failed: when the event was
+// claimed again meanwhile, that claim carries on and this one just stops
+func (r *Relay) lost(ctx context.Context, event *Event, err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		slog.WarnContext(ctx, "event claimed again while it was relayed", "event_id", event.ID.Hex())
+		return nil
+	}
+	return err
+}
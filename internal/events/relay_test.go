@@ -0,0 +1,161 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"yoharsh14/krant-backend/internal/clocktest"
+	"yoharsh14/krant-backend/internal/config"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// subscriber counts its calls and fails while failing is set
+type subscriber struct {
+	calls   int
+	failing bool
+}
+
+func (s *subscriber) handle(ctx context.Context, event Event) error {
+	s.calls++
+	if s.failing {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+type fixture struct {
+	store Store
+	bus   *Bus
+	relay *Relay
+	clock *clocktest.Clock
+}
+
+func newFixture(t *testing.T) fixture {
+	store, bus := NewMemoryStore(), NewBus()
+	c := clocktest.New()
+	c.Advance(time.Second)
+	r := NewRelay(store, bus, config.Events{
+		Lease:       time.Minute,
+		MaxAttempts: 3,
+		RetryBase:   time.Minute,
+		RetryMax:    time.Hour,
+	})
+	r.now = c.Now
+	return fixture{store: store, bus: bus, relay: r, clock: c}
+}
+
+// record adds an event, due now, and returns it
+func (f fixture) record(t *testing.T, eventType string) *Event {
+	t.Helper()
+	event, err := newEvent(eventType, bson.M{"_id": bson.NewObjectID()}, f.clock.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.store.(*memoryStore).coll.InsertOne(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+// relayDue relays every event that is due now
+func (f fixture) relayDue(t *testing.T) {
+	t.Helper()
+	for {
+		relayed, err := f.relay.RelayNext(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !relayed {
+			return
+		}
+	}
+}
+
+func (f fixture) find(t *testing.T, id bson.ObjectID) *Event {
+	t.Helper()
+	event, err := f.store.FindByID(context.Background(), id)
+	if err != nil || event == nil {
+		t.Fatalf("FindByID = %v, %v", event, err)
+	}
+	return event
+}
+
+func TestRelaysToTheSubscribersOfTheType(t *testing.T) {
+	f := newFixture(t)
+	signups, welcome, published := &subscriber{}, &subscriber{}, &subscriber{}
+	f.bus.Subscribe("metrics", UserCreated, signups.handle)
+	f.bus.Subscribe("notifications", UserCreated, welcome.handle)
+	f.bus.Subscribe("metrics", NewsPublished, published.handle)
+
+	created := f.record(t, UserCreated)
+	unheard := f.record(t, CategoryCreated)
+	f.relayDue(t)
+
+	if signups.calls != 1 || welcome.calls != 1 || published.calls != 0 {
+		t.Errorf("calls = %d, %d, %d; want user.created handled once by both of its subscribers only",
+			signups.calls, welcome.calls, published.calls)
+	}
+	for _, id := range []bson.ObjectID{created.ID, unheard.ID} {
+		if event := f.find(t, id); event.Status != StatusDispatched || event.DispatchedAt == nil {
+			t.Errorf("%s = %s, want dispatched, with or without subscribers", event.Type, event.Status)
+		}
+	}
+	if got := f.find(t, created.ID).Handled; len(got) != 2 {
+		t.Errorf("handled by %v, want both subscribers", got)
+	}
+}
+
+func TestRetriesOnlyTheSubscribersThatFailed(t *testing.T) {
+	f := newFixture(t)
+	signups, welcome := &subscriber{}, &subscriber{failing: true}
+	f.bus.Subscribe("metrics", UserCreated, signups.handle)
+	f.bus.Subscribe("notifications", UserCreated, welcome.handle)
+
+	event := f.record(t, UserCreated)
+	f.relayDue(t)
+	got := f.find(t, event.ID)
+	wait := got.NextAttemptAt.Sub(f.clock.Now())
+	if got.Status != StatusPending || got.Error == "" || wait <= 0 || wait > time.Minute {
+		t.Fatalf("after a failure: %s, error %q, retried in %v; want pending with the error, retried within a minute", got.Status, got.Error, wait)
+	}
+
+	welcome.failing = false
+	f.clock.Advance(wait)
+	f.relayDue(t)
+	if got := f.find(t, event.ID); got.Status != StatusDispatched || got.Error != "" {
+		t.Errorf("after the retry: %s, error %q; want dispatched, error cleared", got.Status, got.Error)
+	}
+	if signups.calls != 1 || welcome.calls != 2 {
+		t.Errorf("calls = %d, %d; want the subscriber that succeeded left out of the retry", signups.calls, welcome.calls)
+	}
+}
+
+func TestGivesUpAfterTheLastAttempt(t *testing.T) {
+	f := newFixture(t)
+	broken := &subscriber{failing: true}
+	f.bus.Subscribe("webhooks", NewsPublished, broken.handle)
+
+	event := f.record(t, NewsPublished)
+	for range 4 {
+		f.relayDue(t)
+		f.clock.Advance(time.Hour)
+	}
+	got := f.find(t, event.ID)
+	if got.Status != StatusFailed || got.Attempts != 3 || broken.calls != 3 {
+		t.Errorf("event = %s after %d attempts, %d calls; want failed after 3", got.Status, got.Attempts, broken.calls)
+	}
+}
+
+func TestSubscribeTwicePanics(t *testing.T) {
+	bus := NewBus()
+	bus.Subscribe("metrics", UserCreated, (&subscriber{}).handle)
+	bus.Subscribe("metrics", NewsPublished, (&subscriber{}).handle)
+	defer func() {
+		if recover() == nil {
+			t.Error("a second metrics subscriber to user.created did not panic")
+		}
+	}()
+	bus.Subscribe("metrics", UserCreated, (&subscriber{}).handle)
+}
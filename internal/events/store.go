@@ -0,0 +1,190 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Outbox is what the services need of the event store: a transaction to
+// make a change in and a way to record the event reporting it
+type Outbox interface {
+	// Transaction runs fn in a database transaction: the writes fn makes
+	// with the context it is given, the events it records included, are
+	// committed together or not at all. fn may run more than once when the
+	// transaction is retried.
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+	// Record adds an event of eventType with payload, a document, to the
+	// outbox, due to be relayed at once
+	Record(ctx context.Context, eventType string, payload any) error
+}
+
+// Store is the outbox. NewStore is backed by MongoDB and NewMemoryStore
+// keeps everything in memory; both behave the same. Lookups return nil, nil
+// when nothing matches; updates of an event claimed again since return
+// mongo.ErrNoDocuments.
+type Store interface {
+	Outbox
+	FindByID(ctx context.Context, id bson.ObjectID) (*Event, error)
+	Claim(ctx context.Context, now time.Time, lease time.Duration) (*Event, error)
+	MarkHandled(ctx context.Context, claimed *Event, subscriber string) error
+	Finish(ctx context.Context, claimed *Event, update bson.M) error
+}
+
+type mongoStore struct {
+	coll *mongo.Collection
+
+	mu           sync.Mutex
+	transactions *bool // whether the deployment has them, once known
+}
+
+func NewStore(db *mongo.Database) Store {
+	return &mongoStore{
+		coll: db.Collection("outbox"),
+	}
+}
+
+// Transaction runs fn in a transaction, or joins the one ctx is already in.
+// A standalone mongod has no transactions: fn then runs without one, so a
+// crash between a change and its event loses the event.
+func (s *mongoStore) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil || !s.transactional(ctx) {
+		return fn(ctx)
+	}
+
+	session, err := s.coll.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
+
+// transactional reports whether the deployment is a replica set or sharded
+// cluster, which have transactions, asking it the first time
+func (s *mongoStore) transactional(ctx context.Context) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.transactions != nil {
+		return *s.transactions
+	}
+
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := s.coll.Database().RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return true // the transaction reports the error; asked again next time
+	}
+	transactions := hello.SetName != "" || hello.Msg == "isdbgrid"
+	if !transactions {
+		slog.WarnContext(ctx, "MongoDB is a standalone server without transactions, events are recorded after the changes they report rather than with them")
+	}
+	s.transactions = &transactions
+	return transactions
+}
+
+// Record inserts a new event, within ctx's transaction if there is one
+func (s *mongoStore) Record(ctx context.Context, eventType string, payload any) error {
+	event, err := newEvent(eventType, payload, time.Now())
+	if err != nil {
+		return err
+	}
+	_, err = s.coll.InsertOne(ctx, event)
+	return err
+}
+
+// FindByID retrieves an event by its ObjectID
+func (s *mongoStore) FindByID(ctx context.Context, id bson.ObjectID) (*Event, error) {
+	var event Event
+	err := s.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&event)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // Event not found
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Claim takes the pending event that has been due longest and hides it
+// from other claims for lease, by moving its next attempt past it. A relay
+// that dies while handing it out thus leaves it to be claimed again once the
+// lease runs out. The claim counts as an attempt. It returns nil, nil when
+// nothing is due.
+func (s *mongoStore) Claim(ctx context.Context, now time.Time, lease time.Duration) (*Event, error) {
+	var event Event
+	err := s.coll.FindOneAndUpdate(ctx, dueAt(now), claim(now, lease),
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&event)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // nothing due
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+func dueAt(now time.Time) bson.M {
+	return bson.M{"status": StatusPending, "next_attempt_at": bson.M{"$lte": now}}
+}
+
+func claim(now time.Time, lease time.Duration) bson.M {
+	return bson.M{
+		"$set": bson.M{"next_attempt_at": now.Add(lease), "updated_at": now},
+		"$inc": bson.M{"attempts": 1},
+	}
+}
+
+// MarkHandled records that subscriber is done with a claimed event, so a
+// later attempt skips it. It returns mongo.ErrNoDocuments when the event was
+// claimed again since, after its lease ran out.
+func (s *mongoStore) MarkHandled(ctx context.Context, claimed *Event, subscriber string) error {
+	result, err := s.coll.UpdateOne(ctx, sameClaim(claimed), markHandled(subscriber))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func sameClaim(claimed *Event) bson.M {
+	return bson.M{"_id": claimed.ID, "status": StatusPending, "attempts": claimed.Attempts}
+}
+
+func markHandled(subscriber string) bson.M {
+	return bson.M{"$addToSet": bson.M{"handled": subscriber}}
+}
+
+// Finish sets the fields in update on a claimed event, ending the attempt.
+// It returns mongo.ErrNoDocuments when the event was claimed again since.
+func (s *mongoStore) Finish(ctx context.Context, claimed *Event, update bson.M) error {
+	result, err := s.coll.UpdateOne(ctx, sameClaim(claimed), finish(update))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func finish(update bson.M) bson.M {
+	update["updated_at"] = time.Now()
+	return bson.M{"$set": update}
+}
@@ -0,0 +1,127 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+	"yoharsh14/krant-backend/internal/repository/repositorytest"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, func(t *testing.T) Store { return NewMemoryStore() })
+}
+
+func TestMongoStore(t *testing.T) {
+	testStore(t, func(t *testing.T) Store { return NewStore(repositorytest.Database(t)) })
+}
+
+type payload struct {
+	ID    bson.ObjectID `bson:"_id"`
+	Title string        `bson:"title"`
+}
+
+// testStore is the contract every Store implementation must meet
+func testStore(t *testing.T, newStore func(t *testing.T) Store) {
+	ctx := context.Background()
+
+	t.Run("record and claim", func(t *testing.T) {
+		s := newStore(t)
+		sent := payload{ID: bson.NewObjectID(), Title: "Markets rally"}
+		err := s.Transaction(ctx, func(ctx context.Context) error {
+			if err := s.Record(ctx, NewsPublished, sent); err != nil {
+				return err
+			}
+			return s.Record(ctx, NewsUpdated, sent)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		now := time.Now().Add(time.Second).Truncate(time.Millisecond)
+		var types []string
+		for range 2 {
+			claimed, err := s.Claim(ctx, now, time.Minute)
+			if err != nil || claimed == nil {
+				t.Fatalf("Claim = %v, %v; want each event recorded", claimed, err)
+			}
+			var got payload
+			if err := claimed.Decode(&got); err != nil || got != sent {
+				t.Errorf("Decode = %+v, %v; want %+v", got, err, sent)
+			}
+			if claimed.Status != StatusPending || claimed.Attempts != 1 ||
+				!claimed.NextAttemptAt.Equal(now.Add(time.Minute)) || len(claimed.Handled) != 0 {
+				t.Errorf("claimed event = %+v; want it pending, first attempt, leased for a minute", claimed)
+			}
+			types = append(types, claimed.Type)
+		}
+		slices.Sort(types)
+		if want := []string{NewsPublished, NewsUpdated}; !slices.Equal(types, want) {
+			t.Errorf("claimed %v, want %v", types, want)
+		}
+		if none, err := s.Claim(ctx, now, time.Minute); none != nil || err != nil {
+			t.Errorf("Claim with both leased = %+v, %v; want nil, nil", none, err)
+		}
+	})
+
+	t.Run("handled and finished", func(t *testing.T) {
+		s := newStore(t)
+		if err := s.Record(ctx, UserCreated, payload{ID: bson.NewObjectID()}); err != nil {
+			t.Fatal(err)
+		}
+		now := time.Now().Add(time.Second).Truncate(time.Millisecond)
+		claimed, err := s.Claim(ctx, now, time.Minute)
+		if err != nil || claimed == nil {
+			t.Fatalf("Claim = %v, %v", claimed, err)
+		}
+
+		for _, subscriber := range []string{"metrics", "notifications", "metrics"} {
+			if err := s.MarkHandled(ctx, claimed, subscriber); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := s.Finish(ctx, claimed, bson.M{"next_attempt_at": now, "error": "webhooks: boom"}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.FindByID(ctx, claimed.ID)
+		if err != nil || got == nil {
+			t.Fatalf("FindByID = %v, %v", got, err)
+		}
+		if !slices.Equal(got.Handled, []string{"metrics", "notifications"}) || got.Error != "webhooks: boom" {
+			t.Errorf("event = handled %v, error %q; want each subscriber once and the error", got.Handled, got.Error)
+		}
+
+		// the retry is a new claim: the first one is stale
+		again, err := s.Claim(ctx, now, time.Minute)
+		if err != nil || again == nil || again.Attempts != 2 {
+			t.Fatalf("Claim of the retry = %+v, %v; want the event, second attempt", again, err)
+		}
+		if err := s.MarkHandled(ctx, claimed, "webhooks"); !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Errorf("MarkHandled of a stale claim = %v, want ErrNoDocuments", err)
+		}
+		if err := s.Finish(ctx, claimed, bson.M{"status": StatusDispatched}); !errors.Is(err, mongo.ErrNoDocuments) {
+			t.Errorf("Finish of a stale claim = %v, want ErrNoDocuments", err)
+		}
+		if err := s.Finish(ctx, again, bson.M{"status": StatusDispatched, "dispatched_at": now}); err != nil {
+			t.Fatal(err)
+		}
+		if none, err := s.Claim(ctx, now.Add(time.Hour), time.Minute); none != nil || err != nil {
+			t.Errorf("Claim after dispatch = %+v, %v; want nil, nil", none, err)
+		}
+		if got, _ := s.FindByID(ctx, claimed.ID); got.Status != StatusDispatched || got.DispatchedAt == nil {
+			t.Errorf("dispatched event = %+v; want its status and time", got)
+		}
+	})
+
+	t.Run("transaction errors", func(t *testing.T) {
+		s := newStore(t)
+		boom := errors.New("boom")
+		if err := s.Transaction(ctx, func(ctx context.Context) error { return boom }); !errors.Is(err, boom) {
+			t.Errorf("Transaction = %v, want fn's error", err)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+// Package health serves the liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Check probes one dependency. It may return details worth reporting even
+// when it succeeds.
+type Check func(ctx context.Context) (detail any, err error)
+
+// Status values in probe responses
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// Result is the outcome of one check
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Detail   any    `json:"detail,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Response is the body of the probe endpoints
+type Response struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the readiness checks
+type Checker struct {
+	timeout time.Duration
+
+	mu     sync.Mutex
+	checks []namedCheck
+}
+
+// NewChecker returns a checker giving each run of the checks timeout to finish
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a readiness check
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Run runs every check concurrently and reports whether all passed
+func (c *Checker) Run(ctx context.Context) Response {
+	c.mu.Lock()
+	checks := c.checks
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, nc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			detail, err := nc.check(ctx)
+			results[i] = Result{Status: StatusOK, Detail: detail, Duration: time.Since(start).String()}
+			if err != nil {
+				results[i].Status = StatusUnavailable
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	resp := Response{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	for i, nc := range checks {
+		resp.Checks[nc.name] = results[i]
+		if results[i].Status != StatusOK {
+			resp.Status = StatusUnavailable
+		}
+	}
+	return resp
+}
+
+// Liveness answers 200 as long as the process can serve requests at all
+func Liveness(w http.ResponseWriter, r *http.Request) {
+	writeResponse(w, http.StatusOK, Response{Status: StatusOK})
+}
+
+// Readiness answers 200 when every check passes and 503 otherwise, so load
+// balancers only route traffic to instances that can handle it
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	resp := c.Run(r.Context())
+	status := http.StatusOK
+	if resp.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeResponse(w, status, resp)
+}
+
+// writeResponse writes a probe response. Probes must never be cached.
+func writeResponse(w http.ResponseWriter, status int, resp Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
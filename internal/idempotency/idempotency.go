@@ -0,0 +1,63 @@
+// Package idempotency lets clients retry mutating requests without doing
+// the work twice.
+//
+// A request carrying an Idempotency-Key header is served once. Its response
+// is stored with a fingerprint of the request, and retries with the same key
+// are answered with the stored response instead of reaching the handler. A
+// key reused for a different request is rejected, and so is a retry that
+// arrives while the first request is still being served. Keys are scoped to
+// the credentials a request presents, so a response is only ever replayed
+// to a caller holding the same ones.
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Response is a stored response
+type Response struct {
+	Status int         `bson:"status"`
+	Header http.Header `bson:"header"`
+	Body   []byte      `bson:"body"`
+}
+
+// Record is what a store holds per key
+type Record struct {
+	Fingerprint string    `bson:"fingerprint"`
+	Response    *Response `bson:"response"` // nil while the first request is being served
+	// Claim identifies the request that claimed the key, so one whose lease
+	// ran out cannot complete or release the claim of the request that took
+	// the key over
+	Claim string `bson:"claim"`
+}
+
+// ErrClaimLost is returned by Complete when the lease ran out and the key
+// was claimed again meanwhile
+var ErrClaimLost = errors.New("idempotency key was claimed by another request")
+
+// Store keeps records by key. Implementations are safe for concurrent use,
+// and only one caller can claim a key.
+type Store interface {
+	// Begin claims key for a request with fingerprint, for lease at most:
+	// a claim whose request never completes, e.g. as its instance crashed,
+	// is given up then. The claimed record holds the claim to complete or
+	// release it with. A key that is taken is not claimed; its record is
+	// returned instead.
+	Begin(ctx context.Context, key, fingerprint string, lease time.Duration) (rec Record, claimed bool, err error)
+	// Complete stores resp under the key claimed by Begin with claim, kept
+	// for ttl
+	Complete(ctx context.Context, key, claim string, resp Response, ttl time.Duration) error
+	// Release gives the key claimed with claim up without a response, so
+	// the request can be retried, e.g. after it failed. A claim that was
+	// taken over is left alone.
+	Release(ctx context.Context, key, claim string) error
+}
+
+// newClaim returns a random claim
+func newClaim() string {
+	return rand.Text()
+}
@@ -0,0 +1,79 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often expired records are dropped
+const sweepInterval = time.Minute
+
+// MemoryStore keeps records in the process. Retries reaching another
+// instance are not recognised, so it suits a single instance and tests.
+type MemoryStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	records   map[string]*memoryRecord
+	lastSweep time.Time
+}
+
+type memoryRecord struct {
+	Record
+	expires time.Time
+}
+
+// NewMemoryStore returns an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{now: time.Now, records: map[string]*memoryRecord{}}
+}
+
+func (s *MemoryStore) Begin(_ context.Context, key, fingerprint string, lease time.Duration) (Record, bool, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	if rec, ok := s.records[key]; ok && now.Before(rec.expires) {
+		return rec.Record, false, nil
+	}
+	rec := Record{Fingerprint: fingerprint, Claim: newClaim()}
+	s.records[key] = &memoryRecord{Record: rec, expires: now.Add(lease)}
+	return rec, true, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, key, claim string, resp Response, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[key]
+	if !ok || rec.Claim != claim {
+		return ErrClaimLost
+	}
+	rec.Response = &resp
+	rec.expires = s.now().Add(ttl)
+	return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key, claim string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rec, ok := s.records[key]; ok && rec.Claim == claim {
+		delete(s.records, key)
+	}
+	return nil
+}
+
+// sweep drops expired records. The caller holds the lock.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, rec := range s.records {
+		if !now.Before(rec.expires) {
+			delete(s.records, key)
+		}
+	}
+}
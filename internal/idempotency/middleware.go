@@ -0,0 +1,219 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"yoharsh14/krant-backend/internal/apperr"
+	"yoharsh14/krant-backend/internal/json"
+	"yoharsh14/krant-backend/internal/metrics"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Header carries the key, chosen by the client, e.g. a UUID per operation
+const Header = "Idempotency-Key"
+
+// ReplayedHeader marks responses replayed from the store
+const ReplayedHeader = "Idempotent-Replayed"
+
+const maxKeyLength = 255
+
+// MaxBodyBytes is the largest request body that is fingerprinted, above the
+// largest image upload
+var MaxBodyBytes int64 = 16 << 20
+
+var (
+	// ErrInvalidKey answers keys that are too long or not printable ASCII
+	ErrInvalidKey = apperr.Validation(fmt.Sprintf("%s must be 1 to %d printable characters", Header, maxKeyLength)).WithCode("invalid_idempotency_key")
+	// ErrKeyReused answers a key sent again with a different request
+	ErrKeyReused = apperr.New(apperr.KindUnprocessable, Header+" was already used for a different request").WithCode("idempotency_key_reused")
+	// ErrInProgress answers a retry arriving while the first request is served
+	ErrInProgress = apperr.Conflict("a request with this " + Header + " is still being served, retry later").WithCode("idempotency_key_in_use")
+	// ErrBodyTooLarge answers bodies over MaxBodyBytes
+	ErrBodyTooLarge = apperr.New(apperr.KindTooLarge, fmt.Sprintf("request body must not exceed %d bytes", MaxBodyBytes))
+)
+
+var requests = metrics.NewCounterVec("http_idempotent_requests_total",
+	"Requests carrying an Idempotency-Key, by outcome (served, replayed, in_progress, reused).",
+	"outcome")
+
+// keepHeader tells the headers stored with a response from those that
+// describe answering the first request only, such as its rate limit
+func keepHeader(name string) bool {
+	return !strings.HasPrefix(name, "Ratelimit-") && name != "Retry-After" && name != "Date"
+}
+
+// Guard serves requests carrying an Idempotency-Key once
+type Guard struct {
+	store Store
+	ttl   time.Duration
+	lease time.Duration
+}
+
+// NewGuard returns a guard keeping responses in store for ttl. A request
+// being served holds its key for lease, which should outlast the request
+// timeout: a retry after that is served again.
+func NewGuard(store Store, ttl, lease time.Duration) *Guard {
+	return &Guard{store: store, ttl: ttl, lease: lease}
+}
+
+// Middleware applies to POST, PUT, PATCH and DELETE requests with an
+// Idempotency-Key. Keys are scoped to the request's Authorization header,
+// so it may run before authentication; within a scope they are shared by
+// all clients, who should pick random ones. Responses that may not be the
+// request's final answer are not stored, so it can be retried: 5xx, 401,
+// 403 and 429, and those of a handler that panicked.
+func (g *Guard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		if key == "" || !mutating(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !validKey(key) {
+			json.Error(w, r, ErrInvalidKey)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, MaxBodyBytes+1))
+		if err != nil {
+			json.Error(w, r, err)
+			return
+		}
+		if int64(len(body)) > MaxBodyBytes {
+			json.Error(w, r, ErrBodyTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := fingerprintOf(r, body)
+		key = scopeOf(r) + ":" + key
+
+		rec, claimed, err := g.store.Begin(r.Context(), key, fingerprint, g.lease)
+		if err != nil {
+			// as without a key: a broken store must not take the API down
+			slog.WarnContext(r.Context(), "idempotency store failed", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !claimed {
+			g.answer(w, r, rec, fingerprint)
+			return
+		}
+
+		// after the handler returns or panics, the request context may be done
+		ctx := context.WithoutCancel(r.Context())
+		stored := false
+		defer func() {
+			if stored {
+				return
+			}
+			if err := g.store.Release(ctx, key, rec.Claim); err != nil {
+				slog.WarnContext(ctx, "could not release idempotency key", "error", err)
+			}
+		}()
+
+		var buf bytes.Buffer
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Tee(&buf)
+		next.ServeHTTP(ww, r)
+		requests.With("served").Inc()
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK // nothing written
+		}
+		if !final(status) {
+			return
+		}
+		resp := Response{Status: status, Header: http.Header{}, Body: buf.Bytes()}
+		for name, values := range w.Header() {
+			if keepHeader(name) {
+				resp.Header[name] = values
+			}
+		}
+		if err := g.store.Complete(ctx, key, rec.Claim, resp, g.ttl); err != nil {
+			slog.WarnContext(ctx, "could not store idempotent response", "error", err)
+			return
+		}
+		stored = true
+	})
+}
+
+// answer responds to a request whose key is taken: with the stored
+// response when it is a retry of the same request
+func (g *Guard) answer(w http.ResponseWriter, r *http.Request, rec Record, fingerprint string) {
+	switch {
+	case rec.Fingerprint != fingerprint:
+		requests.With("reused").Inc()
+		json.Error(w, r, ErrKeyReused)
+	case rec.Response == nil:
+		requests.With("in_progress").Inc()
+		w.Header().Set("Retry-After", "1")
+		json.Error(w, r, ErrInProgress)
+	default:
+		requests.With("replayed").Inc()
+		h := w.Header()
+		for name, values := range rec.Response.Header {
+			h[name] = values
+		}
+		h.Set(ReplayedHeader, "true")
+		w.WriteHeader(rec.Response.Status)
+		w.Write(rec.Response.Body)
+	}
+}
+
+// final reports whether a response with status is the answer to its
+// request, rather than one a retry may change: a server error, credentials
+// that a retry may fix, or a rate limit that a retry may have waited out
+func final(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return false
+	}
+	return status < http.StatusInternalServerError
+}
+
+// scopeOf identifies the caller by the credentials the request presents,
+// hashed so they are not stored
+func scopeOf(r *http.Request) string {
+	sum := sha256.Sum256([]byte(r.Header.Get("Authorization")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// fingerprintOf identifies a request by what it asks for: method, URL,
+// content type and body
+func fingerprintOf(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n%s\n", r.Method, r.URL.RequestURI(), r.Header.Get("Content-Type"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// validKey accepts up to maxKeyLength printable ASCII characters
+func validKey(key string) bool {
+	if len(key) > maxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,182 @@
+package idempotency
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// server answers POSTs with status and counts them. Each handler waits for
+// release when it is set.
+type server struct {
+	status  atomic.Int32
+	calls   atomic.Int32
+	release chan struct{}
+	panics  bool
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := s.calls.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	if s.panics {
+		panic("boom")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(int(s.status.Load()))
+	io.WriteString(w, `{"call":`+strconv.Itoa(int(n))+`}`)
+}
+
+func newServer(status int) *server {
+	s := &server{}
+	s.status.Store(int32(status))
+	return s
+}
+
+func post(t *testing.T, h http.Handler, key, authorization, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/v1/admin/webhooks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func newGuarded(s http.Handler) http.Handler {
+	return middleware.Recoverer(NewGuard(NewMemoryStore(), time.Hour, time.Minute).Middleware(s))
+}
+
+func TestRetriesAreReplayed(t *testing.T) {
+	s := newServer(http.StatusCreated)
+	h := newGuarded(s)
+
+	first := post(t, h, "k1", "Bearer admin", `{"url":"https://example.com"}`)
+	retry := post(t, h, "k1", "Bearer admin", `{"url":"https://example.com"}`)
+	if s.calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want once", s.calls.Load())
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() || retry.Header().Get(ReplayedHeader) != "true" {
+		t.Errorf("retry = %d %q, replayed %q; want the first response replayed", retry.Code, retry.Body, retry.Header().Get(ReplayedHeader))
+	}
+
+	if reused := post(t, h, "k1", "Bearer admin", `{"url":"https://example.org"}`); reused.Code != http.StatusUnprocessableEntity {
+		t.Errorf("key reused for another body = %d, want 422", reused.Code)
+	}
+	if without := post(t, h, "", "Bearer admin", `{"url":"https://example.com"}`); without.Code != http.StatusCreated || s.calls.Load() != 2 {
+		t.Errorf("request without a key = %d after %d calls, want it served", without.Code, s.calls.Load())
+	}
+}
+
+func TestKeysAreScopedToCredentials(t *testing.T) {
+	s := newServer(http.StatusCreated)
+	h := newGuarded(s)
+
+	post(t, h, "k1", "Bearer admin", `{}`)
+	other := post(t, h, "k1", "", `{}`)
+	if other.Header().Get(ReplayedHeader) != "" || s.calls.Load() != 2 {
+		t.Errorf("same key and body without credentials was replayed the admin's response")
+	}
+}
+
+func TestNonFinalResponsesAreNotStored(t *testing.T) {
+	for _, status := range []int{http.StatusInternalServerError, http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests} {
+		s := newServer(status)
+		h := newGuarded(s)
+		post(t, h, "k1", "Bearer admin", `{}`)
+		s.status.Store(http.StatusCreated)
+		if retry := post(t, h, "k1", "Bearer admin", `{}`); retry.Code != http.StatusCreated || s.calls.Load() != 2 {
+			t.Errorf("retry after a %d = %d after %d calls; want it served again", status, retry.Code, s.calls.Load())
+		}
+	}
+}
+
+func TestPanicsReleaseTheKey(t *testing.T) {
+	s := newServer(http.StatusCreated)
+	s.panics = true
+	h := newGuarded(s)
+	if first := post(t, h, "k1", "", `{}`); first.Code != http.StatusInternalServerError {
+		t.Fatalf("panicking handler = %d, want 500", first.Code)
+	}
+	s.panics = false
+	if retry := post(t, h, "k1", "", `{}`); retry.Code != http.StatusCreated || s.calls.Load() != 2 {
+		t.Errorf("retry after a panic = %d after %d calls; want it served again", retry.Code, s.calls.Load())
+	}
+}
+
+func TestConcurrentDuplicatesAreRefused(t *testing.T) {
+	s := newServer(http.StatusCreated)
+	s.release = make(chan struct{})
+	h := newGuarded(s)
+
+	var wg sync.WaitGroup
+	wg.Go(func() { post(t, h, "k1", "", `{}`) })
+	for s.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	var codes []int
+	var mu sync.Mutex
+	var dups sync.WaitGroup
+	for range 4 {
+		dups.Go(func() {
+			rec := post(t, h, "k1", "", `{}`)
+			mu.Lock()
+			codes = append(codes, rec.Code)
+			mu.Unlock()
+			if rec.Code == http.StatusConflict && rec.Header().Get("Retry-After") == "" {
+				t.Error("409 without Retry-After")
+			}
+		})
+	}
+	dups.Wait()
+	close(s.release)
+	wg.Wait()
+
+	for _, code := range codes {
+		if code != http.StatusConflict {
+			t.Errorf("duplicates while the first is served = %v, want all 409", codes)
+			break
+		}
+	}
+	if s.calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want once", s.calls.Load())
+	}
+}
+
+func TestAbandonedClaimsLapseAfterTheLease(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	if _, claimed, err := store.Begin(t.Context(), "k1", "fp", time.Minute); err != nil || !claimed {
+		t.Fatalf("Begin = %v, %v", claimed, err)
+	}
+
+	// the instance serving it died: no Complete nor Release
+	now = now.Add(time.Minute)
+	rec, claimed, err := store.Begin(t.Context(), "k1", "fp", time.Minute)
+	if err != nil || !claimed {
+		t.Errorf("Begin once the lease ran out = %v, %v; want the key claimed", claimed, err)
+	}
+	if err := store.Complete(t.Context(), "k1", rec.Claim, Response{Status: http.StatusCreated}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(30 * time.Minute)
+	if rec, claimed, _ := store.Begin(t.Context(), "k1", "fp", time.Minute); claimed || rec.Response == nil {
+		t.Errorf("Begin within the TTL of a completed key = %+v, %v; want the response kept", rec, claimed)
+	}
+}
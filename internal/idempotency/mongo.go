@@ -0,0 +1,74 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Collection holds the records of the mongo store. A TTL index on
+// expires_at deletes them, see the migrations.
+const Collection = "idempotency_keys"
+
+// MongoStore keeps records in the database, shared by every instance
+type MongoStore struct {
+	coll *mongo.Collection
+	now  func() time.Time
+}
+
+// NewMongoStore returns a store on the idempotency_keys collection of db
+func NewMongoStore(db *mongo.Database) *MongoStore {
+	return &MongoStore{coll: db.Collection(Collection), now: time.Now}
+}
+
+// Begin claims the key by upserting its record, unless an unexpired one
+// exists: the upsert then collides with it on _id, which is what makes the
+// claim atomic across instances. Expired records the TTL monitor has not
+// deleted yet are taken over, claims whose lease ran out included.
+func (s *MongoStore) Begin(ctx context.Context, key, fingerprint string, lease time.Duration) (Record, bool, error) {
+	now := s.now()
+	claimed := Record{Fingerprint: fingerprint, Claim: newClaim()}
+	_, err := s.coll.UpdateOne(ctx,
+		bson.M{"_id": key, "expires_at": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"fingerprint": fingerprint, "claim": claimed.Claim, "response": nil, "expires_at": now.Add(lease)}},
+		options.UpdateOne().SetUpsert(true),
+	)
+	if err == nil {
+		return claimed, true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return Record{}, false, err
+	}
+
+	var rec Record
+	err = s.coll.FindOne(ctx, bson.M{"_id": key}).Decode(&rec)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// released or expired meanwhile; the client's next retry claims it
+		return Record{Fingerprint: fingerprint}, false, nil
+	}
+	if err != nil {
+		return Record{}, false, err
+	}
+	return rec, false, nil
+}
+
+func (s *MongoStore) Complete(ctx context.Context, key, claim string, resp Response, ttl time.Duration) error {
+	res, err := s.coll.UpdateOne(ctx, bson.M{"_id": key, "claim": claim},
+		bson.M{"$set": bson.M{"response": resp, "expires_at": s.now().Add(ttl)}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrClaimLost
+	}
+	return nil
+}
+
+func (s *MongoStore) Release(ctx context.Context, key, claim string) error {
+	_, err := s.coll.DeleteOne(ctx, bson.M{"_id": key, "claim": claim})
+	return err
+}
@@ -0,0 +1,128 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+	"yoharsh14/krant-backend/internal/repository/repositorytest"
+)
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, func(t *testing.T) Store { return NewMemoryStore() })
+}
+
+func TestMongoStore(t *testing.T) {
+	testStore(t, func(t *testing.T) Store { return NewMongoStore(repositorytest.Database(t)) })
+}
+
+// testStore is the contract every Store implementation must meet
+func testStore(t *testing.T, newStore func(t *testing.T) Store) {
+	ctx := context.Background()
+
+	t.Run("claim, complete and replay", func(t *testing.T) {
+		s := newStore(t)
+		first, claimed, err := s.Begin(ctx, "k1", "fp", time.Minute)
+		if err != nil || !claimed || first.Claim == "" {
+			t.Fatalf("Begin = %+v, %v, %v; want the key claimed", first, claimed, err)
+		}
+		rec, claimed, err := s.Begin(ctx, "k1", "fp", time.Minute)
+		if err != nil || claimed || rec.Fingerprint != "fp" || rec.Response != nil {
+			t.Fatalf("Begin while served = %+v, %v, %v; want the record without a response", rec, claimed, err)
+		}
+
+		resp := Response{Status: http.StatusCreated, Header: http.Header{"Content-Type": {"application/json"}}, Body: []byte(`{"id":"1"}`)}
+		if err := s.Complete(ctx, "k1", first.Claim, resp, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		rec, claimed, err = s.Begin(ctx, "k1", "fp", time.Minute)
+		if err != nil || claimed || rec.Response == nil || rec.Response.Status != http.StatusCreated ||
+			string(rec.Response.Body) != `{"id":"1"}` || rec.Response.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Begin once completed = %+v, %v, %v; want the stored response", rec, claimed, err)
+		}
+	})
+
+	t.Run("release", func(t *testing.T) {
+		s := newStore(t)
+		rec, claimed, err := s.Begin(ctx, "k1", "fp", time.Minute)
+		if err != nil || !claimed {
+			t.Fatalf("Begin = %v, %v", claimed, err)
+		}
+		if err := s.Release(ctx, "k1", rec.Claim); err != nil {
+			t.Fatal(err)
+		}
+		if _, claimed, err := s.Begin(ctx, "k1", "other", time.Minute); err != nil || !claimed {
+			t.Errorf("Begin after Release = %v, %v; want the key claimed again", claimed, err)
+		}
+	})
+
+	t.Run("a lapsed lease is taken over", func(t *testing.T) {
+		s := newStore(t)
+		if _, claimed, err := s.Begin(ctx, "k1", "fp", time.Millisecond); err != nil || !claimed {
+			t.Fatalf("Begin = %v, %v", claimed, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+		if _, claimed, err := s.Begin(ctx, "k1", "fp", time.Minute); err != nil || !claimed {
+			t.Errorf("Begin after the lease = %v, %v; want the key claimed again", claimed, err)
+		}
+	})
+
+	t.Run("a lapsed claim cannot touch the one taking over", func(t *testing.T) {
+		s := newStore(t)
+		late, claimed, err := s.Begin(ctx, "k1", "fp", time.Millisecond)
+		if err != nil || !claimed {
+			t.Fatalf("Begin = %v, %v", claimed, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+		current, claimed, err := s.Begin(ctx, "k1", "fp", time.Minute)
+		if err != nil || !claimed || current.Claim == late.Claim {
+			t.Fatalf("Begin after the lease = %+v, %v, %v; want a new claim", current, claimed, err)
+		}
+
+		// the first request finishes after all
+		if err := s.Complete(ctx, "k1", late.Claim, Response{Status: http.StatusCreated}, time.Hour); !errors.Is(err, ErrClaimLost) {
+			t.Errorf("Complete with the lapsed claim = %v, want ErrClaimLost", err)
+		}
+		if err := s.Release(ctx, "k1", late.Claim); err != nil {
+			t.Fatal(err)
+		}
+		if rec, claimed, err := s.Begin(ctx, "k1", "fp", time.Minute); err != nil || claimed || rec.Response != nil {
+			t.Fatalf("Begin = %+v, %v, %v; want the key still held by the request taking over", rec, claimed, err)
+		}
+
+		if err := s.Complete(ctx, "k1", current.Claim, Response{Status: http.StatusAccepted}, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		if rec, _, err := s.Begin(ctx, "k1", "fp", time.Minute); err != nil || rec.Response == nil || rec.Response.Status != http.StatusAccepted {
+			t.Errorf("Begin once completed = %+v, %v; want the response of the request taking over", rec, err)
+		}
+	})
+
+	t.Run("one of concurrent claims wins", func(t *testing.T) {
+		s := newStore(t)
+		var (
+			wg   sync.WaitGroup
+			mu   sync.Mutex
+			wins int
+		)
+		for range 8 {
+			wg.Go(func() {
+				_, claimed, err := s.Begin(ctx, "k1", "fp", time.Minute)
+				if err != nil {
+					t.Error(err)
+				}
+				if claimed {
+					mu.Lock()
+					wins++
+					mu.Unlock()
+				}
+			})
+		}
+		wg.Wait()
+		if wins != 1 {
+			t.Errorf("%d concurrent claims won, want 1", wins)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package json
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WriteConditional writes data with status 200 like Write, tagged with a
+// strong ETag computed from the encoded body and, unless lastModified is
+// zero, a Last-Modified header. Conditional GETs whose If-None-Match, or
+// failing that If-Modified-Since, shows the client already has this
+// representation are answered 304 without a body.
+func WriteConditional(w http.ResponseWriter, r *http.Request, data any, lastModified time.Time) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(data); err != nil {
+		Error(w, r, err)
+		return
+	}
+	sum := sha256.Sum256(body.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	h := w.Header()
+	h.Set("ETag", etag)
+	lastModified = lastModified.UTC().Truncate(time.Second) // the header's precision
+	if !lastModified.IsZero() {
+		h.Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
+}
+
+// notModified evaluates the request's preconditions as RFC 9110 does for GET
+// and HEAD: If-Modified-Since is only looked at without If-None-Match
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return matchesETag(inm, etag)
+	}
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	return err == nil && !lastModified.After(since)
+}
+
+// matchesETag reports whether the If-None-Match list holds etag, comparing
+// weakly as that header requires, so W/"x" matches "x"
+func matchesETag(list, etag string) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package json
+
+import (
+	"log/slog"
+	"net/http"
+	"yoharsh14/krant-backend/internal/apperr"
+	"yoharsh14/krant-backend/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// statuses maps error kinds to HTTP status codes
+var statuses = map[apperr.Kind]int{
+	apperr.KindNotFound:      http.StatusNotFound,
+	apperr.KindConflict:      http.StatusConflict,
+	apperr.KindValidation:    http.StatusBadRequest,
+	apperr.KindUnauthorized:  http.StatusUnauthorized,
+	apperr.KindForbidden:     http.StatusForbidden,
+	apperr.KindTooLarge:      http.StatusRequestEntityTooLarge,
+	apperr.KindUnsupported:   http.StatusUnsupportedMediaType,
+	apperr.KindRateLimited:   http.StatusTooManyRequests,
+	apperr.KindUnprocessable: http.StatusUnprocessableEntity,
+}
+
+// Error writes err as an ErrorResponse. Typed errors get their kind's status,
+// their code and, for validation errors, the offending fields under
+// details.fields. Anything else is logged with the request's context and
+// answered with a bare 500 so internals never leak to clients.
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	e, ok := apperr.As(err)
+	if !ok {
+		slog.ErrorContext(r.Context(), "request failed", "error", err)
+		Write(w, http.StatusInternalServerError, models.ErrorResponse{Error: "internal_error"})
+		return
+	}
+
+	status, ok := statuses[e.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+
+	resp := models.ErrorResponse{Error: e.Code, Message: err.Error()}
+	if len(e.Fields) > 0 {
+		resp.Details = map[string]interface{}{"fields": e.Fields}
+	}
+	Write(w, status, resp)
+}
+
+// ErrInvalidID is answered for URL parameters that are not object ids
+var ErrInvalidID = apperr.Validation("invalid id").WithCode("invalid_id")
+
+// ObjectID parses the ObjectID URL parameter param. When it is malformed it
+// answers 400 invalid_id naming the parameter and reports false, and the
+// handler should return.
+func ObjectID(w http.ResponseWriter, r *http.Request, param string) (bson.ObjectID, bool) {
+	id, err := bson.ObjectIDFromHex(chi.URLParam(r, param))
+	if err != nil {
+		Error(w, r, ErrInvalidID.WithField(param, "must be a 24 character hex object id"))
+		return bson.ObjectID{}, false
+	}
+	return id, true
+}
@@ -1,18 +1,67 @@
 package json
 
 import (
-    "encoding/json"
-    "net/http"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"yoharsh14/krant-backend/internal/apperr"
+	"yoharsh14/krant-backend/internal/validate"
+)
+
+// MaxBodyBytes is the largest request body Read accepts
+var MaxBodyBytes int64 = 1 << 20
+
+var (
+	// ErrInvalidBody is returned by Read when the request body is not the
+	// expected JSON
+	ErrInvalidBody = apperr.Validation("invalid request body").WithCode("invalid_body")
+	// ErrBodyTooLarge is returned by Read for bodies over MaxBodyBytes
+	ErrBodyTooLarge = apperr.New(apperr.KindTooLarge, fmt.Sprintf("request body must not exceed %d bytes", MaxBodyBytes))
+	// ErrUnsupportedMediaType is returned by Read unless the body is sent as
+	// application/json
+	ErrUnsupportedMediaType = apperr.New(apperr.KindUnsupported, "request body must be application/json")
 )
 
 func Write(w http.ResponseWriter, status int, data any) {
-    w.Header().Set("Content-Type", "application/json")
-    w.WriteHeader(http.StatusOK)
-    json.NewEncoder(w).Encode(data)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
 }
 
+// Read decodes the JSON request body into data and validates it against
+// its binding tags. Bodies that are not application/json, are larger than
+// MaxBodyBytes, malformed, have unknown fields or are empty are rejected, and
+// so are values breaking their rules, with every offending field listed.
 func Read(r *http.Request, data any) error {
-    decoder := json.NewDecoder(r.Body)
-    decoder.DisallowUnknownFields()
-    return decoder.Decode(data)
+	if !isJSON(r.Header.Get("Content-Type")) {
+		return ErrUnsupportedMediaType
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, MaxBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(data); err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			return ErrBodyTooLarge
+		case errors.Is(err, io.EOF):
+			return ErrInvalidBody.WithField("body", "must not be empty")
+		}
+		return fmt.Errorf("%w: %w", ErrInvalidBody, err)
+	}
+	return validate.Struct(data)
+}
+
+// isJSON accepts application/json and the +json types, e.g.
+// application/merge-patch+json
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
 }
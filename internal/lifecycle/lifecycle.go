@@ -0,0 +1,169 @@
+// Package lifecycle starts and stops the parts of the process in order.
+//
+// Components register a Hook. Start hooks run in registration order; stop
+// hooks run in reverse, so whatever was started last (usually the HTTP
+// server) is stopped first and the database connection it depends on is
+// closed last.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Hook is a component's start and stop logic. Either function may be nil.
+type Hook struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Manager runs the registered hooks
+type Manager struct {
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	hooks   []Hook
+	started int // number of hooks whose Start succeeded
+
+	stopping atomic.Bool
+	failOnce sync.Once
+	failed   chan error
+}
+
+func New(logger *slog.Logger) *Manager {
+	return &Manager{
+		logger: logger,
+		failed: make(chan error, 1),
+	}
+}
+
+// Append registers a hook. Hooks must be registered before Start.
+func (m *Manager) Append(h Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, h)
+}
+
+// Start runs the start hooks in order. When one fails, the hooks started so
+// far are stopped again and the error is returned.
+func (m *Manager) Start(ctx context.Context) error {
+	if err := m.start(ctx); err != nil {
+		if stopErr := m.Stop(context.WithoutCancel(ctx)); stopErr != nil {
+			err = errors.Join(err, stopErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// start runs the start hooks in order until one fails, leaving the started
+// ones running
+func (m *Manager) start(ctx context.Context) error {
+	m.mu.Lock()
+	hooks := m.hooks
+	m.mu.Unlock()
+
+	for i, h := range hooks {
+		if h.Start != nil {
+			m.logger.Debug("starting component", "component", h.Name)
+			if err := h.Start(ctx); err != nil {
+				return fmt.Errorf("starting %s: %w", h.Name, err)
+			}
+		}
+		m.mu.Lock()
+		m.started = i + 1
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// Stop runs the stop hooks of every started component in reverse order.
+// All hooks run even if some fail; ctx bounds the whole shutdown.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.stopping.Store(true)
+
+	m.mu.Lock()
+	hooks := m.hooks[:m.started]
+	m.started = 0
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if h.Stop == nil {
+			continue
+		}
+		m.logger.Info("stopping component", "component", h.Name)
+		if err := h.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("stopping %s: %w", h.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Fail reports that a running component broke down, e.g. the HTTP server
+// stopped serving. It makes Run shut everything down and return err.
+func (m *Manager) Fail(err error) {
+	m.failOnce.Do(func() {
+		m.failed <- err
+	})
+}
+
+// Started reports whether every registered component has started and
+// shutdown has not begun
+func (m *Manager) Started() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.started == len(m.hooks) && !m.stopping.Load()
+}
+
+// ShuttingDown reports whether shutdown has begun
+func (m *Manager) ShuttingDown() bool {
+	return m.stopping.Load()
+}
+
+// Run starts every component and blocks until SIGINT or SIGTERM arrives,
+// ctx is cancelled or a component fails. It then stops every component
+// within timeout.
+//
+// Signals are caught from the start on: one arriving while a component is
+// still starting, e.g. migrating the database, cancels the context the
+// component starts with and the components started so far are stopped. A
+// start cut short that way is a shutdown, not an error.
+func (m *Manager) Run(ctx context.Context, timeout time.Duration) error {
+	signals, stopSignals := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	var runErr error
+	if err := m.start(signals); err != nil {
+		if signals.Err() == nil {
+			runErr = err
+		} else {
+			m.logger.Info("shutting down during start", "error", err)
+		}
+	} else {
+		select {
+		case <-signals.Done():
+			m.logger.Info("shutting down")
+		case runErr = <-m.failed:
+			m.logger.Error("component failed, shutting down", "error", runErr)
+		}
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+	if err := m.Stop(stopCtx); err != nil {
+		return errors.Join(runErr, err)
+	}
+	m.logger.Info("shutdown complete")
+	return runErr
+}
@@ -0,0 +1,111 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder logs the hooks run, in order
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) add(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.calls)
+}
+
+// hook records its start and stop; start runs on start unless nil
+func (r *recorder) hook(name string, start func(ctx context.Context) error) Hook {
+	return Hook{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			r.add("start " + name)
+			if start != nil {
+				return start(ctx)
+			}
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			r.add("stop " + name)
+			return nil
+		},
+	}
+}
+
+func newManager() *Manager {
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestStartAndStopOrder(t *testing.T) {
+	var r recorder
+	m := newManager()
+	m.Append(r.hook("db", nil))
+	m.Append(r.hook("http", nil))
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Started() {
+		t.Error("Started = false once every hook started")
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"start db", "start http", "stop http", "stop db"}
+	if got := r.get(); !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+	if !m.ShuttingDown() || m.Started() {
+		t.Error("stopped manager still reports started")
+	}
+}
+
+func TestFailedStartStopsWhatStarted(t *testing.T) {
+	var r recorder
+	broken := errors.New("port taken")
+	m := newManager()
+	m.Append(r.hook("db", nil))
+	m.Append(r.hook("http", func(context.Context) error { return broken }))
+	m.Append(r.hook("worker", nil))
+
+	if err := m.Start(context.Background()); !errors.Is(err, broken) {
+		t.Fatalf("Start = %v, want the hook's error", err)
+	}
+	want := []string{"start db", "start http", "stop db"}
+	if got := r.get(); !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestRunStopsOnFailure(t *testing.T) {
+	var r recorder
+	broken := errors.New("listener closed")
+	m := newManager()
+	m.Append(r.hook("db", nil))
+	m.Append(r.hook("http", func(context.Context) error {
+		m.Fail(broken)
+		return nil
+	}))
+
+	if err := m.Run(context.Background(), time.Second); !errors.Is(err, broken) {
+		t.Fatalf("Run = %v, want the failure", err)
+	}
+	want := []string{"start db", "start http", "stop http", "stop db"}
+	if got := r.get(); !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
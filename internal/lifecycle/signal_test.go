@@ -0,0 +1,40 @@
+//go:build unix
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSignalDuringStartStopsWhatStarted(t *testing.T) {
+	var r recorder
+	m := newManager()
+	m.Append(r.hook("db", nil))
+	m.Append(r.hook("migrations", func(ctx context.Context) error {
+		// SIGTERM arrives while migrating
+		if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return errors.New("start was not cancelled")
+		}
+	}))
+	m.Append(r.hook("http", nil))
+
+	if err := m.Run(context.Background(), time.Second); err != nil {
+		t.Fatalf("Run = %v, want a clean shutdown", err)
+	}
+	want := []string{"start db", "start migrations", "stop db"}
+	if got := r.get(); !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
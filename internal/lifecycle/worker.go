@@ -0,0 +1,44 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+)
+
+// Worker returns a hook running fn in the background. fn receives a context
+// that is cancelled when the hook stops; Stop then waits for fn to return or
+// for the shutdown deadline, whichever comes first.
+func Worker(name string, fn func(ctx context.Context)) Hook {
+	var (
+		cancel context.CancelFunc
+		wg     sync.WaitGroup
+	)
+
+	return Hook{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			var runCtx context.Context
+			runCtx, cancel = context.WithCancel(context.WithoutCancel(ctx))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				fn(runCtx)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			done := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
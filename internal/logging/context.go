@@ -0,0 +1,139 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type requestKey struct{}
+
+// request holds the attributes handlers add while serving a request
+type request struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// Middleware gives each request a place for log attributes and logs one line
+// per request once it has been served. It must be installed with Use on the
+// root router, after middleware.RequestID and before middleware.Recoverer so
+// panics are logged with their 500.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx := context.WithValue(r.Context(), requestKey{}, &request{})
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK // nothing written
+		}
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.Default().LogAttrs(ctx, level, "request served",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", ww.BytesWritten()),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote_addr", r.RemoteAddr),
+		)
+	})
+}
+
+// AddAttrs adds attributes to every record logged for the request ctx
+// belongs to from now on, the request line included. An attribute replaces
+// an earlier one with the same key. Outside a request it does nothing.
+func AddAttrs(ctx context.Context, attrs ...slog.Attr) {
+	req, ok := ctx.Value(requestKey{}).(*request)
+	if !ok {
+		return
+	}
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	for _, a := range attrs {
+		replaced := false
+		for i := range req.attrs {
+			if req.attrs[i].Key == a.Key {
+				req.attrs[i], replaced = a, true
+			}
+		}
+		if !replaced {
+			req.attrs = append(req.attrs, a)
+		}
+	}
+}
+
+// URLParam returns middleware adding the chi URL parameter param to the
+// request's log attributes under key, e.g. URLParam("id", "user_id")
+func URLParam(param, key string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			AddAttrs(r.Context(), slog.String(key, chi.URLParam(r, param)))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestAttrs returns the request id, route and added attributes of the
+// request ctx belongs to
+func requestAttrs(ctx context.Context) []slog.Attr {
+	var attrs []slog.Attr
+	if id := middleware.GetReqID(ctx); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+		attrs = append(attrs, slog.String("route", rctx.RoutePattern()))
+	}
+	if req, ok := ctx.Value(requestKey{}).(*request); ok {
+		req.mu.Lock()
+		attrs = append(attrs, req.attrs...)
+		req.mu.Unlock()
+	}
+	return attrs
+}
+
+// FromContext returns the default logger bound to ctx: records logged without
+// a context of their own still carry the request's attributes and trace
+func FromContext(ctx context.Context) *slog.Logger {
+	return slog.New(boundHandler{Handler: slog.Default().Handler(), ctx: ctx})
+}
+
+type boundHandler struct {
+	slog.Handler
+	ctx context.Context
+}
+
+func (h boundHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.Handler.Enabled(h.pick(ctx), level)
+}
+
+func (h boundHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.Handler.Handle(h.pick(ctx), r)
+}
+
+func (h boundHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return boundHandler{Handler: h.Handler.WithAttrs(attrs), ctx: h.ctx}
+}
+
+func (h boundHandler) WithGroup(name string) slog.Handler {
+	return boundHandler{Handler: h.Handler.WithGroup(name), ctx: h.ctx}
+}
+
+// pick prefers a context passed explicitly, e.g. to InfoContext, over the
+// bound one; Info and friends pass context.Background
+func (h boundHandler) pick(ctx context.Context) context.Context {
+	if ctx == nil || ctx == context.Background() {
+		return h.ctx
+	}
+	return ctx
+}
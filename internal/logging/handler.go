@@ -0,0 +1,32 @@
+package logging
+
+import (
+	"net/http"
+	"yoharsh14/krant-backend/internal/json"
+)
+
+// GetLevels answers the levels in effect
+func GetLevels(levels *Levels) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		json.Write(w, http.StatusOK, levels.Settings())
+	}
+}
+
+// SetLevels replaces the levels with the Settings in the body and answers
+// the new ones. The change is not persisted; a restart goes back to the
+// configured levels.
+func SetLevels(levels *Levels) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var settings Settings
+		if err := json.Read(r, &settings); err != nil {
+			json.Error(w, r, err)
+			return
+		}
+		if err := levels.Apply(settings); err != nil {
+			json.Error(w, r, err)
+			return
+		}
+		FromContext(r.Context()).Info("log levels changed", "default", settings.Default, "packages", settings.Packages)
+		json.Write(w, http.StatusOK, levels.Settings())
+	}
+}
@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"yoharsh14/krant-backend/internal/apperr"
+)
+
+// Settings are the log levels by name: debug, info, warn or error. Packages
+// are named by the last element of their import path, e.g. "news" or
+// "json"; the request log lines come from "logging".
+type Settings struct {
+	Default  string            `json:"default" binding:"required,oneof=debug info warn error"`
+	Packages map[string]string `json:"packages" binding:"dive,oneof=debug info warn error"`
+}
+
+// ErrInvalidLevel is returned by Apply for level names it does not know
+var ErrInvalidLevel = apperr.Validation("invalid log level").WithCode("invalid_log_level")
+
+// Levels is the minimum level logged, by default and per package. It is
+// safe to change while logging.
+type Levels struct {
+	current atomic.Pointer[levelSet]
+}
+
+type levelSet struct {
+	fallback slog.Level
+	packages map[string]slog.Level
+	lowest   slog.Level // the lowest of all, for Enabled
+}
+
+// NewLevels returns the levels described by s
+func NewLevels(s Settings) (*Levels, error) {
+	l := &Levels{}
+	if err := l.Apply(s); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Apply replaces every level with the ones in s. Nothing changes when one of
+// them is invalid.
+func (l *Levels) Apply(s Settings) error {
+	set := &levelSet{packages: make(map[string]slog.Level, len(s.Packages))}
+	problems := map[string]string{}
+	if err := set.fallback.UnmarshalText([]byte(s.Default)); err != nil {
+		problems["default"] = "must be one of debug, info, warn, error"
+	}
+	for pkg, name := range s.Packages {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(name)); err != nil || pkg == "" {
+			problems["packages["+pkg+"]"] = "must be one of debug, info, warn, error"
+			continue
+		}
+		set.packages[pkg] = level
+	}
+	if len(problems) > 0 {
+		err := ErrInvalidLevel
+		for field, problem := range problems {
+			err = err.WithField(field, problem)
+		}
+		return err
+	}
+
+	set.lowest = set.fallback
+	for _, level := range set.packages {
+		set.lowest = min(set.lowest, level)
+	}
+	l.current.Store(set)
+	return nil
+}
+
+// Settings returns the levels in effect
+func (l *Levels) Settings() Settings {
+	set := l.current.Load()
+	s := Settings{Default: levelName(set.fallback), Packages: make(map[string]string, len(set.packages))}
+	for pkg, level := range set.packages {
+		s.Packages[pkg] = levelName(level)
+	}
+	return s
+}
+
+// Level implements slog.Leveler with the lowest level of any package, so
+// handlers pass on every record some package might want
+func (l *Levels) Level() slog.Level {
+	return l.current.Load().lowest
+}
+
+func (l *Levels) enabled(pkg string, level slog.Level) bool {
+	set := l.current.Load()
+	threshold, ok := set.packages[pkg]
+	if !ok {
+		threshold = set.fallback
+	}
+	return level >= threshold
+}
+
+func levelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
@@ -0,0 +1,89 @@
+// Package logging builds the slog handler the process logs through and keeps
+// per-request log attributes in the context.
+//
+// Log with the context whenever there is one:
+//
+//	slog.InfoContext(ctx, "article published", "id", id)
+//	logging.FromContext(ctx).Warn("could not fetch image", "error", err)
+//
+// and the record carries the request_id, route and user_id of the request
+// being served. Levels can be set per package and changed at runtime (see
+// Levels). Emails, bearer tokens and credentials in DSNs are redacted from
+// messages and attributes, and attributes named like secrets are dropped
+// altogether.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// NewHandler returns a handler writing records to w as text or, when format
+// is "json", as JSON lines
+func NewHandler(w io.Writer, format string, levels *Levels) slog.Handler {
+	opts := &slog.HandlerOptions{Level: levels, ReplaceAttr: redactAttr}
+	var next slog.Handler
+	if format == "json" {
+		next = slog.NewJSONHandler(w, opts)
+	} else {
+		next = slog.NewTextHandler(w, opts)
+	}
+	return &handler{next: next, levels: levels}
+}
+
+// handler applies the per package levels and adds the request's attributes
+type handler struct {
+	next   slog.Handler
+	levels *Levels
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	// the package is only known from the record; Handle filters by it
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.levels.enabled(packageOf(r.PC), r.Level) {
+		return nil
+	}
+	r.Message = Redact(r.Message)
+	if attrs := requestAttrs(ctx); len(attrs) > 0 {
+		r = r.Clone()
+		r.AddAttrs(attrs...)
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &handler{next: h.next.WithAttrs(attrs), levels: h.levels}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{next: h.next.WithGroup(name), levels: h.levels}
+}
+
+// packages caches the package name of each logging call site
+var packages sync.Map // program counter to package name
+
+// packageOf returns the name of the package the function at pc belongs to,
+// e.g. "news" for yoharsh14/krant-backend/internal/business/news.(*svc).Get
+func packageOf(pc uintptr) string {
+	if pc == 0 {
+		return ""
+	}
+	if name, ok := packages.Load(pc); ok {
+		return name.(string)
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	name := frame.Function
+	name = name[strings.LastIndexByte(name, '/')+1:]
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[:i]
+	}
+	packages.Store(pc, name)
+	return name
+}
@@ -0,0 +1,121 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	cases := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:2800:21f:cb07:6820:80da:af6b:8b2c", true},
+		{"::ffff:93.184.215.14", true},
+
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"0.1.2.3", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false}, // cloud metadata
+		{"fe80::1", false},
+		{"224.0.0.1", false},
+		{"ff02::1", false},
+		{"255.255.255.255", false},
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"192.0.0.170", false},
+		{"192.0.2.1", false},
+		{"198.18.0.1", false},
+		{"198.19.255.254", false},
+		{"198.51.100.1", false},
+		{"203.0.113.1", false},
+		{"240.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"::ffff:100.64.0.1", false},
+		{"64:ff9b::a9fe:a9fe", false},
+		{"2002:7f00:1::", false},
+		{"2001:db8::1", false},
+	}
+	for _, c := range cases {
+		if got := isPublicAddr(netip.MustParseAddr(c.addr)); got != c.public {
+			t.Errorf("isPublicAddr(%s) = %v, want %v", c.addr, got, c.public)
+		}
+	}
+}
+
+func TestDownloadRefusesInternalHosts(t *testing.T) {
+	var hits int
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Write(png1x1)
+	}))
+	defer internal.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(internal.URL+"/secret", http.StatusFound))
+	defer redirect.Close()
+
+	client := newFetchClient(time.Second)
+	for _, url := range []string{
+		internal.URL,
+		strings.Replace(internal.URL, "127.0.0.1", "[::ffff:127.0.0.1]", 1),
+		strings.Replace(internal.URL, "127.0.0.1", "localhost", 1),
+		redirect.URL,
+	} {
+		if _, err := download(context.Background(), client, url, MaxImageBytes); !errors.Is(err, ErrForbiddenHost) || !strings.Contains(err.Error(), ErrForbiddenHost.Error()) {
+			t.Errorf("download(%s) = %v, want ErrForbiddenHost", url, err)
+		}
+	}
+	if hits != 0 {
+		t.Errorf("the internal server was reached %d times", hits)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image.png":
+			w.Write(png1x1)
+		case "/large.png":
+			w.Header().Set("Content-Length", "2048")
+			w.Write(make([]byte, 2048))
+		case "/chunked.png":
+			w.(http.Flusher).Flush() // no Content-Length
+			w.Write(make([]byte, 2048))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	data, err := download(ctx, srv.Client(), srv.URL+"/image.png", 1024)
+	if err != nil || string(data) != string(png1x1) {
+		t.Errorf("download = %d bytes, %v; want the image", len(data), err)
+	}
+	for _, path := range []string{"/large.png", "/chunked.png"} {
+		if _, err := download(ctx, srv.Client(), srv.URL+path, 1024); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("download(%s) = %v, want ErrTooLarge", path, err)
+		}
+	}
+	if _, err := download(ctx, srv.Client(), srv.URL+"/missing.png", 1024); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("download of a missing image = %v, want the status", err)
+	}
+	for _, url := range []string{"ftp://example.com/image.png", "file:///etc/passwd", "/image.png", "http://", "http://exa mple.com/"} {
+		if _, err := download(ctx, srv.Client(), url, 1024); !errors.Is(err, ErrInvalidURL) || !strings.Contains(err.Error(), ErrInvalidURL.Error()) {
+			t.Errorf("download(%q) = %v, want ErrInvalidURL", url, err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package media
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"yoharsh14/krant-backend/internal/apperr"
+	"yoharsh14/krant-backend/internal/json"
+	"yoharsh14/krant-backend/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler interface {
+	UploadImage(w http.ResponseWriter, r *http.Request)
+	GetImage(w http.ResponseWriter, r *http.Request)
+}
+
+type h struct {
+	service Service
+}
+
+func NewHandler(service Service) Handler {
+	return &h{
+		service: service,
+	}
+}
+
+var (
+	errInvalidPreset   = apperr.Validation("unknown preset").WithCode("invalid_preset").WithField("preset", "must be news or avatar")
+	errUnsupportedBody = apperr.New(apperr.KindUnsupported, "request body must be multipart/form-data or application/json")
+)
+
+// fetchImageInput asks the server to fetch an image instead of uploading it
+type fetchImageInput struct {
+	URL string `json:"url" binding:"required"`
+}
+
+// UploadImage accepts either a multipart upload in the "file" field or a
+// JSON body with a URL to fetch. ?preset=news|avatar picks the widths.
+func (h *h) UploadImage(w http.ResponseWriter, r *http.Request) {
+	preset, ok := PresetByName(r.URL.Query().Get("preset"))
+	if !ok {
+		json.Error(w, r, errInvalidPreset)
+		return
+	}
+
+	var (
+		img *models.Image
+		err error
+	)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "multipart/form-data":
+		r.Body = http.MaxBytesReader(w, r.Body, MaxImageBytes+1<<20)
+		file, _, ferr := r.FormFile("file")
+		if ferr != nil {
+			json.Error(w, r, fmt.Errorf("%w: %w", json.ErrInvalidBody, ferr))
+			return
+		}
+		defer file.Close()
+		img, err = h.service.Upload(r.Context(), file, preset)
+	case "application/json":
+		var input fetchImageInput
+		if rerr := json.Read(r, &input); rerr != nil {
+			json.Error(w, r, rerr)
+			return
+		}
+		img, err = h.service.Fetch(r.Context(), input.URL, preset)
+	default:
+		json.Error(w, r, errUnsupportedBody)
+		return
+	}
+
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusCreated, img.ToResponse())
+}
+
+func (h *h) GetImage(w http.ResponseWriter, r *http.Request) {
+	img, err := h.service.GetImage(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		json.Error(w, r, err)
+		return
+	}
+	json.Write(w, http.StatusOK, img.ToResponse())
+}
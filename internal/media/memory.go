@@ -0,0 +1,43 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/repository"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// memoryRepository is the in-memory Repository, meant for tests
+type memoryRepository struct {
+	coll *repository.Memory
+}
+
+// NewMemoryRepository returns an empty in-memory Repository
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		coll: repository.NewMemory(),
+	}
+}
+
+func (r *memoryRepository) Create(ctx context.Context, img *models.Image) error {
+	err := r.coll.InsertOne(ctx, img)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+func (r *memoryRepository) FindByID(ctx context.Context, id string) (*models.Image, error) {
+	var img models.Image
+	err := r.coll.FindOne(ctx, bson.M{"_id": id}, &img)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // Image not found
+		}
+		return nil, err
+	}
+	return &img, nil
+}
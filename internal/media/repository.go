@@ -0,0 +1,51 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"yoharsh14/krant-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Repository stores image metadata. NewRepository is backed by MongoDB and
+// NewMemoryRepository keeps everything in memory; both behave the same.
+// Lookups return nil, nil when nothing matches.
+type Repository interface {
+	Create(ctx context.Context, img *models.Image) error
+	FindByID(ctx context.Context, id string) (*models.Image, error)
+}
+
+type mongoRepository struct {
+	coll *mongo.Collection
+}
+
+func NewRepository(db *mongo.Database) Repository {
+	return &mongoRepository{
+		coll: db.Collection("images"),
+	}
+}
+
+// Create stores image metadata. Storing the same content twice is not an
+// error because IDs are content hashes.
+func (r *mongoRepository) Create(ctx context.Context, img *models.Image) error {
+	_, err := r.coll.InsertOne(ctx, img)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// FindByID retrieves image metadata by its ID
+func (r *mongoRepository) FindByID(ctx context.Context, id string) (*models.Image, error) {
+	var img models.Image
+	err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&img)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // Image not found
+		}
+		return nil, err
+	}
+	return &img, nil
+}
@@ -0,0 +1,60 @@
+package media
+
+import (
+	"context"
+	"testing"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/repository/repositorytest"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewMemoryRepository() })
+}
+
+func TestMongoRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewRepository(repositorytest.Database(t)) })
+}
+
+// testRepository is the contract every Repository implementation must meet
+func testRepository(t *testing.T, newRepo func(t *testing.T) Repository) {
+	ctx := context.Background()
+
+	t.Run("create and find", func(t *testing.T) {
+		r := newRepo(t)
+		img := &models.Image{
+			ID:          "0123456789abcdef01234567",
+			ContentType: "image/png",
+			Width:       1000,
+			Height:      500,
+			Original:    models.ImageVariant{Key: "images/0123456789abcdef01234567/original.png", Width: 1000, Height: 500},
+			Variants:    []models.ImageVariant{{Key: "images/0123456789abcdef01234567/w320.jpg", Width: 320, Height: 160}},
+			CreatedAt:   time.Now().Truncate(time.Millisecond),
+		}
+		if err := r.Create(ctx, img); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		got, err := r.FindByID(ctx, img.ID)
+		if err != nil || got == nil {
+			t.Fatalf("FindByID = %v, %v", got, err)
+		}
+		if got.Width != 1000 || got.Original.Key != img.Original.Key || len(got.Variants) != 1 || got.Variants[0].Width != 320 || !got.CreatedAt.Equal(img.CreatedAt) {
+			t.Errorf("FindByID = %+v, want %+v", got, img)
+		}
+		if got, err := r.FindByID(ctx, "missing"); got != nil || err != nil {
+			t.Errorf("FindByID(missing) = %v, %v, want nil, nil", got, err)
+		}
+	})
+
+	t.Run("storing the same content twice", func(t *testing.T) {
+		r := newRepo(t)
+		img := &models.Image{ID: "fedcba9876543210fedcba98", ContentType: "image/png", Width: 10, Height: 10, Variants: []models.ImageVariant{}}
+		if err := r.Create(ctx, img); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if err := r.Create(ctx, img); err != nil {
+			t.Errorf("Create of a stored image = %v, want nil", err)
+		}
+	})
+}
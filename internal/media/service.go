@@ -0,0 +1,253 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"yoharsh14/krant-backend/internal/apperr"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/models"
+
+	_ "image/gif" // register decoders for image.Decode
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	MaxImageBytes  = 10 << 20   // 10 MiB
+	MaxImagePixels = 40_000_000 // guards against decompression bombs
+	jpegQuality    = 82
+)
+
+var (
+	ErrImageNotFound   = apperr.NotFound("image not found")
+	ErrUnsupportedType = apperr.New(apperr.KindUnprocessable, "unsupported image type").WithCode("invalid_image")
+	ErrTooLarge        = apperr.New(apperr.KindTooLarge, "image is too large").WithCode("image_too_large")
+	ErrInvalidURL      = apperr.New(apperr.KindUnprocessable, "invalid image url").WithCode("invalid_image")
+	ErrRemoteDisabled  = apperr.Forbidden("fetching remote images is disabled").WithCode("remote_images_disabled")
+)
+
+// allowedTypes maps sniffed content types to file extensions
+var allowedTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// Preset is a named set of thumbnail widths
+type Preset struct {
+	Name   string
+	Widths []int // ascending
+}
+
+var (
+	PresetNews   = Preset{Name: "news", Widths: []int{320, 640, 960, 1280, 1920}}
+	PresetAvatar = Preset{Name: "avatar", Widths: []int{48, 96, 192, 384}}
+)
+
+// PresetByName returns the preset with the given name, defaulting to news
+func PresetByName(name string) (Preset, bool) {
+	switch name {
+	case "", PresetNews.Name:
+		return PresetNews, true
+	case PresetAvatar.Name:
+		return PresetAvatar, true
+	}
+	return Preset{}, false
+}
+
+type Service interface {
+	Upload(ctx context.Context, r io.Reader, preset Preset) (*models.Image, error)
+	Fetch(ctx context.Context, url string, preset Preset) (*models.Image, error)
+	GetImage(ctx context.Context, id string) (*models.Image, error)
+}
+
+type svc struct {
+	r       Repository
+	storage Storage
+	client  *http.Client
+	remote  bool // whether Fetch may download images
+}
+
+func NewService(repo Repository, storage Storage, cfg config.Media, features config.Features) Service {
+	return &svc{
+		r:       repo,
+		storage: storage,
+		client:  newFetchClient(cfg.FetchTimeout),
+		remote:  features.RemoteImages,
+	}
+}
+
+// Upload validates an uploaded image and stores it with its thumbnails
+func (s *svc) Upload(ctx context.Context, r io.Reader, preset Preset) (*models.Image, error) {
+	data, err := readLimited(r, MaxImageBytes)
+	if err != nil {
+		return nil, err
+	}
+	return s.process(ctx, data, "", preset)
+}
+
+// Fetch downloads an image from a public URL and stores it with its thumbnails
+func (s *svc) Fetch(ctx context.Context, url string, preset Preset) (*models.Image, error) {
+	if !s.remote {
+		return nil, ErrRemoteDisabled
+	}
+	data, err := download(ctx, s.client, url, MaxImageBytes)
+	if err != nil {
+		return nil, err
+	}
+	return s.process(ctx, data, url, preset)
+}
+
+// GetImage retrieves image metadata by its ID
+func (s *svc) GetImage(ctx context.Context, id string) (*models.Image, error) {
+	img, err := s.r.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if img == nil {
+		return nil, ErrImageNotFound
+	}
+	return img, nil
+}
+
+// process validates the bytes, generates the preset's thumbnails and
+// stores everything. Identical content is only processed once.
+func (s *svc) process(ctx context.Context, data []byte, sourceURL string, preset Preset) (*models.Image, error) {
+	contentType := http.DetectContentType(data)
+	ext, ok := allowedTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	sum := sha256.Sum256(append([]byte(preset.Name+":"), data...))
+	id := hex.EncodeToString(sum[:12])
+
+	if existing, err := s.r.FindByID(ctx, id); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return existing, nil
+	}
+
+	// check dimensions before decoding the full bitmap
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxImagePixels {
+		return nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+
+	img := &models.Image{
+		ID:          id,
+		SourceURL:   sourceURL,
+		ContentType: contentType,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		Size:        int64(len(data)),
+		Variants:    []models.ImageVariant{},
+		CreatedAt:   time.Now(),
+	}
+
+	originalKey := "images/" + id + "/original" + ext
+	if err := s.storage.Put(ctx, originalKey, bytes.NewReader(data), contentType); err != nil {
+		return nil, err
+	}
+	img.Original = models.ImageVariant{
+		Key:         originalKey,
+		URL:         s.storage.URL(originalKey),
+		ContentType: contentType,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+	}
+
+	for _, width := range thumbnailWidths(cfg.Width, preset.Widths) {
+		variant, err := s.thumbnail(ctx, src, id, width)
+		if err != nil {
+			return nil, err
+		}
+		img.Variants = append(img.Variants, variant)
+	}
+
+	if err := s.r.Create(ctx, img); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// thumbnail resizes src to the given width and stores the result
+func (s *svc) thumbnail(ctx context.Context, src image.Image, id string, width int) (models.ImageVariant, error) {
+	bounds := src.Bounds()
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	contentType, ext := "image/jpeg", ".jpg"
+	if isOpaque(src) {
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return models.ImageVariant{}, err
+		}
+	} else {
+		contentType, ext = "image/png", ".png"
+		if err := png.Encode(&buf, dst); err != nil {
+			return models.ImageVariant{}, err
+		}
+	}
+
+	key := "images/" + id + "/w" + strconv.Itoa(width) + ext
+	if err := s.storage.Put(ctx, key, &buf, contentType); err != nil {
+		return models.ImageVariant{}, err
+	}
+
+	return models.ImageVariant{
+		Key:         key,
+		URL:         s.storage.URL(key),
+		ContentType: contentType,
+		Width:       width,
+		Height:      height,
+	}, nil
+}
+
+// thumbnailWidths returns the preset widths smaller than the original.
+// Images narrower than every preset width get one re-encoded copy so
+// clients always have at least one optimized variant.
+func thumbnailWidths(original int, widths []int) []int {
+	var out []int
+	for _, w := range widths {
+		if w < original {
+			out = append(out, w)
+		}
+	}
+	if len(out) == 0 {
+		out = append(out, original)
+	}
+	return out
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
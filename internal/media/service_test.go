@@ -0,0 +1,203 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"yoharsh14/krant-backend/internal/config"
+	"yoharsh14/krant-backend/internal/models"
+)
+
+var png1x1 = encodePNG(image.NewNRGBA(image.Rect(0, 0, 1, 1)))
+
+func encodePNG(img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// testImage is a width by height PNG, opaque or see-through
+func testImage(width, height int, opaque bool) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			c := color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255}
+			if !opaque {
+				c.A = 128
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return encodePNG(img)
+}
+
+// withSize rewrites the dimensions in a PNG header, leaving the pixel data
+// as it was
+func withSize(data []byte, width, height uint32) []byte {
+	data = slices.Clone(data)
+	ihdr := data[12:29] // chunk type and data, after the signature and length
+	binary.BigEndian.PutUint32(ihdr[4:], width)
+	binary.BigEndian.PutUint32(ihdr[8:], height)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(ihdr))
+	return data
+}
+
+func newTestService(t *testing.T) (Service, *LocalStorage) {
+	t.Helper()
+	storage, err := NewLocalStorage(t.TempDir(), "/media")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := NewMemoryRepository()
+	return NewService(repo, storage, config.Default().Media, config.Features{}), storage
+}
+
+func TestUploadValidates(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := context.Background()
+
+	cases := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"not an image", []byte("<svg onload=alert(1)></svg>"), ErrUnsupportedType},
+		{"empty", nil, ErrUnsupportedType},
+		{"corrupt", png1x1[:40], ErrUnsupportedType},
+		{"over the byte limit", append(slices.Clone(png1x1), make([]byte, MaxImageBytes)...), ErrTooLarge},
+		{"over the pixel limit", withSize(png1x1, 10_000, 10_000), ErrTooLarge},
+		{"no pixels", withSize(png1x1, 0, 1), ErrUnsupportedType},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			img, err := s.Upload(ctx, bytes.NewReader(c.data), PresetNews)
+			if !errors.Is(err, c.want) || !strings.Contains(err.Error(), c.want.Error()) {
+				t.Errorf("Upload = %v, %v; want %v", img, err, c.want)
+			}
+		})
+	}
+}
+
+func TestUploadResizes(t *testing.T) {
+	s, storage := newTestService(t)
+	ctx := context.Background()
+
+	stored := func(t *testing.T, v models.ImageVariant) image.Config {
+		t.Helper()
+		if v.URL != "/media/"+v.Key {
+			t.Errorf("variant %s has URL %s", v.Key, v.URL)
+		}
+		f, err := os.Open(filepath.Join(storage.root, filepath.FromSlash(v.Key)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		cfg, format, err := image.DecodeConfig(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if "image/"+format != v.ContentType {
+			t.Errorf("variant %s is %s, recorded as %s", v.Key, format, v.ContentType)
+		}
+		return cfg
+	}
+
+	t.Run("preset widths below the original", func(t *testing.T) {
+		img, err := s.Upload(ctx, bytes.NewReader(testImage(1000, 500, true)), PresetNews)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.Width != 1000 || img.Height != 500 || img.ContentType != "image/png" {
+			t.Errorf("image is %dx%d %s, want 1000x500 image/png", img.Width, img.Height, img.ContentType)
+		}
+		if cfg := stored(t, img.Original); cfg.Width != 1000 || cfg.Height != 500 {
+			t.Errorf("stored original is %dx%d", cfg.Width, cfg.Height)
+		}
+
+		var widths []int
+		for _, v := range img.Variants {
+			widths = append(widths, v.Width)
+			if v.Height != v.Width/2 || v.ContentType != "image/jpeg" {
+				t.Errorf("variant %s is %dx%d %s, want half as high and a JPEG", v.Key, v.Width, v.Height, v.ContentType)
+			}
+			if cfg := stored(t, v); cfg.Width != v.Width || cfg.Height != v.Height {
+				t.Errorf("stored variant %s is %dx%d, recorded as %dx%d", v.Key, cfg.Width, cfg.Height, v.Width, v.Height)
+			}
+		}
+		if want := []int{320, 640, 960}; !slices.Equal(widths, want) {
+			t.Errorf("variant widths %v, want %v", widths, want)
+		}
+
+		again, err := s.Upload(ctx, bytes.NewReader(testImage(1000, 500, true)), PresetNews)
+		if err != nil || again.ID != img.ID {
+			t.Errorf("uploading the same image again = %v, %v; want image %s", again, err, img.ID)
+		}
+		avatar, err := s.Upload(ctx, bytes.NewReader(testImage(1000, 500, true)), PresetAvatar)
+		if err != nil || avatar.ID == img.ID || len(avatar.Variants) != len(PresetAvatar.Widths) {
+			t.Errorf("uploading it as an avatar = %v, %v; want a new image with every avatar width", avatar, err)
+		}
+		if got, err := s.GetImage(ctx, img.ID); err != nil || got.ID != img.ID {
+			t.Errorf("GetImage = %v, %v", got, err)
+		}
+	})
+
+	t.Run("one variant for a small image", func(t *testing.T) {
+		img, err := s.Upload(ctx, bytes.NewReader(testImage(100, 40, true)), PresetNews)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(img.Variants) != 1 || img.Variants[0].Width != 100 || img.Variants[0].Height != 40 {
+			t.Errorf("variants %+v, want one at the original size", img.Variants)
+		}
+	})
+
+	t.Run("transparent images stay PNG", func(t *testing.T) {
+		img, err := s.Upload(ctx, bytes.NewReader(testImage(400, 400, false)), PresetNews)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range img.Variants {
+			if v.ContentType != "image/png" {
+				t.Errorf("variant %s is %s, want image/png", v.Key, v.ContentType)
+			}
+			stored(t, v)
+		}
+	})
+}
+
+func TestFetchNeedsRemoteImages(t *testing.T) {
+	s := NewService(nil, nil, config.Default().Media, config.Features{})
+	if _, err := s.Fetch(context.Background(), "https://example.com/image.png", PresetNews); !errors.Is(err, ErrRemoteDisabled) {
+		t.Errorf("Fetch = %v, want ErrRemoteDisabled", err)
+	}
+}
+
+func TestThumbnailWidths(t *testing.T) {
+	cases := []struct {
+		original int
+		want     []int
+	}{
+		{2000, []int{320, 640, 960, 1280, 1920}},
+		{1280, []int{320, 640, 960}},
+		{321, []int{320}},
+		{320, []int{320}},
+		{100, []int{100}},
+	}
+	for _, c := range cases {
+		if got := thumbnailWidths(c.original, PresetNews.Widths); !slices.Equal(got, c.want) {
+			t.Errorf("thumbnailWidths(%d) = %v, want %v", c.original, got, c.want)
+		}
+	}
+}
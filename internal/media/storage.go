@@ -0,0 +1,111 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage keeps image bytes under slash separated keys
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// LocalStorage stores files below a root directory and serves them
+// under a public base URL
+type LocalStorage struct {
+	root    string
+	baseURL string
+}
+
+func NewLocalStorage(root, baseURL string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{
+		root:    root,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}, nil
+}
+
+// Put writes to a temporary file first so readers never see partial images
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (s *LocalStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(name)
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// Handler serves stored files. Keys are content addressed so responses
+// can be cached forever. Directory listings are not exposed.
+func (s *LocalStorage) Handler() http.Handler {
+	files := http.FileServer(http.Dir(s.root))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		files.ServeHTTP(w, r)
+	})
+}
+
+// path maps a key to a file below root, refusing keys that escape it
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean != "/"+key {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
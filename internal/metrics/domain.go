@@ -0,0 +1,33 @@
+package metrics
+
+// Domain counters, incremented by subscribers to the events they count
+var (
+	Signups = NewCounter("krant_user_signups_total",
+		"Users created.")
+	NewsPublished = NewCounter("krant_news_published_total",
+		"Articles updated to the published status.")
+	NotificationsSent = NewCounterVec("krant_notifications_sent_total",
+		"Notifications created, by type.",
+		"type")
+)
+
+// Worker metrics, fed by the background workers
+var (
+	IngestionLag = NewGaugeFunc("krant_ingestion_lag_seconds",
+		"Seconds since the newest article was ingested.")
+	WebhookDeliveries = NewCounterVec("krant_webhook_deliveries_total",
+		"Webhook delivery attempts, by event and result (succeeded, retried, dead).",
+		"event", "result")
+	EventsRelayed = NewCounterVec("krant_events_relayed_total",
+		"Domain event relay attempts, by type and result (dispatched, retried, failed).",
+		"type", "result")
+	JobsRun = NewCounterVec("krant_jobs_total",
+		"Background job runs, by queue, type and result (succeeded, retried, dead).",
+		"queue", "type", "result")
+	JobQueueDepth = NewGaugeVec("krant_job_queue_depth",
+		"Background jobs due and waiting for a worker, by queue.",
+		"queue")
+	ChangesHandled = NewCounterVec("krant_changes_handled_total",
+		"Database changes handed to watch handlers, by watch and result (handled, failed).",
+		"watch", "result")
+)
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+var (
+	httpRequests = NewCounterVec("http_requests_total",
+		"HTTP requests served, by method, chi route pattern and status.",
+		"method", "route", "status")
+	httpDuration = NewHistogramVec("http_request_duration_seconds",
+		"Time to serve HTTP requests, by method, chi route pattern and status.",
+		DefBuckets, "method", "route", "status")
+	httpInFlight = NewGauge("http_requests_in_flight",
+		"HTTP requests being served.")
+)
+
+// unmatchedRoute labels requests no route matched, so that random paths do
+// not each get a series
+const unmatchedRoute = "unmatched"
+
+// Middleware records the count and latency of requests by route pattern. It
+// must be installed with Use on the root router so the pattern is known once
+// the request has been served.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		httpInFlight.Inc()
+		defer httpInFlight.Dec()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := unmatchedRoute
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK // nothing written
+		}
+		labels := []string{method(r.Method), route, strconv.Itoa(status)}
+		httpRequests.With(labels...).Inc()
+		httpDuration.With(labels...).Observe(time.Since(start).Seconds())
+	})
+}
+
+// method keeps the method label to the standard methods
+func method(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return m
+	}
+	return "OTHER"
+}
@@ -0,0 +1,140 @@
+// Package metrics keeps counters, gauges and histograms and serves them in
+// the Prometheus text exposition format.
+//
+// Metrics are package level values registered on creation, like expvar:
+//
+//	var signups = metrics.NewCounter("krant_user_signups_total", "Users created.")
+//
+//	signups.Inc()
+//
+// Labelled metrics are vectors; With picks the child for a set of label
+// values, which must be few and bounded (a route pattern, never a URL).
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// collector is a metric family the registry can expose
+type collector interface {
+	name() string
+	help() string
+	kind() string // counter, gauge or histogram
+	write(ctx context.Context, w *bufio.Writer)
+}
+
+// Registry holds the metric families exposed together
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{collectors: map[string]collector{}}
+}
+
+// Default is the registry the New* functions register with
+var Default = NewRegistry()
+
+var validName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// register adds c. Invalid or duplicate names are programming errors and
+// panic at init.
+func (r *Registry) register(c collector) {
+	if !validName.MatchString(c.name()) {
+		panic(fmt.Sprintf("metrics: invalid name %q", c.name()))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.collectors[c.name()]; ok {
+		panic(fmt.Sprintf("metrics: %s registered twice", c.name()))
+	}
+	r.collectors[c.name()] = c
+}
+
+// Write writes every family in the text exposition format, sorted by name
+func (r *Registry) Write(ctx context.Context, w *bufio.Writer) error {
+	r.mu.Lock()
+	collectors := make([]collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mu.Unlock()
+	slices.SortFunc(collectors, func(a, b collector) int { return strings.Compare(a.name(), b.name()) })
+
+	for _, c := range collectors {
+		fmt.Fprintf(w, "# HELP %s %s\n", c.name(), escapeHelp(c.help()))
+		fmt.Fprintf(w, "# TYPE %s %s\n", c.name(), c.kind())
+		c.write(ctx, w)
+	}
+	return w.Flush()
+}
+
+// Handler serves the registry to Prometheus scrapes
+func (r *Registry) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		r.Write(req.Context(), bufio.NewWriter(w))
+	}
+}
+
+// Handler serves the Default registry
+func Handler() http.HandlerFunc {
+	return Default.Handler()
+}
+
+// ============================================================================
+// TEXT FORMAT
+// ============================================================================
+
+// writeSample writes one line: name{labels} value
+func writeSample(w *bufio.Writer, name string, labels, values []string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(label)
+			w.WriteString(`="`)
+			w.WriteString(escapeLabel(values[i]))
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"yoharsh14/krant-backend/internal/mongocmd"
+
+	"go.mongodb.org/mongo-driver/v2/event"
+)
+
+var (
+	mongoDuration = NewHistogramVec("mongodb_command_duration_seconds",
+		"Time for MongoDB commands to complete, by command and collection.",
+		DefBuckets, "command", "collection")
+	mongoErrors = NewCounterVec("mongodb_command_errors_total",
+		"MongoDB commands that failed, by command and collection.",
+		"command", "collection")
+)
+
+// CommandMonitor returns a driver monitor recording the latency and failures
+// of every command. Set it with options.Client().SetMonitor.
+func CommandMonitor() *event.CommandMonitor {
+	// the collection is only in the started event; keep it until the command
+	// finishes
+	var collections sync.Map // request id to collection
+
+	finished := func(e event.CommandFinishedEvent) string {
+		collection, _ := collections.LoadAndDelete(e.RequestID)
+		name, _ := collection.(string)
+		mongoDuration.With(e.CommandName, name).Observe(e.Duration.Seconds())
+		return name
+	}
+
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, e *event.CommandStartedEvent) {
+			if collection, ok := mongocmd.Collection(e); ok {
+				collections.Store(e.RequestID, collection)
+			}
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			finished(e.CommandFinishedEvent)
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			collection := finished(e.CommandFinishedEvent)
+			mongoErrors.With(e.CommandName, collection).Inc()
+		},
+	}
+}
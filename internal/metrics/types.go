@@ -0,0 +1,312 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefBuckets suit latencies in seconds, from 5ms to 10s
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// ExponentialBuckets returns count buckets, the first at start and each
+// factor times the one before
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return buckets
+}
+
+// ============================================================================
+// VECTORS
+// ============================================================================
+
+// vec is a family of metrics of one type told apart by label values
+type vec[M any] struct {
+	metricName string
+	helpText   string
+	labels     []string
+	newChild   func() *M
+
+	mu       sync.RWMutex
+	children map[string]*child[M]
+}
+
+type child[M any] struct {
+	values []string
+	metric *M
+}
+
+func newVec[M any](name, help string, labels []string, newChild func() *M) *vec[M] {
+	return &vec[M]{metricName: name, helpText: help, labels: labels, newChild: newChild, children: map[string]*child[M]{}}
+}
+
+func (v *vec[M]) name() string { return v.metricName }
+func (v *vec[M]) help() string { return v.helpText }
+
+// with returns the child for values, creating it on first use
+func (v *vec[M]) with(values []string) *M {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s takes labels %v, got %d values", v.metricName, v.labels, len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	c, ok := v.children[key]
+	v.mu.RUnlock()
+	if ok {
+		return c.metric
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c, ok := v.children[key]; ok {
+		return c.metric
+	}
+	c = &child[M]{values: slices.Clone(values), metric: v.newChild()}
+	v.children[key] = c
+	return c.metric
+}
+
+// each calls fn for every child, ordered by label values
+func (v *vec[M]) each(fn func(values []string, m *M)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.children))
+	for k := range v.children {
+		keys = append(keys, k)
+	}
+	children := make([]*child[M], 0, len(keys))
+	slices.Sort(keys)
+	for _, k := range keys {
+		children = append(children, v.children[k])
+	}
+	v.mu.RUnlock()
+
+	for _, c := range children {
+		fn(c.values, c.metric)
+	}
+}
+
+// atomicFloat is a float64 updated without locks
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) add(delta float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) set(v float64) { f.bits.Store(math.Float64bits(v)) }
+func (f *atomicFloat) get() float64  { return math.Float64frombits(f.bits.Load()) }
+
+// ============================================================================
+// COUNTER
+// ============================================================================
+
+// Counter only goes up, e.g. requests served
+type Counter struct {
+	value atomicFloat
+}
+
+// Inc adds one
+func (c *Counter) Inc() { c.value.add(1) }
+
+// Add adds delta, which must not be negative
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	c.value.add(delta)
+}
+
+// CounterVec is a counter per set of label values
+type CounterVec struct {
+	*vec[Counter]
+}
+
+// NewCounterVec registers a counter family with the given labels
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{newVec(name, help, labels, func() *Counter { return &Counter{} })}
+	Default.register(v)
+	return v
+}
+
+// NewCounter registers a counter without labels
+func NewCounter(name, help string) *Counter {
+	return NewCounterVec(name, help).With()
+}
+
+// With returns the counter for the label values, in label order
+func (v *CounterVec) With(values ...string) *Counter { return v.with(values) }
+
+func (v *CounterVec) kind() string { return "counter" }
+
+func (v *CounterVec) write(_ context.Context, w *bufio.Writer) {
+	v.each(func(values []string, c *Counter) {
+		writeSample(w, v.metricName, v.labels, values, c.value.get())
+	})
+}
+
+// ============================================================================
+// GAUGE
+// ============================================================================
+
+// Gauge goes up and down, e.g. queue depth
+type Gauge struct {
+	value atomicFloat
+}
+
+func (g *Gauge) Set(v float64)     { g.value.set(v) }
+func (g *Gauge) Add(delta float64) { g.value.add(delta) }
+func (g *Gauge) Inc()              { g.value.add(1) }
+func (g *Gauge) Dec()              { g.value.add(-1) }
+
+// GaugeVec is a gauge per set of label values
+type GaugeVec struct {
+	*vec[Gauge]
+}
+
+// NewGaugeVec registers a gauge family with the given labels
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	v := &GaugeVec{newVec(name, help, labels, func() *Gauge { return &Gauge{} })}
+	Default.register(v)
+	return v
+}
+
+// NewGauge registers a gauge without labels
+func NewGauge(name, help string) *Gauge {
+	return NewGaugeVec(name, help).With()
+}
+
+// With returns the gauge for the label values, in label order
+func (v *GaugeVec) With(values ...string) *Gauge { return v.with(values) }
+
+func (v *GaugeVec) kind() string { return "gauge" }
+
+func (v *GaugeVec) write(_ context.Context, w *bufio.Writer) {
+	v.each(func(values []string, g *Gauge) {
+		writeSample(w, v.metricName, v.labels, values, g.value.get())
+	})
+}
+
+// GaugeFunc is a gauge computed on every scrape, e.g. the age of the newest
+// document
+type GaugeFunc struct {
+	metricName string
+	helpText   string
+	fn         atomic.Pointer[func(ctx context.Context) float64]
+}
+
+// NewGaugeFunc registers a gauge that is reported once Set gives it a
+// function
+func NewGaugeFunc(name, help string) *GaugeFunc {
+	g := &GaugeFunc{metricName: name, helpText: help}
+	Default.register(g)
+	return g
+}
+
+// Set makes fn compute the gauge. fn gets the scrape's context and should
+// be quick.
+func (g *GaugeFunc) Set(fn func(ctx context.Context) float64) {
+	g.fn.Store(&fn)
+}
+
+func (g *GaugeFunc) name() string { return g.metricName }
+func (g *GaugeFunc) help() string { return g.helpText }
+func (g *GaugeFunc) kind() string { return "gauge" }
+
+func (g *GaugeFunc) write(ctx context.Context, w *bufio.Writer) {
+	if fn := g.fn.Load(); fn != nil {
+		writeSample(w, g.metricName, nil, nil, (*fn)(ctx))
+	}
+}
+
+// ============================================================================
+// HISTOGRAM
+// ============================================================================
+
+// Histogram counts observations into buckets, e.g. request latencies
+type Histogram struct {
+	upper []float64 // bucket upper bounds, ascending
+
+	mu     sync.Mutex
+	counts []uint64 // per bucket, not cumulative; the last is +Inf
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{upper: buckets, counts: make([]uint64, len(buckets)+1)}
+}
+
+// Observe records one value
+func (h *Histogram) Observe(v float64) {
+	i, _ := slices.BinarySearch(h.upper, v)
+	h.mu.Lock()
+	h.counts[i]++
+	h.sum += v
+	h.count++
+	h.mu.Unlock()
+}
+
+// HistogramVec is a histogram per set of label values
+type HistogramVec struct {
+	*vec[Histogram]
+}
+
+// NewHistogramVec registers a histogram family with the given buckets and
+// labels
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if !slices.IsSorted(buckets) {
+		panic(fmt.Sprintf("metrics: buckets of %s are not sorted", name))
+	}
+	buckets = slices.Clone(buckets)
+	v := &HistogramVec{newVec(name, help, labels, func() *Histogram { return newHistogram(buckets) })}
+	Default.register(v)
+	return v
+}
+
+// NewHistogram registers a histogram without labels
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	return NewHistogramVec(name, help, buckets).With()
+}
+
+// With returns the histogram for the label values, in label order
+func (v *HistogramVec) With(values ...string) *Histogram { return v.with(values) }
+
+func (v *HistogramVec) kind() string { return "histogram" }
+
+func (v *HistogramVec) write(_ context.Context, w *bufio.Writer) {
+	labels := append(slices.Clone(v.labels), "le")
+	v.each(func(values []string, h *Histogram) {
+		h.mu.Lock()
+		counts := slices.Clone(h.counts)
+		sum, count := h.sum, h.count
+		h.mu.Unlock()
+
+		bucketValues := append(slices.Clone(values), "")
+		var cumulative uint64
+		for i, upper := range h.upper {
+			cumulative += counts[i]
+			bucketValues[len(values)] = formatFloat(upper)
+			writeSample(w, v.metricName+"_bucket", labels, bucketValues, float64(cumulative))
+		}
+		bucketValues[len(values)] = "+Inf"
+		writeSample(w, v.metricName+"_bucket", labels, bucketValues, float64(count))
+		writeSample(w, v.metricName+"_sum", v.labels, values, sum)
+		writeSample(w, v.metricName+"_count", v.labels, values, float64(count))
+	})
+}
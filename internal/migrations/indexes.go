@@ -0,0 +1,265 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// notificationTTL is how long notifications are kept before MongoDB
+// expires them
+const notificationTTL int32 = 90 * 24 * 60 * 60
+
+// outboxTTL is how long events are kept once dispatched; those given up are
+// kept until they are dealt with
+const outboxTTL int32 = 7 * 24 * 60 * 60
+
+// jobTTL is how long jobs are kept once they succeeded; dead ones are kept
+// until retried
+const jobTTL int32 = 7 * 24 * 60 * 60
+
+// positionTTL is how long the change positions of an instance are kept
+// after it last saved one, so those of instances that are gone expire
+const positionTTL int32 = 7 * 24 * 60 * 60
+
+// quotaTTL is how long an ingestion quota is kept after its hour started;
+// it only counts during that hour
+const quotaTTL int32 = 2 * 60 * 60
+
+// All returns the application's migrations. New ones are appended with the
+// next version; applied migrations must never be edited.
+func All() []Migration {
+	return []Migration{
+		indexMigration(1, "users: unique email and google_id", "users",
+			mongo.IndexModel{
+				Keys: bson.D{{Key: "email", Value: 1}},
+				Options: options.Index().SetName("email_unique").SetUnique(true).
+					SetPartialFilterExpression(bson.M{"email": bson.M{"$type": "string"}}),
+			},
+			mongo.IndexModel{
+				Keys: bson.D{{Key: "google_id", Value: 1}},
+				Options: options.Index().SetName("google_id_unique").SetUnique(true).
+					SetPartialFilterExpression(bson.M{"google_id": bson.M{"$type": "string"}}),
+			},
+		),
+		indexMigration(2, "users: listing by trader type and interest", "users",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "trader_type", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+				Options: options.Index().SetName("trader_type_created_at"),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "interests", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+				Options: options.Index().SetName("interests_created_at"),
+			},
+		),
+		indexMigration(3, "news: listing by status and publication date", "news",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "status", Value: 1}, {Key: "published_at", Value: -1}, {Key: "_id", Value: -1}},
+				Options: options.Index().SetName("status_published_at"),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "published_at", Value: -1}, {Key: "_id", Value: -1}},
+				Options: options.Index().SetName("published_at"),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "categories", Value: 1}, {Key: "published_at", Value: -1}},
+				Options: options.Index().SetName("categories_published_at"),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "source_id", Value: 1}, {Key: "created_at", Value: -1}},
+				Options: options.Index().SetName("source_id_created_at"),
+			},
+			mongo.IndexModel{
+				// also serves the ingestion lag readiness check
+				Keys:    bson.D{{Key: "created_at", Value: -1}},
+				Options: options.Index().SetName("created_at"),
+			},
+		),
+		indexMigration(4, "news: full text search", "news",
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "title", Value: "text"},
+					{Key: "description", Value: "text"},
+					{Key: "content_text", Value: "text"},
+					{Key: "tags", Value: "text"},
+				},
+				Options: options.Index().SetName("search_text").SetWeights(bson.D{
+					{Key: "title", Value: 10},
+					{Key: "tags", Value: 5},
+					{Key: "description", Value: 3},
+					{Key: "content_text", Value: 1},
+				}),
+			},
+		),
+		indexMigration(5, "sources: unique domain", "sources",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "domain", Value: 1}},
+				Options: options.Index().SetName("domain_unique").SetUnique(true),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "is_blocked", Value: 1}, {Key: "name", Value: 1}},
+				Options: options.Index().SetName("is_blocked_name"),
+			},
+		),
+		indexMigration(6, "categories: unique slug", "categories",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "slug", Value: 1}},
+				Options: options.Index().SetName("slug_unique").SetUnique(true),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "order", Value: 1}, {Key: "_id", Value: 1}},
+				Options: options.Index().SetName("order"),
+			},
+		),
+		indexMigration(7, "notifications: per user listing and expiry", "notifications",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+				Options: options.Index().SetName("user_id_created_at"),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "is_read", Value: 1}},
+				Options: options.Index().SetName("user_id_is_read"),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "created_at", Value: 1}},
+				Options: options.Index().SetName("created_at_ttl").SetExpireAfterSeconds(notificationTTL),
+			},
+		),
+		indexMigration(8, "activities: per user listing and per article counts", "activities",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+				Options: options.Index().SetName("user_id_created_at"),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "news_id", Value: 1}, {Key: "activity_type", Value: 1}},
+				Options: options.Index().SetName("news_id_activity_type"),
+			},
+		),
+		indexMigration(9, "idempotency_keys: expiry", "idempotency_keys",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "expires_at", Value: 1}},
+				Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(0),
+			},
+		),
+		indexMigration(10, "webhook_deliveries: due and per webhook", "webhook_deliveries",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+				Options: options.Index().SetName("status_next_attempt_at"),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+				Options: options.Index().SetName("webhook_id_created_at"),
+			},
+		),
+		indexMigration(11, "webhooks: subscriptions", "webhooks",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "events", Value: 1}, {Key: "is_active", Value: 1}},
+				Options: options.Index().SetName("events_is_active"),
+			},
+		),
+		indexMigration(12, "outbox: due events and expiry", "outbox",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+				Options: options.Index().SetName("status_next_attempt_at"),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "dispatched_at", Value: 1}},
+				Options: options.Index().SetName("dispatched_at_ttl").SetExpireAfterSeconds(outboxTTL),
+			},
+		),
+		indexMigration(13, "webhook_deliveries: one per webhook and event", "webhook_deliveries",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "webhook_id", Value: 1}, {Key: "event_id", Value: 1}},
+				Options: options.Index().SetName("webhook_id_event_id_unique").SetUnique(true),
+			},
+		),
+		indexMigration(14, "jobs: due per queue and unique keys", "jobs",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "queue", Value: 1}, {Key: "status", Value: 1}, {Key: "run_at", Value: 1}},
+				Options: options.Index().SetName("queue_status_run_at"),
+			},
+			mongo.IndexModel{
+				Keys: bson.D{{Key: "unique_key", Value: 1}},
+				Options: options.Index().SetName("unique_key_unique").SetUnique(true).
+					SetPartialFilterExpression(bson.M{"unique_key": bson.M{"$type": "string"}}),
+			},
+			mongo.IndexModel{
+				Keys: bson.D{{Key: "finished_at", Value: 1}},
+				Options: options.Index().SetName("finished_at_ttl").SetExpireAfterSeconds(jobTTL).
+					SetPartialFilterExpression(bson.M{"status": "succeeded"}),
+			},
+		),
+		indexMigration(15, "news: changed since, for polling without change streams", "news",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "updated_at", Value: 1}, {Key: "_id", Value: 1}},
+				Options: options.Index().SetName("updated_at_id"),
+			},
+		),
+		indexMigration(16, "change_positions: expiry", "change_positions",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "updated_at", Value: 1}},
+				Options: options.Index().SetName("updated_at_ttl").SetExpireAfterSeconds(positionTTL),
+			},
+		),
+		indexMigration(17, "ingestion_quotas: expiry", "ingestion_quotas",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "hour", Value: 1}},
+				Options: options.Index().SetName("hour_ttl").SetExpireAfterSeconds(quotaTTL),
+			},
+		),
+	}
+}
+
+// indexMigration creates the given named indexes on up and drops them on
+// down
+func indexMigration(version int, name, collection string, indexes ...mongo.IndexModel) Migration {
+	return Migration{
+		Version: version,
+		Name:    name,
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection(collection).Indexes().CreateMany(ctx, indexes)
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			for _, index := range indexes {
+				name := indexName(index)
+				if name == "" {
+					return fmt.Errorf("index %v on %s has no name to drop it by", index.Keys, collection)
+				}
+				if err := dropIndex(ctx, db.Collection(collection), name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// indexName reads the name set on an index model, "" when there is none
+func indexName(index mongo.IndexModel) string {
+	var opts options.IndexOptions
+	if index.Options != nil {
+		for _, set := range index.Options.List() {
+			_ = set(&opts)
+		}
+	}
+	if opts.Name == nil {
+		return ""
+	}
+	return *opts.Name
+}
+
+// dropIndex drops an index, treating an index that is already gone as
+// dropped
+func dropIndex(ctx context.Context, coll *mongo.Collection, name string) error {
+	err := coll.Indexes().DropOne(ctx, name)
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && (cmdErr.Code == 27 || cmdErr.Code == 26) { // IndexNotFound, NamespaceNotFound
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,231 @@
+// Package migrations applies versioned schema changes, mostly indexes, to
+// the database.
+//
+// Applied versions are recorded in the migrations collection. A lock
+// document in the same collection keeps two processes from migrating at
+// the same time.
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Collection records the applied migrations
+const Collection = "migrations"
+
+const (
+	lockID  = "lock"
+	lockTTL = 10 * time.Minute // a lock older than this is considered abandoned
+)
+
+var (
+	ErrLocked         = errors.New("another process is running migrations")
+	ErrUnknownVersion = errors.New("unknown migration version")
+	ErrNoDown         = errors.New("migration cannot be rolled back")
+)
+
+// Migration is one versioned change. Versions must be unique and positive;
+// they are applied in ascending order.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database) error
+	Down    func(ctx context.Context, db *mongo.Database) error
+}
+
+// record is what the migrations collection stores per applied version
+type record struct {
+	Version   int       `bson:"_id"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+// Status describes one known migration
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"` // nil while pending
+}
+
+// Runner applies and rolls back migrations
+type Runner struct {
+	db         *mongo.Database
+	coll       *mongo.Collection
+	migrations []Migration
+	logger     *slog.Logger
+}
+
+// NewRunner returns a runner for the given migrations, which must have
+// unique positive versions
+func NewRunner(db *mongo.Database, migrations []Migration, logger *slog.Logger) (*Runner, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, m := range sorted {
+		if m.Version <= 0 || m.Up == nil {
+			return nil, fmt.Errorf("migration %d %q: needs a positive version and an Up function", m.Version, m.Name)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("migration version %d is used twice", m.Version)
+		}
+	}
+
+	return &Runner{
+		db:         db,
+		coll:       db.Collection(Collection),
+		migrations: sorted,
+		logger:     logger,
+	}, nil
+}
+
+// Status lists every known migration and when it was applied
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		s := Status{Version: m.Version, Name: m.Name}
+		if rec, ok := applied[m.Version]; ok {
+			s.AppliedAt = &rec.AppliedAt
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// Up applies every pending migration in order and returns the versions it
+// applied
+func (r *Runner) Up(ctx context.Context) ([]int, error) {
+	return r.UpTo(ctx, 0)
+}
+
+// UpTo applies the pending migrations up to and including target; 0 means
+// all of them
+func (r *Runner) UpTo(ctx context.Context, target int) ([]int, error) {
+	if target != 0 && r.find(target) == nil {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, target)
+	}
+
+	var done []int
+	err := r.locked(ctx, func() error {
+		applied, err := r.applied(ctx)
+		if err != nil {
+			return err
+		}
+		for _, m := range r.migrations {
+			if target != 0 && m.Version > target {
+				break
+			}
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+
+			r.logger.Info("applying migration", "version", m.Version, "name", m.Name)
+			if err := m.Up(ctx, r.db); err != nil {
+				return fmt.Errorf("migration %d %q: %w", m.Version, m.Name, err)
+			}
+			rec := record{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}
+			if _, err := r.coll.InsertOne(ctx, rec); err != nil {
+				return fmt.Errorf("recording migration %d: %w", m.Version, err)
+			}
+			done = append(done, m.Version)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the last steps applied migrations, newest first, and
+// returns the versions it rolled back
+func (r *Runner) Down(ctx context.Context, steps int) ([]int, error) {
+	var done []int
+	err := r.locked(ctx, func() error {
+		applied, err := r.applied(ctx)
+		if err != nil {
+			return err
+		}
+		for i := len(r.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			m := r.migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			if m.Down == nil {
+				return fmt.Errorf("%w: %d %q", ErrNoDown, m.Version, m.Name)
+			}
+
+			r.logger.Info("rolling back migration", "version", m.Version, "name", m.Name)
+			if err := m.Down(ctx, r.db); err != nil {
+				return fmt.Errorf("rolling back migration %d %q: %w", m.Version, m.Name, err)
+			}
+			if _, err := r.coll.DeleteOne(ctx, bson.M{"_id": m.Version}); err != nil {
+				return fmt.Errorf("unrecording migration %d: %w", m.Version, err)
+			}
+			done = append(done, m.Version)
+		}
+		return nil
+	})
+	return done, err
+}
+
+func (r *Runner) find(version int) *Migration {
+	for i := range r.migrations {
+		if r.migrations[i].Version == version {
+			return &r.migrations[i]
+		}
+	}
+	return nil
+}
+
+// applied loads the recorded versions
+func (r *Runner) applied(ctx context.Context) (map[int]record, error) {
+	cursor, err := r.coll.Find(ctx, bson.M{"_id": bson.M{"$type": "number"}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	applied := make(map[int]record, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = rec
+	}
+	return applied, nil
+}
+
+// locked runs fn while holding the migration lock. A lock left behind by a
+// crashed process is taken over once it is older than lockTTL.
+func (r *Runner) locked(ctx context.Context, fn func() error) error {
+	now := time.Now()
+	_, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": lockID, "locked_at": bson.M{"$lt": now.Add(-lockTTL)}},
+		bson.M{"$set": bson.M{"locked_at": now}},
+		options.UpdateOne().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrLocked
+	}
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if _, err := r.coll.DeleteOne(context.WithoutCancel(ctx), bson.M{"_id": lockID, "locked_at": now}); err != nil {
+			r.logger.Error("could not release migration lock", "error", err)
+		}
+	}()
+	return fn()
+}
@@ -0,0 +1,198 @@
+package migrations_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+	"yoharsh14/krant-backend/internal/migrations"
+	"yoharsh14/krant-backend/internal/repository/repositorytest"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// steps records the migrations run against it, in order
+type steps struct {
+	mu  sync.Mutex
+	ran []string
+}
+
+func (s *steps) migration(version int, down bool) migrations.Migration {
+	run := func(what string) func(context.Context, *mongo.Database) error {
+		return func(ctx context.Context, db *mongo.Database) error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.ran = append(s.ran, what)
+			return nil
+		}
+	}
+	m := migrations.Migration{Version: version, Name: "step", Up: run(fmt.Sprintf("up %d", version))}
+	if down {
+		m.Down = run(fmt.Sprintf("down %d", version))
+	}
+	return m
+}
+
+func (s *steps) get() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.ran)
+}
+
+func TestNewRunnerChecksVersions(t *testing.T) {
+	// the client connects lazily; no server is needed to build runners
+	client, err := mongo.Connect(options.Client().ApplyURI("mongodb://localhost:1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(context.Background())
+	db := client.Database("krant")
+
+	if _, err := migrations.NewRunner(db, migrations.All(), discard); err != nil {
+		t.Errorf("NewRunner(All()) = %v", err)
+	}
+	up := func(context.Context, *mongo.Database) error { return nil }
+	for name, list := range map[string][]migrations.Migration{
+		"duplicate version": {{Version: 1, Up: up}, {Version: 1, Up: up}},
+		"zero version":      {{Version: 0, Up: up}},
+		"no Up":             {{Version: 1}},
+	} {
+		if _, err := migrations.NewRunner(db, list, discard); err == nil {
+			t.Errorf("%s: NewRunner succeeded", name)
+		}
+	}
+}
+
+func TestRunner(t *testing.T) {
+	ctx := context.Background()
+
+	// versions past the application's, which repositorytest has applied
+	newRunner := func(t *testing.T, db *mongo.Database, s *steps) *migrations.Runner {
+		t.Helper()
+		r, err := migrations.NewRunner(db, []migrations.Migration{
+			s.migration(103, true),
+			s.migration(101, true),
+			s.migration(102, true),
+		}, discard)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	t.Run("applies pending migrations in order", func(t *testing.T) {
+		db := repositorytest.Database(t)
+		var s steps
+		r := newRunner(t, db, &s)
+
+		applied, err := r.UpTo(ctx, 102)
+		if err != nil || !slices.Equal(applied, []int{101, 102}) {
+			t.Fatalf("UpTo(102) = %v, %v; want 101 and 102", applied, err)
+		}
+		applied, err = r.Up(ctx)
+		if err != nil || !slices.Equal(applied, []int{103}) {
+			t.Fatalf("Up = %v, %v; want 103", applied, err)
+		}
+		if applied, err := r.Up(ctx); err != nil || len(applied) != 0 {
+			t.Errorf("Up with nothing pending = %v, %v", applied, err)
+		}
+		if got, want := s.get(), []string{"up 101", "up 102", "up 103"}; !slices.Equal(got, want) {
+			t.Errorf("ran %v, want %v", got, want)
+		}
+		if _, err := r.UpTo(ctx, 104); !errors.Is(err, migrations.ErrUnknownVersion) {
+			t.Errorf("UpTo(104) = %v, want ErrUnknownVersion", err)
+		}
+
+		statuses, err := r.Status(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, st := range statuses {
+			if st.AppliedAt == nil {
+				t.Errorf("migration %d pending after Up", st.Version)
+			}
+		}
+	})
+
+	t.Run("rolls back newest first", func(t *testing.T) {
+		db := repositorytest.Database(t)
+		var s steps
+		r := newRunner(t, db, &s)
+		if _, err := r.Up(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		rolledBack, err := r.Down(ctx, 2)
+		if err != nil || !slices.Equal(rolledBack, []int{103, 102}) {
+			t.Fatalf("Down(2) = %v, %v; want 103 then 102", rolledBack, err)
+		}
+		if got, want := s.get()[3:], []string{"down 103", "down 102"}; !slices.Equal(got, want) {
+			t.Errorf("ran %v, want %v", got, want)
+		}
+		statuses, err := r.Status(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, st := range statuses {
+			if pending := st.AppliedAt == nil; pending != (st.Version != 101) {
+				t.Errorf("migration %d applied at %v after rolling back 102 and 103", st.Version, st.AppliedAt)
+			}
+		}
+
+		// rolled back migrations are applied again
+		if applied, err := r.Up(ctx); err != nil || !slices.Equal(applied, []int{102, 103}) {
+			t.Errorf("Up after Down = %v, %v; want 102 and 103", applied, err)
+		}
+
+		irreversible, err := migrations.NewRunner(db, []migrations.Migration{s.migration(101, true), s.migration(104, false)}, discard)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := irreversible.Up(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := irreversible.Down(ctx, 1); !errors.Is(err, migrations.ErrNoDown) {
+			t.Errorf("Down of a migration without Down = %v, want ErrNoDown", err)
+		}
+	})
+
+	t.Run("refuses to run while another runner holds the lock", func(t *testing.T) {
+		db := repositorytest.Database(t)
+		var s steps
+		r := newRunner(t, db, &s)
+
+		coll := db.Collection(migrations.Collection)
+		if _, err := coll.InsertOne(ctx, bson.M{"_id": "lock", "locked_at": time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+		if applied, err := r.Up(ctx); !errors.Is(err, migrations.ErrLocked) || len(applied) != 0 {
+			t.Fatalf("Up while locked = %v, %v; want ErrLocked", applied, err)
+		}
+		if _, err := r.Down(ctx, 1); !errors.Is(err, migrations.ErrLocked) {
+			t.Errorf("Down while locked = %v, want ErrLocked", err)
+		}
+		if len(s.get()) != 0 {
+			t.Errorf("ran %v while locked", s.get())
+		}
+
+		// a lock left behind by a crashed runner is taken over
+		if _, err := coll.UpdateOne(ctx, bson.M{"_id": "lock"}, bson.M{"$set": bson.M{"locked_at": time.Now().Add(-time.Hour)}}); err != nil {
+			t.Fatal(err)
+		}
+		if applied, err := r.Up(ctx); err != nil || len(applied) != 3 {
+			t.Errorf("Up past an abandoned lock = %v, %v; want every migration", applied, err)
+		}
+		if n, err := coll.CountDocuments(ctx, bson.M{"_id": "lock"}); err != nil || n != 0 {
+			t.Errorf("%d locks left after Up, %v", n, err)
+		}
+	})
+}
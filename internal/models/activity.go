@@ -25,7 +25,7 @@ type ActivityMetadata struct {
 // CreateActivityInput represents input for creating user activity
 type CreateActivityInput struct {
 	NewsID       bson.ObjectID    `json:"news_id" binding:"required"`
-	ActivityType string           `json:"activity_type" binding:"required"`
+	ActivityType string           `json:"activity_type" binding:"required,activity_type"`
 	Metadata     ActivityMetadata `json:"metadata"`
 }
 
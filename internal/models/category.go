@@ -18,6 +18,7 @@ type Category struct {
 	IsActive       bool           `json:"is_active" bson:"is_active"`
 	ParentCategory *bson.ObjectID `json:"parent_category,omitempty" bson:"parent_category,omitempty"`
 	CreatedAt      time.Time      `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" bson:"updated_at"`
 }
 
 // CreateCategoryInput represents input for creating a category
@@ -43,19 +44,20 @@ type UpdateCategoryInput struct {
 
 // CategoryResponse represents category data returned to client
 type CategoryResponse struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Slug        string `json:"slug"`
-	Description string `json:"description"`
-	Icon        string `json:"icon"`
-	Color       string `json:"color"`
-	Order       int    `json:"order"`
-	IsActive    bool   `json:"is_active"`
+	ID             string  `json:"id"`
+	Name           string  `json:"name"`
+	Slug           string  `json:"slug"`
+	Description    string  `json:"description"`
+	Icon           string  `json:"icon"`
+	Color          string  `json:"color"`
+	Order          int     `json:"order"`
+	IsActive       bool    `json:"is_active"`
+	ParentCategory *string `json:"parent_category,omitempty"`
 }
 
 // ToResponse converts Category to CategoryResponse
 func (c *Category) ToResponse() CategoryResponse {
-	return CategoryResponse{
+	resp := CategoryResponse{
 		ID:          c.ID.Hex(),
 		Name:        c.Name,
 		Slug:        c.Slug,
@@ -65,4 +67,15 @@ func (c *Category) ToResponse() CategoryResponse {
 		Order:       c.Order,
 		IsActive:    c.IsActive,
 	}
-}
\ No newline at end of file
+	if c.ParentCategory != nil {
+		parent := c.ParentCategory.Hex()
+		resp.ParentCategory = &parent
+	}
+	return resp
+}
+
+// CategoryListResponse represents a paginated category listing
+type CategoryListResponse struct {
+	Categories []any `json:"categories"` // CategoryResponse, trimmed to the requested fields
+	PageMeta
+}
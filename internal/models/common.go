@@ -1,9 +1,17 @@
 package models
 
-// PaginationParams represents pagination parameters
+import (
+	"net/url"
+	"strconv"
+)
+
+// PaginationParams represents pagination parameters. When Cursor is set the
+// listing continues after it (keyset pagination) and Page is ignored;
+// otherwise the legacy page/limit mode applies.
 type PaginationParams struct {
-	Page  int `json:"page" form:"page"`
-	Limit int `json:"limit" form:"limit"`
+	Page   int    `json:"page" form:"page"`
+	Limit  int    `json:"limit" form:"limit"`
+	Cursor string `json:"cursor,omitempty" form:"cursor"`
 }
 
 // GetPaginationParams returns validated pagination params with defaults
@@ -20,11 +28,58 @@ func GetPaginationParams(page, limit int) PaginationParams {
 	}
 }
 
+// GetCursorParams returns validated cursor pagination params with defaults
+func GetCursorParams(cursor string, limit int) PaginationParams {
+	params := GetPaginationParams(1, limit)
+	params.Cursor = cursor
+	return params
+}
+
+// UsesCursor reports whether the listing should continue after a cursor
+func (p PaginationParams) UsesCursor() bool {
+	return p.Cursor != ""
+}
+
+// ParsePagination reads the page, limit and cursor query parameters
+func ParsePagination(q url.Values) PaginationParams {
+	page, _ := strconv.Atoi(q.Get("page"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	params := GetPaginationParams(page, limit)
+	params.Cursor = q.Get("cursor")
+	return params
+}
+
 // GetSkip returns the number of documents to skip
 func (p PaginationParams) GetSkip() int {
 	return (p.Page - 1) * p.Limit
 }
 
+// PageMeta is the pagination part of a list response. Page, TotalCount and
+// TotalPages are only filled in the legacy page mode.
+type PageMeta struct {
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	TotalCount int64  `json:"total_count"`
+	TotalPages int    `json:"total_pages"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPageMeta describes the page fetched with the given params
+func NewPageMeta(p PaginationParams, info PageInfo) PageMeta {
+	meta := PageMeta{
+		Limit:      p.Limit,
+		HasMore:    info.HasMore,
+		NextCursor: info.NextCursor,
+	}
+	if !p.UsesCursor() {
+		meta.Page = p.Page
+		meta.TotalCount = info.TotalCount
+		meta.TotalPages = int((info.TotalCount + int64(p.Limit) - 1) / int64(p.Limit))
+	}
+	return meta
+}
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error   string                 `json:"error"`
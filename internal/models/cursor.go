@@ -0,0 +1,126 @@
+package models
+
+import (
+	"encoding/base64"
+	"slices"
+	"yoharsh14/krant-backend/internal/apperr"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded or
+// was issued for a different sort order
+var ErrInvalidCursor = apperr.Validation("invalid cursor").WithCode("invalid_cursor")
+
+// Cursor is the decoded position of the last item of a page. It holds the
+// sort field and direction, the item's value for it and the _id of that
+// item, which breaks ties so the order is total.
+type Cursor struct {
+	Field string        `bson:"f"`
+	Desc  bool          `bson:"d"`
+	Value bson.RawValue `bson:"v"`
+	ID    bson.ObjectID `bson:"id"`
+}
+
+// EncodeCursor returns the opaque cursor string for the given position
+func EncodeCursor(field string, desc bool, value bson.RawValue, id bson.ObjectID) (string, error) {
+	raw, err := bson.Marshal(Cursor{Field: field, Desc: desc, Value: value, ID: id})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// DecodeCursor parses a cursor string produced by EncodeCursor and checks
+// that it was issued for the given sort order
+func DecodeCursor(s, field string, desc bool) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := bson.Unmarshal(raw, &c); err != nil || c.Field != field || c.Desc != desc || c.ID.IsZero() {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// typeBrackets are the $type aliases of the BSON type brackets in their sort
+// order, after null and missing fields, which sort first. Arrays are left
+// out: listings are not sorted by them.
+var typeBrackets = []string{"number", "string", "object", "binData", "objectId", "bool", "date", "timestamp", "regex"}
+
+// bracketOf returns the index in typeBrackets of the bracket of a value of
+// type t
+func bracketOf(t bson.Type) (int, bool) {
+	var alias string
+	switch t {
+	case bson.TypeDouble, bson.TypeInt32, bson.TypeInt64, bson.TypeDecimal128:
+		alias = "number"
+	case bson.TypeString, bson.TypeSymbol:
+		alias = "string"
+	case bson.TypeEmbeddedDocument:
+		alias = "object"
+	case bson.TypeBinary:
+		alias = "binData"
+	case bson.TypeObjectID:
+		alias = "objectId"
+	case bson.TypeBoolean:
+		alias = "bool"
+	case bson.TypeDateTime:
+		alias = "date"
+	case bson.TypeTimestamp:
+		alias = "timestamp"
+	case bson.TypeRegex:
+		alias = "regex"
+	}
+	i := slices.Index(typeBrackets, alias)
+	return i, i >= 0
+}
+
+// After returns the filter selecting the documents that come after the cursor
+// in its sort order, with _id as the tie breaker.
+//
+// Range operators only compare values of the same type bracket, so the
+// documents whose field sorts in a later bracket are selected by $type, and
+// null and missing fields, which sort before every other value, are
+// matched explicitly.
+func (c Cursor) After() bson.M {
+	op := "$gt"
+	if c.Desc {
+		op = "$lt"
+	}
+	if c.Value.Type == 0 || c.Value.Type == bson.TypeNull || c.Value.Type == bson.TypeUndefined {
+		after := []bson.M{{c.Field: nil, "_id": bson.M{op: c.ID}}}
+		if !c.Desc {
+			after = append(after, bson.M{c.Field: bson.M{"$ne": nil}})
+		}
+		return bson.M{"$or": after}
+	}
+
+	after := []bson.M{
+		{c.Field: bson.M{op: c.Value}},
+		{c.Field: c.Value, "_id": bson.M{op: c.ID}},
+	}
+	if i, ok := bracketOf(c.Value.Type); ok {
+		later := typeBrackets[i+1:]
+		if c.Desc {
+			later = typeBrackets[:i]
+		}
+		if len(later) > 0 {
+			after = append(after, bson.M{c.Field: bson.M{"$type": later}})
+		}
+	}
+	if c.Desc {
+		after = append(after, bson.M{c.Field: nil})
+	}
+	return bson.M{"$or": after}
+}
+
+// PageInfo describes where a page sits in a listing. TotalCount is only
+// computed in page mode; cursor mode skips the count.
+type PageInfo struct {
+	TotalCount int64
+	NextCursor string
+	HasMore    bool
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := bson.NewObjectID()
+	_, raw, err := bson.MarshalValue("Rates hold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	value := bson.RawValue{Type: bson.TypeString, Value: raw}
+	s, err := EncodeCursor("title", true, value, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := DecodeCursor(s, "title", true)
+	if err != nil || c.Field != "title" || !c.Desc || c.ID != id || c.Value.StringValue() != "Rates hold" {
+		t.Fatalf("DecodeCursor = %+v, %v; want the encoded position", c, err)
+	}
+	for _, other := range []struct {
+		s, field string
+		desc     bool
+	}{
+		{s, "title", false},     // another direction
+		{s, "created_at", true}, // another field
+		{"not a cursor!", "title", true},
+		{"", "title", true},
+	} {
+		if _, err := DecodeCursor(other.s, other.field, other.desc); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q, %q, %v) = %v, want ErrInvalidCursor", other.s, other.field, other.desc, err)
+		}
+	}
+}
+
+func TestCursorAfter(t *testing.T) {
+	id := bson.NewObjectID()
+	null := bson.RawValue{Type: bson.TypeNull}
+	_, raw, _ := bson.MarshalValue("b")
+	str := bson.RawValue{Type: bson.TypeString, Value: raw}
+
+	cases := []struct {
+		name   string
+		cursor Cursor
+		want   int // branches of the $or
+		types  []string
+	}{
+		// the nulls after it, then every other value
+		{"null ascending", Cursor{Field: "f", Value: null, ID: id}, 2, nil},
+		// nulls sort last descending: only the nulls after it
+		{"null descending", Cursor{Field: "f", Desc: true, Value: null, ID: id}, 1, nil},
+		// the range, the ties and the later brackets
+		{"string ascending", Cursor{Field: "f", Value: str, ID: id}, 3, typeBrackets[2:]},
+		// the range, the ties, numbers and nulls
+		{"string descending", Cursor{Field: "f", Desc: true, Value: str, ID: id}, 4, typeBrackets[:1]},
+	}
+	for _, c := range cases {
+		or, _ := c.cursor.After()["$or"].([]bson.M)
+		if len(or) != c.want {
+			t.Errorf("%s: After = %v, want %d branches", c.name, or, c.want)
+			continue
+		}
+		if c.types == nil {
+			continue
+		}
+		cond, _ := or[2]["f"].(bson.M)
+		if types, _ := cond["$type"].([]string); len(types) != len(c.types) || types[0] != c.types[0] {
+			t.Errorf("%s: later brackets = %v, want %v", c.name, cond, c.types)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Image represents a validated image and its resized variants
+type Image struct {
+	ID          string         `json:"id" bson:"_id"` // content hash, identical uploads share an ID
+	SourceURL   string         `json:"source_url,omitempty" bson:"source_url,omitempty"`
+	ContentType string         `json:"content_type" bson:"content_type"`
+	Width       int            `json:"width" bson:"width"`
+	Height      int            `json:"height" bson:"height"`
+	Size        int64          `json:"size" bson:"size"` // bytes of the original
+	Original    ImageVariant   `json:"original" bson:"original"`
+	Variants    []ImageVariant `json:"variants" bson:"variants"` // thumbnails, smallest first
+	CreatedAt   time.Time      `json:"created_at" bson:"created_at"`
+}
+
+// Clone returns a deep copy of i, or nil for a nil image
+func (i *Image) Clone() *Image {
+	if i == nil {
+		return nil
+	}
+	c := *i
+	c.Variants = slices.Clone(i.Variants)
+	return &c
+}
+
+// ImageVariant represents one stored rendition of an image
+type ImageVariant struct {
+	Key         string `json:"-" bson:"key"` // storage key
+	URL         string `json:"url" bson:"url"`
+	ContentType string `json:"content_type" bson:"content_type"`
+	Width       int    `json:"width" bson:"width"`
+	Height      int    `json:"height" bson:"height"`
+}
+
+// ImageResponse represents responsive image data returned to client
+type ImageResponse struct {
+	ID       string         `json:"id"`
+	Src      string         `json:"src"`    // largest thumbnail, a sensible default
+	SrcSet   string         `json:"srcset"` // ready for <img srcset>
+	Width    int            `json:"width"`
+	Height   int            `json:"height"`
+	Variants []ImageVariant `json:"variants"`
+}
+
+// ToResponse converts Image to ImageResponse
+func (i *Image) ToResponse() *ImageResponse {
+	if i == nil {
+		return nil
+	}
+
+	resp := &ImageResponse{
+		ID:       i.ID,
+		Src:      i.Original.URL,
+		Width:    i.Width,
+		Height:   i.Height,
+		Variants: i.Variants,
+	}
+
+	srcset := make([]string, 0, len(i.Variants))
+	for _, v := range i.Variants {
+		srcset = append(srcset, v.URL+" "+strconv.Itoa(v.Width)+"w")
+	}
+	if len(i.Variants) > 0 {
+		resp.Src = i.Variants[len(i.Variants)-1].URL
+	}
+	resp.SrcSet = strings.Join(srcset, ", ")
+
+	return resp
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Job is a unit of background work in a queue, with its attempts so far
+type Job struct {
+	ID          bson.ObjectID `json:"id" bson:"_id,omitempty"`
+	Queue       string        `json:"queue" bson:"queue"`
+	Type        string        `json:"type" bson:"type"`
+	Payload     string        `json:"payload" bson:"payload"` // JSON, decoded by the type's handler
+	Status      string        `json:"status" bson:"status"`   // pending, running, succeeded, dead
+	Attempts    int           `json:"attempts" bson:"attempts"`
+	MaxAttempts int           `json:"max_attempts" bson:"max_attempts"`
+	// RunAt is when a pending job is due and when a running one is visible
+	// to other workers again, should its run never finish
+	RunAt      time.Time  `json:"run_at" bson:"run_at"`
+	UniqueKey  string     `json:"unique_key,omitempty" bson:"unique_key,omitempty"` // no two jobs share one
+	Schedule   string     `json:"schedule,omitempty" bson:"schedule,omitempty"`     // the recurring schedule it is a run of
+	LastError  string     `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty" bson:"started_at,omitempty"` // of the last attempt
+	FinishedAt *time.Time `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" bson:"updated_at"`
+}
+
+// JobResponse represents job data returned to client
+type JobResponse struct {
+	ID          string          `json:"id"`
+	Queue       string          `json:"queue"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       *time.Time      `json:"run_at,omitempty"`
+	Schedule    string          `json:"schedule,omitempty"`
+	LastError   string          `json:"last_error,omitempty"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// ToResponse converts Job to JobResponse
+func (j *Job) ToResponse() JobResponse {
+	resp := JobResponse{
+		ID:          j.ID.Hex(),
+		Queue:       j.Queue,
+		Type:        j.Type,
+		Payload:     json.RawMessage(j.Payload),
+		Status:      j.Status,
+		Attempts:    j.Attempts,
+		MaxAttempts: j.MaxAttempts,
+		Schedule:    j.Schedule,
+		LastError:   j.LastError,
+		StartedAt:   j.StartedAt,
+		FinishedAt:  j.FinishedAt,
+		CreatedAt:   j.CreatedAt,
+		UpdatedAt:   j.UpdatedAt,
+	}
+	if j.Status == JobPending || j.Status == JobRunning {
+		runAt := j.RunAt
+		resp.RunAt = &runAt
+	}
+	return resp
+}
+
+// JobListResponse represents a paginated job listing
+type JobListResponse struct {
+	Jobs []any `json:"jobs"` // JobResponse, trimmed to the requested fields
+	PageMeta
+}
+
+// JobStatus constants
+const (
+	JobPending   = "pending"   // waiting until it is due, or for a retry
+	JobRunning   = "running"   // claimed by a worker
+	JobSucceeded = "succeeded" // its handler returned without error
+	JobDead      = "dead"      // out of attempts, until retried
+)
+
+// ValidJobStatuses returns all valid job statuses
+func ValidJobStatuses() []string {
+	return []string{
+		JobPending,
+		JobRunning,
+		JobSucceeded,
+		JobDead,
+	}
+}
+
+// IsValidJobStatus checks if the job status is valid
+func IsValidJobStatus(status string) bool {
+	for _, s := range ValidJobStatuses() {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,9 @@
 package models
 
 import (
-	"time"
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"slices"
+	"time"
 )
 
 // News represents a news article
@@ -10,10 +11,15 @@ type News struct {
 	ID              bson.ObjectID `json:"id" bson:"_id,omitempty"`
 	Title           string        `json:"title" bson:"title"`
 	Description     string        `json:"description" bson:"description"`
-	Content         string        `json:"content" bson:"content"`
+	Content         string        `json:"content" bson:"content"`           // sanitized HTML
+	ContentText     string        `json:"content_text" bson:"content_text"` // plain text for search and summaries
+	WordCount       int           `json:"word_count" bson:"word_count"`
+	ReadingTime     int           `json:"reading_time" bson:"reading_time"` // minutes
 	Author          string        `json:"author" bson:"author"`
-	Source          NewsSource    `json:"source" bson:"source"`
-	ImageURL        string        `json:"image_url" bson:"image_url"`
+	SourceID        bson.ObjectID `json:"source_id" bson:"source_id,omitempty"` // references sources._id
+	Source          NewsSource    `json:"source" bson:"source"`                 // snapshot of the source for display
+	ImageURL        string        `json:"image_url" bson:"image_url"`           // where the image was fetched from
+	Image           *Image        `json:"image,omitempty" bson:"image,omitempty"`
 	Categories      []string      `json:"categories" bson:"categories"`
 	Tags            []string      `json:"tags" bson:"tags"`
 	TraderRelevance []string      `json:"trader_relevance" bson:"trader_relevance"`
@@ -26,8 +32,10 @@ type News struct {
 
 // NewsSource represents the source of the news
 type NewsSource struct {
-	Name string `json:"name" bson:"name"`
-	URL  string `json:"url" bson:"url"`
+	ID      bson.ObjectID `json:"id,omitempty" bson:"id,omitempty"`
+	Name    string        `json:"name" bson:"name"`
+	URL     string        `json:"url" bson:"url"`
+	LogoURL string        `json:"logo_url,omitempty" bson:"logo_url,omitempty"`
 }
 
 // NewsMetrics represents engagement metrics for a news article
@@ -43,44 +51,51 @@ type CreateNewsInput struct {
 	Description     string     `json:"description" binding:"required"`
 	Content         string     `json:"content" binding:"required"`
 	Author          string     `json:"author"`
-	Source          NewsSource `json:"source" binding:"required"`
-	ImageURL        string     `json:"image_url"`
-	Categories      []string   `json:"categories" binding:"required,min=1"`
+	SourceID        string     `json:"source_id"`  // a registered source
+	Source          NewsSource `json:"source"`     // looked up (or registered) by domain when SourceID is empty
+	ImageURL        string     `json:"image_url"`  // fetched and resized on create
+	ImageID         string     `json:"image_id"`   // an image uploaded beforehand, wins over ImageURL
+	Categories      []string   `json:"categories"` // defaults to the source's categories
 	Tags            []string   `json:"tags"`
-	TraderRelevance []string   `json:"trader_relevance" binding:"required,min=1"`
+	TraderRelevance []string   `json:"trader_relevance" binding:"required,min=1,dive,trader_type"`
 	PublishedAt     time.Time  `json:"published_at"`
 }
 
 // UpdateNewsInput represents input for updating news
 type UpdateNewsInput struct {
-	Title           *string     `json:"title,omitempty"`
-	Description     *string     `json:"description,omitempty"`
-	Content         *string     `json:"content,omitempty"`
-	Author          *string     `json:"author,omitempty"`
-	Source          *NewsSource `json:"source,omitempty"`
-	ImageURL        *string     `json:"image_url,omitempty"`
-	Categories      *[]string   `json:"categories,omitempty"`
-	Tags            *[]string   `json:"tags,omitempty"`
-	TraderRelevance *[]string   `json:"trader_relevance,omitempty"`
-	Status          *string     `json:"status,omitempty"`
+	Title           *string   `json:"title,omitempty"`
+	Description     *string   `json:"description,omitempty"`
+	Content         *string   `json:"content,omitempty"`
+	Author          *string   `json:"author,omitempty"`
+	SourceID        *string   `json:"source_id,omitempty"`
+	ImageURL        *string   `json:"image_url,omitempty"`
+	ImageID         *string   `json:"image_id,omitempty"`
+	Categories      *[]string `json:"categories,omitempty"`
+	Tags            *[]string `json:"tags,omitempty"`
+	TraderRelevance *[]string `json:"trader_relevance,omitempty" binding:"omitempty,min=1,dive,trader_type"`
+	Status          *string   `json:"status,omitempty" binding:"omitempty,news_status"`
 }
 
 // NewsResponse represents news data returned to client
 type NewsResponse struct {
-	ID              string      `json:"id"`
-	Title           string      `json:"title"`
-	Description     string      `json:"description"`
-	Content         string      `json:"content"`
-	Author          string      `json:"author"`
-	Source          NewsSource  `json:"source"`
-	ImageURL        string      `json:"image_url"`
-	Categories      []string    `json:"categories"`
-	Tags            []string    `json:"tags"`
-	TraderRelevance []string    `json:"trader_relevance"`
-	PublishedAt     time.Time   `json:"published_at"`
-	Metrics         NewsMetrics `json:"metrics"`
-	IsBookmarked    bool        `json:"is_bookmarked"` // Set based on user context
-	CreatedAt       time.Time   `json:"created_at"`
+	ID              string         `json:"id"`
+	Title           string         `json:"title"`
+	Description     string         `json:"description"`
+	Content         string         `json:"content"`
+	ContentFormat   string         `json:"content_format"` // html, text, markdown
+	WordCount       int            `json:"word_count"`
+	ReadingTime     int            `json:"reading_time"`
+	Author          string         `json:"author"`
+	Source          NewsSource     `json:"source"`
+	Image           *ImageResponse `json:"image,omitempty"` // responsive variants served by us
+	Categories      []string       `json:"categories"`
+	Tags            []string       `json:"tags"`
+	TraderRelevance []string       `json:"trader_relevance"`
+	PublishedAt     time.Time      `json:"published_at"`
+	Metrics         NewsMetrics    `json:"metrics"`
+	Status          string         `json:"status"`
+	IsBookmarked    bool           `json:"is_bookmarked"` // Set based on user context
+	CreatedAt       time.Time      `json:"created_at"`
 }
 
 // ToResponse converts News to NewsResponse
@@ -90,27 +105,37 @@ func (n *News) ToResponse(isBookmarked bool) NewsResponse {
 		Title:           n.Title,
 		Description:     n.Description,
 		Content:         n.Content,
+		ContentFormat:   "html",
+		WordCount:       n.WordCount,
+		ReadingTime:     n.ReadingTime,
 		Author:          n.Author,
 		Source:          n.Source,
-		ImageURL:        n.ImageURL,
+		Image:           n.Image.ToResponse(),
 		Categories:      n.Categories,
 		Tags:            n.Tags,
 		TraderRelevance: n.TraderRelevance,
 		PublishedAt:     n.PublishedAt,
 		Metrics:         n.Metrics,
+		Status:          n.Status,
 		IsBookmarked:    isBookmarked,
 		CreatedAt:       n.CreatedAt,
 	}
 }
 
+// Clone returns a deep copy of n, sharing no slices or image with it
+func (n *News) Clone() *News {
+	c := *n
+	c.Image = n.Image.Clone()
+	c.Categories = slices.Clone(n.Categories)
+	c.Tags = slices.Clone(n.Tags)
+	c.TraderRelevance = slices.Clone(n.TraderRelevance)
+	return &c
+}
+
 // NewsListResponse represents paginated news response
 type NewsListResponse struct {
-	News       []NewsResponse `json:"news"`
-	Page       int            `json:"page"`
-	Limit      int            `json:"limit"`
-	TotalCount int64          `json:"total_count"`
-	TotalPages int            `json:"total_pages"`
-	HasMore    bool           `json:"has_more"`
+	News []any `json:"news"` // NewsResponse, trimmed to the requested fields
+	PageMeta
 }
 
 // NewsStatus constants
@@ -137,4 +162,4 @@ func IsValidNewsStatus(status string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
@@ -1,8 +1,8 @@
 package models
 
 import (
-	"time"
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"time"
 )
 
 // Notification represents a user notification
@@ -19,10 +19,11 @@ type Notification struct {
 
 // CreateNotificationInput represents input for creating a notification
 type CreateNotificationInput struct {
+	ID      bson.ObjectID  `json:"-"` // set by event subscribers, which create a notification once per ID
 	UserID  bson.ObjectID  `json:"user_id" binding:"required"`
 	Title   string         `json:"title" binding:"required"`
 	Message string         `json:"message" binding:"required"`
-	Type    string         `json:"type" binding:"required"`
+	Type    string         `json:"type" binding:"required,notification_type"`
 	NewsID  *bson.ObjectID `json:"news_id,omitempty"`
 }
 
@@ -56,6 +57,13 @@ func (n *Notification) ToResponse() NotificationResponse {
 	return resp
 }
 
+// NotificationListResponse represents a paginated notification listing
+type NotificationListResponse struct {
+	Notifications []any `json:"notifications"` // NotificationResponse, trimmed to the requested fields
+	UnreadCount   int64 `json:"unread_count"`
+	PageMeta
+}
+
 // NotificationType constants
 const (
 	NotificationTypeNewsAlert = "news_alert"
@@ -80,4 +88,4 @@ func IsValidNotificationType(notifType string) bool {
 		}
 	}
 	return false
-}	
\ No newline at end of file
+}
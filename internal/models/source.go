@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Source represents a publisher that news articles are ingested from
+type Source struct {
+	ID                bson.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name              string        `json:"name" bson:"name"`
+	Domain            string        `json:"domain" bson:"domain"` // lower case host without www., unique
+	URL               string        `json:"url" bson:"url"`
+	LogoURL           string        `json:"logo_url" bson:"logo_url"`
+	TrustScore        float64       `json:"trust_score" bson:"trust_score"` // 0 (unreliable) to 100 (fully trusted)
+	DefaultCategories []string      `json:"default_categories" bson:"default_categories"`
+	IsActive          bool          `json:"is_active" bson:"is_active"`   // inactive sources are not ingested
+	IsBlocked         bool          `json:"is_blocked" bson:"is_blocked"` // blocked by an admin, hidden everywhere
+	BlockedReason     string        `json:"blocked_reason,omitempty" bson:"blocked_reason,omitempty"`
+	RateLimit         int           `json:"rate_limit" bson:"rate_limit"` // max articles ingested per clock hour, 0 means unlimited
+	CreatedAt         time.Time     `json:"created_at" bson:"created_at"`
+	UpdatedAt         time.Time     `json:"updated_at" bson:"updated_at"`
+}
+
+// CreateSourceInput represents input for registering a source
+type CreateSourceInput struct {
+	Name              string   `json:"name" binding:"required"`
+	URL               string   `json:"url" binding:"required,url"`
+	LogoURL           string   `json:"logo_url"`
+	TrustScore        *float64 `json:"trust_score,omitempty" binding:"omitempty,trust_score"`
+	DefaultCategories []string `json:"default_categories"`
+	RateLimit         int      `json:"rate_limit" binding:"min=0"`
+}
+
+// UpdateSourceInput represents input for updating a source
+type UpdateSourceInput struct {
+	Name              *string   `json:"name,omitempty"`
+	LogoURL           *string   `json:"logo_url,omitempty"`
+	TrustScore        *float64  `json:"trust_score,omitempty" binding:"omitempty,trust_score"`
+	DefaultCategories *[]string `json:"default_categories,omitempty"`
+	IsActive          *bool     `json:"is_active,omitempty"`
+	RateLimit         *int      `json:"rate_limit,omitempty" binding:"omitempty,min=0"`
+}
+
+// BlockSourceInput represents input for blocking a source globally
+type BlockSourceInput struct {
+	Reason string `json:"reason"`
+}
+
+// SourceResponse represents source data returned to client
+type SourceResponse struct {
+	ID                string   `json:"id"`
+	Name              string   `json:"name"`
+	Domain            string   `json:"domain"`
+	URL               string   `json:"url"`
+	LogoURL           string   `json:"logo_url"`
+	TrustScore        float64  `json:"trust_score"`
+	DefaultCategories []string `json:"default_categories"`
+	IsActive          bool     `json:"is_active"`
+	IsBlocked         bool     `json:"is_blocked"`
+}
+
+// ToResponse converts Source to SourceResponse
+func (s *Source) ToResponse() SourceResponse {
+	return SourceResponse{
+		ID:                s.ID.Hex(),
+		Name:              s.Name,
+		Domain:            s.Domain,
+		URL:               s.URL,
+		LogoURL:           s.LogoURL,
+		TrustScore:        s.TrustScore,
+		DefaultCategories: s.DefaultCategories,
+		IsActive:          s.IsActive,
+		IsBlocked:         s.IsBlocked,
+	}
+}
+
+// Snapshot returns the denormalized copy embedded in news articles
+func (s *Source) Snapshot() NewsSource {
+	return NewsSource{
+		ID:      s.ID,
+		Name:    s.Name,
+		URL:     s.URL,
+		LogoURL: s.LogoURL,
+	}
+}
+
+// Trust score bounds
+const (
+	MinTrustScore     = 0
+	MaxTrustScore     = 100
+	DefaultTrustScore = 50
+)
+
+// IsValidTrustScore checks if the trust score is within bounds
+func IsValidTrustScore(score float64) bool {
+	return score >= MinTrustScore && score <= MaxTrustScore
+}
@@ -0,0 +1,214 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Webhook is an endpoint of a partner that is sent the events it subscribed to
+type Webhook struct {
+	ID          bson.ObjectID `json:"id" bson:"_id,omitempty"`
+	URL         string        `json:"url" bson:"url"`
+	Events      []string      `json:"events" bson:"events"`
+	Secret      string        `json:"-" bson:"secret"` // signs the deliveries
+	Description string        `json:"description" bson:"description"`
+	IsActive    bool          `json:"is_active" bson:"is_active"`
+	CreatedAt   time.Time     `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at" bson:"updated_at"`
+}
+
+// CreateWebhookInput represents input for registering a webhook
+type CreateWebhookInput struct {
+	URL         string   `json:"url" binding:"required,url"`
+	Events      []string `json:"events" binding:"required,min=1,dive,webhook_event"`
+	Description string   `json:"description" binding:"max=500"`
+}
+
+// UpdateWebhookInput represents input for updating a webhook. RotateSecret
+// replaces the signing secret; the new one is returned once.
+type UpdateWebhookInput struct {
+	URL          *string   `json:"url,omitempty" binding:"omitempty,url"`
+	Events       *[]string `json:"events,omitempty" binding:"omitempty,min=1,dive,webhook_event"`
+	Description  *string   `json:"description,omitempty" binding:"omitempty,max=500"`
+	IsActive     *bool     `json:"is_active,omitempty"`
+	RotateSecret bool      `json:"rotate_secret,omitempty"`
+}
+
+// WebhookResponse represents webhook data returned to client. The secret is
+// only returned when it is created.
+type WebhookResponse struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Secret      string    `json:"secret,omitempty"`
+	Description string    `json:"description"`
+	IsActive    bool      `json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ToResponse converts Webhook to WebhookResponse, without the secret
+func (w *Webhook) ToResponse() WebhookResponse {
+	return WebhookResponse{
+		ID:          w.ID.Hex(),
+		URL:         w.URL,
+		Events:      w.Events,
+		Description: w.Description,
+		IsActive:    w.IsActive,
+		CreatedAt:   w.CreatedAt,
+		UpdatedAt:   w.UpdatedAt,
+	}
+}
+
+// WebhookListResponse represents a paginated webhook listing
+type WebhookListResponse struct {
+	Webhooks []any `json:"webhooks"` // WebhookResponse, trimmed to the requested fields
+	PageMeta
+}
+
+// WebhookEvent is the body of every delivery
+type WebhookEvent struct {
+	ID        string    `json:"id"` // the same in every delivery of the event
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"` // e.g. a NewsResponse
+}
+
+// WebhookDelivery is one event sent to one webhook, with its attempts so far.
+// The deliveries are the delivery log.
+type WebhookDelivery struct {
+	ID            bson.ObjectID    `json:"id" bson:"_id,omitempty"`
+	WebhookID     bson.ObjectID    `json:"webhook_id" bson:"webhook_id"`
+	EventID       string           `json:"event_id" bson:"event_id"`
+	Event         string           `json:"event" bson:"event"`
+	Payload       string           `json:"payload" bson:"payload"` // the signed JSON body, a WebhookEvent
+	Status        string           `json:"status" bson:"status"`   // pending, succeeded, dead
+	Attempts      int              `json:"attempts" bson:"attempts"`
+	History       []WebhookAttempt `json:"history" bson:"history"`
+	NextAttemptAt time.Time        `json:"next_attempt_at" bson:"next_attempt_at"`
+	DeliveredAt   *time.Time       `json:"delivered_at,omitempty" bson:"delivered_at,omitempty"`
+	CreatedAt     time.Time        `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at" bson:"updated_at"`
+}
+
+// WebhookAttempt records one try at a delivery
+type WebhookAttempt struct {
+	At         time.Time     `json:"at" bson:"at"`
+	StatusCode int           `json:"status_code,omitempty" bson:"status_code,omitempty"` // 0 when no response came
+	Error      string        `json:"error,omitempty" bson:"error,omitempty"`
+	Duration   time.Duration `json:"duration" bson:"duration"`
+}
+
+// WebhookDeliveryResponse represents delivery data returned to client
+type WebhookDeliveryResponse struct {
+	ID            string                   `json:"id"`
+	WebhookID     string                   `json:"webhook_id"`
+	EventID       string                   `json:"event_id"`
+	Event         string                   `json:"event"`
+	Payload       json.RawMessage          `json:"payload"`
+	Status        string                   `json:"status"`
+	Attempts      int                      `json:"attempts"`
+	History       []WebhookAttemptResponse `json:"history"`
+	NextAttemptAt *time.Time               `json:"next_attempt_at,omitempty"`
+	DeliveredAt   *time.Time               `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time                `json:"created_at"`
+}
+
+// WebhookAttemptResponse represents an attempt returned to client
+type WebhookAttemptResponse struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// ToResponse converts WebhookDelivery to WebhookDeliveryResponse
+func (d *WebhookDelivery) ToResponse() WebhookDeliveryResponse {
+	resp := WebhookDeliveryResponse{
+		ID:          d.ID.Hex(),
+		WebhookID:   d.WebhookID.Hex(),
+		EventID:     d.EventID,
+		Event:       d.Event,
+		Payload:     json.RawMessage(d.Payload),
+		Status:      d.Status,
+		Attempts:    d.Attempts,
+		History:     make([]WebhookAttemptResponse, 0, len(d.History)),
+		DeliveredAt: d.DeliveredAt,
+		CreatedAt:   d.CreatedAt,
+	}
+	if d.Status == WebhookDeliveryPending {
+		next := d.NextAttemptAt
+		resp.NextAttemptAt = &next
+	}
+	for _, a := range d.History {
+		resp.History = append(resp.History, WebhookAttemptResponse{
+			At:         a.At,
+			StatusCode: a.StatusCode,
+			Error:      a.Error,
+			DurationMS: a.Duration.Milliseconds(),
+		})
+	}
+	return resp
+}
+
+// WebhookDeliveryListResponse represents a paginated delivery listing
+type WebhookDeliveryListResponse struct {
+	Deliveries []any `json:"deliveries"` // WebhookDeliveryResponse, trimmed to the requested fields
+	PageMeta
+}
+
+// WebhookDeliveryStatus constants
+const (
+	WebhookDeliveryPending   = "pending"   // waiting for its next attempt
+	WebhookDeliverySucceeded = "succeeded" // answered with a 2xx status
+	WebhookDeliveryDead      = "dead"      // gave up, until replayed
+)
+
+// ValidWebhookDeliveryStatuses returns all valid delivery statuses
+func ValidWebhookDeliveryStatuses() []string {
+	return []string{
+		WebhookDeliveryPending,
+		WebhookDeliverySucceeded,
+		WebhookDeliveryDead,
+	}
+}
+
+// IsValidWebhookDeliveryStatus checks if the delivery status is valid
+func IsValidWebhookDeliveryStatus(status string) bool {
+	for _, s := range ValidWebhookDeliveryStatuses() {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook event types
+const (
+	WebhookEventNewsPublished   = "news.published"
+	WebhookEventNewsUpdated     = "news.updated"
+	WebhookEventCategoryCreated = "category.created"
+	WebhookEventCategoryUpdated = "category.updated"
+)
+
+// ValidWebhookEvents returns all event types webhooks can subscribe to
+func ValidWebhookEvents() []string {
+	return []string{
+		WebhookEventNewsPublished,
+		WebhookEventNewsUpdated,
+		WebhookEventCategoryCreated,
+		WebhookEventCategoryUpdated,
+	}
+}
+
+// IsValidWebhookEvent checks if the event type is valid
+func IsValidWebhookEvent(event string) bool {
+	for _, e := range ValidWebhookEvents() {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,16 @@
+// Package mongocmd reads what the driver's command monitors need out of the
+// commands it reports.
+package mongocmd
+
+import "go.mongodb.org/mongo-driver/v2/event"
+
+// Collection returns the collection a command works on. For CRUD commands
+// the first element names it, e.g. {find: "news", ...}; admin commands hold
+// a number there and have none.
+func Collection(e *event.CommandStartedEvent) (string, bool) {
+	value, err := e.Command.LookupErr(e.CommandName)
+	if err != nil {
+		return "", false
+	}
+	return value.StringValueOK()
+}
@@ -0,0 +1,30 @@
+package mongocmd
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/event"
+)
+
+func TestCollection(t *testing.T) {
+	cases := []struct {
+		name    string
+		command bson.D
+		want    string
+	}{
+		{"find", bson.D{{Key: "find", Value: "news"}, {Key: "filter", Value: bson.D{}}}, "news"},
+		{"ping", bson.D{{Key: "ping", Value: 1}}, ""},
+		{"getMore", bson.D{{Key: "getMore", Value: int64(42)}, {Key: "collection", Value: "news"}}, ""},
+	}
+	for _, c := range cases {
+		raw, err := bson.Marshal(c.command)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := Collection(&event.CommandStartedEvent{Command: raw, CommandName: c.command[0].Key})
+		if got != c.want || ok != (c.want != "") {
+			t.Errorf("Collection(%s) = %q, %v; want %q", c.name, got, ok, c.want)
+		}
+	}
+}
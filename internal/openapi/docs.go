@@ -0,0 +1,21 @@
+package openapi
+
+import (
+	_ "embed"
+	"html"
+	"net/http"
+	"strings"
+)
+
+//go:embed docs.html
+var docsPage string
+
+// DocsHandler serves a self-contained reference page rendering the document
+// served at specURL
+func DocsHandler(specURL string) http.HandlerFunc {
+	page := strings.ReplaceAll(docsPage, "{{SPEC_URL}}", html.EscapeString(specURL))
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}
+}
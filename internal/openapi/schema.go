@@ -0,0 +1,250 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/validate"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var (
+	timeType     = reflect.TypeFor[time.Time]()
+	objectIDType = reflect.TypeFor[bson.ObjectID]()
+	rawJSONType  = reflect.TypeFor[json.RawMessage]()
+)
+
+// objectIDSchema is how ids look on the wire
+func objectIDSchema() *Schema {
+	return &Schema{Type: "string", Pattern: "^[0-9a-f]{24}$", Description: "object id"}
+}
+
+// withItems documents a response whose list field is []any, because it
+// holds items trimmed to the requested ?fields=, as a list of item
+type withItems struct {
+	value any
+	item  any
+}
+
+// Items describes list, a response with a []any field, as holding item
+// values, e.g. Items(models.NewsListResponse{}, models.NewsResponse{})
+func Items(list, item any) any {
+	return withItems{value: list, item: item}
+}
+
+// success documents a SuccessResponse with a known data shape
+type success struct {
+	data any
+}
+
+// Success describes a models.SuccessResponse whose data is shaped like data
+func Success(data any) any {
+	return success{data: data}
+}
+
+// generator turns Go values into schemas, collecting named structs under
+// components
+type generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newGenerator() *generator {
+	return &generator{schemas: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+// schemaOf returns the schema of v, which may be wrapped by Items or Success
+func (g *generator) schemaOf(v any) *Schema {
+	switch v := v.(type) {
+	case withItems:
+		s := g.inline(reflect.TypeOf(v.value))
+		item := g.schemaOf(v.item)
+		for _, prop := range s.Properties {
+			if prop.Type == "array" && prop.Items != nil && prop.Items.Type == "" && prop.Items.Ref == "" {
+				prop.Items = item
+			}
+		}
+		return s
+	case success:
+		s := g.inline(reflect.TypeFor[models.SuccessResponse]())
+		s.Properties["data"] = g.schemaOf(v.data)
+		return s
+	}
+	return g.schema(reflect.TypeOf(v))
+}
+
+func (g *generator) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == objectIDType:
+		return objectIDSchema()
+	case t == rawJSONType:
+		return &Schema{} // any value
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", ContentMediaType: "application/octet-stream"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.inline(t)
+		}
+		return g.ref(t)
+	}
+	return &Schema{} // any value
+}
+
+// ref registers a named struct under components and returns a reference
+func (g *generator) ref(t reflect.Type) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := g.schemas[name]; taken {
+			name = strings.ToUpper(path.Base(t.PkgPath())[:1]) + path.Base(t.PkgPath())[1:] + name
+		}
+		g.names[t] = name
+		g.schemas[name] = nil // placeholder, so recursive types terminate
+		g.schemas[name] = g.inline(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// inline returns the object schema of a struct. Embedded structs are
+// flattened like encoding/json does; binding tags become constraints.
+func (g *generator) inline(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	partial := t.NumField() > 0
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded := g.inline(field.Type)
+			for k, v := range embedded.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			partial = false
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if field.Type.Kind() != reflect.Pointer {
+			partial = false
+		}
+
+		prop := g.schema(field.Type)
+		if tag, ok := field.Tag.Lookup("binding"); ok {
+			rules := strings.Split(tag, ",")
+			if contains(rules, "required") {
+				s.Required = append(s.Required, name)
+			}
+			prop = constrain(prop, rules)
+		}
+		s.Properties[name] = prop
+	}
+	if partial {
+		s.Description = "Partial update: only the fields sent are changed."
+	}
+	return s
+}
+
+// constrain applies binding rules to a copy of prop
+func constrain(prop *Schema, rules []string) *Schema {
+	c := *prop
+	target := &c
+	for i, r := range rules {
+		name, arg, _ := strings.Cut(strings.TrimSpace(r), "=")
+		switch name {
+		case "dive":
+			switch {
+			case c.Items != nil:
+				c.Items = constrain(c.Items, rules[i+1:])
+			case c.AdditionalProperties != nil:
+				c.AdditionalProperties = constrain(c.AdditionalProperties, rules[i+1:])
+			}
+			return &c
+		case "email":
+			target.Format = "email"
+		case "url":
+			target.Format = "uri"
+		case "min", "max":
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				continue
+			}
+			setBound(target, name == "min", n)
+		case "trust_score":
+			lo, hi := float64(models.MinTrustScore), float64(models.MaxTrustScore)
+			target.Minimum, target.Maximum = &lo, &hi
+		default:
+			if values, ok := validate.Enum(name, arg); ok {
+				target.Enum = values
+			}
+		}
+	}
+	return &c
+}
+
+func setBound(s *Schema, lower bool, n int) {
+	switch s.Type {
+	case "string":
+		if lower {
+			s.MinLength = &n
+		} else {
+			s.MaxLength = &n
+		}
+	case "array":
+		if lower {
+			s.MinItems = &n
+		} else {
+			s.MaxItems = &n
+		}
+	case "integer", "number":
+		f := float64(n)
+		if lower {
+			s.Minimum = &f
+		} else {
+			s.Maximum = &f
+		}
+	}
+}
+
+func contains(rules []string, rule string) bool {
+	for _, r := range rules {
+		if strings.TrimSpace(r) == rule {
+			return true
+		}
+	}
+	return false
+}
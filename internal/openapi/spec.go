@@ -0,0 +1,340 @@
+package openapi
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"yoharsh14/krant-backend/internal/json"
+	"yoharsh14/krant-backend/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// adminScheme and ingestScheme are the security schemes of the admin and
+// ingest routes
+const (
+	adminScheme  = "adminToken"
+	ingestScheme = "ingestToken"
+)
+
+// Route describes one registered route
+type Route struct {
+	Summary      string
+	Description  string
+	Tags         []string
+	Admin        bool        // needs the admin bearer token
+	Ingest       bool        // needs the ingest or the admin bearer token
+	Query        []Parameter // path parameters are taken from the pattern
+	Body         any         // request body, a value of the input type
+	OptionalBody bool        // the body may be left out
+	Upload       bool        // the body may also be a multipart form with a "file" part
+	Status       int         // success status, 200 if zero
+	Response     any         // success body, a value of the response type
+	ContentType  string      // success content type when it is not JSON
+	Conditional  bool        // tagged with an ETag, answers conditional GETs 304
+	Deprecated   bool        // still served, but clients should move on, see Spec.Alias
+	Errors       []int       // statuses answered with an ErrorResponse
+}
+
+// Spec collects route descriptions and builds the document for a router
+type Spec struct {
+	info    Info
+	routes  map[string]Route  // by "METHOD pattern"
+	aliases map[string]string // "METHOD pattern" of the described route, by alias
+	common  []int             // error statuses of every route
+
+	// parameters and error statuses of every POST, PUT, PATCH and DELETE
+	mutatingParams []Parameter
+	mutatingErrors []int
+
+	once sync.Once
+	doc  *Document
+}
+
+// NewSpec returns an empty spec for the API with the given title and version
+func NewSpec(title, version string) *Spec {
+	return &Spec{
+		info:    Info{Title: title, Version: version},
+		routes:  map[string]Route{},
+		aliases: map[string]string{},
+	}
+}
+
+// Describe documents the route registered for method and the chi pattern.
+// Describing a route twice is a programming error and panics.
+func (s *Spec) Describe(method, pattern string, route Route) {
+	key := method + " " + pattern
+	if _, ok := s.routes[key]; ok {
+		panic("openapi: " + key + " described twice")
+	}
+	s.routes[key] = route
+}
+
+// Alias documents the route registered for method and pattern as a
+// deprecated copy of the one described for method and successor
+func (s *Spec) Alias(method, pattern, successor string) {
+	key := method + " " + pattern
+	if _, ok := s.aliases[key]; ok {
+		panic("openapi: " + key + " described twice")
+	}
+	s.aliases[key] = method + " " + successor
+}
+
+// route returns the description of key, following aliases
+func (s *Spec) route(key string) (Route, bool) {
+	if route, ok := s.routes[key]; ok {
+		return route, true
+	}
+	target, ok := s.aliases[key]
+	if !ok {
+		return Route{}, false
+	}
+	route, ok := s.routes[target]
+	if !ok {
+		return Route{}, false
+	}
+	_, successor, _ := strings.Cut(target, " ")
+	route.Deprecated = true
+	route.Description = strings.TrimSpace(route.Description + " Deprecated, use " + successor + ".")
+	return route, true
+}
+
+// CommonErrors adds statuses any route may answer with an ErrorResponse,
+// e.g. 429 from a rate limiter installed on the root router
+func (s *Spec) CommonErrors(codes ...int) {
+	s.common = append(s.common, codes...)
+}
+
+// Mutating adds params and error statuses to every POST, PUT, PATCH and
+// DELETE route, e.g. the Idempotency-Key header read by middleware on the
+// root router
+func (s *Spec) Mutating(params []Parameter, codes ...int) {
+	s.mutatingParams = append(s.mutatingParams, params...)
+	s.mutatingErrors = append(s.mutatingErrors, codes...)
+}
+
+// Build walks routes and returns the document. The error lists the routes
+// without a description and the descriptions without a route; the document
+// is still built from the rest.
+func (s *Spec) Build(routes chi.Routes) (*Document, error) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    s.info,
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			SecuritySchemes: map[string]SecurityScheme{
+				adminScheme:  {Type: "http", Scheme: "bearer"},
+				ingestScheme: {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+	g := newGenerator()
+	g.schema(reflect.TypeFor[models.ErrorResponse]())
+
+	var walked, undescribed []string
+	err := chi.Walk(routes, func(method, pattern string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		walked = append(walked, method+" "+pattern)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// sorted, so that schema names are picked in the same order every time
+	slices.Sort(walked)
+
+	described := map[string]bool{}
+	for _, key := range walked {
+		route, ok := s.route(key)
+		if !ok {
+			undescribed = append(undescribed, key)
+			continue
+		}
+		described[key] = true
+
+		method, pattern, _ := strings.Cut(key, " ")
+		p, params := openAPIPath(pattern)
+		if doc.Paths[p] == nil {
+			doc.Paths[p] = PathItem{}
+		}
+		doc.Paths[p][strings.ToLower(method)] = s.operation(g, method, p, params, route)
+	}
+	doc.Components.Schemas = g.schemas
+
+	var unrouted []string
+	for key := range s.routes {
+		if !described[key] {
+			unrouted = append(unrouted, key)
+		}
+	}
+	for key := range s.aliases {
+		if !described[key] {
+			unrouted = append(unrouted, key)
+		}
+	}
+	if len(undescribed) > 0 || len(unrouted) > 0 {
+		slices.Sort(unrouted)
+		return doc, fmt.Errorf("openapi: routes without a description: %v; descriptions without a route: %v", undescribed, unrouted)
+	}
+	return doc, nil
+}
+
+// Handler serves the document built from routes. It is built on the first
+// request, once every route is registered; mismatches are logged.
+func (s *Spec) Handler(routes chi.Routes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.once.Do(func() {
+			var err error
+			s.doc, err = s.Build(routes)
+			if err != nil {
+				slog.Warn("openapi document is incomplete", "error", err)
+			}
+		})
+		if s.doc == nil {
+			json.Error(w, r, fmt.Errorf("openapi document could not be built"))
+			return
+		}
+		json.Write(w, http.StatusOK, s.doc)
+	}
+}
+
+func (s *Spec) operation(g *generator, method, path string, params []Parameter, route Route) *Operation {
+	op := &Operation{
+		OperationID: operationID(method, path),
+		Summary:     route.Summary,
+		Description: route.Description,
+		Tags:        route.Tags,
+		Deprecated:  route.Deprecated,
+		Parameters:  append(params, route.Query...),
+		Responses:   map[string]Response{},
+	}
+
+	if route.Body != nil {
+		op.RequestBody = &RequestBody{
+			Required: !route.OptionalBody,
+			Content:  map[string]MediaType{"application/json": {Schema: g.schemaOf(route.Body)}},
+		}
+	}
+	if route.Upload {
+		if op.RequestBody == nil {
+			op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{}}
+		}
+		op.RequestBody.Content["multipart/form-data"] = MediaType{Schema: &Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"file": {Type: "string", ContentMediaType: "application/octet-stream"}},
+			Required:   []string{"file"},
+		}}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Response{Description: http.StatusText(status)}
+	switch {
+	case route.ContentType != "":
+		success.Content = map[string]MediaType{route.ContentType: {Schema: &Schema{Type: "string", ContentMediaType: route.ContentType}}}
+	case route.Response != nil:
+		success.Content = map[string]MediaType{"application/json": {Schema: g.schemaOf(route.Response)}}
+	}
+	op.Responses[fmt.Sprint(status)] = success
+	if route.Conditional {
+		op.Parameters = append(op.Parameters,
+			Parameter{Name: "If-None-Match", In: "header", Description: "ETags of representations the client has.", Schema: &Schema{Type: "string"}},
+			Parameter{Name: "If-Modified-Since", In: "header", Description: "Ignored when If-None-Match is sent.", Schema: &Schema{Type: "string"}},
+		)
+		op.Responses[fmt.Sprint(http.StatusNotModified)] = Response{Description: http.StatusText(http.StatusNotModified)}
+	}
+
+	errorsFor := slices.Concat(route.Errors, s.common)
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		op.Parameters = append(op.Parameters, s.mutatingParams...)
+		errorsFor = slices.Concat(errorsFor, s.mutatingErrors)
+	}
+	switch {
+	case route.Admin:
+		op.Security = []map[string][]string{{adminScheme: {}}}
+		errorsFor = slices.Concat(errorsFor, []int{http.StatusUnauthorized, http.StatusForbidden})
+	case route.Ingest:
+		op.Security = []map[string][]string{{ingestScheme: {}}, {adminScheme: {}}}
+		errorsFor = slices.Concat(errorsFor, []int{http.StatusUnauthorized, http.StatusForbidden})
+	}
+	errorBody := map[string]MediaType{"application/json": {Schema: &Schema{Ref: "#/components/schemas/ErrorResponse"}}}
+	for _, code := range errorsFor {
+		op.Responses[fmt.Sprint(code)] = Response{Description: http.StatusText(code), Content: errorBody}
+	}
+	op.Responses["default"] = Response{Description: "Unexpected error", Content: errorBody}
+	return op
+}
+
+var paramPattern = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// openAPIPath converts a chi pattern to an OpenAPI path and its path
+// parameters. A trailing wildcard becomes a {path} parameter. Parameters
+// named id or ending in ID are object ids.
+func openAPIPath(pattern string) (string, []Parameter) {
+	if strings.HasSuffix(pattern, "/*") {
+		pattern = strings.TrimSuffix(pattern, "*") + "{path}"
+	}
+
+	var params []Parameter
+	p := paramPattern.ReplaceAllStringFunc(pattern, func(m string) string {
+		name := paramPattern.FindStringSubmatch(m)[1]
+		schema := &Schema{Type: "string"}
+		if name == "id" || strings.HasSuffix(name, "ID") {
+			schema = objectIDSchema()
+		}
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: schema})
+		return "{" + name + "}"
+	})
+	return p, params
+}
+
+// operationID names an operation after its method and path, e.g.
+// GET /user/{id}/feed is getUserByIdFeed
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, segment := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '-' || r == '.' }) {
+		if strings.HasPrefix(segment, "{") {
+			b.WriteString("By")
+			segment = strings.Trim(segment, "{}")
+		}
+		b.WriteString(strings.ToUpper(segment[:1]) + segment[1:])
+	}
+	return b.String()
+}
+
+// ============================================================================
+// COMMON PARAMETERS
+// ============================================================================
+
+// PageParams are the pagination parameters of list endpoints
+func PageParams() []Parameter {
+	return []Parameter{
+		{Name: "limit", In: "query", Description: "Items per page, 1 to 100.", Schema: &Schema{Type: "integer"}},
+		{Name: "cursor", In: "query", Description: "The next_cursor of the previous page.", Schema: &Schema{Type: "string"}},
+		{Name: "page", In: "query", Description: "Legacy offset pagination, ignored when cursor is set.", Schema: &Schema{Type: "integer"}},
+	}
+}
+
+// QueryParams are the pagination and query language parameters of list
+// endpoints: ?filter[field][op]=value, ?sort=-field and ?fields=a,b
+func QueryParams() []Parameter {
+	explode := true
+	return append(PageParams(),
+		Parameter{Name: "filter", In: "query", Description: "Filters as filter[field]=value or filter[field][op]=value; op is one of eq, ne, gt, gte, lt, lte, in, nin, contains, exists.", Style: "deepObject", Explode: &explode, Schema: &Schema{Type: "object", AdditionalProperties: &Schema{}}},
+		Parameter{Name: "sort", In: "query", Description: "Field to sort by, prefixed with - for descending.", Schema: &Schema{Type: "string"}},
+		Parameter{Name: "fields", In: "query", Description: "Comma separated fields to include in each item.", Schema: &Schema{Type: "string"}},
+	)
+}
+
+// Enum returns an optional query parameter taking one of values
+func Enum(name, description string, values ...string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "string", Enum: values}}
+}
@@ -0,0 +1,94 @@
+// Package query turns list endpoint URL parameters into validated Mongo
+// filters, sorts and projections.
+//
+// The syntax is shared by every list endpoint:
+//
+//	?filter[trader_type]=day_trader          equality
+//	?filter[created_at][gte]=2024-01-01      operator
+//	?filter[categories][in]=stocks,crypto    comma separated list
+//	?sort=-created_at                        one field, "-" for descending
+//	?fields=id,name                          sparse response
+//
+// Each resource declares a Schema listing the fields clients may use and the
+// operators allowed on them; anything else is rejected with ErrInvalidQuery.
+package query
+
+import (
+	"encoding/json"
+	"yoharsh14/krant-backend/internal/apperr"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ErrInvalidQuery wraps every error caused by bad query parameters
+var ErrInvalidQuery = apperr.Validation("invalid query").WithCode("invalid_query")
+
+// Sort is the field a listing is ordered by. _id is always appended as a tie
+// breaker so that the order is total.
+type Sort struct {
+	Field string // stored (bson) path
+	Desc  bool
+}
+
+// Direction returns the Mongo sort direction
+func (s Sort) Direction() int {
+	if s.Desc {
+		return -1
+	}
+	return 1
+}
+
+// Query is a parsed listing request
+type Query struct {
+	Filter     bson.M
+	Sort       Sort
+	Projection bson.M   // nil selects every stored field
+	Fields     []string // response fields requested through ?fields=, nil for all
+}
+
+// And narrows the query down with an extra condition, e.g. one the service
+// enforces regardless of what the client asked for
+func (q Query) And(cond bson.M) Query {
+	switch {
+	case len(cond) == 0:
+	case len(q.Filter) == 0:
+		q.Filter = cond
+	default:
+		q.Filter = bson.M{"$and": []bson.M{q.Filter, cond}}
+	}
+	return q
+}
+
+// Where returns a query with the given filter and sort and no projection
+func Where(filter bson.M, sort Sort) Query {
+	return Query{Filter: filter, Sort: sort}
+}
+
+// Select trims a response down to the fields requested through ?fields=.
+// The id is always kept. Responses are returned unchanged when no fields
+// were requested.
+func (q Query) Select(v any) (any, error) {
+	if q.Fields == nil {
+		return v, nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return nil, err
+	}
+
+	picked := make(map[string]json.RawMessage, len(q.Fields)+1)
+	if id, ok := all["id"]; ok {
+		picked["id"] = id
+	}
+	for _, name := range q.Fields {
+		if val, ok := all[name]; ok {
+			picked[name] = val
+		}
+	}
+	return picked, nil
+}
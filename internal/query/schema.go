@@ -0,0 +1,242 @@
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Op is a filter operator
+type Op string
+
+const (
+	Eq       Op = "eq"
+	Ne       Op = "ne"
+	Gt       Op = "gt"
+	Gte      Op = "gte"
+	Lt       Op = "lt"
+	Lte      Op = "lte"
+	In       Op = "in"
+	Nin      Op = "nin"
+	Contains Op = "contains" // case-insensitive substring
+	Exists   Op = "exists"
+)
+
+// Kind is the type filter values are converted to
+type Kind int
+
+const (
+	String Kind = iota
+	Int
+	Float
+	Bool
+	Time
+	ObjectID
+)
+
+// Range is the set of comparison operators
+var Range = []Op{Gt, Gte, Lt, Lte}
+
+// Field describes one field clients may filter, sort or select on
+type Field struct {
+	Name     string // name in URLs and responses
+	Path     string // stored (bson) path, defaults to Name
+	Kind     Kind
+	Ops      []Op // allowed filter operators, none means not filterable
+	Sortable bool
+	Hidden   bool              // can be filtered on but is not part of responses
+	Valid    func(string) bool // optional check of each filter value
+	Needs    []string          // other stored paths the response needs for this field
+}
+
+func (f Field) path() string {
+	if f.Path != "" {
+		return f.Path
+	}
+	return f.Name
+}
+
+func (f Field) allows(op Op) bool {
+	for _, o := range f.Ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Schema is the allowlist of a resource's queryable fields
+type Schema struct {
+	fields      map[string]Field
+	defaultSort Sort
+}
+
+// NewSchema builds a schema. defaultSort applies when ?sort= is absent and
+// uses the stored path.
+func NewSchema(defaultSort Sort, fields ...Field) *Schema {
+	s := &Schema{fields: make(map[string]Field, len(fields)), defaultSort: defaultSort}
+	for _, f := range fields {
+		s.fields[f.Name] = f
+	}
+	return s
+}
+
+// Parse reads the filter[...], sort and fields parameters. Unknown
+// parameters are left alone so endpoints can add their own.
+func (s *Schema) Parse(values url.Values) (Query, error) {
+	q := Query{Filter: bson.M{}, Sort: s.defaultSort}
+
+	for key, vals := range values {
+		if !strings.HasPrefix(key, "filter[") {
+			continue
+		}
+		name, op, err := parseFilterKey(key)
+		if err != nil {
+			return Query{}, err
+		}
+		for _, raw := range vals {
+			if err := s.addFilter(q.Filter, name, op, raw); err != nil {
+				return Query{}, err
+			}
+		}
+	}
+
+	if sort := values.Get("sort"); sort != "" {
+		desc := strings.HasPrefix(sort, "-")
+		f, ok := s.fields[strings.TrimPrefix(sort, "-")]
+		if !ok || !f.Sortable {
+			return Query{}, fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, strings.TrimPrefix(sort, "-"))
+		}
+		q.Sort = Sort{Field: f.path(), Desc: desc}
+	}
+
+	if fields := values.Get("fields"); fields != "" {
+		// _id and the sort field are always loaded; cursors are built from them
+		q.Projection = bson.M{"_id": 1, q.Sort.Field: 1}
+		for _, name := range strings.Split(fields, ",") {
+			name = strings.TrimSpace(name)
+			f, ok := s.fields[name]
+			if !ok || f.Hidden {
+				return Query{}, fmt.Errorf("%w: unknown field %q", ErrInvalidQuery, name)
+			}
+			q.Projection[f.path()] = 1
+			for _, p := range f.Needs {
+				q.Projection[p] = 1
+			}
+			q.Fields = append(q.Fields, name)
+		}
+	}
+
+	return q, nil
+}
+
+// parseFilterKey splits filter[name] and filter[name][op]
+func parseFilterKey(key string) (string, Op, error) {
+	inner := strings.TrimPrefix(key, "filter")
+	if len(inner) < 2 || !strings.HasSuffix(inner, "]") {
+		return "", "", fmt.Errorf("%w: malformed parameter %q", ErrInvalidQuery, key)
+	}
+	parts := strings.Split(inner[1:len(inner)-1], "][")
+	switch len(parts) {
+	case 1:
+		return parts[0], Eq, nil
+	case 2:
+		return parts[0], Op(parts[1]), nil
+	}
+	return "", "", fmt.Errorf("%w: malformed parameter %q", ErrInvalidQuery, key)
+}
+
+func (s *Schema) addFilter(filter bson.M, name string, op Op, raw string) error {
+	f, ok := s.fields[name]
+	if !ok || len(f.Ops) == 0 {
+		return fmt.Errorf("%w: cannot filter by %q", ErrInvalidQuery, name)
+	}
+	if !f.allows(op) {
+		return fmt.Errorf("%w: operator %q is not allowed on %q", ErrInvalidQuery, op, name)
+	}
+
+	var value any
+	switch op {
+	case In, Nin:
+		items := strings.Split(raw, ",")
+		list := make([]any, 0, len(items))
+		for _, item := range items {
+			v, err := f.convert(strings.TrimSpace(item))
+			if err != nil {
+				return err
+			}
+			list = append(list, v)
+		}
+		value = list
+	case Contains:
+		value = bson.Regex{Pattern: regexp.QuoteMeta(raw), Options: "i"}
+	case Exists:
+		exists, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%w: bad value %q for %q", ErrInvalidQuery, raw, name)
+		}
+		value = exists
+	default:
+		v, err := f.convert(raw)
+		if err != nil {
+			return err
+		}
+		value = v
+	}
+
+	mongoOp := "$" + string(op)
+	if op == Contains {
+		mongoOp = "$regex"
+	}
+	cond, _ := filter[f.path()].(bson.M)
+	if cond == nil {
+		cond = bson.M{}
+		filter[f.path()] = cond
+	}
+	if _, dup := cond[mongoOp]; dup {
+		return fmt.Errorf("%w: operator %q given twice for %q", ErrInvalidQuery, op, name)
+	}
+	cond[mongoOp] = value
+	return nil
+}
+
+// convert parses a filter value into the field's kind
+func (f Field) convert(raw string) (any, error) {
+	if f.Valid != nil && !f.Valid(raw) {
+		return nil, fmt.Errorf("%w: %q is not a valid %s", ErrInvalidQuery, raw, f.Name)
+	}
+
+	var v any
+	var err error
+	switch f.Kind {
+	case Int:
+		v, err = strconv.ParseInt(raw, 10, 64)
+	case Float:
+		v, err = strconv.ParseFloat(raw, 64)
+	case Bool:
+		v, err = strconv.ParseBool(raw)
+	case Time:
+		v, err = parseTime(raw)
+	case ObjectID:
+		v, err = bson.ObjectIDFromHex(raw)
+	default:
+		v = raw
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad value %q for %q", ErrInvalidQuery, raw, f.Name)
+	}
+	return v, nil
+}
+
+// parseTime accepts RFC 3339 timestamps and plain dates
+func parseTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, raw)
+}
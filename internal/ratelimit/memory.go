@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often idle buckets are dropped
+const sweepInterval = time.Minute
+
+// MemoryStore keeps buckets in the process. Each instance limits on its own,
+// so behind a load balancer clients get the limit once per instance.
+type MemoryStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time // when tokens was last brought up to date
+	full   time.Time // when the bucket will be full again
+}
+
+// NewMemoryStore returns an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{now: time.Now, buckets: map[string]*bucket{}}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, policy Policy) (Result, error) {
+	now := s.now()
+	limit, rate := float64(policy.Limit), policy.rate()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit, last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(limit, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	res := Result{}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((limit - b.tokens) / rate)
+	b.full = now.Add(res.Reset)
+	return res, nil
+}
+
+// sweep drops the buckets that have filled up again, which are the same as
+// no bucket at all. The caller holds the lock.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreBuckets(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+	policy := Policy{Name: "default", Limit: 3, Period: 3 * time.Second} // a token a second
+
+	take := func(key string) Result {
+		t.Helper()
+		res, err := s.Take(ctx, key, policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	// a full bucket allows a burst of the whole limit
+	for i, remaining := range []int{2, 1, 0} {
+		res := take("a")
+		if !res.Allowed || res.Remaining != remaining || res.Reset != time.Duration(3-remaining)*time.Second {
+			t.Errorf("request %d = %+v; want allowed with %d left, full again in %ds", i+1, res, remaining, 3-remaining)
+		}
+	}
+	if res := take("a"); res.Allowed || res.Remaining != 0 || res.RetryAfter != time.Second || res.Reset != 3*time.Second {
+		t.Errorf("request past the burst = %+v; want refused, retry in 1s", res)
+	}
+	if res := take("b"); !res.Allowed || res.Remaining != 2 {
+		t.Errorf("another key = %+v; want its own full bucket", res)
+	}
+
+	// the bucket refills at the steady rate
+	now = now.Add(500 * time.Millisecond)
+	if res := take("a"); res.Allowed || res.RetryAfter != 500*time.Millisecond {
+		t.Errorf("half a token in = %+v; want refused, retry in 500ms", res)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if res := take("a"); !res.Allowed || res.Remaining != 0 {
+		t.Errorf("a token in = %+v; want allowed with none left", res)
+	}
+	now = now.Add(time.Hour)
+	if res := take("a"); !res.Allowed || res.Remaining != 2 {
+		t.Errorf("long idle = %+v; want a full bucket, not more", res)
+	}
+}
+
+func TestMemoryStoreSweepsFullBuckets(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+	policy := Policy{Name: "default", Limit: 10, Period: time.Minute}
+
+	for _, key := range []string{"a", "b"} {
+		if _, err := s.Take(ctx, key, policy); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now = now.Add(2 * sweepInterval)
+	if _, err := s.Take(ctx, "c", policy); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.buckets) != 1 {
+		t.Errorf("%d buckets after a sweep, want only the one just used", len(s.buckets))
+	}
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+	"yoharsh14/krant-backend/internal/apperr"
+	"yoharsh14/krant-backend/internal/auth"
+	"yoharsh14/krant-backend/internal/json"
+	"yoharsh14/krant-backend/internal/metrics"
+)
+
+// ErrRateLimited is answered to clients whose bucket is empty
+var ErrRateLimited = apperr.New(apperr.KindRateLimited, "too many requests, retry later")
+
+var rejected = metrics.NewCounterVec("http_rate_limited_total",
+	"Requests answered 429 by the rate limiter, by policy.",
+	"policy")
+
+// Limiter holds clients to policies, keeping their buckets in a store
+type Limiter struct {
+	store Store
+}
+
+// NewLimiter returns a limiter counting in store. A nil store lets every
+// request through, which is how limiting is turned off.
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store}
+}
+
+// Limit returns middleware holding each client to policy. The client's bucket
+// is reported in RateLimit-Limit, -Remaining, -Reset and -Policy headers;
+// once it is empty requests are answered 429 with a Retry-After header.
+func (l *Limiter) Limit(policy Policy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if l.store == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res, err := l.store.Take(r.Context(), policy.Name+":"+ClientKey(r), policy)
+			if err != nil {
+				// a broken store must not take the API down with it
+				slog.WarnContext(r.Context(), "rate limit store failed", "policy", policy.Name, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			writeHeaders(w.Header(), policy, res)
+			if !res.Allowed {
+				rejected.With(policy.Name).Inc()
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				json.Error(w, r, ErrRateLimited)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientKey identifies who is limited: the authenticated subject when a
+// limiter runs after the credentials were checked, so clients sharing an
+// address behind NAT keep their own buckets, and the client IP otherwise,
+// which clientip.Middleware takes from the headers of trusted proxies.
+// Limiters running before the check limit guessing the credentials too.
+func ClientKey(r *http.Request) string {
+	if subject, ok := auth.Subject(r.Context()); ok {
+		return "subject:" + subject
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr // clientip leaves a bare address
+	}
+	return "ip:" + host
+}
+
+// writeHeaders reports res unless an earlier policy on the same request left
+// the client fewer requests, so clients see the limit they will hit first
+func writeHeaders(h http.Header, policy Policy, res Result) {
+	if current, err := strconv.Atoi(h.Get("RateLimit-Remaining")); err == nil && current < res.Remaining {
+		return
+	}
+	h.Set("RateLimit-Limit", strconv.Itoa(policy.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, ceilSeconds(policy.Period)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
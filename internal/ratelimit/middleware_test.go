@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"yoharsh14/krant-backend/internal/auth"
+)
+
+// brokenStore fails every Take
+type brokenStore struct{}
+
+func (brokenStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	return Result{}, errors.New("connection refused")
+}
+
+func TestLimit(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limiter := NewLimiter(store)
+	h := limiter.Limit(Policy{Name: "default", Limit: 2, Period: time.Minute})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	get := func(addr string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest("GET", "/v1/news", nil)
+		r.RemoteAddr = addr
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get("203.0.113.7:51000")
+	if w.Code != http.StatusOK {
+		t.Fatalf("first request = %d, want 200", w.Code)
+	}
+	for header, want := range map[string]string{
+		"RateLimit-Limit":     "2",
+		"RateLimit-Remaining": "1",
+		"RateLimit-Reset":     "30",
+		"RateLimit-Policy":    "2;w=60",
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	// the port differs per connection, the client does not
+	get("203.0.113.7:51001")
+	w = get("203.0.113.7:51002")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("request past the limit = %d with Retry-After %q; want 429, retry in 30s", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := get("198.51.100.1:51000"); w.Code != http.StatusOK {
+		t.Errorf("another client = %d, want 200", w.Code)
+	}
+}
+
+func TestLimitReportsTheTightestPolicy(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore())
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h = limiter.Limit(Policy{Name: "loose", Limit: 100, Period: time.Minute})(h)
+	h = limiter.Limit(Policy{Name: "tight", Limit: 5, Period: time.Minute})(h)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if got := w.Header().Get("RateLimit-Limit"); got != "5" {
+		t.Errorf("RateLimit-Limit = %q, want the tight policy's 5", got)
+	}
+}
+
+func TestLimitLetsRequestsThroughWithoutAStore(t *testing.T) {
+	for name, limiter := range map[string]*Limiter{"disabled": NewLimiter(nil), "broken store": NewLimiter(brokenStore{})} {
+		called := false
+		h := limiter.Limit(Policy{Name: "default", Limit: 1, Period: time.Minute})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		if !called || w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+			t.Errorf("%s: called %v with %d, want the request through unlimited", name, called, w.Code)
+		}
+	}
+}
+
+func TestLimitKeysAuthenticatedClientsBySubject(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore())
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h = limiter.Limit(Policy{Name: "ingest", Limit: 1, Period: time.Minute})(h)
+	h = auth.RequireIngest("ingest-token", "admin-token")(h)
+
+	post := func(addr, token string) int {
+		t.Helper()
+		r := httptest.NewRequest("POST", "/v1/news", nil)
+		r.RemoteAddr = addr
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// behind one NAT address, each credential has its own bucket
+	if code := post("203.0.113.7:51000", "ingest-token"); code != http.StatusOK {
+		t.Fatalf("first ingest request = %d, want 200", code)
+	}
+	if code := post("203.0.113.7:51001", "admin-token"); code != http.StatusOK {
+		t.Errorf("admin request from the same address = %d, want 200", code)
+	}
+	// and a credential keeps its bucket across addresses
+	if code := post("198.51.100.1:51000", "ingest-token"); code != http.StatusTooManyRequests {
+		t.Errorf("ingest request from another address = %d, want 429", code)
+	}
+}
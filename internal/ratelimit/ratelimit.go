@@ -0,0 +1,45 @@
+// Package ratelimit limits how often each client may call the API, with a
+// token bucket per client and policy.
+//
+// A bucket holds up to Limit tokens and refills at Limit per Period; every
+// request takes one, and a request finding the bucket empty is answered 429.
+// Clients can burst through their whole allowance at once, then continue at
+// the steady rate.
+//
+// Policies are applied per route with Limiter.Limit; a request passing
+// several limiters, e.g. the default one and a stricter one for ingestion,
+// must get through all of them. Buckets live in a Store, in memory unless
+// the limits have to be shared between instances.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Policy is a rate limit applied to a set of routes
+type Policy struct {
+	Name   string // tells the buckets of different policies apart
+	Limit  int    // requests allowed per Period, and the largest burst
+	Period time.Duration
+}
+
+// rate returns how many tokens the bucket regains per second
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// Result is the state of a bucket after a request tried to take a token
+type Result struct {
+	Allowed    bool
+	Remaining  int           // tokens left
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next token, when not allowed
+}
+
+// Store keeps the buckets
+type Store interface {
+	// Take takes a token from the bucket of key under policy, creating a full
+	// bucket if there is none yet
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
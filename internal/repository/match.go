@@ -0,0 +1,596 @@
+package repository
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// This file evaluates MongoDB filters, sorts, projections and updates against
+// documents held in memory. It covers the operators the repositories and the
+// query language produce, with MongoDB's semantics for missing fields, arrays
+// and the ordering between BSON types.
+
+// normalize round-trips v through BSON so documents and filter values are
+// made of the same types (int32, int64, float64, string, bool, nil,
+// bson.DateTime, bson.ObjectID, bson.Regex, bson.A, bson.D)
+func normalize(v any) (bson.D, error) {
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var d bson.D
+	if err := bson.Unmarshal(raw, &d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// normalizeValue normalizes a single value
+func normalizeValue(v any) (any, error) {
+	d, err := normalize(bson.D{{Key: "v", Value: v}})
+	if err != nil {
+		return nil, err
+	}
+	return d[0].Value, nil
+}
+
+func get(d bson.D, key string) (any, bool) {
+	for _, e := range d {
+		if e.Key == key {
+			return e.Value, true
+		}
+	}
+	return nil, false
+}
+
+// lookup resolves a dotted path. Arrays met along the way fan out to their
+// elements, as in MongoDB, so the result may hold several values.
+func lookup(v any, path []string) []any {
+	if len(path) == 0 {
+		return []any{v}
+	}
+	switch v := v.(type) {
+	case bson.D:
+		next, ok := get(v, path[0])
+		if !ok {
+			return nil
+		}
+		return lookup(next, path[1:])
+	case bson.A:
+		var out []any
+		for _, item := range v {
+			if _, isDoc := item.(bson.D); isDoc {
+				out = append(out, lookup(item, path)...)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// matches reports whether doc satisfies filter
+func matches(doc bson.D, filter bson.D) (bool, error) {
+	for _, e := range filter {
+		var ok bool
+		var err error
+		switch e.Key {
+		case "$and", "$or", "$nor":
+			ok, err = matchLogical(doc, e.Key, e.Value)
+		default:
+			ok, err = matchField(lookup(doc, strings.Split(e.Key, ".")), e.Value)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchLogical(doc bson.D, op string, v any) (bool, error) {
+	clauses, ok := v.(bson.A)
+	if !ok || len(clauses) == 0 {
+		return false, fmt.Errorf("%s needs a non-empty array", op)
+	}
+	for _, c := range clauses {
+		sub, ok := c.(bson.D)
+		if !ok {
+			return false, fmt.Errorf("%s entries must be documents", op)
+		}
+		m, err := matches(doc, sub)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case op == "$and" && !m:
+			return false, nil
+		case op == "$or" && m:
+			return true, nil
+		case op == "$nor" && m:
+			return false, nil
+		}
+	}
+	return op != "$or", nil
+}
+
+// isOperatorDoc reports whether cond is an operator expression such as
+// {$gt: 1} rather than a document to compare against
+func isOperatorDoc(cond any) (bson.D, bool) {
+	d, ok := cond.(bson.D)
+	if !ok || len(d) == 0 || !strings.HasPrefix(d[0].Key, "$") {
+		return nil, false
+	}
+	return d, true
+}
+
+func matchField(values []any, cond any) (bool, error) {
+	ops, ok := isOperatorDoc(cond)
+	if !ok {
+		return equalsAny(values, cond), nil
+	}
+
+	for _, op := range ops {
+		var ok bool
+		switch op.Key {
+		case "$eq":
+			ok = equalsAny(values, op.Value)
+		case "$ne":
+			ok = !equalsAny(values, op.Value)
+		case "$gt", "$gte", "$lt", "$lte":
+			ok = compareAny(values, op.Key, op.Value)
+		case "$in", "$nin":
+			list, isList := op.Value.(bson.A)
+			if !isList {
+				return false, fmt.Errorf("%s needs an array", op.Key)
+			}
+			for _, item := range list {
+				if equalsAny(values, item) {
+					ok = true
+					break
+				}
+			}
+			if op.Key == "$nin" {
+				ok = !ok
+			}
+		case "$exists":
+			want, _ := op.Value.(bool)
+			ok = (len(values) > 0) == want
+		case "$type":
+			var err error
+			if ok, err = matchType(values, op.Value); err != nil {
+				return false, err
+			}
+		case "$regex":
+			re, err := compileRegex(op.Value, ops)
+			if err != nil {
+				return false, err
+			}
+			ok = equalsAny(values, re)
+		case "$options":
+			continue // read together with $regex
+		default:
+			return false, fmt.Errorf("unsupported operator %s", op.Key)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// matchType applies $type given type aliases, e.g. "string" or ["int",
+// "long"]. A missing field has no type; the elements of arrays are matched
+// as well as the arrays.
+func matchType(values []any, types any) (bool, error) {
+	aliases, isList := types.(bson.A)
+	if !isList {
+		aliases = bson.A{types}
+	}
+	if len(values) == 0 {
+		return false, nil
+	}
+	for _, alias := range aliases {
+		name, isName := alias.(string)
+		if !isName {
+			return false, fmt.Errorf("$type needs type aliases")
+		}
+		for _, v := range candidates(values) {
+			if typeAlias(v) == name || name == "number" && rank(v) == rank(0.0) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// typeAlias is the $type alias of a normalized value
+func typeAlias(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case float64:
+		return "double"
+	case int32:
+		return "int"
+	case int64:
+		return "long"
+	case string:
+		return "string"
+	case bson.D:
+		return "object"
+	case bson.A:
+		return "array"
+	case bson.Binary:
+		return "binData"
+	case bson.ObjectID:
+		return "objectId"
+	case bool:
+		return "bool"
+	case bson.DateTime:
+		return "date"
+	case bson.Timestamp:
+		return "timestamp"
+	case bson.Regex:
+		return "regex"
+	}
+	return ""
+}
+
+func compileRegex(pattern any, ops bson.D) (*regexp.Regexp, error) {
+	var expr, flags string
+	switch p := pattern.(type) {
+	case bson.Regex:
+		expr, flags = p.Pattern, p.Options
+	case string:
+		expr = p
+		if o, ok := get(ops, "$options"); ok {
+			flags, _ = o.(string)
+		}
+	default:
+		return nil, fmt.Errorf("$regex needs a string")
+	}
+	if strings.Contains(flags, "i") {
+		expr = "(?i)" + expr
+	}
+	return regexp.Compile(expr)
+}
+
+// candidates expands array values to their elements while keeping the array
+// itself, so a condition can match either. A missing field is a null.
+func candidates(values []any) []any {
+	if len(values) == 0 {
+		return []any{nil}
+	}
+	var out []any
+	for _, v := range values {
+		out = append(out, v)
+		if a, ok := v.(bson.A); ok {
+			out = append(out, a...)
+		}
+	}
+	return out
+}
+
+func equalsAny(values []any, want any) bool {
+	var re *regexp.Regexp
+	switch w := want.(type) {
+	case *regexp.Regexp:
+		re = w
+	case bson.Regex:
+		re, _ = compileRegex(w, nil)
+	}
+
+	for _, v := range candidates(values) {
+		if re != nil {
+			if s, ok := v.(string); ok && re.MatchString(s) {
+				return true
+			}
+			continue
+		}
+		if compare(v, want) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// compareAny applies a range operator. As in MongoDB only values of the same
+// type bracket are compared, so {$gt: 5} never matches a string.
+func compareAny(values []any, op string, bound any) bool {
+	for _, v := range candidates(values) {
+		if rank(v) != rank(bound) {
+			continue
+		}
+		c := compare(v, bound)
+		if (op == "$gt" && c > 0) || (op == "$gte" && c >= 0) ||
+			(op == "$lt" && c < 0) || (op == "$lte" && c <= 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// rank is MongoDB's ordering between BSON types
+func rank(v any) int {
+	switch v.(type) {
+	case nil:
+		return 1
+	case int32, int64, float64:
+		return 2
+	case string:
+		return 3
+	case bson.D:
+		return 4
+	case bson.A:
+		return 5
+	case bson.Binary:
+		return 6
+	case bson.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case bson.DateTime:
+		return 9
+	case bson.Timestamp:
+		return 10
+	case bson.Regex:
+		return 11
+	}
+	return 12
+}
+
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
+
+func sign[T int | int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compare orders two normalized values the way MongoDB sorts them
+func compare(a, b any) int {
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return sign(ra, rb)
+	}
+
+	switch a := a.(type) {
+	case nil:
+		return 0
+	case int32, int64, float64:
+		return sign(toFloat(a), toFloat(b))
+	case string:
+		return strings.Compare(a, b.(string))
+	case bool:
+		bb := b.(bool)
+		if a == bb {
+			return 0
+		}
+		if !a {
+			return -1
+		}
+		return 1
+	case bson.DateTime:
+		return sign(int64(a), int64(b.(bson.DateTime)))
+	case bson.ObjectID:
+		bo := b.(bson.ObjectID)
+		return bytes.Compare(a[:], bo[:])
+	case bson.D:
+		bd := b.(bson.D)
+		for i := 0; i < len(a) && i < len(bd); i++ {
+			if c := strings.Compare(a[i].Key, bd[i].Key); c != 0 {
+				return c
+			}
+			if c := compare(a[i].Value, bd[i].Value); c != 0 {
+				return c
+			}
+		}
+		return sign(len(a), len(bd))
+	case bson.A:
+		ba := b.(bson.A)
+		for i := 0; i < len(a) && i < len(ba); i++ {
+			if c := compare(a[i], ba[i]); c != 0 {
+				return c
+			}
+		}
+		return sign(len(a), len(ba))
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// sortValue is the value a document sorts by for the given path
+func sortValue(doc bson.D, path string) any {
+	values := lookup(doc, strings.Split(path, "."))
+	if len(values) == 0 {
+		return nil
+	}
+	return values[0]
+}
+
+// project keeps _id and the included paths; only inclusion projections are
+// supported
+func project(doc bson.D, projection bson.D) bson.D {
+	out := bson.D{}
+	if id, ok := get(doc, "_id"); ok {
+		out = append(out, bson.E{Key: "_id", Value: id})
+	}
+	for _, e := range doc {
+		if e.Key == "_id" {
+			continue
+		}
+		var nested bson.D
+		for _, p := range projection {
+			if p.Key == e.Key {
+				out = append(out, e)
+				nested = nil
+				break
+			}
+			if rest, ok := strings.CutPrefix(p.Key, e.Key+"."); ok {
+				nested = append(nested, bson.E{Key: rest, Value: p.Value})
+			}
+		}
+		if sub, ok := e.Value.(bson.D); ok && len(nested) > 0 {
+			projected := project(sub, nested)
+			if id, _ := get(sub, "_id"); id == nil {
+				projected = dropKey(projected, "_id")
+			}
+			out = append(out, bson.E{Key: e.Key, Value: projected})
+		}
+	}
+	return out
+}
+
+func dropKey(d bson.D, key string) bson.D {
+	out := d[:0:0]
+	for _, e := range d {
+		if e.Key != key {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// applyUpdate applies an update document and reports whether doc changed
+func applyUpdate(doc bson.D, update bson.D) (bson.D, bool, error) {
+	before, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, op := range update {
+		fields, ok := op.Value.(bson.D)
+		if !ok {
+			return nil, false, fmt.Errorf("%s needs a document", op.Key)
+		}
+		for _, f := range fields {
+			path := strings.Split(f.Key, ".")
+			switch op.Key {
+			case "$set":
+				doc = setPath(doc, path, f.Value)
+			case "$unset":
+				doc = unsetPath(doc, path)
+			case "$inc":
+				current := lookup(doc, path)
+				var sum any = f.Value
+				if len(current) == 1 {
+					sum = addNumbers(current[0], f.Value)
+				}
+				doc = setPath(doc, path, sum)
+			case "$addToSet", "$push", "$pull":
+				doc, err = updateArray(doc, op.Key, path, f.Value)
+				if err != nil {
+					return nil, false, err
+				}
+			default:
+				return nil, false, fmt.Errorf("unsupported update operator %s", op.Key)
+			}
+		}
+	}
+
+	after, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, false, err
+	}
+	return doc, !bytes.Equal(before, after), nil
+}
+
+func addNumbers(a, b any) any {
+	if rank(a) != 2 {
+		return b
+	}
+	_, aFloat := a.(float64)
+	_, bFloat := b.(float64)
+	if aFloat || bFloat {
+		return toFloat(a) + toFloat(b)
+	}
+	return int64(toFloat(a)) + int64(toFloat(b))
+}
+
+func updateArray(doc bson.D, op string, path []string, value any) (bson.D, error) {
+	var arr bson.A
+	if current := lookup(doc, path); len(current) == 1 && current[0] != nil {
+		var ok bool
+		if arr, ok = current[0].(bson.A); !ok {
+			return nil, fmt.Errorf("%s on non-array field %s", op, strings.Join(path, "."))
+		}
+	}
+
+	next := bson.A{}
+	switch op {
+	case "$push":
+		next = append(append(next, arr...), value)
+	case "$addToSet":
+		next = append(next, arr...)
+		if !contains(arr, value) {
+			next = append(next, value)
+		}
+	case "$pull":
+		for _, item := range arr {
+			if compare(item, value) != 0 {
+				next = append(next, item)
+			}
+		}
+	}
+	return setPath(doc, path, next), nil
+}
+
+func contains(arr bson.A, value any) bool {
+	for _, item := range arr {
+		if compare(item, value) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func setPath(doc bson.D, path []string, value any) bson.D {
+	out := append(bson.D(nil), doc...)
+	for i, e := range out {
+		if e.Key != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			out[i].Value = value
+		} else {
+			sub, _ := e.Value.(bson.D)
+			out[i].Value = setPath(sub, path[1:], value)
+		}
+		return out
+	}
+	if len(path) == 1 {
+		return append(out, bson.E{Key: path[0], Value: value})
+	}
+	return append(out, bson.E{Key: path[0], Value: setPath(bson.D{}, path[1:], value)})
+}
+
+func unsetPath(doc bson.D, path []string) bson.D {
+	out := bson.D{}
+	for _, e := range doc {
+		switch {
+		case e.Key != path[0]:
+			out = append(out, e)
+		case len(path) > 1:
+			if sub, ok := e.Value.(bson.D); ok {
+				e.Value = unsetPath(sub, path[1:])
+			}
+			out = append(out, e)
+		}
+	}
+	return out
+}
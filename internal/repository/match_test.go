@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// doc normalizes v, as the memory repository does with what it stores and
+// the filters it is given
+func doc(t *testing.T, v any) bson.D {
+	t.Helper()
+	d, err := normalize(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// filterCase is a filter and whether the document under test matches it
+type filterCase struct {
+	filter bson.D
+	want   bool
+}
+
+func checkMatches(t *testing.T, d bson.D, cases []filterCase) {
+	t.Helper()
+	for _, c := range cases {
+		got, err := matches(d, doc(t, c.filter))
+		if err != nil {
+			t.Errorf("matches(%v) = %v", c.filter, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("matches(%v) = %v, want %v", c.filter, got, c.want)
+		}
+	}
+}
+
+func TestMatchMissingFields(t *testing.T) {
+	// a missing field is null to equality, absent to $exists and outside
+	// every range
+	d := doc(t, bson.D{{Key: "name", Value: "Ada"}, {Key: "deleted_at", Value: nil}})
+	checkMatches(t, d, []filterCase{
+		{bson.D{{Key: "age", Value: nil}}, true},
+		{bson.D{{Key: "age", Value: bson.D{{Key: "$exists", Value: false}}}}, true},
+		{bson.D{{Key: "age", Value: bson.D{{Key: "$exists", Value: true}}}}, false},
+		{bson.D{{Key: "age", Value: bson.D{{Key: "$ne", Value: 30}}}}, true},
+		{bson.D{{Key: "age", Value: bson.D{{Key: "$in", Value: bson.A{nil, 30}}}}}, true},
+		{bson.D{{Key: "age", Value: bson.D{{Key: "$nin", Value: bson.A{30}}}}}, true},
+		{bson.D{{Key: "age", Value: bson.D{{Key: "$gte", Value: 0}}}}, false},
+		{bson.D{{Key: "age", Value: bson.D{{Key: "$lt", Value: 0}}}}, false},
+		{bson.D{{Key: "address.city", Value: nil}}, true},
+		// a null that is there exists
+		{bson.D{{Key: "deleted_at", Value: bson.D{{Key: "$exists", Value: true}}}}, true},
+		{bson.D{{Key: "deleted_at", Value: nil}}, true},
+		{bson.D{{Key: "name", Value: nil}}, false},
+	})
+}
+
+func TestMatchArrayFanOut(t *testing.T) {
+	d := doc(t, bson.D{
+		{Key: "tags", Value: bson.A{"stocks", "crypto"}},
+		{Key: "items", Value: bson.A{
+			bson.D{{Key: "n", Value: 1}, {Key: "kind", Value: "a"}},
+			bson.D{{Key: "n", Value: 5}, {Key: "kind", Value: "b"}},
+		}},
+	})
+	checkMatches(t, d, []filterCase{
+		// any element, or the whole array
+		{bson.D{{Key: "tags", Value: "crypto"}}, true},
+		{bson.D{{Key: "tags", Value: bson.A{"stocks", "crypto"}}}, true},
+		{bson.D{{Key: "tags", Value: bson.A{"crypto", "stocks"}}}, false},
+		{bson.D{{Key: "tags", Value: bson.D{{Key: "$in", Value: bson.A{"forex", "crypto"}}}}}, true},
+		{bson.D{{Key: "tags", Value: bson.D{{Key: "$nin", Value: bson.A{"crypto"}}}}}, false},
+		{bson.D{{Key: "tags", Value: bson.D{{Key: "$ne", Value: "stocks"}}}}, false},
+		{bson.D{{Key: "tags", Value: bson.D{{Key: "$regex", Value: "^CRY"}, {Key: "$options", Value: "i"}}}}, true},
+		// paths through arrays of documents reach every element
+		{bson.D{{Key: "items.n", Value: 5}}, true},
+		{bson.D{{Key: "items.kind", Value: "c"}}, false},
+		{bson.D{{Key: "items.n", Value: bson.D{{Key: "$gt", Value: 4}}}}, true},
+		// each condition may be met by a different element
+		{bson.D{{Key: "items.n", Value: bson.D{{Key: "$gt", Value: 1}, {Key: "$lt", Value: 5}}}}, true},
+		{bson.D{{Key: "items.n", Value: bson.D{{Key: "$gt", Value: 5}}}}, false},
+	})
+}
+
+func TestMatchCrossTypeComparisons(t *testing.T) {
+	d := doc(t, bson.D{
+		{Key: "count", Value: int32(5)},
+		{Key: "score", Value: 5.0},
+		{Key: "code", Value: "6"},
+		{Key: "at", Value: time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)},
+	})
+	checkMatches(t, d, []filterCase{
+		// numbers compare across int32, int64 and double
+		{bson.D{{Key: "count", Value: int64(5)}}, true},
+		{bson.D{{Key: "count", Value: 5.0}}, true},
+		{bson.D{{Key: "score", Value: bson.D{{Key: "$gte", Value: int32(5)}}}}, true},
+		// ranges only compare within a type bracket
+		{bson.D{{Key: "code", Value: bson.D{{Key: "$gt", Value: 5}}}}, false},
+		{bson.D{{Key: "count", Value: bson.D{{Key: "$lt", Value: "9"}}}}, false},
+		{bson.D{{Key: "count", Value: "5"}}, false},
+		{bson.D{{Key: "at", Value: bson.D{{Key: "$gt", Value: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)}}}}, true},
+		{bson.D{{Key: "at", Value: bson.D{{Key: "$gt", Value: "2026-03-01"}}}}, false},
+		// $type crosses brackets, by alias
+		{bson.D{{Key: "count", Value: bson.D{{Key: "$type", Value: "int"}}}}, true},
+		{bson.D{{Key: "score", Value: bson.D{{Key: "$type", Value: "number"}}}}, true},
+		{bson.D{{Key: "code", Value: bson.D{{Key: "$type", Value: bson.A{"bool", "date"}}}}}, false},
+		{bson.D{{Key: "at", Value: bson.D{{Key: "$type", Value: bson.A{"bool", "date"}}}}}, true},
+		{bson.D{{Key: "missing", Value: bson.D{{Key: "$type", Value: "null"}}}}, false},
+	})
+
+	// sorting orders the brackets as MongoDB does
+	oid := bson.NewObjectID()
+	values := []any{
+		bson.NewDateTimeFromTime(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)),
+		true,
+		oid,
+		bson.A{int32(1)},
+		bson.D{{Key: "a", Value: int32(1)}},
+		"b",
+		"a",
+		int64(2),
+		1.5,
+		nil,
+	}
+	slices.SortStableFunc(values, compare)
+	want := []any{nil, 1.5, int64(2), "a", "b", bson.D{{Key: "a", Value: int32(1)}}, bson.A{int32(1)}, oid,
+		true, bson.NewDateTimeFromTime(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC))}
+	for i := range want {
+		if compare(values[i], want[i]) != 0 {
+			t.Fatalf("sorted = %v, want %v", values, want)
+		}
+	}
+
+	// documents missing the sort field come first, as nulls
+	if v := sortValue(doc(t, bson.D{{Key: "a", Value: 1}}), "b.c"); v != nil {
+		t.Errorf("sortValue of a missing path = %v, want nil", v)
+	}
+}
+
+func TestMatchLogicalOperators(t *testing.T) {
+	d := doc(t, bson.D{{Key: "status", Value: "published"}, {Key: "views", Value: 10}})
+	published := bson.D{{Key: "status", Value: "published"}}
+	popular := bson.D{{Key: "views", Value: bson.D{{Key: "$gt", Value: 100}}}}
+	checkMatches(t, d, []filterCase{
+		{bson.D{{Key: "$and", Value: bson.A{published, popular}}}, false},
+		{bson.D{{Key: "$or", Value: bson.A{published, popular}}}, true},
+		{bson.D{{Key: "$nor", Value: bson.A{popular}}}, true},
+	})
+	for _, filter := range []bson.D{
+		{{Key: "$or", Value: bson.A{}}},
+		{{Key: "views", Value: bson.D{{Key: "$in", Value: 10}}}},
+		{{Key: "views", Value: bson.D{{Key: "$where", Value: "true"}}}},
+	} {
+		if _, err := matches(d, doc(t, filter)); err == nil {
+			t.Errorf("matches(%v) succeeded, want an error", filter)
+		}
+	}
+}
+
+func TestApplyUpdate(t *testing.T) {
+	d := doc(t, bson.D{{Key: "name", Value: "Ada"}, {Key: "tags", Value: bson.A{"a"}}, {Key: "views", Value: int32(1)}})
+	update := doc(t, bson.D{
+		{Key: "$set", Value: bson.D{{Key: "prefs.theme", Value: "dark"}}},
+		{Key: "$unset", Value: bson.D{{Key: "name", Value: ""}}},
+		{Key: "$inc", Value: bson.D{{Key: "views", Value: 2}, {Key: "shares", Value: 1}}},
+		{Key: "$addToSet", Value: bson.D{{Key: "tags", Value: "a"}}},
+		{Key: "$push", Value: bson.D{{Key: "log", Value: "x"}}},
+	})
+	got, changed, err := applyUpdate(d, update)
+	if err != nil || !changed {
+		t.Fatalf("applyUpdate = %v, %v, %v", got, changed, err)
+	}
+	checkMatches(t, got, []filterCase{
+		{bson.D{{Key: "name", Value: bson.D{{Key: "$exists", Value: false}}}}, true},
+		{bson.D{{Key: "prefs.theme", Value: "dark"}}, true},
+		{bson.D{{Key: "views", Value: 3}}, true},
+		{bson.D{{Key: "shares", Value: 1}}, true},
+		{bson.D{{Key: "tags", Value: bson.A{"a"}}}, true},
+		{bson.D{{Key: "log", Value: bson.A{"x"}}}, true},
+	})
+
+	if _, changed, err := applyUpdate(got, doc(t, bson.D{{Key: "$addToSet", Value: bson.D{{Key: "tags", Value: "a"}}}})); err != nil || changed {
+		t.Errorf("adding an element already in the set: changed %v, %v; want unchanged", changed, err)
+	}
+	if _, _, err := applyUpdate(got, doc(t, bson.D{{Key: "$push", Value: bson.D{{Key: "prefs", Value: "x"}}}})); err == nil {
+		t.Error("$push on a document succeeded")
+	}
+}
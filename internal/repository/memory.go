@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Memory is an in-memory collection for the in-memory repositories. It
+// stores documents in their BSON form and answers filters, sorts and updates
+// the way MongoDB does, so a repository built on it behaves like its Mongo
+// counterpart: missing documents surface as mongo.ErrNoDocuments and unique
+// fields as duplicate key errors.
+type Memory struct {
+	mu     sync.RWMutex
+	docs   []bson.D // in insertion order
+	unique []string // paths that must be unique when set
+}
+
+// NewMemory returns an empty collection. Each unique path acts like a unique
+// index that ignores documents without the field; paths joined by commas,
+// e.g. "webhook_id,event_id", are unique together.
+func NewMemory(unique ...string) *Memory {
+	return &Memory{unique: unique}
+}
+
+// InsertOne stores doc, which must carry an _id
+func (m *Memory) InsertOne(ctx context.Context, doc any) error {
+	d, err := normalize(doc)
+	if err != nil {
+		return err
+	}
+	if _, ok := get(d, "_id"); !ok {
+		return fmt.Errorf("document has no _id")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkUnique(d, -1); err != nil {
+		return err
+	}
+	m.docs = append(m.docs, d)
+	return nil
+}
+
+// FindOne decodes the first document matching filter into out, or returns
+// mongo.ErrNoDocuments
+func (m *Memory) FindOne(ctx context.Context, filter bson.M, out any) error {
+	return m.FindOneSorted(ctx, filter, nil, out)
+}
+
+// FindOneSorted is FindOne taking the first match in the given sort order
+func (m *Memory) FindOneSorted(ctx context.Context, filter bson.M, order bson.D, out any) error {
+	raws, err := m.find(ctx, filter, order, nil, 0, 1)
+	if err != nil {
+		return err
+	}
+	if len(raws) == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return bson.Unmarshal(raws[0], out)
+}
+
+// CountDocuments counts the documents matching filter
+func (m *Memory) CountDocuments(ctx context.Context, filter bson.M) (int64, error) {
+	return m.count(ctx, filter)
+}
+
+// UpdateOne applies update to the first document matching filter and
+// returns how many documents matched
+func (m *Memory) UpdateOne(ctx context.Context, filter, update bson.M) (matched int64, err error) {
+	matched, _, err = m.update(filter, update, false)
+	return matched, err
+}
+
+// UpdateMany applies update to every document matching filter and returns
+// how many documents changed
+func (m *Memory) UpdateMany(ctx context.Context, filter, update bson.M) (modified int64, err error) {
+	_, modified, err = m.update(filter, update, true)
+	return modified, err
+}
+
+// FindOneAndUpdate applies update to the first document matching filter in
+// the given sort order and decodes the updated document into out, or returns
+// mongo.ErrNoDocuments. Both happen under one lock, so concurrent callers
+// never take the same document when the update stops it matching, as with
+// MongoDB's findOneAndUpdate.
+func (m *Memory) FindOneAndUpdate(ctx context.Context, filter bson.M, order bson.D, update bson.M, out any) error {
+	f, err := normalize(filter)
+	if err != nil {
+		return err
+	}
+	u, err := normalize(update)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	found, err := m.matching(f, order)
+	if err != nil {
+		return err
+	}
+	if len(found) == 0 {
+		return mongo.ErrNoDocuments
+	}
+	i := found[0]
+	next, changed, err := applyUpdate(m.docs[i], u)
+	if err != nil {
+		return err
+	}
+	if changed {
+		if err := m.checkUnique(next, i); err != nil {
+			return err
+		}
+		m.docs[i] = next
+	}
+	raw, err := bson.Marshal(next)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, out)
+}
+
+// DeleteOne removes the first document matching filter and returns how many
+// were removed
+func (m *Memory) DeleteOne(ctx context.Context, filter bson.M) (int64, error) {
+	f, err := normalize(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, d := range m.docs {
+		ok, err := matches(d, f)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			m.docs = append(m.docs[:i:i], m.docs[i+1:]...)
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (m *Memory) count(ctx context.Context, filter bson.M) (int64, error) {
+	f, err := normalize(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var n int64
+	for _, d := range m.docs {
+		ok, err := matches(d, f)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *Memory) find(ctx context.Context, filter bson.M, order bson.D, projection bson.M, skip, limit int64) ([]bson.Raw, error) {
+	f, err := normalize(filter)
+	if err != nil {
+		return nil, err
+	}
+	var fields bson.D
+	if projection != nil {
+		if fields, err = normalize(projection); err != nil {
+			return nil, err
+		}
+	}
+
+	m.mu.RLock()
+	indexes, err := m.matching(f, order)
+	found := make([]bson.D, len(indexes))
+	for i, index := range indexes {
+		found[i] = m.docs[index]
+	}
+	m.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if skip >= int64(len(found)) {
+		return nil, nil
+	}
+	found = found[skip:]
+	if limit > 0 && limit < int64(len(found)) {
+		found = found[:limit]
+	}
+
+	raws := make([]bson.Raw, len(found))
+	for i, d := range found {
+		if fields != nil {
+			d = project(d, fields)
+		}
+		if raws[i], err = bson.Marshal(d); err != nil {
+			return nil, err
+		}
+	}
+	return raws, nil
+}
+
+// matching returns the indexes of the documents matching f, in the given
+// sort order. The caller holds the lock.
+func (m *Memory) matching(f bson.D, order bson.D) ([]int, error) {
+	var found []int
+	for i, d := range m.docs {
+		ok, err := matches(d, f)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			found = append(found, i)
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		for _, key := range order {
+			c := compare(sortValue(m.docs[found[i]], key.Key), sortValue(m.docs[found[j]], key.Key))
+			if c == 0 {
+				continue
+			}
+			if direction, _ := normalizeValue(key.Value); toFloat(direction) < 0 {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+	return found, nil
+}
+
+func (m *Memory) update(filter, update bson.M, many bool) (matched, modified int64, err error) {
+	f, err := normalize(filter)
+	if err != nil {
+		return 0, 0, err
+	}
+	u, err := normalize(update)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, d := range m.docs {
+		ok, err := matches(d, f)
+		if err != nil {
+			return matched, modified, err
+		}
+		if !ok {
+			continue
+		}
+
+		matched++
+		next, changed, err := applyUpdate(d, u)
+		if err != nil {
+			return matched, modified, err
+		}
+		if changed {
+			if err := m.checkUnique(next, i); err != nil {
+				return matched, modified, err
+			}
+			m.docs[i] = next
+			modified++
+		}
+		if !many {
+			break
+		}
+	}
+	return matched, modified, nil
+}
+
+// checkUnique reports a duplicate key error when d collides with a stored
+// document other than the one at index self
+func (m *Memory) checkUnique(d bson.D, self int) error {
+	id, _ := get(d, "_id")
+	for i, other := range m.docs {
+		if i == self {
+			continue
+		}
+		if otherID, _ := get(other, "_id"); compare(id, otherID) == 0 {
+			return duplicateKey("_id", id)
+		}
+		for _, index := range m.unique {
+			if value, ok := collides(d, other, strings.Split(index, ",")); ok {
+				return duplicateKey(index, value)
+			}
+		}
+	}
+	return nil
+}
+
+// collides reports whether d has the same values as other on every path of
+// a unique index, and d's value on the first one. Documents without one of
+// the fields never collide.
+func collides(d, other bson.D, paths []string) (any, bool) {
+	var first any
+	for i, path := range paths {
+		values := lookup(d, strings.Split(path, "."))
+		if len(values) == 0 || values[0] == nil {
+			return nil, false
+		}
+		if !equalsAny(lookup(other, strings.Split(path, ".")), values[0]) {
+			return nil, false
+		}
+		if i == 0 {
+			first = values[0]
+		}
+	}
+	return first, true
+}
+
+func duplicateKey(path string, value any) error {
+	return mongo.WriteException{WriteErrors: []mongo.WriteError{{
+		Code:    11000,
+		Message: fmt.Sprintf("E11000 duplicate key error dup key: { %s: %v }", path, value),
+	}}}
+}
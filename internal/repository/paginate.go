@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// pageFinder is the storage FindPage reads from
+type pageFinder interface {
+	count(ctx context.Context, filter bson.M) (int64, error)
+	find(ctx context.Context, filter bson.M, sort bson.D, projection bson.M, skip, limit int64) ([]bson.Raw, error)
+}
+
+// mongoFinder reads pages from a MongoDB collection
+type mongoFinder struct {
+	coll *mongo.Collection
+}
+
+func (f mongoFinder) count(ctx context.Context, filter bson.M) (int64, error) {
+	return f.coll.CountDocuments(ctx, filter)
+}
+
+func (f mongoFinder) find(ctx context.Context, filter bson.M, sort bson.D, projection bson.M, skip, limit int64) ([]bson.Raw, error) {
+	findOptions := options.Find().SetSort(sort).SetSkip(skip).SetLimit(limit)
+	if projection != nil {
+		findOptions.SetProjection(projection)
+	}
+
+	cur, err := f.coll.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var raws []bson.Raw
+	if err = cur.All(ctx, &raws); err != nil {
+		return nil, err
+	}
+	return raws, nil
+}
+
+// FindPage runs a paginated find on coll.
+//
+// With a cursor it continues right after the cursor position and skips the
+// count, so deep pages cost the same as the first one. Without a cursor it
+// falls back to the legacy skip/limit mode with a total count. Both modes
+// return a next cursor when more documents follow.
+func FindPage[T any](ctx context.Context, coll *mongo.Collection, q query.Query, pagination models.PaginationParams) ([]T, models.PageInfo, error) {
+	return findPage[T](ctx, mongoFinder{coll}, q, pagination)
+}
+
+// FindMemoryPage is FindPage for an in-memory collection
+func FindMemoryPage[T any](ctx context.Context, coll *Memory, q query.Query, pagination models.PaginationParams) ([]T, models.PageInfo, error) {
+	return findPage[T](ctx, coll, q, pagination)
+}
+
+// FindMemoryAll decodes every document of coll matching filter, in the
+// given sort order, for the unpaginated reads of the in-memory repositories
+func FindMemoryAll[T any](ctx context.Context, coll *Memory, filter bson.M, order bson.D) ([]T, error) {
+	raws, err := coll.find(ctx, filter, order, nil, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]T, len(raws))
+	for i, raw := range raws {
+		if err := bson.Unmarshal(raw, &items[i]); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func findPage[T any](ctx context.Context, coll pageFinder, q query.Query, pagination models.PaginationParams) ([]T, models.PageInfo, error) {
+	var info models.PageInfo
+	filter, sort := q.Filter, q.Sort
+	if filter == nil {
+		filter = bson.M{}
+	}
+	order := bson.D{{Key: sort.Field, Value: sort.Direction()}, {Key: "_id", Value: sort.Direction()}}
+
+	var skip, limit int64
+	if pagination.UsesCursor() {
+		cursor, err := models.DecodeCursor(pagination.Cursor, sort.Field, sort.Desc)
+		if err != nil {
+			return nil, info, err
+		}
+		filter = q.And(cursor.After()).Filter
+		// One extra document tells whether another page follows
+		limit = int64(pagination.Limit) + 1
+	} else {
+		totalCount, err := coll.count(ctx, filter)
+		if err != nil {
+			return nil, info, err
+		}
+		info.TotalCount = totalCount
+		info.HasMore = int64(pagination.GetSkip()+pagination.Limit) < totalCount
+		skip, limit = int64(pagination.GetSkip()), int64(pagination.Limit)
+	}
+
+	raws, err := coll.find(ctx, filter, order, q.Projection, skip, limit)
+	if err != nil {
+		return nil, info, err
+	}
+	if pagination.UsesCursor() && len(raws) > pagination.Limit {
+		info.HasMore = true
+		raws = raws[:pagination.Limit]
+	}
+
+	items := make([]T, len(raws))
+	for i, raw := range raws {
+		if err := bson.Unmarshal(raw, &items[i]); err != nil {
+			return nil, info, err
+		}
+	}
+
+	if info.HasMore && len(raws) > 0 {
+		last := raws[len(raws)-1]
+		id, _ := last.Lookup("_id").ObjectIDOK()
+		value, err := last.LookupErr(strings.Split(sort.Field, ".")...)
+		if err != nil {
+			// Missing fields sort as null
+			value = bson.RawValue{Type: bson.TypeNull}
+		}
+		info.NextCursor, err = models.EncodeCursor(sort.Field, sort.Desc, value, id)
+		if err != nil {
+			return nil, info, err
+		}
+	}
+
+	return items, info, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/query"
+	"yoharsh14/krant-backend/internal/repository/repositorytest"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestCursorPagination(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		m := NewMemory()
+		testCursorPagination(t, m.InsertOne, m)
+	})
+	t.Run("mongo", func(t *testing.T) {
+		coll := repositorytest.Database(t).Collection("cursor_pages")
+		insert := func(ctx context.Context, doc any) error {
+			_, err := coll.InsertOne(ctx, doc)
+			return err
+		}
+		testCursorPagination(t, insert, mongoFinder{coll})
+	})
+}
+
+// testCursorPagination walks listings sorted by a field that is missing,
+// null or of different types, one small page at a time
+func testCursorPagination(t *testing.T, insert func(context.Context, any) error, finder pageFinder) {
+	ctx := context.Background()
+
+	// inserted in ascending sort order, so _id breaks the ties the same way
+	ranks := []any{
+		nil, // missing
+		bson.Null{},
+		nil,
+		int32(1),
+		2.5,
+		int64(3),
+		"a",
+		"a",
+		"b",
+		true,
+		time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+	}
+	var want []bson.ObjectID
+	for _, rank := range ranks {
+		doc := bson.D{{Key: "_id", Value: bson.NewObjectID()}}
+		switch rank.(type) {
+		case nil:
+		case bson.Null:
+			doc = append(doc, bson.E{Key: "rank", Value: nil})
+		default:
+			doc = append(doc, bson.E{Key: "rank", Value: rank})
+		}
+		if err := insert(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, doc[0].Value.(bson.ObjectID))
+	}
+
+	type item struct {
+		ID bson.ObjectID `bson:"_id"`
+	}
+	walk := func(desc bool) []bson.ObjectID {
+		q := query.Query{Sort: query.Sort{Field: "rank", Desc: desc}}
+		items := repositorytest.AllPages(t, 2, func(pagination models.PaginationParams) ([]item, models.PageInfo, error) {
+			return findPage[item](ctx, finder, q, pagination)
+		})
+		return repositorytest.IDs(items, func(i item) bson.ObjectID { return i.ID })
+	}
+
+	if got := walk(false); !slices.Equal(got, want) {
+		t.Errorf("ascending pages = %v, want %v", got, want)
+	}
+	slices.Reverse(want)
+	if got := walk(true); !slices.Equal(got, want) {
+		t.Errorf("descending pages = %v, want %v", got, want)
+	}
+}
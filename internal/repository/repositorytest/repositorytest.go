@@ -0,0 +1,108 @@
+// Package repositorytest holds helpers for the repository conformance
+// suites, which run every repository contract against both the MongoDB and
+// the in-memory implementation.
+//
+// The Mongo half is skipped unless MONGO_TEST_URI names a server. The change
+// streams and transactions the suites use need a replica set, so a single
+// node one is enough:
+//
+//	docker run -d --name krant-test-mongo -p 27017:27017 mongo:7 --replSet rs0
+//	docker exec krant-test-mongo mongosh --quiet --eval 'rs.initiate()'
+//	MONGO_TEST_URI='mongodb://localhost:27017/?directConnection=true' go test ./...
+//
+// Each test gets its own database, dropped when it ends, so the suites can
+// share a server.
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+	"yoharsh14/krant-backend/internal/migrations"
+	"yoharsh14/krant-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// URIVar names the MongoDB server the Mongo suites run against. They are
+// skipped when it is unset.
+const URIVar = "MONGO_TEST_URI"
+
+// Database returns a fresh, migrated database that is dropped when the test
+// ends
+func Database(t *testing.T) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv(URIVar)
+	if uri == "" {
+		t.Skipf("%s not set", URIVar)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connecting to %s: %v", URIVar, err)
+	}
+	db := client.Database(fmt.Sprintf("krant_test_%s", bson.NewObjectID().Hex()))
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		_ = db.Drop(ctx)
+		_ = client.Disconnect(ctx)
+	})
+
+	runner, err := migrations.NewRunner(db, migrations.All(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(ctx); err != nil {
+		t.Fatalf("migrating test database: %v", err)
+	}
+	return db
+}
+
+// Page is a repository listing call
+type Page[T any] func(pagination models.PaginationParams) ([]T, models.PageInfo, error)
+
+// AllPages follows next cursors from the first page until the listing ends
+// and returns every item in order
+func AllPages[T any](t *testing.T, limit int, page Page[T]) []T {
+	t.Helper()
+	var all []T
+	pagination := models.GetPaginationParams(1, limit)
+	for range 1000 {
+		items, info, err := page(pagination)
+		if err != nil {
+			t.Fatalf("listing page %+v: %v", pagination, err)
+		}
+		if len(items) > limit {
+			t.Fatalf("got %d items with limit %d", len(items), limit)
+		}
+		all = append(all, items...)
+		if !info.HasMore {
+			return all
+		}
+		if info.NextCursor == "" {
+			t.Fatal("HasMore without a next cursor")
+		}
+		pagination = models.GetCursorParams(info.NextCursor, limit)
+	}
+	t.Fatal("listing does not end")
+	return nil
+}
+
+// IDs returns the IDs of items in order
+func IDs[T any](items []T, id func(T) bson.ObjectID) []bson.ObjectID {
+	ids := make([]bson.ObjectID, len(items))
+	for i, item := range items {
+		ids[i] = id(item)
+	}
+	return ids
+}
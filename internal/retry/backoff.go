@@ -0,0 +1,19 @@
+// Package retry spaces out the attempts of background work that failed
+package retry
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff is the wait after the given number of failed attempts: base,
+// doubled for every attempt after the first and capped at limit, less up to
+// a fifth at random so the retries after an outage spread out
+func Backoff(base, limit time.Duration, attempts int) time.Duration {
+	wait := base
+	for i := 1; i < attempts && wait < limit; i++ {
+		wait *= 2
+	}
+	wait = min(wait, limit)
+	return wait - rand.N(wait/5+1)
+}
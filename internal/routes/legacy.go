@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Alias is a route kept for old clients after it moved. It is served by its
+// successor, so both behave the same, and answers with the headers of a
+// deprecated route pointing at the successor.
+type Alias struct {
+	Method    string
+	Pattern   string // the old path
+	Successor string // the path under /v1, with the same parameters
+}
+
+// The unversioned route was deprecated when /v1 was introduced. No sunset
+// is set yet: old app builds are still around.
+var (
+	LegacyDeprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	LegacySunset     time.Time
+)
+
+// Legacy are the routes served before versioning that clients still call
+var Legacy = []Alias{
+	{"POST", "/user/create", "/users"},
+}
+
+// mountLegacy registers the legacy aliases on r, served by the v1 router
+func mountLegacy(r chi.Router, v1 http.Handler) {
+	for _, alias := range Legacy {
+		r.Method(alias.Method, alias.Pattern, alias.handler(v1))
+	}
+}
+
+// handler routes the request to the successor on v1. The request keeps its
+// URL, so logs and metrics show the path the client used.
+func (a Alias) handler(v1 http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := a.successorPath(chi.RouteContext(r.Context()))
+		deprecate(w.Header(), LegacyDeprecated, LegacySunset, "/"+V1.Name+path)
+
+		rctx := chi.NewRouteContext()
+		rctx.RoutePath = path
+		v1.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx)))
+	})
+}
+
+// successorPath fills the successor's parameters in from the old route's,
+// as chi matched them
+func (a Alias) successorPath(rctx *chi.Context) string {
+	path := a.Successor
+	for i, key := range rctx.URLParams.Keys {
+		path = strings.ReplaceAll(path, "{"+key+"}", rctx.URLParams.Values[i])
+	}
+	return path
+}
@@ -1,2 +0,0 @@
-package routes
-
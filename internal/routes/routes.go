@@ -0,0 +1,145 @@
+// Package routes lays out the API: which handler serves which path, and
+// under which version.
+//
+// Every business area registers its routes on the router of a version, e.g.
+// users on /v1/users. The one route served before versioning is kept as a
+// deprecated alias of its /v1 successor, see legacy.go, so old app builds
+// keep working while they are told to move on.
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"yoharsh14/krant-backend/internal/business/category"
+	"yoharsh14/krant-backend/internal/business/job"
+	"yoharsh14/krant-backend/internal/business/news"
+	"yoharsh14/krant-backend/internal/business/notification"
+	"yoharsh14/krant-backend/internal/business/source"
+	"yoharsh14/krant-backend/internal/business/user"
+	"yoharsh14/krant-backend/internal/business/webhook"
+	"yoharsh14/krant-backend/internal/json"
+	"yoharsh14/krant-backend/internal/logging"
+	"yoharsh14/krant-backend/internal/media"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// API holds what the versioned routes are served by
+type API struct {
+	Media         media.Handler
+	Sources       source.Handler
+	Categories    category.Handler
+	Users         user.Handler
+	News          news.Handler
+	Notifications notification.Handler
+	Webhooks      webhook.Handler
+	Jobs          job.Handler
+	LogLevels     *logging.Levels
+
+	// Admin guards the admin routes, e.g. checking the admin token. Until
+	// users sign in it also guards the routes acting for one user, so only
+	// a trusted backend calls them.
+	Admin chi.Middlewares
+	// Ingest guards writing articles and uploading images, e.g. checking
+	// the ingest token
+	Ingest chi.Middlewares
+	// Signup runs before creating users, e.g. to rate limit it
+	Signup chi.Middlewares
+}
+
+// Mount registers the current version under its prefix and the legacy
+// aliases at the root of r
+func (a API) Mount(r chi.Router) {
+	r.NotFound(notFound) // before mounting, so the version routers share it
+	v1 := chi.NewRouter()
+	v1.Use(V1.Middleware)
+	a.v1(v1)
+	r.Mount("/"+V1.Name, v1)
+
+	mountLegacy(r, v1)
+}
+
+// v1 registers the routes of version 1
+func (a API) v1(r chi.Router) {
+	a.media(r)
+	a.sources(r)
+	a.categories(r)
+	a.users(r)
+	a.news(r)
+	r.Route("/admin", a.admin)
+}
+
+func (a API) media(r chi.Router) {
+	r.With(a.Ingest...).Post("/media/images", a.Media.UploadImage)
+	r.Get("/media/images/{id}", a.Media.GetImage)
+}
+
+func (a API) sources(r chi.Router) {
+	r.Get("/sources", a.Sources.ListSources)
+	r.Get("/sources/{id}", a.Sources.GetSource)
+}
+
+func (a API) categories(r chi.Router) {
+	r.Get("/categories", a.Categories.ListCategories)
+	r.Get("/categories/{id}", a.Categories.GetCategory)
+}
+
+// users registers the users and everything scoped to one user: their muted
+// sources, feed and notifications, behind the admin guard
+func (a API) users(r chi.Router) {
+	r.With(a.Signup...).Post("/users", a.Users.CreateUser)
+	r.Route("/users/{id}", func(r chi.Router) {
+		r.Use(a.Admin...)
+		r.Use(logging.URLParam("id", "user_id")) // tag their log lines with the user
+		r.Put("/muted-sources/{sourceID}", a.Users.MuteSource)
+		r.Delete("/muted-sources/{sourceID}", a.Users.UnmuteSource)
+		r.Get("/feed", a.News.ListFeed)
+		r.Get("/notifications", a.Notifications.ListNotifications)
+		r.Post("/notifications/read", a.Notifications.MarkAllRead)
+		r.Post("/notifications/{notificationID}/read", a.Notifications.MarkRead)
+	})
+}
+
+func (a API) news(r chi.Router) {
+	r.With(a.Ingest...).Post("/news", a.News.CreateNews)
+	r.Get("/news", a.News.ListNews)
+	r.Get("/news/{id}", a.News.GetNews)
+	r.With(a.Ingest...).Patch("/news/{id}", a.News.UpdateNews)
+}
+
+func (a API) admin(r chi.Router) {
+	r.Use(a.Admin...)
+	r.Get("/sources", a.Sources.ListAllSources)
+	r.Post("/sources", a.Sources.CreateSource)
+	r.Patch("/sources/{id}", a.Sources.UpdateSource)
+	r.Post("/sources/{id}/block", a.Sources.BlockSource)
+	r.Post("/sources/{id}/unblock", a.Sources.UnblockSource)
+	r.Get("/users", a.Users.ListAllUser)
+	r.Get("/categories", a.Categories.ListAllCategories)
+	r.Post("/categories", a.Categories.CreateCategory)
+	r.Patch("/categories/{id}", a.Categories.UpdateCategory)
+	r.Post("/notifications", a.Notifications.CreateNotification)
+	r.Get("/webhooks", a.Webhooks.ListWebhooks)
+	r.Post("/webhooks", a.Webhooks.CreateWebhook)
+	r.Get("/webhooks/deliveries", a.Webhooks.ListDeliveries)
+	r.Get("/webhooks/deliveries/{id}", a.Webhooks.GetDelivery)
+	r.Post("/webhooks/deliveries/{id}/replay", a.Webhooks.ReplayDelivery)
+	r.Get("/webhooks/{id}", a.Webhooks.GetWebhook)
+	r.Patch("/webhooks/{id}", a.Webhooks.UpdateWebhook)
+	r.Delete("/webhooks/{id}", a.Webhooks.DeleteWebhook)
+	r.Get("/jobs", a.Jobs.ListJobs)
+	r.Get("/jobs/{id}", a.Jobs.GetJob)
+	r.Post("/jobs/{id}/retry", a.Jobs.RetryJob)
+	r.Get("/log-levels", logging.GetLevels(a.LogLevels))
+	r.Put("/log-levels", logging.SetLevels(a.LogLevels))
+}
+
+// notFound answers unknown paths, telling clients that ask for a version
+// which versions there are
+func notFound(w http.ResponseWriter, r *http.Request) {
+	if name, ok := requestedVersion(r.URL.Path); ok && !supported(name) {
+		json.Error(w, r, fmt.Errorf("%w %s, use one of %s", ErrUnsupportedVersion, name, versionNames()))
+		return
+	}
+	json.Error(w, r, ErrNotFound)
+}
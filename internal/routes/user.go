@@ -1,13 +0,0 @@
-package routes
-
-// import (
-// 	"yoharsh14/krant-backend/internal/business/user"
-
-// 	"github.com/go-chi/chi/v5"
-// )
-
-// func setupUserRoutes(r chi.Router,handler *user.Handler) {
-// 	r.Route("/user",func(r chi.Router){
-// 		r.Post("/create")
-// 	})
-// }
\ No newline at end of file
@@ -0,0 +1,90 @@
+package routes
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"yoharsh14/krant-backend/internal/apperr"
+)
+
+// Version is a generation of the API, served under /<Name>. Responses name
+// the version that served them in an API-Version header. Once a version is
+// deprecated its responses also carry Deprecation and Sunset headers and a
+// successor-version link, and the version can be removed after its sunset.
+type Version struct {
+	Name       string
+	Deprecated time.Time // when clients were told to move on, zero while current
+	Sunset     time.Time // when it may stop answering, zero while undecided
+	Successor  string    // the version to move to
+}
+
+// V1 is the current version
+var V1 = Version{Name: "v1"}
+
+// Versions are the versions served, oldest first
+var Versions = []Version{V1}
+
+var (
+	// ErrNotFound answers paths no route matches
+	ErrNotFound = apperr.NotFound("no such route")
+	// ErrUnsupportedVersion answers paths under a version that is not served
+	ErrUnsupportedVersion = apperr.NotFound("unsupported API version").WithCode("unsupported_api_version")
+)
+
+// Middleware announces the version, and its deprecation if any, on every
+// response
+func (v Version) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("API-Version", v.Name)
+		if successor := v.successorPath(r.URL.Path); successor != "" {
+			deprecate(h, v.Deprecated, v.Sunset, successor)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// successorPath is the path of the same route under the successor, or empty
+// while v is current. Routes are only renamed between versions with care, so
+// swapping the prefix is a good guess and the link is advisory.
+func (v Version) successorPath(path string) string {
+	if v.Deprecated.IsZero() || v.Successor == "" {
+		return ""
+	}
+	return "/" + v.Successor + strings.TrimPrefix(path, "/"+v.Name)
+}
+
+// deprecate sets the headers of a deprecated route: Deprecation (RFC 9745),
+// Sunset (RFC 8594) when a date is set and a link to the successor
+func deprecate(h http.Header, since, sunset time.Time, successor string) {
+	h.Set("Deprecation", "@"+strconv.FormatInt(since.Unix(), 10))
+	if !sunset.IsZero() {
+		h.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+	}
+	h.Add("Link", "<"+successor+`>; rel="successor-version"`)
+}
+
+var versionPattern = regexp.MustCompile(`^v[0-9]+$`)
+
+// requestedVersion returns the version path starts with, if it starts with
+// something that looks like one
+func requestedVersion(path string) (string, bool) {
+	first, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return first, versionPattern.MatchString(first)
+}
+
+func supported(name string) bool {
+	return slices.ContainsFunc(Versions, func(v Version) bool { return v.Name == name })
+}
+
+// versionNames lists the served versions for error messages
+func versionNames() string {
+	names := make([]string, 0, len(Versions))
+	for _, v := range Versions {
+		names = append(names, v.Name)
+	}
+	return strings.Join(names, ", ")
+}
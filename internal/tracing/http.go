@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestIDKey tags server spans with the id middleware.RequestID gave the
+// request, so a reported request id leads to its trace
+const requestIDKey = attribute.Key("http.request.id")
+
+// Middleware starts a server span for each request, continuing the trace
+// from the traceparent header when there is one. It must be installed with
+// Use on the root router, after middleware.RequestID; the span is renamed to
+// the chi route pattern once routing is done.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		method := requestMethod(r.Method)
+		ctx, span := tracer().Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(method),
+				semconv.URLPath(r.URL.Path),
+				semconv.ClientAddress(r.RemoteAddr),
+				semconv.UserAgentOriginal(r.UserAgent()),
+				requestIDKey.String(middleware.GetReqID(r.Context())),
+			),
+		)
+		defer span.End()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(method + " " + rctx.RoutePattern())
+			span.SetAttributes(semconv.HTTPRoute(rctx.RoutePattern()))
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK // nothing written
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		// client errors are the client's; only server errors fail the span
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// requestMethod keeps span names to the standard methods
+func requestMethod(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return m
+	}
+	return "_OTHER"
+}
@@ -0,0 +1,29 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const jobNameKey = attribute.Key("job.name")
+
+// Job runs fn for one run of the background job called name, inside a span
+// of its own. The span is a child of the one in ctx, if any, and a new trace
+// otherwise; the database calls fn makes become its children. An error from
+// fn fails the span and is returned unchanged.
+func Job(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	ctx, span := tracer().Start(ctx, "job "+name,
+		trace.WithAttributes(jobNameKey.String(name)),
+	)
+	defer span.End()
+
+	err := fn(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
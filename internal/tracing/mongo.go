@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"yoharsh14/krant-backend/internal/mongocmd"
+
+	"go.mongodb.org/mongo-driver/v2/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CommandMonitor returns a driver monitor starting a client span for every
+// command, as a child of the span in the context the command was issued
+// with. Set it with options.Client().SetMonitor.
+func CommandMonitor() *event.CommandMonitor {
+	// the driver reports the end of a command without its context; keep the
+	// span until then
+	var spans sync.Map // request id to trace.Span
+
+	finished := func(requestID int64) trace.Span {
+		span, ok := spans.LoadAndDelete(requestID)
+		if !ok {
+			return nil
+		}
+		return span.(trace.Span)
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			attrs := []attribute.KeyValue{
+				semconv.DBSystemNameMongoDB,
+				semconv.DBOperationName(e.CommandName),
+				semconv.DBNamespace(e.DatabaseName),
+			}
+			name := e.CommandName
+			if collection, ok := mongocmd.Collection(e); ok {
+				attrs = append(attrs, semconv.DBCollectionName(collection))
+				name += " " + collection
+			}
+			_, span := tracer().Start(ctx, name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attrs...),
+			)
+			spans.Store(e.RequestID, span)
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			if span := finished(e.RequestID); span != nil {
+				span.End()
+			}
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			if span := finished(e.RequestID); span != nil {
+				span.RecordError(e.Failure)
+				span.SetStatus(codes.Error, e.Failure.Error())
+				span.End()
+			}
+		},
+	}
+}
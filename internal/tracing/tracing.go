@@ -0,0 +1,81 @@
+// Package tracing records OpenTelemetry traces: a server span per HTTP
+// request named by its chi route, a child span per MongoDB command and a
+// root span per background job run.
+//
+// Spans travel in the context, so services and repositories join the
+// request's trace simply by passing ctx on to the driver. Incoming W3C
+// traceparent and baggage headers continue the caller's trace.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"yoharsh14/krant-backend/internal/buildinfo"
+	"yoharsh14/krant-backend/internal/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer the spans of this service come from
+const instrumentationName = "yoharsh14/krant-backend"
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup installs the global tracer provider and W3C propagator described by
+// cfg and returns the function that flushes and stops it.
+//
+// With the none exporter spans are still created and propagated, so logs
+// carry trace ids that match the caller's, but nothing is sent anywhere.
+// That keeps tests and offline development free of a collector.
+func Setup(ctx context.Context, cfg config.Tracing) (shutdown func(context.Context) error, err error) {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(cfg.ServiceName),
+			semconv.ServiceVersion(buildinfo.Version),
+		)),
+	}
+
+	switch cfg.Exporter {
+	case config.TraceExporterOTLP:
+		exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		if err != nil {
+			return nil, fmt.Errorf("creating OTLP exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case config.TraceExporterStdout:
+		exporter, err := stdouttrace.New()
+		if err != nil {
+			return nil, fmt.Errorf("creating stdout exporter: %w", err)
+		}
+		// write each span as it ends, so the output follows the logs
+		opts = append(opts, sdktrace.WithSyncer(exporter))
+	case config.TraceExporterNone:
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	// export failures, e.g. an unreachable collector, must not go unnoticed
+	// but are no reason to fail requests
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		slog.Warn("tracing error", "error", err)
+	}))
+
+	return provider.Shutdown, nil
+}
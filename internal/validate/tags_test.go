@@ -0,0 +1,99 @@
+package validate_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"yoharsh14/krant-backend/internal/business/user"
+	"yoharsh14/krant-backend/internal/logging"
+	"yoharsh14/krant-backend/internal/models"
+	"yoharsh14/krant-backend/internal/validate"
+)
+
+// requests are the types request bodies are decoded into. A tagged type in
+// models or business/*/types.go that is missing here fails
+// TestEveryTaggedTypeIsListed.
+var requests = []any{
+	models.CreateActivityInput{},
+	models.CreateCategoryInput{},
+	models.CreateNewsInput{},
+	models.UpdateNewsInput{},
+	models.CreateNotificationInput{},
+	models.CreateSourceInput{},
+	models.UpdateSourceInput{},
+	models.CreateWebhookInput{},
+	models.UpdateWebhookInput{},
+	user.CreateUserInput{},
+	user.UpdateUserInput{},
+	user.UpdatePreferencesInput{},
+	logging.Settings{},
+}
+
+func TestRequestTags(t *testing.T) {
+	for _, v := range requests {
+		if err := validate.Tags(v); err != nil {
+			t.Errorf("%T: %v", v, err)
+		}
+	}
+}
+
+func TestEveryTaggedTypeIsListed(t *testing.T) {
+	listed := map[string]bool{}
+	for _, v := range requests {
+		listed[reflect.TypeOf(v).String()] = true
+	}
+
+	files, err := filepath.Glob("../models/*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	types, err := filepath.Glob("../business/*/types.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range append(files, types...) {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		for _, name := range taggedTypes(t, file) {
+			if !listed[name] {
+				t.Errorf("%s has binding tags but is not in requests", name)
+			}
+		}
+	}
+}
+
+// taggedTypes returns the struct types declared in file that have a field
+// with a binding tag, as package.Type
+func taggedTypes(t *testing.T, file string) []string {
+	t.Helper()
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			for _, field := range st.Fields.List {
+				if field.Tag != nil && strings.Contains(field.Tag.Value, `binding:"`) {
+					names = append(names, f.Name.Name+"."+ts.Name.Name)
+					break
+				}
+			}
+		}
+	}
+	return names
+}
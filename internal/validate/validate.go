@@ -0,0 +1,416 @@
+// Package validate checks decoded request bodies against the binding tags
+// of their fields, e.g.
+//
+//	Email      string   `json:"email" binding:"required,email"`
+//	TraderType *string  `json:"trader_type,omitempty" binding:"omitempty,trader_type"`
+//	Relevance  []string `json:"trader_relevance" binding:"required,min=1,dive,trader_type"`
+//
+// Rules are applied left to right and every failing field is reported, keyed
+// by its JSON path, so clients can fix the whole form in one round trip.
+//
+// Built-in rules:
+//
+//	required     not the zero value; strings must not be blank
+//	omitempty    skip the remaining rules when the value is empty
+//	email        a bare address such as ada@example.com
+//	url          an absolute http(s) url
+//	min=N max=N  length of strings, slices and maps; value of numbers
+//	oneof=a b c  one of the listed strings
+//	dive         apply the remaining rules to each element of a slice or
+//	             each value of a map
+//
+// plus the domain rules trader_type, news_status, activity_type,
+// notification_type, webhook_event and trust_score.
+//
+// A tag naming an unknown rule, or a rule that cannot apply to its field,
+// is a programming error and Struct panics on it. Tags checks them without
+// a value, so tests can catch them before a request does.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"yoharsh14/krant-backend/internal/apperr"
+	"yoharsh14/krant-backend/internal/models"
+)
+
+// ErrInvalid is returned, with one field per problem, when a struct breaks
+// its rules
+var ErrInvalid = apperr.Validation("request validation failed").WithCode("validation_failed")
+
+// rule checks v against its tag argument and returns the problem, or "" if
+// there is none
+type rule func(v reflect.Value, arg string) string
+
+var rules = map[string]rule{
+	"required":    required,
+	"email":       email,
+	"url":         absoluteURL,
+	"min":         bound(false),
+	"max":         bound(true),
+	"oneof":       oneOf,
+	"trust_score": trustScore,
+}
+
+// domains are the rules backed by a models.IsValid* check
+var domains = map[string]struct {
+	valid   func(string) bool
+	options func() []string
+}{
+	"trader_type":       {models.IsValidTraderType, models.ValidTraderTypes},
+	"news_status":       {models.IsValidNewsStatus, models.ValidNewsStatuses},
+	"activity_type":     {models.IsValidActivityType, models.ValidActivityTypes},
+	"notification_type": {models.IsValidNotificationType, models.ValidNotificationTypes},
+	"webhook_event":     {models.IsValidWebhookEvent, models.ValidWebhookEvents},
+}
+
+func init() {
+	for name, d := range domains {
+		rules[name] = domain(d.valid, d.options)
+	}
+}
+
+// Enum returns the values a rule accepts when it only accepts a fixed set,
+// e.g. Enum("oneof", "light dark") or Enum("trader_type", "")
+func Enum(rule, arg string) ([]string, bool) {
+	if rule == "oneof" {
+		return strings.Fields(arg), true
+	}
+	if d, ok := domains[rule]; ok {
+		return d.options(), true
+	}
+	return nil, false
+}
+
+// Struct validates v, a struct or a pointer to one, and its nested structs.
+// Other values have no tags to check and always pass.
+func Struct(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	problems := map[string]string{}
+	checkStruct(rv, "", problems)
+	if len(problems) == 0 {
+		return nil
+	}
+	err := ErrInvalid
+	for field, problem := range problems {
+		err = err.WithField(field, problem)
+	}
+	return err
+}
+
+func checkStruct(rv reflect.Value, prefix string, problems map[string]string) {
+	t := rv.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		value := rv.Field(i)
+		if tag, ok := field.Tag.Lookup("binding"); ok {
+			if !checkValue(value, strings.Split(tag, ","), name, problems) {
+				continue
+			}
+		}
+		if nested := indirect(value); nested.Kind() == reflect.Struct && nested.Type().PkgPath() != "time" {
+			checkStruct(nested, name, problems)
+		}
+	}
+}
+
+// checkValue applies the rules in order and stops at the first problem. It
+// reports whether the value passed.
+func checkValue(v reflect.Value, tags []string, name string, problems map[string]string) bool {
+	for i, tag := range tags {
+		ruleName, arg, _ := strings.Cut(strings.TrimSpace(tag), "=")
+		switch ruleName {
+		case "":
+			continue
+		case "omitempty":
+			if isEmpty(v) {
+				return true
+			}
+			continue
+		case "dive":
+			elems := indirect(v)
+			ok := true
+			switch elems.Kind() {
+			case reflect.Slice, reflect.Array:
+				for j := range elems.Len() {
+					ok = checkValue(elems.Index(j), tags[i+1:], fmt.Sprintf("%s[%d]", name, j), problems) && ok
+				}
+			case reflect.Map:
+				for _, key := range elems.MapKeys() {
+					ok = checkValue(elems.MapIndex(key), tags[i+1:], fmt.Sprintf("%s[%v]", name, key), problems) && ok
+				}
+			default:
+				panic(fmt.Sprintf("validate: dive on %s of kind %s", name, elems.Kind()))
+			}
+			return ok
+		}
+
+		check, known := rules[ruleName]
+		if !known {
+			panic(fmt.Sprintf("validate: unknown rule %q on %s", ruleName, name))
+		}
+		if ruleName != "required" {
+			if v.Kind() == reflect.Pointer && v.IsNil() {
+				continue // nothing was sent, only required complains about that
+			}
+			v = indirect(v)
+		}
+		if problem := check(v, arg); problem != "" {
+			problems[name] = problem
+			return false
+		}
+	}
+	return true
+}
+
+// Tags checks the binding tags of v's type and its nested structs: every
+// rule must exist, take a valid argument and suit the kind of its field.
+func Tags(v any) error {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	var errs []error
+	checkStructTags(t, t.Name(), &errs)
+	return errors.Join(errs...)
+}
+
+func checkStructTags(t reflect.Type, prefix string, errs *[]error) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name := jsonName(field)
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		if tag, ok := field.Tag.Lookup("binding"); ok {
+			checkTags(field.Type, strings.Split(tag, ","), name, errs)
+		}
+		nested := field.Type
+		for nested.Kind() == reflect.Pointer {
+			nested = nested.Elem()
+		}
+		if nested.Kind() == reflect.Struct && nested.PkgPath() != "time" {
+			checkStructTags(nested, name, errs)
+		}
+	}
+}
+
+// checkTags mirrors checkValue on the type of a field
+func checkTags(t reflect.Type, tags []string, name string, errs *[]error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, fmt.Errorf("%s: "+format, append([]any{name}, args...)...))
+	}
+	for i, tag := range tags {
+		ruleName, arg, _ := strings.Cut(strings.TrimSpace(tag), "=")
+		switch ruleName {
+		case "", "omitempty", "required":
+			continue
+		case "dive":
+			switch t.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				checkTags(t.Elem(), tags[i+1:], name+"[]", errs)
+			default:
+				fail("dive on kind %s", t.Kind())
+			}
+			return
+		}
+
+		if _, known := rules[ruleName]; !known {
+			fail("unknown rule %q", ruleName)
+			continue
+		}
+		switch ruleName {
+		case "min", "max":
+			if _, err := strconv.ParseFloat(arg, 64); err != nil {
+				fail("bad limit %q for %s", arg, ruleName)
+			}
+			switch t.Kind() {
+			case reflect.String, reflect.Slice, reflect.Array, reflect.Map,
+				reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+				reflect.Float32, reflect.Float64:
+			default:
+				fail("%s on kind %s", ruleName, t.Kind())
+			}
+		case "trust_score":
+			if t.Kind() != reflect.Float32 && t.Kind() != reflect.Float64 {
+				fail("%s on kind %s", ruleName, t.Kind())
+			}
+		case "oneof":
+			if len(strings.Fields(arg)) == 0 {
+				fail("oneof without options")
+			}
+			fallthrough
+		default: // email, url and the domain rules read strings
+			if t.Kind() != reflect.String {
+				fail("%s on kind %s", ruleName, t.Kind())
+			}
+		}
+	}
+}
+
+// ============================================================================
+// RULES
+// ============================================================================
+
+func required(v reflect.Value, _ string) string {
+	if isEmpty(v) {
+		return "is required"
+	}
+	return ""
+}
+
+func email(v reflect.Value, _ string) string {
+	s := v.String()
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return "must be a valid email address"
+	}
+	return ""
+}
+
+func absoluteURL(v reflect.Value, _ string) string {
+	u, err := url.Parse(v.String())
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "must be an absolute http(s) url"
+	}
+	return ""
+}
+
+// bound builds min (upper false) and max (upper true)
+func bound(upper bool) rule {
+	return func(v reflect.Value, arg string) string {
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			panic(fmt.Sprintf("validate: bad limit %q", arg))
+		}
+		word := "at least"
+		if upper {
+			word = "at most"
+		}
+
+		var n float64
+		var unit string
+		switch v.Kind() {
+		case reflect.String:
+			n, unit = float64(utf8.RuneCountInString(v.String())), " character"
+		case reflect.Slice, reflect.Array, reflect.Map:
+			n, unit = float64(v.Len()), " item"
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = float64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n = float64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			n = v.Float()
+		default:
+			panic(fmt.Sprintf("validate: min/max on kind %s", v.Kind()))
+		}
+		if (upper && n > limit) || (!upper && n < limit) {
+			if unit != "" && limit != 1 {
+				unit += "s"
+			}
+			if unit != "" {
+				return fmt.Sprintf("must have %s %s%s", word, arg, unit)
+			}
+			return fmt.Sprintf("must be %s %s", word, arg)
+		}
+		return ""
+	}
+}
+
+func oneOf(v reflect.Value, arg string) string {
+	options := strings.Fields(arg)
+	for _, option := range options {
+		if v.String() == option {
+			return ""
+		}
+	}
+	return "must be one of " + strings.Join(options, ", ")
+}
+
+// domain builds a rule from one of the models.IsValid* checks
+func domain(valid func(string) bool, options func() []string) rule {
+	return func(v reflect.Value, _ string) string {
+		if valid(v.String()) {
+			return ""
+		}
+		return "must be one of " + strings.Join(options(), ", ")
+	}
+}
+
+func trustScore(v reflect.Value, _ string) string {
+	if models.IsValidTrustScore(v.Float()) {
+		return ""
+	}
+	return fmt.Sprintf("must be between %v and %v", models.MinTrustScore, models.MaxTrustScore)
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// jsonName is the name clients know the field by
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
@@ -0,0 +1,202 @@
+package validate
+
+import (
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+	"yoharsh14/krant-backend/internal/apperr"
+)
+
+// fields returns the problems Struct reports, by field
+func fields(t *testing.T, v any) map[string]string {
+	t.Helper()
+	err := Struct(v)
+	if err == nil {
+		return nil
+	}
+	var e *apperr.Error
+	if !errors.As(err, &e) || !errors.Is(err, ErrInvalid) {
+		t.Fatalf("Struct = %v, want ErrInvalid", err)
+	}
+	return e.Fields
+}
+
+func TestRules(t *testing.T) {
+	type form struct {
+		Name     string  `json:"name" binding:"required"`
+		Email    string  `json:"email" binding:"omitempty,email"`
+		Site     string  `json:"site" binding:"omitempty,url"`
+		Title    string  `json:"title" binding:"min=2,max=5"`
+		Count    int     `json:"count" binding:"min=1,max=3"`
+		Theme    string  `json:"theme" binding:"omitempty,oneof=light dark"`
+		Trader   string  `json:"trader" binding:"omitempty,trader_type"`
+		Trust    float64 `json:"trust" binding:"trust_score"`
+		Ignored  string  `json:"-" binding:"required"`
+		internal string
+	}
+	valid := form{Name: "Ada", Email: "ada@example.com", Site: "https://ex.com", Title: "Rates", Count: 2, Theme: "dark", Trader: "day_trader", Trust: 50}
+	if got := fields(t, valid); got != nil {
+		t.Fatalf("valid form: %v", got)
+	}
+
+	cases := []struct {
+		name  string
+		edit  func(f *form)
+		field string
+		want  string
+	}{
+		{"required", func(f *form) { f.Name = "  " }, "name", "is required"},
+		{"email", func(f *form) { f.Email = "Ada <ada@example.com>" }, "email", "must be a valid email address"},
+		{"url", func(f *form) { f.Site = "ftp://ex.com" }, "site", "must be an absolute http(s) url"},
+		{"relative url", func(f *form) { f.Site = "/news" }, "site", "must be an absolute http(s) url"},
+		{"min length", func(f *form) { f.Title = "R" }, "title", "must have at least 2 characters"},
+		{"max length counts runes", func(f *form) { f.Title = "Zürich" }, "title", "must have at most 5 characters"},
+		{"min number", func(f *form) { f.Count = 0 }, "count", "must be at least 1"},
+		{"max number", func(f *form) { f.Count = 4 }, "count", "must be at most 3"},
+		{"oneof", func(f *form) { f.Theme = "blue" }, "theme", "must be one of light, dark"},
+		{"domain", func(f *form) { f.Trader = "gambler" }, "trader", "must be one of "},
+		{"trust score", func(f *form) { f.Trust = 101 }, "trust", "must be between "},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f := valid
+			c.edit(&f)
+			got := fields(t, f)
+			if len(got) != 1 || !strings.HasPrefix(got[c.field], c.want) {
+				t.Errorf("problems = %v, want %s: %s", got, c.field, c.want)
+			}
+		})
+	}
+}
+
+func TestDive(t *testing.T) {
+	type form struct {
+		Events []string          `json:"events" binding:"required,min=1,dive,webhook_event"`
+		Levels map[string]string `json:"levels" binding:"dive,oneof=debug info"`
+		Tags   *[]string         `json:"tags" binding:"omitempty,dive,min=2"`
+	}
+	tags := []string{"ok", "x"}
+	got := fields(t, form{
+		Events: []string{"news.published", "nope", "also nope"},
+		Levels: map[string]string{"news": "debug", "json": "loud"},
+		Tags:   &tags,
+	})
+	want := map[string]string{
+		"events[1]":    got["events[1]"],
+		"events[2]":    got["events[2]"],
+		"levels[json]": "must be one of debug, info",
+		"tags[1]":      "must have at least 2 characters",
+	}
+	if !maps.Equal(got, want) || !strings.HasPrefix(got["events[1]"], "must be one of ") {
+		t.Errorf("problems = %v, want %v", got, want)
+	}
+
+	// the rules before dive apply to the collection itself
+	if got := fields(t, form{}); len(got) != 1 || got["events"] != "is required" {
+		t.Errorf("empty form: problems = %v, want only events required", got)
+	}
+}
+
+func TestOmitemptyWithPointers(t *testing.T) {
+	type form struct {
+		Status *string  `json:"status" binding:"omitempty,news_status"`
+		Score  *float64 `json:"score" binding:"omitempty,trust_score"`
+		Limit  *int     `json:"limit" binding:"min=1"`
+		Title  *string  `json:"title" binding:"required"`
+	}
+	status, score, limit, title := "archived", 0.0, 5, "Rates"
+
+	// a nil pointer was not sent: only required complains
+	if got := fields(t, form{}); len(got) != 1 || got["title"] != "is required" {
+		t.Errorf("nothing sent: problems = %v, want only title required", got)
+	}
+	// a pointer to the zero value was sent and is checked
+	if got := fields(t, form{Status: &status, Score: &score, Limit: &limit, Title: &title}); got != nil {
+		t.Errorf("valid values: problems = %v", got)
+	}
+	bad, zero := "gone", 0
+	if got := fields(t, form{Status: &bad, Limit: &zero, Title: &title}); len(got) != 2 || got["limit"] != "must be at least 1" {
+		t.Errorf("invalid values: problems = %v, want status and limit", got)
+	}
+}
+
+func TestReportsEveryFieldAtOnce(t *testing.T) {
+	type address struct {
+		City string `json:"city" binding:"required"`
+	}
+	type form struct {
+		Name    string   `json:"name" binding:"required"`
+		Email   string   `json:"email" binding:"required,email"`
+		Address address  `json:"address"`
+		Billing *address `json:"billing"`
+	}
+	got := fields(t, &form{Email: "nope", Billing: &address{}})
+	want := map[string]string{
+		"name":         "is required",
+		"email":        "must be a valid email address",
+		"address.city": "is required",
+		"billing.city": "is required",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("problems = %v, want %v", got, want)
+	}
+
+	// the first failing rule of a field is the one reported
+	if got := fields(t, form{Email: " ", Address: address{City: "Oslo"}, Name: "Ada"}); got["email"] != "is required" {
+		t.Errorf("problems = %v, want email required", got)
+	}
+	if err := Struct((*form)(nil)); err != nil {
+		t.Errorf("Struct(nil) = %v", err)
+	}
+}
+
+func TestTagsRejectsBrokenTags(t *testing.T) {
+	cases := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"unknown rule", struct {
+			A string `json:"a" binding:"required,emial"`
+		}{}, `unknown rule "emial"`},
+		{"bad limit", struct {
+			A string `json:"a" binding:"min=one"`
+		}{}, `bad limit "one"`},
+		{"dive on a string", struct {
+			A string `json:"a" binding:"dive,required"`
+		}{}, "dive on kind string"},
+		{"email on a number", struct {
+			A int `json:"a" binding:"email"`
+		}{}, "email on kind int"},
+		{"min on a bool", struct {
+			A bool `json:"a" binding:"min=1"`
+		}{}, "min on kind bool"},
+		{"trust score on a string", struct {
+			A string `json:"a" binding:"trust_score"`
+		}{}, "trust_score on kind string"},
+		{"after dive", struct {
+			A []int `json:"a" binding:"dive,news_status"`
+		}{}, "a[]: news_status on kind int"},
+		{"nested", struct {
+			B *struct {
+				A string `json:"a" binding:"oneof"`
+			} `json:"b"`
+		}{}, "b.a: oneof without options"},
+	}
+	for _, c := range cases {
+		err := Tags(c.v)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: Tags = %v, want an error containing %q", c.name, err, c.want)
+		}
+	}
+
+	type fine struct {
+		A *[]string         `json:"a" binding:"omitempty,min=1,dive,trader_type"`
+		B map[string]string `json:"b" binding:"dive,oneof=x y"`
+		C *float64          `json:"c" binding:"omitempty,trust_score"`
+	}
+	if err := Tags(&fine{}); err != nil {
+		t.Errorf("Tags of valid tags = %v", err)
+	}
+}